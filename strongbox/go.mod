@@ -10,6 +10,7 @@ require (
 	github.com/sourcegraph/conc v0.3.0
 	github.com/stretchr/testify v1.9.0
 	github.com/visualfc/atk v1.2.3 // see ../go.work
+	golang.org/x/sys v0.47.0
 )
 
 require (
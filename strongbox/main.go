@@ -130,14 +130,31 @@ func main_gui() *ui.GUIUI {
 		{Title: "tags"},
 		{Title: core.ITEM_FIELD_DATE_CREATED},
 		{Title: core.ITEM_FIELD_DATE_UPDATED},
-		{Title: "dirsize"},
+		{Title: "size"},
 		{Title: "installed-version", MaxWidth: 15},
 		{Title: "available-version", MaxWidth: 15},
 		{Title: "version"}, // addon version if no updates, else available-version
 		{Title: "game-version"},
+		{Title: "outdated"},
 	}
 	addons_dir_tab.SetColumnAttrs(addons_dir_tab_column_list)
 
+	// --- outdated addons tab
+
+	gui.AddTab(strongbox.TAB_LABEL_OUTDATED, strongbox.OutdatedAddonFilter)
+	gui_outdated_tab := gui.GetTab(strongbox.TAB_LABEL_OUTDATED)
+	gui_outdated_tab.IgnoreMissingParents = true
+	gui_outdated_tab.SetColumnAttrs([]ui.UIColumn{
+		{Title: "source"},
+		{Title: core.ITEM_FIELD_NAME, MaxWidth: 30},
+		{Title: core.ITEM_FIELD_DESC, MaxWidth: 75},
+		{Title: core.ITEM_FIELD_DATE_UPDATED},
+		{Title: "installed-version", MaxWidth: 15},
+		{Title: "available-version", MaxWidth: 15},
+		{Title: "game-version"},
+		{Title: "outdated"},
+	})
+
 	// --- search catalogue tab
 
 	gui.AddTab("search", func(r core.Result) bool {
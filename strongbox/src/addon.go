@@ -6,8 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"reflect"
 	"slices"
 	"strings"
 	"time"
@@ -30,10 +32,71 @@ type SourceMap struct {
 // --- Source Updates
 // extra data a source (wowinterface, github, etc) provides about an addon.
 
-// todo: rename 'release' or similar? release.type: 'lib', 'nolib'. release.stability: 'stable', 'beta', 'alpha', etc.
+// how stable a release is.
+type ReleaseStability = string
+
+const (
+	RELEASE_STABILITY_STABLE ReleaseStability = "stable"
+	RELEASE_STABILITY_BETA   ReleaseStability = "beta"
+	RELEASE_STABILITY_ALPHA  ReleaseStability = "alpha"
+)
+
+// stability ordered from most to least stable.
+// a release channel accepts releases at it's own position and everything before it.
+var RELEASE_STABILITY_LIST = []ReleaseStability{
+	RELEASE_STABILITY_STABLE,
+	RELEASE_STABILITY_BETA,
+	RELEASE_STABILITY_ALPHA,
+}
+
+// whether a release bundles it's libraries or not.
+type ReleaseType = string
+
+const (
+	RELEASE_TYPE_LIB   ReleaseType = "lib"
+	RELEASE_TYPE_NOLIB ReleaseType = "nolib"
+)
+
+// the releases a user wants to be offered.
+// the zero value is stable releases of any type.
+type ReleaseChannel struct {
+	Stability ReleaseStability `json:"release-channel,omitempty"` // least stable release acceptable, "" is "stable"
+	Type      ReleaseType      `json:"release-type,omitempty"`    // preferred release type, "" is no preference
+}
+
+// returns `true` if a release with the given `stability` is acceptable to the release channel.
+// releases of an unknown stability are never acceptable, an unknown release channel is treated as stable.
+func (rc ReleaseChannel) Accepts(stability ReleaseStability) bool {
+	if stability == "" {
+		stability = RELEASE_STABILITY_STABLE
+	}
+	channel := rc.Stability
+	if !slices.Contains(RELEASE_STABILITY_LIST, channel) {
+		channel = RELEASE_STABILITY_STABLE
+	}
+	idx := slices.Index(RELEASE_STABILITY_LIST, stability)
+	return idx != -1 && idx <= slices.Index(RELEASE_STABILITY_LIST, channel)
+}
+
+// returns the first non-empty release channel preference for stability and type separately.
+// typically called with the per-addon preference, the per-addons-dir preference and then the global preference.
+func resolve_release_channel(rc_list ...ReleaseChannel) ReleaseChannel {
+	rc := ReleaseChannel{}
+	for _, r := range rc_list {
+		if rc.Stability == "" {
+			rc.Stability = r.Stability
+		}
+		if rc.Type == "" {
+			rc.Type = r.Type
+		}
+	}
+	return rc
+}
+
+// todo: rename 'release' or similar?
 type SourceUpdate struct {
-	//Type string // lib, nolib
-	//Stability string // beta, alpha, etc
+	Type      ReleaseType      // lib, nolib
+	Stability ReleaseStability // stable, beta, alpha
 	//ReleaseJSON ReleaseJSON
 	Version          string `json:"version"`
 	DownloadURL      string
@@ -41,13 +104,57 @@ type SourceUpdate struct {
 	InterfaceVersion int
 	PublishedDate    time.Time // when was this update made available
 
+	InterfaceVersionSet mapset.Set[int] // optional, the interface versions this update supports, when the source says so
+
 	//---
 
-	AssetName string // an update is essentially a remote file that will be unzipped. this is that file's name.
+	AssetName    string // an update is essentially a remote file that will be unzipped. this is that file's name.
+	MD5          string // optional, checksum of the remote file. verified after downloading.
+	Author       string // optional
+	ReleaseNotes string // optional, what changed in this update. Github release body, wowinterface changelog, etc.
+}
+
+var _ core.ItemInfo = (*SourceUpdate)(nil)
+
+func (su SourceUpdate) ItemKeys() []string {
+	return []string{
+		core.ITEM_FIELD_NAME,
+		core.ITEM_FIELD_DESC,
+		core.ITEM_FIELD_DATE_UPDATED,
+		"version",
+		"author",
+	}
+}
+
+func (su SourceUpdate) ItemMap() map[string]string {
+	published_str := ""
+	if !su.PublishedDate.IsZero() {
+		published, err := core.FormatTimeHumanOffset(su.PublishedDate)
+		if err == nil {
+			published_str = published
+		}
+	}
+	return map[string]string{
+		core.ITEM_FIELD_NAME:         su.Version,
+		core.ITEM_FIELD_DESC:         su.ReleaseNotes,
+		core.ITEM_FIELD_DATE_UPDATED: published_str,
+		"version":                    su.Version,
+		"author":                     su.Author,
+	}
+}
+
+func (su SourceUpdate) ItemHasChildren() core.ITEM_CHILDREN_LOAD {
+	return core.ITEM_CHILDREN_LOAD_FALSE
+}
+
+func (su SourceUpdate) ItemChildren(_ *core.App) []core.Result {
+	return nil
 }
 
 func NewSourceUpdate() SourceUpdate {
 	return SourceUpdate{
+		Type:           RELEASE_TYPE_LIB,
+		Stability:      RELEASE_STABILITY_STABLE,
 		GameTrackIDSet: mapset.NewSet[GameTrackID](),
 	}
 }
@@ -59,8 +166,8 @@ func NewSourceUpdate() SourceUpdate {
 type InstalledAddon struct {
 	URL string
 
-	// an addon may have many .toc files, keyed by filename
-	// todo: this will lead to non-deterministic results with many toc files supporting overlapping game tracks
+	// an addon may have many .toc files, keyed by filename.
+	// use `TOCList` when the order matters.
 	TOCMap map[PathToFile]TOC // required, >= 1
 
 	// an installed addon has zero or one `strongbox.json` 'nfo' files,
@@ -110,10 +217,7 @@ func MakeInstalledAddon(url string, toc_map map[GameTrackID]TOC, nfo_list []NFO)
 		}
 	} else {
 		// some values are identical between toc files
-		for _, val := range toc_map {
-			ia.Name = val.DirName
-			break
-		}
+		ia.Name = ia.TOCList()[0].DirName
 	}
 
 	return &ia
@@ -123,16 +227,22 @@ func (ia *InstalledAddon) IsEmpty() bool {
 	return len(ia.TOCMap) == 0
 }
 
+// returns the toc data of the addon ordered by key, so the same toc data is always picked first.
+func (ia InstalledAddon) TOCList() []TOC {
+	key_list := slices.Sorted(maps.Keys(ia.TOCMap))
+	rv := []TOC{}
+	for _, key := range key_list {
+		rv = append(rv, ia.TOCMap[key])
+	}
+	return rv
+}
+
 func (ia InstalledAddon) SomeTOC() (TOC, error) {
 	var some_toc TOC
 	if len(ia.TOCMap) < 1 {
 		return some_toc, errors.New("InstalledAddon has an empty tocmap")
 	}
-	for _, toc := range ia.TOCMap {
-		some_toc = toc
-		break
-	}
-	return some_toc, nil
+	return ia.TOCList()[0], nil
 }
 
 // an InstalledAddon has 1+ .toc files that can be loaded immediately.
@@ -162,7 +272,7 @@ func (ia InstalledAddon) ItemMap() map[string]string {
 // are .toc files.
 func (ia InstalledAddon) ItemChildren(_ *core.App) []core.Result {
 	toc_result_list := []core.Result{}
-	for _, toc := range ia.TOCMap {
+	for _, toc := range ia.TOCList() {
 		toc_result := core.MakeResult(NS_TOC, toc, core.UniqueID())
 		toc_result_list = append(toc_result_list, toc_result)
 	}
@@ -195,6 +305,12 @@ type Addon struct {
 	IsIgnored    bool           // resolved from bool ptr
 	IsPinned     bool           // Addon.Primary.NFO[-1].PinnedVersion
 
+	ReleaseChannel ReleaseChannel // per-addon release channel preference, Addon.Primary.NFO[-1].ReleaseChannel
+
+	CurrentInterfaceVersion int  // interface version of the game for Addon.AddonsDir, zero if not known
+	IsOutdated              bool // Addon.TOC interface version is behind the game's interface version
+	IsPossiblyAbandoned     bool // outdated and upstream has nothing newer to offer
+
 	// --- formerly only accessible for Addon.Attr.
 	// for now these values are just the stringified versions of the original values. may change!
 
@@ -210,7 +326,8 @@ type Addon struct {
 	Tags             []string
 	Created          time.Time
 	Updated          time.Time
-	Size             string
+	Size             string // human readable `SizeBytes`
+	SizeBytes        int64  // disk space used by every directory in `InstalledAddonGroup`, zero until measured
 	PinnedVersion    string
 	InstalledVersion string
 	AvailableVersion string // Addon.SourceUpdate.Version, previously just 'Version'
@@ -225,9 +342,10 @@ var _ core.ItemInfo = (*Addon)(nil)
 func _make_addon__find_toc(game_track_id GameTrackID, primary_addon InstalledAddon, strict bool) *TOC {
 	var final_toc *TOC
 
+	toc_list := primary_addon.TOCList()
 	if strict {
 		// return the first set of toc data that supports the given game track
-		for _, toc := range primary_addon.TOCMap {
+		for _, toc := range toc_list {
 			if toc.GameTrackIDSet.Contains(game_track_id) {
 				final_toc = &toc
 				break
@@ -236,13 +354,14 @@ func _make_addon__find_toc(game_track_id GameTrackID, primary_addon InstalledAdd
 	} else {
 		// in relaxed mode, if there is *any* toc data it will be used.
 		// use the preference map to decide the best one to use.
-		gt_pref_list := GAMETRACK_PREF_MAP[game_track_id]
+		gt_pref_list := game_tracks().FallbackList(game_track_id)
+	pref_loop:
 		for _, gt := range gt_pref_list {
-			// return the first set of toc data that supports the given game track
-			for _, toc := range primary_addon.TOCMap {
+			// return the first set of toc data that supports the most preferred game track
+			for _, toc := range toc_list {
 				if toc.GameTrackIDSet.Contains(gt) {
 					final_toc = &toc
-					break
+					break pref_loop
 				}
 			}
 		}
@@ -250,33 +369,65 @@ func _make_addon__find_toc(game_track_id GameTrackID, primary_addon InstalledAdd
 	return final_toc
 }
 
-// given a list of updates, a game track and a strictness flag,
+// given a list of updates, a game track, a strictness flag and a release channel,
 // return the best update available.
+// updates less stable than the release channel allows are never returned.
+// if the release channel prefers a release type ('nolib') and the best update has an alternative of that type, the alternative is returned.
 // assumes list of updates is sorted newest to oldest.
-func _make_addon__pick_source_update(source_update_list []SourceUpdate, game_track_id GameTrackID, strict bool) *SourceUpdate {
+func _make_addon__pick_source_update(source_update_list []SourceUpdate, game_track_id GameTrackID, strict bool, release_channel ReleaseChannel) *SourceUpdate {
 	var empty_result *SourceUpdate
 
-	if len(source_update_list) == 0 {
+	acceptable_list := []SourceUpdate{}
+	for _, source_update := range source_update_list {
+		if release_channel.Accepts(source_update.Stability) {
+			acceptable_list = append(acceptable_list, source_update)
+		}
+	}
+
+	if len(acceptable_list) == 0 {
 		return empty_result
 	}
 
+	// the game track the update was chosen for
+	var matched_game_track_id GameTrackID
+	var picked *SourceUpdate
+
 	if strict {
-		for _, source_update := range source_update_list {
+		for _, source_update := range acceptable_list {
 			if source_update.GameTrackIDSet.Contains(game_track_id) {
-				return &source_update
+				picked = &source_update
+				matched_game_track_id = game_track_id
+				break
 			}
 		}
 	} else {
-		game_track_pref_list := GAMETRACK_PREF_MAP[game_track_id]
+		game_track_pref_list := game_tracks().FallbackList(game_track_id)
+	outer:
 		for _, game_track_id_pref := range game_track_pref_list {
-			for _, source_update := range source_update_list {
+			for _, source_update := range acceptable_list {
 				if source_update.GameTrackIDSet.Contains(game_track_id_pref) {
-					return &source_update
+					picked = &source_update
+					matched_game_track_id = game_track_id_pref
+					break outer
 				}
 			}
 		}
 	}
-	return empty_result
+
+	if picked == nil || release_channel.Type == "" || picked.Type == release_channel.Type {
+		return picked
+	}
+
+	// look for an alternative of the preferred type within the same release
+	for _, source_update := range acceptable_list {
+		if source_update.Version == picked.Version &&
+			source_update.Type == release_channel.Type &&
+			source_update.GameTrackIDSet.Contains(matched_game_track_id) {
+			return &source_update
+		}
+	}
+
+	return picked
 }
 
 // mega constructor for the complex struct `Addon`.
@@ -296,7 +447,7 @@ func MakeAddon(addons_dir AddonsDir, installed_addon_list []InstalledAddon, prim
 	}
 
 	// sanity checks
-	if addons_dir == (AddonsDir{}) {
+	if reflect.ValueOf(addons_dir).IsZero() {
 		slog.Error("an `Addon` is tied to a specific `AddonsDir` and cannot be empty")
 		panic("programming error")
 	}
@@ -308,6 +459,11 @@ func MakeAddon(addons_dir AddonsDir, installed_addon_list []InstalledAddon, prim
 
 	a.TOC = _make_addon__find_toc(a.AddonsDir.GameTrackID, primary_addon, a.AddonsDir.Strict)
 
+	// disk usage is measured in the background, see `update_addon_sizes`.
+	if size_bytes, present := addon_size_cached(a); present {
+		a = set_addon_size(a, size_bytes)
+	}
+
 	// ---
 
 	has_toc := a.TOC != nil
@@ -331,11 +487,17 @@ func MakeAddon(addons_dir AddonsDir, installed_addon_list []InstalledAddon, prim
 		a.IsPinned = nfo_pinned(*nfo)
 	}
 
+	// 'release channel'
+	if has_nfo {
+		a.ReleaseChannel = nfo.ReleaseChannel
+	}
+
 	// pick a `SourceUpdate` from a list of updates.
 	if has_updates_available && has_game_track {
 		// choose a specific update from a list of updates.
 		// assumes `source_update_list` is sorted newest to oldest.
-		a.SourceUpdate = _make_addon__pick_source_update(source_update_list, a.AddonsDir.GameTrackID, a.AddonsDir.Strict)
+		// the addon's own release channel preference trumps the addons dir's preference.
+		a.SourceUpdate = _make_addon__pick_source_update(source_update_list, a.AddonsDir.GameTrackID, a.AddonsDir.Strict, a.EffectiveReleaseChannel())
 	}
 
 	has_update := a.SourceUpdate != nil
@@ -461,9 +623,48 @@ func MakeAddon(addons_dir AddonsDir, installed_addon_list []InstalledAddon, prim
 		}
 	}
 
+	// 'outdated', the game would show "out of date" for this addon
+	if has_toc && has_game_track {
+		a.CurrentInterfaceVersion = CurrentInterfaceVersion(*a.AddonsDir)
+		a.IsOutdated = interface_version_outdated(*a.TOC, a.AddonsDir.GameTrackID, a.CurrentInterfaceVersion)
+		// we've checked upstream and there is nothing newer to install
+		a.IsPossiblyAbandoned = a.IsOutdated && !a.IsIgnored && has_updates_available && !Updateable(a)
+	}
+
 	return a
 }
 
+// returns `true` if the interface version of the `toc` data is behind the `current_interface_version` for `game_track_id`.
+// only the interface versions for `game_track_id` are considered, unless there are none,
+// in which case the .toc data is for a different game track and is compared as-is.
+// returns `false` if the `current_interface_version` isn't known.
+func interface_version_outdated(toc TOC, game_track_id GameTrackID, current_interface_version int) bool {
+	if current_interface_version == 0 {
+		return false
+	}
+
+	latest := 0
+	latest_any := 0
+	for _, iv := range toc.InterfaceVersionSet.ToSlice() {
+		latest_any = max(latest_any, iv)
+		gt, err := InterfaceVersionToGameTrack(iv)
+		if err == nil && gt == game_track_id {
+			latest = max(latest, iv)
+		}
+	}
+	if latest == 0 {
+		latest = latest_any
+	}
+
+	return latest < current_interface_version
+}
+
+// a `core.ViewFilter` for addons whose interface version is behind the game's.
+func OutdatedAddonFilter(r core.Result) bool {
+	a, is_addon := r.Item.(Addon)
+	return is_addon && a.IsOutdated
+}
+
 // cli.clj/unique-group-id-from-zip-file
 // returns a friendly unique ID for a zipfile based on the file name.
 // zipfile need not exist.
@@ -520,6 +721,9 @@ func (a Addon) ItemKeys() []string {
 		"available-version",
 		"version",
 		"game-version",
+		"release-notes",
+		"outdated",
+		"author",
 	}
 }
 
@@ -544,11 +748,25 @@ func (a Addon) ItemMap() map[string]string {
 		updated_str = updated_formatted
 	}
 
+	// "outdated", "possibly abandoned" or nothing at all
+	outdated_str := ""
+	if a.IsPossiblyAbandoned {
+		outdated_str = "possibly abandoned"
+	} else if a.IsOutdated {
+		outdated_str = "outdated"
+	}
+
 	version := a.InstalledVersion
 	if a.AvailableVersion != "" {
 		version = a.AvailableVersion
 	}
 
+	// only known once the addon's releases have been fetched, and only for some sources
+	author := ""
+	if a.SourceUpdate != nil {
+		author = a.SourceUpdate.Author
+	}
+
 	return map[string]string{
 		"source":                     a.Source,
 		core.ITEM_FIELD_NAME:         a.Label,
@@ -562,6 +780,9 @@ func (a Addon) ItemMap() map[string]string {
 		"available-version":          a.AvailableVersion,
 		"version":                    version,
 		"game-version":               a.GameVersion,
+		"release-notes":              PendingReleaseNotes(a),
+		"outdated":                   outdated_str,
+		"author":                     author,
 	}
 }
 
@@ -578,17 +799,85 @@ func (a Addon) ItemChildren(_ *core.App) []core.Result {
 		children = append(children, ia_result)
 	}
 
-	// todo: doesn't work with gui. ItemChildren is evaluated once, when the row is added.
-	// if the updates don't exist then they won't be shown.
-	/*
-		for _, source_update := range a.SourceUpdateList {
-			su_result := core.MakeResult(NS_SOURCE_UPDATE, source_update, core.UniqueID())
-			children = append(children, su_result)
-		}
-	*/
+	for _, state := range addon_character_states_cached(a) {
+		children = append(children, core.MakeResult(NS_ADDON_CHARACTER, state, core.UniqueID()))
+	}
+
+	// todo: doesn't work well with gui. ItemChildren is evaluated once, when the row is added.
+	// if the updates don't exist yet then they won't be shown.
+	for _, source_update := range PendingUpdates(a) {
+		su_result := core.MakeResult(NS_SOURCE_UPDATE, source_update, core.UniqueID())
+		children = append(children, su_result)
+	}
 	return children
 }
 
+// returns the release channel used to pick the addon's update.
+// the addon's own release channel preference trumps the addons dir's preference, which trumps the global preference.
+func (a Addon) EffectiveReleaseChannel() ReleaseChannel {
+	addons_dir_rc := ReleaseChannel{}
+	if a.AddonsDir != nil {
+		addons_dir_rc = a.AddonsDir.ReleaseChannel
+	}
+	return resolve_release_channel(a.ReleaseChannel, addons_dir_rc, default_release_channel())
+}
+
+// returns the updates between the addon's installed version and it's available version, newest first.
+// only updates sharing a game track with the chosen update and accepted by the addon's release channel are included,
+// and each version appears once.
+// if the installed version can't be found, every update up to the available version is returned.
+func PendingUpdates(a Addon) []SourceUpdate {
+	rv := []SourceUpdate{}
+	if a.SourceUpdate == nil || a.AvailableVersion == "" || a.AvailableVersion == a.InstalledVersion {
+		return rv
+	}
+
+	sul := slices.Clone(a.SourceUpdateList)
+	slices.SortStableFunc(sul, func(x, y SourceUpdate) int {
+		return y.PublishedDate.Compare(x.PublishedDate)
+	})
+
+	release_channel := a.EffectiveReleaseChannel()
+	seen := mapset.NewSet[string]()
+	collecting := false
+	for _, su := range sul {
+		if su.Version == a.SourceUpdate.Version {
+			collecting = true
+		}
+		if !collecting {
+			continue
+		}
+		if su.Version == a.InstalledVersion {
+			break
+		}
+		if seen.Contains(su.Version) {
+			continue
+		}
+		if su.GameTrackIDSet != nil && a.SourceUpdate.GameTrackIDSet != nil && su.GameTrackIDSet.Intersect(a.SourceUpdate.GameTrackIDSet).IsEmpty() {
+			continue
+		}
+		if !release_channel.Accepts(su.Stability) {
+			continue
+		}
+		seen.Add(su.Version)
+		rv = append(rv, su)
+	}
+	return rv
+}
+
+// returns the release notes of each pending update as a single block of text, newest first.
+func PendingReleaseNotes(a Addon) string {
+	note_list := []string{}
+	for _, su := range PendingUpdates(a) {
+		notes := strings.TrimSpace(su.ReleaseNotes)
+		if notes == "" {
+			continue
+		}
+		note_list = append(note_list, fmt.Sprintf("%s\n\n%s", su.Version, notes))
+	}
+	return strings.Join(note_list, "\n\n")
+}
+
 // clj: addon/updateable?
 // an `Addon` may have updates available,
 // but other reasons may prevent it from being updated (ignored, pinned, etc).
@@ -663,8 +952,9 @@ func load_installed_addon(addon_dir PathToAddon) (InstalledAddon, error) {
 		return empty_result, fmt.Errorf("failed to load addon: %w", err)
 	}
 	url := "file://" + addon_dir
-	nfo_list, err := read_nfo_file(addon_dir)
-	if err != nil {
+	nfo_list, err := read_nfo(addon_dir)
+	if err != nil && !errors.Is(err, ErrNFODNE) {
+		// addons without nfo data are still installed addons, just not ones installed by strongbox
 		return empty_result, err
 	}
 	return *MakeInstalledAddon(url, toc_map, nfo_list), nil
@@ -717,6 +1007,21 @@ func determine_primary_subdir(toplevel_dirs mapset.Set[string]) (string, error)
 	return "", fmt.Errorf("no common directory prefix")
 }
 
+// picks the primary directory of a group the same way an installation does,
+// falling back to the first directory by name.
+func pick_primary_dir(dir_name_list []string) string {
+	if len(dir_name_list) == 0 {
+		return ""
+	}
+	primary, err := determine_primary_subdir(mapset.NewSet(dir_name_list...))
+	if err == nil {
+		return primary
+	}
+	srtd := slices.Clone(dir_name_list)
+	slices.Sort(srtd)
+	return srtd[0]
+}
+
 // --- public
 
 // addon.clj/load-all-installed-addons
@@ -760,8 +1065,10 @@ func LoadAllInstalledAddons(addons_dir AddonsDir) ([]Addon, error) {
 
 	addon_list := []Addon{}
 
-	// for each group of `InstalledAddon`, create an `Addon` and select the primary
-	for group_id, installed_addon_group := range installed_addon_groups {
+	// for each group of `InstalledAddon`, create an `Addon` and select the primary.
+	// groups are visited in order and addons within a group are already ordered by directory name.
+	for _, group_id := range slices.Sorted(maps.Keys(installed_addon_groups)) {
+		installed_addon_group := installed_addon_groups[group_id]
 
 		// TODO: how much of this picking the primary etc can be pushed into MakeAddon?
 
@@ -787,25 +1094,32 @@ func LoadAllInstalledAddons(addons_dir AddonsDir) ([]Addon, error) {
 				final_installed_addon_list = installed_addon_group
 				final_primary = &installed_addon_group[0]
 			} else {
-				// multiple addons in group
-				default_primary := &installed_addon_group[0] // default. todo: sort by NFO for reproducible testing.
-				var primary *InstalledAddon
-
-				// read the nfo data to discover the primary
-				for _, installed_addon := range installed_addon_group {
+				// multiple addons in group.
+				// read the nfo data to discover the primary.
+				idx := map[string]*InstalledAddon{}
+				all_list := []string{}
+				primary_list := []string{}
+				for i, installed_addon := range installed_addon_group {
+					idx[installed_addon.Name] = &installed_addon_group[i]
+					all_list = append(all_list, installed_addon.Name)
 					nfo, _ := pick_nfo(installed_addon.NFOList)
 					if nfo.Primary {
-						if primary != nil {
-							slog.Debug("multiple NFO files in addon group are set as the primary. last one wins.")
-							// TODO: ensure this isn't propagated
-						}
-						primary = &installed_addon
+						primary_list = append(primary_list, installed_addon.Name)
 					}
 				}
 
-				if primary == nil {
-					slog.Debug("no NFO files in addon group are set as the primary. first one wins.")
-					primary = default_primary
+				// an addon group should have exactly one primary.
+				// when it doesn't, pick one the same way an installation would. see `RepairAddonsDir` and `SetPrimaryAddon`.
+				var primary *InstalledAddon
+				switch len(primary_list) {
+				case 1:
+					primary = idx[primary_list[0]]
+				case 0:
+					slog.Debug("no NFO files in addon group are set as the primary", "group-id", group_id)
+					primary = idx[pick_primary_dir(all_list)]
+				default:
+					slog.Debug("multiple NFO files in addon group are set as the primary", "group-id", group_id, "primary-list", primary_list)
+					primary = idx[pick_primary_dir(primary_list)]
 				}
 
 				primary_nfo, _ := pick_nfo(primary.NFOList)
@@ -822,7 +1136,7 @@ func LoadAllInstalledAddons(addons_dir AddonsDir) ([]Addon, error) {
 
 	// deterministic order.
 	slices.SortStableFunc(addon_list, func(a Addon, b Addon) int {
-		return cmp.Compare(a.Label, b.Label)
+		return cmp.Or(cmp.Compare(a.Label, b.Label), cmp.Compare(a.Primary.Name, b.Primary.Name))
 	})
 
 	return addon_list, nil
@@ -831,7 +1145,8 @@ func LoadAllInstalledAddons(addons_dir AddonsDir) ([]Addon, error) {
 // safely removes the given `addon-dirname` from `install-dir`.
 // if the given `addon-dirname` is a mutual dependency with another addon, just remove it's entry from
 // the nfo file instead of deleting the whole directory."
-func _remove_addon(ia InstalledAddon, addons_dir AddonsDir, grpid string) error {
+// if a `manifest` is given, only the files in the manifest are removed and any others are kept.
+func _remove_addon(ia InstalledAddon, addons_dir AddonsDir, grpid string, manifest []ManifestEntry) error {
 	addon_dirname := ia.Name                                          // "EveryAddon"
 	final_addon_path := filepath.Join(addons_dir.Path, addon_dirname) // "/path/to/addons/dir/EveryAddon"
 	if !filepath.IsAbs(final_addon_path) {
@@ -852,6 +1167,8 @@ func _remove_addon(ia InstalledAddon, addons_dir AddonsDir, grpid string) error
 		return fmt.Errorf("addon directory is outside of the addons directory: %s", final_addon_path)
 	}
 
+	forget_addon_dir_size(final_addon_path)
+
 	if is_mutual_dependency(ia.NFOList) {
 		// other addons depend on this addon, just remove the nfo file entry
 
@@ -868,6 +1185,20 @@ func _remove_addon(ia InstalledAddon, addons_dir AddonsDir, grpid string) error
 			return fmt.Errorf("failed to write nfo data during removal of mutual dependency addon: %w", err)
 		}
 		slog.Debug("removed addon as mutual dependency", "addon", final_addon_path)
+	} else if manifest != nil {
+		// remove just the files we installed, keeping anything the user added
+		err := nfo_store().Remove(final_addon_path)
+		if err != nil {
+			return fmt.Errorf("failed to remove nfo data during uninstallation: %w", err)
+		}
+		removed, err := remove_manifest_files(addons_dir.Path, addon_dirname, manifest)
+		if err != nil {
+			return fmt.Errorf("failed to remove installed files during uninstallation: %w", err)
+		}
+		if !removed {
+			slog.Info("kept files not installed by strongbox", "addon", final_addon_path)
+		}
+		slog.Debug("removed installed files", "addon", final_addon_path)
 	} else {
 		// all good, remove addon
 		err := os.RemoveAll(final_addon_path)
@@ -878,6 +1209,11 @@ func _remove_addon(ia InstalledAddon, addons_dir AddonsDir, grpid string) error
 			slog.Error("addon dir still exists", "final-addon-path", final_addon_path)
 			panic("programming error")
 		}
+		// nfo data may not live in the addon directory
+		err = nfo_store().Remove(final_addon_path)
+		if err != nil {
+			return fmt.Errorf("failed to remove nfo data during uninstallation: %w", err)
+		}
 		slog.Debug("removed addon directory", "addon", final_addon_path)
 	}
 
@@ -886,7 +1222,7 @@ func _remove_addon(ia InstalledAddon, addons_dir AddonsDir, grpid string) error
 
 // removes the given `addon` from within the `addons_dir`.
 // if addon is part of a group, all addons in group are removed.
-func remove_addon(addon Addon, addons_dir AddonsDir) error {
+func remove_addon(addon Addon, addons_dir AddonsDir, opts RemoveOpts) error {
 	// if addon is being ignored, refuse to remove addon.
 	// note: `group-addons` will add a top level `:ignore?` flag if any addon in a bundle is being ignored.
 	// 2024-08-25: behaviour changed. this is not the place to prevent ignored addons from being removed.
@@ -896,8 +1232,18 @@ func remove_addon(addon Addon, addons_dir AddonsDir) error {
 		slog.Warn("deleting ignored addon", "addon", addon.Label, "addons-dir", addons_dir.Path)
 	}
 
+	var manifest []ManifestEntry
+	if opts.KeepUserFiles && len(addon.InstalledAddonGroup) > 0 {
+		m, err := nfo_store().ReadManifest(addons_dir.Path, addon.NFO.GroupID)
+		if err != nil {
+			// without a manifest we can't tell the files we installed from the files the user added
+			return fmt.Errorf("refusing to remove addon, no record of the files it installed: %w", err)
+		}
+		manifest = m
+	}
+
 	for _, ia := range addon.InstalledAddonGroup {
-		err := _remove_addon(ia, addons_dir, addon.NFO.GroupID)
+		err := _remove_addon(ia, addons_dir, addon.NFO.GroupID, manifest)
 		if err != nil {
 			// bail early with a partial removal?
 			// or continue attempting to remove installed addons and risk more partial removals?
@@ -908,5 +1254,10 @@ func remove_addon(addon Addon, addons_dir AddonsDir) error {
 		}
 	}
 
+	err := nfo_store().RemoveManifest(addons_dir.Path, addon.NFO.GroupID)
+	if err != nil {
+		slog.Warn("failed to remove manifest", "addon", addon.Label, "error", err)
+	}
+
 	return nil
 }
@@ -1,8 +1,10 @@
 package strongbox
 
 import (
+	"bw/core"
 	"errors"
 	"testing"
+	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/stretchr/testify/assert"
@@ -83,6 +85,11 @@ func TestMakeAddon__no_nfo_no_catalogue_match_no_source_update(t *testing.T) {
 		Tags:             nil, // No catalogue match means no tags
 		InterfaceVersion: "100000",
 		GameVersion:      "10.0.0",
+
+		// 10.0.0 is behind retail and the only update isn't for retail
+		CurrentInterfaceVersion: 110200,
+		IsOutdated:              true,
+		IsPossiblyAbandoned:     true,
 	}
 
 	actual := MakeAddon(addons_dir, []InstalledAddon{installed_addon}, primary_installed_addon, &nfo, nil, []SourceUpdate{source_update_list})
@@ -125,3 +132,225 @@ func TestMakeAddonFromCatalogueAddon(t *testing.T) {
 	assert.Equal(t, expected, actual)
 
 }
+
+func Test_ReleaseChannel__Accepts(t *testing.T) {
+	var cases = []struct {
+		channel   ReleaseStability
+		stability ReleaseStability
+		expected  bool
+	}{
+		{"", "", true},
+		{"", RELEASE_STABILITY_STABLE, true},
+		{"", RELEASE_STABILITY_BETA, false},
+		{RELEASE_STABILITY_STABLE, RELEASE_STABILITY_ALPHA, false},
+		{RELEASE_STABILITY_BETA, RELEASE_STABILITY_STABLE, true},
+		{RELEASE_STABILITY_BETA, RELEASE_STABILITY_BETA, true},
+		{RELEASE_STABILITY_BETA, RELEASE_STABILITY_ALPHA, false},
+		{RELEASE_STABILITY_ALPHA, RELEASE_STABILITY_ALPHA, true},
+		{RELEASE_STABILITY_ALPHA, RELEASE_STABILITY_STABLE, true},
+		{"unstable", RELEASE_STABILITY_STABLE, true},
+		{"unstable", RELEASE_STABILITY_BETA, false},
+		{RELEASE_STABILITY_ALPHA, "nightly", false},
+	}
+	for i, c := range cases {
+		assert.Equal(t, c.expected, ReleaseChannel{Stability: c.channel}.Accepts(c.stability), i)
+	}
+}
+
+// the addon's preference trumps the addons dir's preference, field by field.
+func Test_resolve_release_channel(t *testing.T) {
+	addon_rc := ReleaseChannel{Stability: RELEASE_STABILITY_ALPHA}
+	addons_dir_rc := ReleaseChannel{Stability: RELEASE_STABILITY_BETA, Type: RELEASE_TYPE_NOLIB}
+	expected := ReleaseChannel{Stability: RELEASE_STABILITY_ALPHA, Type: RELEASE_TYPE_NOLIB}
+	assert.Equal(t, expected, resolve_release_channel(addon_rc, addons_dir_rc))
+	assert.Equal(t, ReleaseChannel{}, resolve_release_channel())
+}
+
+func Test_make_addon__pick_source_update(t *testing.T) {
+	alpha := SourceUpdate{Version: "1.2.5-alpha", Stability: RELEASE_STABILITY_ALPHA, Type: RELEASE_TYPE_LIB, GameTrackIDSet: mapset.NewSet(GAMETRACK_RETAIL)}
+	beta := SourceUpdate{Version: "1.2.4-beta", Stability: RELEASE_STABILITY_BETA, Type: RELEASE_TYPE_LIB, GameTrackIDSet: mapset.NewSet(GAMETRACK_RETAIL)}
+	stable := SourceUpdate{Version: "1.2.3", Stability: RELEASE_STABILITY_STABLE, Type: RELEASE_TYPE_LIB, GameTrackIDSet: mapset.NewSet(GAMETRACK_RETAIL)}
+	stable_nolib := SourceUpdate{Version: "1.2.3", Stability: RELEASE_STABILITY_STABLE, Type: RELEASE_TYPE_NOLIB, GameTrackIDSet: mapset.NewSet(GAMETRACK_RETAIL)}
+	classic := SourceUpdate{Version: "1.2.3", Stability: RELEASE_STABILITY_STABLE, Type: RELEASE_TYPE_LIB, GameTrackIDSet: mapset.NewSet(GAMETRACK_CLASSIC)}
+
+	sul := []SourceUpdate{alpha, beta, stable, stable_nolib, classic}
+
+	var cases = []struct {
+		game_track_id GameTrackID
+		strict        bool
+		channel       ReleaseChannel
+		expected      *SourceUpdate
+	}{
+		{GAMETRACK_RETAIL, true, ReleaseChannel{}, &stable},
+		{GAMETRACK_RETAIL, true, ReleaseChannel{Stability: RELEASE_STABILITY_BETA}, &beta},
+		{GAMETRACK_RETAIL, true, ReleaseChannel{Stability: RELEASE_STABILITY_ALPHA}, &alpha},
+		{GAMETRACK_RETAIL, true, ReleaseChannel{Type: RELEASE_TYPE_NOLIB}, &stable_nolib},
+		// no nolib alternative for the beta, beta is preferred over an older nolib release
+		{GAMETRACK_RETAIL, true, ReleaseChannel{Stability: RELEASE_STABILITY_BETA, Type: RELEASE_TYPE_NOLIB}, &beta},
+		{GAMETRACK_CLASSIC, true, ReleaseChannel{Stability: RELEASE_STABILITY_ALPHA}, &classic},
+		{GAMETRACK_CLASSIC_TBC, true, ReleaseChannel{}, nil},
+		// not strict, falls back to the closest game track
+		{GAMETRACK_CLASSIC_TBC, false, ReleaseChannel{}, &classic},
+		{GAMETRACK_CLASSIC_TBC, false, ReleaseChannel{Stability: RELEASE_STABILITY_ALPHA}, &classic},
+	}
+	for i, c := range cases {
+		assert.Equal(t, c.expected, _make_addon__pick_source_update(sul, c.game_track_id, c.strict, c.channel), i)
+	}
+
+	assert.Nil(t, _make_addon__pick_source_update([]SourceUpdate{}, GAMETRACK_RETAIL, true, ReleaseChannel{}))
+	assert.Nil(t, _make_addon__pick_source_update([]SourceUpdate{alpha}, GAMETRACK_RETAIL, false, ReleaseChannel{}))
+}
+
+// updates between the installed and available versions are pending, newest first.
+func Test_PendingUpdates(t *testing.T) {
+	su := func(version string, day int, notes string, gtl ...GameTrackID) SourceUpdate {
+		x := NewSourceUpdate()
+		x.Version = version
+		x.PublishedDate = time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC)
+		x.ReleaseNotes = notes
+		x.GameTrackIDSet = mapset.NewSet(gtl...)
+		return x
+	}
+
+	sul := []SourceUpdate{
+		su("1.0.0", 1, "first", GAMETRACK_RETAIL),
+		su("1.2.0", 3, "third", GAMETRACK_RETAIL),
+		su("1.2.0", 3, "third", GAMETRACK_RETAIL), // nolib
+		su("1.1.0", 2, "second", GAMETRACK_RETAIL),
+		su("1.1.1", 2, "classic only", GAMETRACK_CLASSIC),
+		su("1.3.0", 4, "unreleased?", GAMETRACK_RETAIL),
+	}
+
+	a := Addon{
+		SourceUpdateList: sul,
+		SourceUpdate:     &sul[1],
+		InstalledVersion: "1.0.0",
+		AvailableVersion: "1.2.0",
+	}
+
+	actual := PendingUpdates(a)
+	assert.Equal(t, 2, len(actual))
+	assert.Equal(t, "1.2.0", actual[0].Version)
+	assert.Equal(t, "1.1.0", actual[1].Version)
+
+	assert.Equal(t, "1.2.0\n\nthird\n\n1.1.0\n\nsecond", PendingReleaseNotes(a))
+
+	// nothing pending when up to date
+	a.InstalledVersion = "1.2.0"
+	assert.Equal(t, []SourceUpdate{}, PendingUpdates(a))
+
+	// installed version unknown, everything up to the available version
+	a.InstalledVersion = "0.9.0"
+	assert.Equal(t, 3, len(PendingUpdates(a)))
+
+	// no update, nothing pending
+	assert.Equal(t, []SourceUpdate{}, PendingUpdates(Addon{SourceUpdateList: sul}))
+
+	// releases outside of the addon's release channel aren't pending
+	sul[3].Stability = RELEASE_STABILITY_BETA
+	a.InstalledVersion = "1.0.0"
+	actual = PendingUpdates(a)
+	assert.Equal(t, 1, len(actual))
+	assert.Equal(t, "1.2.0", actual[0].Version)
+
+	a.ReleaseChannel = ReleaseChannel{Stability: RELEASE_STABILITY_BETA}
+	assert.Equal(t, 2, len(PendingUpdates(a)))
+}
+
+func Test_interface_version_outdated(t *testing.T) {
+	toc := func(interface_version_list ...int) TOC {
+		toc := NewTOC()
+		toc.InterfaceVersionSet = mapset.NewSet(interface_version_list...)
+		return toc
+	}
+
+	var cases = []struct {
+		toc           TOC
+		game_track_id GameTrackID
+		current       int
+		expected      bool
+	}{
+		{toc(110200), GAMETRACK_RETAIL, 110200, false},
+		{toc(110205), GAMETRACK_RETAIL, 110200, false}, // ahead, ptr perhaps
+		{toc(110100), GAMETRACK_RETAIL, 110200, true},
+		{toc(110100, 11507), GAMETRACK_CLASSIC, 11507, false}, // only classic interface versions are compared
+		{toc(110200, 11506), GAMETRACK_CLASSIC, 11507, true},
+		{toc(11507), GAMETRACK_RETAIL, 110200, true}, // a classic addon in a retail addons dir
+		{toc(), GAMETRACK_RETAIL, 110200, true},      // no interface version at all
+		{toc(100000), GAMETRACK_RETAIL, 0, false},    // current interface version unknown
+	}
+	for i, c := range cases {
+		assert.Equal(t, c.expected, interface_version_outdated(c.toc, c.game_track_id, c.current), i)
+	}
+}
+
+// an outdated addon with an update available isn't abandoned, one without is possibly abandoned.
+func TestMakeAddon__outdated(t *testing.T) {
+	addons_dir := AddonsDir{GameTrackID: GAMETRACK_RETAIL, Strict: true}
+
+	toc := NewTOC()
+	toc.GameTrackIDSet = mapset.NewSet(GAMETRACK_RETAIL)
+	toc.InterfaceVersionSet = mapset.NewSet(100000)
+
+	ia := NewInstalledAddon()
+	ia.TOCMap = map[PathToFile]TOC{"EveryAddon.toc": toc}
+	ia.GametrackIDSet = mapset.NewSet(GAMETRACK_RETAIL)
+	nfo := NFO{InstalledVersion: "1.2.3", InstalledGameTrackID: GAMETRACK_RETAIL, Source: SOURCE_GITHUB, SourceID: "ogri-la/everyaddon"}
+	ia.NFOList = []NFO{nfo}
+
+	su := NewSourceUpdate()
+	su.Version = "1.2.4"
+	su.GameTrackIDSet = mapset.NewSet(GAMETRACK_RETAIL)
+
+	a := MakeAddon(addons_dir, []InstalledAddon{ia}, ia, &nfo, nil, []SourceUpdate{su})
+	assert.True(t, a.IsOutdated)
+	assert.False(t, a.IsPossiblyAbandoned)
+	assert.Equal(t, "outdated", a.ItemMap()["outdated"])
+	assert.True(t, OutdatedAddonFilter(core.MakeResult(NS_ADDON, a, "foo")))
+
+	su.Version = "1.2.3"
+	a = MakeAddon(addons_dir, []InstalledAddon{ia}, ia, &nfo, nil, []SourceUpdate{su})
+	assert.True(t, a.IsPossiblyAbandoned)
+	assert.Equal(t, "possibly abandoned", a.ItemMap()["outdated"])
+
+	// never checked upstream, can't say
+	a = MakeAddon(addons_dir, []InstalledAddon{ia}, ia, &nfo, nil, nil)
+	assert.True(t, a.IsOutdated)
+	assert.False(t, a.IsPossiblyAbandoned)
+
+	// up to date
+	toc.InterfaceVersionSet = mapset.NewSet(110200)
+	ia.TOCMap = map[PathToFile]TOC{"EveryAddon.toc": toc}
+	a = MakeAddon(addons_dir, []InstalledAddon{ia}, ia, &nfo, nil, nil)
+	assert.False(t, a.IsOutdated)
+	assert.Equal(t, "", a.ItemMap()["outdated"])
+	assert.False(t, OutdatedAddonFilter(core.MakeResult(NS_ADDON, a, "foo")))
+}
+
+// the author of the chosen release is shown, when the source provides one
+func Test_Addon_ItemMap__author(t *testing.T) {
+	a := Addon{}
+	assert.Equal(t, "", a.ItemMap()["author"])
+
+	su := NewSourceUpdate()
+	su.Author = "Jane Doe"
+	a.SourceUpdate = &su
+	assert.Contains(t, a.ItemKeys(), "author")
+	assert.Equal(t, "Jane Doe", a.ItemMap()["author"])
+
+	assert.Contains(t, su.ItemKeys(), "author")
+	assert.Equal(t, "Jane Doe", su.ItemMap()["author"])
+}
+
+// the toc data used is the first supporting the most preferred game track, regardless of map order
+func Test_make_addon__find_toc(t *testing.T) {
+	ia := InstalledAddon{TOCMap: map[PathToFile]TOC{
+		"EveryAddon.toc":         {FileName: "EveryAddon.toc", GameTrackIDSet: mapset.NewSet(GAMETRACK_RETAIL, GAMETRACK_CLASSIC)},
+		"EveryAddon-Classic.toc": {FileName: "EveryAddon-Classic.toc", GameTrackIDSet: mapset.NewSet(GAMETRACK_CLASSIC)},
+		"EveryAddon-Mists.toc":   {FileName: "EveryAddon-Mists.toc", GameTrackIDSet: mapset.NewSet(GAMETRACK_RETAIL)},
+	}}
+	for range 10 {
+		assert.Equal(t, "EveryAddon-Classic.toc", _make_addon__find_toc(GAMETRACK_CLASSIC, ia, true).FileName)
+		assert.Equal(t, "EveryAddon-Mists.toc", _make_addon__find_toc(GAMETRACK_RETAIL, ia, false).FileName)
+	}
+}
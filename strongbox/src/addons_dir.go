@@ -2,9 +2,13 @@ package strongbox
 
 import (
 	"bw/core"
+	"fmt"
 	"log/slog"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // --- AddonsDir
@@ -18,6 +22,13 @@ type AddonsDir struct {
 	GameTrackID GameTrackID `json:"game-track"`
 	Strict      bool        `json:"strict"` // new in 8.0 (removed '?')
 
+	// new in 8.0, the releases addons in this directory should be offered.
+	// may be overridden per-addon.
+	ReleaseChannel
+
+	// new in 8.0, overrides of the global preferences for just this directory.
+	AddonsDirPreferences
+
 	// deprecated, use `Strict` instead
 	StrictPtr *bool `json:"strict?,omitempty"`
 
@@ -25,6 +36,109 @@ type AddonsDir struct {
 	selected bool // dynamically set as settings change
 }
 
+// per-addons-dir overrides of the global `Preferences`.
+// a nil value uses the global preference, see `resolve_addons_dir_preferences`.
+type AddonsDirPreferences struct {
+	AddonZipsToKeep       *uint8   `json:"addon-zips-to-keep,omitempty"`
+	CheckForUpdate        *bool    `json:"check-for-update,omitempty"`
+	BackupWTFBeforeUpdate *bool    `json:"backup-wtf-before-update,omitempty"`
+	ExcludedAddonList     []string `json:"excluded-addon-list,omitempty"` // names of addons that are never checked for updates or updated
+}
+
+// the preferences in effect for an addons dir, the per-addons-dir overrides falling back to the global preferences.
+type EffectivePreferences struct {
+	AddonZipsToKeep       *uint8 // nil is 'keep all'
+	CheckForUpdate        bool
+	ReleaseChannel        ReleaseChannel
+	BackupWTFBeforeUpdate bool
+	ExcludedAddonList     []string
+}
+
+// returns `true` if the addon has been excluded from update checks and updates.
+// addons are matched by their normalised name or their directory name.
+func (ep EffectivePreferences) Excludes(a Addon) bool {
+	return slices.ContainsFunc(ep.ExcludedAddonList, func(name string) bool {
+		return name != "" && (name == a.Name || name == a.DirName)
+	})
+}
+
+// resolves the preferences in effect for `addons_dir`.
+// per-addons-dir values are used when set, otherwise the global value in `prefs` is used.
+func resolve_addons_dir_preferences(prefs Preferences, addons_dir AddonsDir) EffectivePreferences {
+	first := func(val_list ...*bool) bool {
+		for _, v := range val_list {
+			if v != nil {
+				return *v
+			}
+		}
+		return false
+	}
+
+	ep := EffectivePreferences{
+		AddonZipsToKeep:       prefs.AddonZipsToKeep,
+		CheckForUpdate:        first(addons_dir.CheckForUpdate, prefs.CheckForUpdate),
+		ReleaseChannel:        resolve_release_channel(addons_dir.ReleaseChannel, prefs.ReleaseChannel),
+		BackupWTFBeforeUpdate: first(addons_dir.BackupWTFBeforeUpdate, prefs.BackupWTFBeforeUpdate),
+		ExcludedAddonList:     []string{},
+	}
+	if addons_dir.AddonZipsToKeep != nil {
+		ep.AddonZipsToKeep = addons_dir.AddonZipsToKeep
+	}
+	if addons_dir.ExcludedAddonList != nil {
+		ep.ExcludedAddonList = slices.Clone(addons_dir.ExcludedAddonList)
+	}
+	return ep
+}
+
+// returns the preferences in effect for `addons_dir`.
+// the addons dir is looked up in the settings by path so stale copies still see the latest overrides.
+// the default settings are used if settings haven't been loaded.
+func addons_dir_preferences(app *core.App, addons_dir AddonsDir) EffectivePreferences {
+	settings, err := find_settings(app.State)
+	if err != nil {
+		settings = NewSettings()
+	}
+	for _, ad := range settings.AddonsDirList {
+		if ad.Path == addons_dir.Path {
+			addons_dir = ad
+			break
+		}
+	}
+	return resolve_addons_dir_preferences(settings.Preferences, addons_dir)
+}
+
+// the global release channel preference, used when neither the addon nor the addons dir have one.
+// kept here as addons are created without access to the settings.
+var global_release_channel atomic.Pointer[ReleaseChannel]
+
+func default_release_channel() ReleaseChannel {
+	rc := global_release_channel.Load()
+	if rc == nil {
+		return ReleaseChannel{}
+	}
+	return *rc
+}
+
+func set_default_release_channel(rc ReleaseChannel) {
+	global_release_channel.Store(&rc)
+}
+
+// keeps the global release channel in step with the settings in app state.
+type release_channel_observer struct{}
+
+func (o release_channel_observer) OnResultsChanged(_, new_snapshot *core.Snapshot) {
+	r := new_snapshot.GetResult(ID_SETTINGS)
+	if r == nil {
+		return
+	}
+	settings, is_settings := r.Item.(Settings)
+	if is_settings {
+		set_default_release_channel(settings.Preferences.ReleaseChannel)
+	}
+}
+
+func (o release_channel_observer) OnAction(_ core.Action) {}
+
 func MakeAddonsDir(path PathToDir) AddonsDir {
 	return AddonsDir{
 		Path:        path,
@@ -119,6 +233,13 @@ func SelectAddonsDir(app *core.App, addons_dir PathToDir) *sync.WaitGroup {
 // updates application state to insert a new addons directory at `path`.
 // DOES NOT save settings.
 func CreateAddonsDir(app *core.App, path PathToDir) *sync.WaitGroup {
+	return AddAddonsDir(app, MakeAddonsDir(path))
+}
+
+// updates application state to insert the given `addons_dir`, selecting it.
+// DOES NOT save settings.
+func AddAddonsDir(app *core.App, addons_dir AddonsDir) *sync.WaitGroup {
+	path := addons_dir.Path
 	return app.UpdateState(func(old_state core.State) core.State {
 		// we're not just fetching the settings, we're also updating them at the same time
 		i, present := old_state.ResultIndex(ID_SETTINGS)
@@ -139,7 +260,7 @@ func CreateAddonsDir(app *core.App, path PathToDir) *sync.WaitGroup {
 		}
 
 		// create a new addons dir
-		ad := MakeAddonsDir(path)
+		ad := addons_dir
 		ad.selected = true
 
 		// update the settings
@@ -208,3 +329,125 @@ func RemoveAddonsDir(app *core.App, path PathToDir) *sync.WaitGroup {
 		return old_state
 	})
 }
+
+// updates application state, replacing the addons dir at `path` with the result of calling `fn` on it.
+// both the settings and the addons dir result are updated.
+// DOES NOT save settings.
+func UpdateAddonsDir(app *core.App, path PathToDir, fn func(AddonsDir) AddonsDir) *sync.WaitGroup {
+	return app.UpdateState(func(old_state core.State) core.State {
+		rl := old_state.Root.Item.([]core.Result)
+		for idx, r := range rl {
+			switch item := r.Item.(type) {
+			case Settings:
+				if r.ID != ID_SETTINGS {
+					continue
+				}
+				new_addons_dir_list := []AddonsDir{}
+				for _, ad := range item.AddonsDirList {
+					if ad.Path == path {
+						ad = fn(ad)
+					}
+					new_addons_dir_list = append(new_addons_dir_list, ad)
+				}
+				item.AddonsDirList = new_addons_dir_list
+				r.Item = item
+				rl[idx] = r
+
+			case AddonsDir:
+				if item.Path != path {
+					continue
+				}
+				selected := item.selected
+				item = fn(item)
+				item.selected = selected
+				r.Item = item
+				rl[idx] = r
+			}
+		}
+		old_state.Root.Item = rl
+		return old_state
+	})
+}
+
+// sets the release channel preference for all addons in the addons dir at `path`.
+// DOES NOT save settings.
+func SetAddonsDirReleaseChannel(app *core.App, path PathToDir, release_channel ReleaseChannel) *sync.WaitGroup {
+	return UpdateAddonsDir(app, path, func(ad AddonsDir) AddonsDir {
+		ad.ReleaseChannel = release_channel
+		return ad
+	})
+}
+
+// replaces the per-addons-dir preferences of the addons dir at `path`.
+// DOES NOT save settings.
+func SetAddonsDirPreferences(app *core.App, path PathToDir, prefs AddonsDirPreferences) *sync.WaitGroup {
+	return UpdateAddonsDir(app, path, func(ad AddonsDir) AddonsDir {
+		ad.AddonsDirPreferences = prefs
+		return ad
+	})
+}
+
+// parses user input into per-addons-dir preferences.
+// empty values and the 'default' choice (see `PREFERENCE_CHOICE_DEFAULT`) become nil and use the global preference.
+// `excluded` is a comma separated list of addon names.
+func parse_addons_dir_preferences(zips_to_keep string, check_for_update string, backup_wtf_before_update string, excluded string) (AddonsDirPreferences, error) {
+	empty_response := AddonsDirPreferences{}
+	prefs := AddonsDirPreferences{}
+
+	parse_bool := func(val string) (*bool, error) {
+		val = strings.TrimSpace(val)
+		if val == "" || val == PREFERENCE_CHOICE_DEFAULT {
+			return nil, nil
+		}
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("expected 'true', 'false' or '%s': %s", PREFERENCE_CHOICE_DEFAULT, val)
+		}
+		return &b, nil
+	}
+
+	zips_to_keep = strings.TrimSpace(zips_to_keep)
+	if zips_to_keep != "" && zips_to_keep != PREFERENCE_CHOICE_DEFAULT {
+		n, err := strconv.ParseUint(zips_to_keep, 10, 8)
+		if err != nil {
+			return empty_response, fmt.Errorf("zip files to keep must be a number between 0 and 255: %s", zips_to_keep)
+		}
+		prefs.AddonZipsToKeep = new(uint8(n))
+	}
+
+	var err error
+	prefs.CheckForUpdate, err = parse_bool(check_for_update)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to parse 'check for update': %w", err)
+	}
+	prefs.BackupWTFBeforeUpdate, err = parse_bool(backup_wtf_before_update)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to parse 'backup WTF before update': %w", err)
+	}
+
+	excluded_list := []string{}
+	for name := range strings.SplitSeq(excluded, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !slices.Contains(excluded_list, name) {
+			excluded_list = append(excluded_list, name)
+		}
+	}
+	if len(excluded_list) > 0 {
+		prefs.ExcludedAddonList = excluded_list
+	}
+
+	return prefs, nil
+}
+
+// changes the game track and strictness of the addons dir at `path`.
+// DOES NOT save settings.
+func SetAddonsDirGameTrack(app *core.App, path PathToDir, game_track_id GameTrackID, strict bool) (*sync.WaitGroup, error) {
+	if !game_tracks().Supported(game_track_id) {
+		return nil, fmt.Errorf("unsupported game track: %s", game_track_id)
+	}
+	return UpdateAddonsDir(app, path, func(ad AddonsDir) AddonsDir {
+		ad.GameTrackID = game_track_id
+		ad.Strict = strict
+		return ad
+	}), nil
+}
@@ -0,0 +1,517 @@
+package strongbox
+
+import (
+	"archive/zip"
+	"bw/core"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// addons_dir_snapshot.go snapshots every addon in an addons directory so the directory can be restored exactly.
+// snapshots are .zip files in the strongbox data directory, one directory of snapshots per addons directory.
+// each snapshot has a manifest of the addons, their nfo data and versions alongside the addon files.
+
+// the number of snapshots to keep per addons directory when not otherwise set.
+const ADDONS_DIR_SNAPSHOTS_TO_KEEP_DEFAULT uint8 = 3
+
+// "addons-dir--20260101T120000Z.zip"
+const ADDONS_DIR_SNAPSHOT_PREFIX = "addons-dir--"
+
+const (
+	snapshot_manifest_file = "manifest.json"
+	snapshot_addons_prefix = "addons/" // addon directories live beneath this directory in the .zip file
+)
+
+// an addon as it was when the snapshot was taken.
+type SnapshotAddon struct {
+	Label            string   `json:"label"`
+	DirNameList      []string `json:"dir-name-list"`
+	GroupID          string   `json:"group-id,omitempty"`
+	Source           Source   `json:"source,omitempty"`
+	SourceID         string   `json:"source-id,omitempty"`
+	InstalledVersion string   `json:"installed-version,omitempty"`
+}
+
+type snapshot_manifest struct {
+	AddonsDir   PathToDir                  `json:"addons-dir"`
+	GameTrackID GameTrackID                `json:"game-track"`
+	Created     time.Time                  `json:"created"`
+	AddonList   []SnapshotAddon            `json:"addon-list"`
+	NFOIdx      map[string][]NFO           `json:"nfo"`      // nfo data keyed by addon directory name
+	ManifestIdx map[string][]ManifestEntry `json:"manifest"` // installed files keyed by group id
+}
+
+// a snapshot of every addon in an addons directory.
+type AddonsDirSnapshot struct {
+	AddonsDir PathToDir  // the addons directory the snapshot was taken from
+	Path      PathToFile // the .zip file
+	Created   time.Time
+	AddonList []SnapshotAddon
+	SizeBytes int64
+}
+
+var _ core.ItemInfo = (*AddonsDirSnapshot)(nil)
+
+func (s AddonsDirSnapshot) ItemKeys() []string {
+	return []string{
+		core.ITEM_FIELD_NAME,
+		core.ITEM_FIELD_DATE_CREATED,
+		"num-addons",
+		"size",
+	}
+}
+
+func (s AddonsDirSnapshot) ItemMap() map[string]string {
+	created, err := core.FormatTimeHumanOffset(s.Created)
+	if err != nil {
+		created = s.Created.Format(time.RFC3339)
+	}
+	return map[string]string{
+		core.ITEM_FIELD_NAME:         filepath.Base(s.Path),
+		core.ITEM_FIELD_DATE_CREATED: created,
+		"num-addons":                 core.IntToString(len(s.AddonList)),
+		"size":                       format_size_bytes(s.SizeBytes),
+	}
+}
+
+func (s AddonsDirSnapshot) ItemHasChildren() core.ITEM_CHILDREN_LOAD {
+	return core.ITEM_CHILDREN_LOAD_FALSE
+}
+
+func (s AddonsDirSnapshot) ItemChildren(_ *core.App) []core.Result {
+	return nil
+}
+
+// ---
+
+// returns the root directory of all addons directory snapshots.
+func snapshot_root(app *core.App) PathToDir {
+	return app.State.GetKeyVal("strongbox.paths.snapshot-dir")
+}
+
+// returns the directory snapshots of the given addons directory are kept in, beneath `snapshot_root`.
+func addons_dir_snapshot_dir(snapshot_root PathToDir, addons_dir PathToDir) PathToDir {
+	return filepath.Join(snapshot_root, slugify(addons_dir))
+}
+
+// returns the names of the addon directories in `addons_dir`, ordered by name.
+// Blizzard addons belong to the game and are skipped.
+func snapshot_dir_name_list(addons_dir PathToDir) ([]string, error) {
+	dir_list, err := core.DirList(addons_dir)
+	if err != nil {
+		return nil, err
+	}
+	rv := []string{}
+	for _, path := range dir_list {
+		if BlizzardAddon(path) {
+			continue
+		}
+		rv = append(rv, filepath.Base(path))
+	}
+	return rv, nil
+}
+
+// reads the addons, nfo data and installed file manifests of `addons_dir` as they are now.
+func make_snapshot_manifest(addons_dir AddonsDir, dir_name_list []string, created time.Time) (snapshot_manifest, error) {
+	empty_response := snapshot_manifest{}
+	addon_list, err := LoadAllInstalledAddons(addons_dir)
+	if err != nil {
+		return empty_response, err
+	}
+
+	rv := snapshot_manifest{
+		AddonsDir:   addons_dir.Path,
+		GameTrackID: addons_dir.GameTrackID,
+		Created:     created,
+		AddonList:   []SnapshotAddon{},
+		NFOIdx:      map[string][]NFO{},
+		ManifestIdx: map[string][]ManifestEntry{},
+	}
+
+	for _, a := range addon_list {
+		sa := SnapshotAddon{
+			Label:            a.Label,
+			DirNameList:      addon_dir_name_list(a),
+			Source:           a.Source,
+			SourceID:         a.SourceID,
+			InstalledVersion: a.InstalledVersion,
+		}
+		if a.NFO != nil {
+			sa.GroupID = a.NFO.GroupID
+		}
+		rv.AddonList = append(rv.AddonList, sa)
+	}
+
+	for _, dir_name := range dir_name_list {
+		nfo_list, err := read_nfo(filepath.Join(addons_dir.Path, dir_name))
+		if err != nil {
+			continue // no nfo data or bad nfo data, the addon files are still kept
+		}
+		rv.NFOIdx[dir_name] = nfo_list
+		for _, nfo := range nfo_list {
+			if _, present := rv.ManifestIdx[nfo.GroupID]; present || nfo.GroupID == "" {
+				continue
+			}
+			manifest, err := nfo_store().ReadManifest(addons_dir.Path, nfo.GroupID)
+			if err == nil {
+				rv.ManifestIdx[nfo.GroupID] = manifest
+			}
+		}
+	}
+
+	return rv, nil
+}
+
+// writes the manifest and every file beneath the given addon directories to `zw`.
+// directories are included so empty directories are restored.
+// symlinks, like addons installed from a local directory, are kept as symlinks and not followed.
+func write_snapshot_zipfile(zw *zip.Writer, addons_dir PathToDir, dir_name_list []string, manifest snapshot_manifest) error {
+	w, err := zw.Create(snapshot_manifest_file)
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(w).Encode(manifest)
+	if err != nil {
+		return err
+	}
+
+	for _, dir_name := range dir_name_list {
+		err = filepath.WalkDir(filepath.Join(addons_dir, dir_name), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(addons_dir, path)
+			if err != nil {
+				return err
+			}
+			name := snapshot_addons_prefix + filepath.ToSlash(rel)
+
+			if d.Type()&fs.ModeSymlink != 0 {
+				return zip_add_symlink(zw, path, name)
+			}
+
+			if d.IsDir() {
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				header, err := zip.FileInfoHeader(info)
+				if err != nil {
+					return err
+				}
+				header.Name = name + "/"
+				_, err = zw.CreateHeader(header)
+				return err
+			}
+
+			return zip_add_file(zw, path, name)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reads the manifest within an open snapshot.
+func read_snapshot_manifest(r *zip.Reader) (snapshot_manifest, error) {
+	empty_response := snapshot_manifest{}
+	fh, err := r.Open(snapshot_manifest_file)
+	if err != nil {
+		return empty_response, fmt.Errorf("snapshot has no manifest: %w", err)
+	}
+	defer fh.Close()
+	rv := snapshot_manifest{}
+	err = json.NewDecoder(fh).Decode(&rv)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read snapshot manifest: %w", err)
+	}
+	return rv, nil
+}
+
+// reads the snapshot at `path`, returning an error if it isn't an addons directory snapshot.
+func read_addons_dir_snapshot(addons_dir PathToDir, path PathToFile) (AddonsDirSnapshot, error) {
+	empty_response := AddonsDirSnapshot{}
+
+	tz, err := parse_timestamped_zipfile(path, ADDONS_DIR_SNAPSHOT_PREFIX)
+	if err != nil {
+		return empty_response, fmt.Errorf("not an addons directory snapshot: %w", err)
+	}
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read addons directory snapshot: %w", err)
+	}
+	defer r.Close()
+
+	manifest, err := read_snapshot_manifest(&r.Reader)
+	if err != nil {
+		return empty_response, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read addons directory snapshot: %w", err)
+	}
+
+	return AddonsDirSnapshot{
+		AddonsDir: addons_dir,
+		Path:      path,
+		Created:   tz.Created,
+		AddonList: manifest.AddonList,
+		SizeBytes: info.Size(),
+	}, nil
+}
+
+// returns the snapshots of the given `addons_dir` beneath `snapshot_root`, newest first.
+func ListAddonsDirSnapshots(snapshot_root PathToDir, addons_dir PathToDir) ([]AddonsDirSnapshot, error) {
+	empty_response := []AddonsDirSnapshot{}
+
+	zipfile_list, err := list_timestamped_zipfiles(addons_dir_snapshot_dir(snapshot_root, addons_dir), ADDONS_DIR_SNAPSHOT_PREFIX)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to list addons directory snapshots: %w", err)
+	}
+
+	rv := []AddonsDirSnapshot{}
+	for _, tz := range zipfile_list {
+		snapshot, err := read_addons_dir_snapshot(addons_dir, tz.Path)
+		if err != nil {
+			slog.Warn("skipping file in addons directory snapshot directory", "path", tz.Path, "error", err)
+			continue
+		}
+		rv = append(rv, snapshot)
+	}
+	return rv, nil
+}
+
+// deletes all but the newest `num_to_keep` snapshots of `addons_dir`.
+// a nil `num_to_keep` keeps all snapshots.
+func prune_addons_dir_snapshots(snapshot_root PathToDir, addons_dir PathToDir, num_to_keep *uint8) error {
+	err := prune_timestamped_zipfiles(addons_dir_snapshot_dir(snapshot_root, addons_dir), ADDONS_DIR_SNAPSHOT_PREFIX, num_to_keep)
+	if err != nil {
+		return fmt.Errorf("failed to prune addons directory snapshots: %w", err)
+	}
+	return nil
+}
+
+// snapshots every addon in `addons_dir` to a timestamped .zip file beneath `snapshot_root`,
+// then removes all but the newest `num_to_keep` snapshots.
+func SnapshotAddonsDir(snapshot_root PathToDir, addons_dir AddonsDir, now time.Time, num_to_keep *uint8) (AddonsDirSnapshot, error) {
+	empty_response := AddonsDirSnapshot{}
+
+	if !core.DirExists(addons_dir.Path) {
+		return empty_response, fmt.Errorf("addons directory not found: %s", addons_dir.Path)
+	}
+
+	dir_name_list, err := snapshot_dir_name_list(addons_dir.Path)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read addons directory: %w", err)
+	}
+
+	created := now.UTC().Truncate(time.Second)
+	manifest, err := make_snapshot_manifest(addons_dir, dir_name_list, created)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read addons directory: %w", err)
+	}
+
+	dir := addons_dir_snapshot_dir(snapshot_root, addons_dir.Path)
+	zipfile, size, err := create_timestamped_zipfile(dir, ADDONS_DIR_SNAPSHOT_PREFIX, created, func(zw *zip.Writer) error {
+		return write_snapshot_zipfile(zw, addons_dir.Path, dir_name_list, manifest)
+	})
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to snapshot addons directory: %w", err)
+	}
+
+	slog.Info("snapshot addons directory", "addons-dir", addons_dir.Path, "path", zipfile, "num-addons", len(manifest.AddonList))
+
+	err = prune_addons_dir_snapshots(snapshot_root, addons_dir.Path, num_to_keep)
+	if err != nil {
+		slog.Warn("failed to prune addons directory snapshots", "error", err)
+	}
+
+	return AddonsDirSnapshot{
+		AddonsDir: addons_dir.Path,
+		Path:      zipfile,
+		Created:   created,
+		AddonList: manifest.AddonList,
+		SizeBytes: size,
+	}, nil
+}
+
+// writes a single file or directory from a snapshot into `addons_dir`.
+func restore_snapshot_file(addons_dir PathToDir, f *zip.File) error {
+	rel := strings.TrimPrefix(f.Name, snapshot_addons_prefix)
+	path := filepath.Join(addons_dir, filepath.FromSlash(rel))
+	if !strings.HasPrefix(path, filepath.Clean(addons_dir)+string(os.PathSeparator)) {
+		return fmt.Errorf("%s: illegal file path", path)
+	}
+
+	if f.FileInfo().IsDir() {
+		return os.MkdirAll(path, 0755)
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if f.Mode()&fs.ModeSymlink != 0 {
+		target, err := io.ReadAll(rc)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(string(target), path)
+	}
+
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, rc)
+	return err
+}
+
+// removes every addon directory in `addons_dir`, along with it's nfo data and the manifests of it's groups.
+func clear_addons_dir(addons_dir PathToDir, dir_name_list []string) error {
+	for _, dir_name := range dir_name_list {
+		path := filepath.Join(addons_dir, dir_name)
+		nfo_list, err := read_nfo(path)
+		if err == nil {
+			for _, nfo := range nfo_list {
+				if nfo.GroupID == "" {
+					continue
+				}
+				err = nfo_store().RemoveManifest(addons_dir, nfo.GroupID)
+				if err != nil && !errors.Is(err, ErrManifestDNE) {
+					slog.Warn("failed to remove manifest", "group-id", nfo.GroupID, "error", err)
+				}
+			}
+		}
+		err = nfo_store().Remove(path)
+		if err != nil {
+			return err
+		}
+		err = os.RemoveAll(path)
+		if err != nil {
+			return err
+		}
+		forget_addon_dir_size(path)
+	}
+	return nil
+}
+
+// restores the addons directory the `snapshot` was taken from to exactly how it was.
+// addons installed since the snapshot was taken are removed.
+// the current addons directory is snapshot first and that snapshot is returned.
+// Blizzard addons and files that aren't in an addon directory are left alone.
+func RestoreAddonsDirSnapshot(snapshot_root PathToDir, addons_dir AddonsDir, snapshot AddonsDirSnapshot, now time.Time, num_to_keep *uint8) (AddonsDirSnapshot, error) {
+	empty_response := AddonsDirSnapshot{}
+
+	r, err := zip.OpenReader(snapshot.Path)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read addons directory snapshot: %w", err)
+	}
+	defer r.Close()
+
+	manifest, err := read_snapshot_manifest(&r.Reader)
+	if err != nil {
+		return empty_response, err
+	}
+
+	// don't prune yet, the snapshot being restored may be the oldest
+	safety_snapshot, err := SnapshotAddonsDir(snapshot_root, addons_dir, now, nil)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to snapshot addons directory before restoring, nothing restored: %w", err)
+	}
+	restore_err := func(err error) error {
+		return fmt.Errorf("failed to restore addons directory snapshot, restore the snapshot taken beforehand: %s: %w", safety_snapshot.Path, err)
+	}
+
+	dir_name_list, err := snapshot_dir_name_list(addons_dir.Path)
+	if err != nil {
+		return safety_snapshot, restore_err(err)
+	}
+	err = clear_addons_dir(addons_dir.Path, dir_name_list)
+	if err != nil {
+		return safety_snapshot, restore_err(err)
+	}
+
+	for _, f := range r.File {
+		if !strings.HasPrefix(f.Name, snapshot_addons_prefix) {
+			continue
+		}
+		err = restore_snapshot_file(addons_dir.Path, f)
+		if err != nil {
+			return safety_snapshot, restore_err(err)
+		}
+	}
+
+	// nfo data restored with the addon files is used as-is,
+	// otherwise it's written to the nfo store in use.
+	for _, dir_name := range slices.Sorted(maps.Keys(manifest.NFOIdx)) {
+		path := filepath.Join(addons_dir.Path, dir_name)
+		_, err := read_nfo(path)
+		if !errors.Is(err, ErrNFODNE) {
+			continue
+		}
+		err = nfo_store().Write(path, manifest.NFOIdx[dir_name])
+		if err != nil {
+			return safety_snapshot, restore_err(err)
+		}
+	}
+
+	for group_id, entry_list := range manifest.ManifestIdx {
+		err = nfo_store().WriteManifest(addons_dir.Path, group_id, entry_list)
+		if err != nil {
+			slog.Warn("failed to restore manifest", "group-id", group_id, "error", err)
+		}
+	}
+
+	slog.Info("restored addons directory snapshot", "addons-dir", addons_dir.Path, "path", snapshot.Path, "num-addons", len(manifest.AddonList))
+
+	err = prune_addons_dir_snapshots(snapshot_root, addons_dir.Path, num_to_keep)
+	if err != nil {
+		slog.Warn("failed to prune addons directory snapshots", "error", err)
+	}
+
+	return safety_snapshot, nil
+}
+
+// snapshots `addons_dir`, keeping as many snapshots as the user's preferences allow.
+func snapshot_addons_dir(app *core.App, addons_dir AddonsDir) (AddonsDirSnapshot, error) {
+	prefs := FindSettings(app).Preferences
+	return SnapshotAddonsDir(snapshot_root(app), addons_dir, time.Now(), prefs.AddonsDirSnapshotsToKeep)
+}
+
+// snapshots `addons_dir` before an operation touching many addons, if the user wants snapshots taken.
+// the operation should not go ahead if the snapshot fails.
+func snapshot_before_bulk_operation(app *core.App, addons_dir AddonsDir, operation string) error {
+	prefs := FindSettings(app).Preferences
+	if prefs.SnapshotBeforeBulkOperation == nil || !*prefs.SnapshotBeforeBulkOperation {
+		return nil
+	}
+	_, err := snapshot_addons_dir(app, addons_dir)
+	if err != nil {
+		slog.Error("failed to snapshot addons directory", "addons-dir", addons_dir.Path, "operation", operation, "error", err)
+		return fmt.Errorf("failed to snapshot addons directory before %s: %w", operation, err)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package strongbox
+
+import (
+	"bw/core"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func snapshot_test_addons_dir(t *testing.T) AddonsDir {
+	addons_dir := AddonsDir{Path: t.TempDir(), GameTrackID: GAMETRACK_RETAIL}
+	make_file_tree(t, addons_dir.Path, map[string]string{
+		"EveryAddon/EveryAddon.toc":               "## Title: EveryAddon\n## Interface: 110000\n",
+		"EveryAddon/EveryAddon.lua":               "-- everyaddon\n",
+		"EveryAddon/.strongbox.json":              `{"group-id": "https://everyaddon", "primary?": true}`,
+		"EveryAddon_Config/EveryAddon_Config.toc": "## Title: EveryAddon Config\n## Interface: 110000\n",
+		"EveryAddon_Config/.strongbox.json":       `{"group-id": "https://everyaddon", "primary?": false}`,
+		"LocalAddon/LocalAddon.toc":               "## Title: LocalAddon\n## Version: 1.2.3\n## Interface: 110000\n",
+		"Blizzard_Foo/Blizzard_Foo.toc":           "## Title: Foo\n",
+	})
+	err := os.MkdirAll(filepath.Join(addons_dir.Path, "LocalAddon", "empty"), 0755)
+	assert.Nil(t, err)
+	return addons_dir
+}
+
+// returns every file and directory beneath `root` with the contents of each file.
+func snapshot_test_tree(t *testing.T, root PathToDir) map[string]string {
+	rv := map[string]string{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		if d.IsDir() {
+			rv[rel+"/"] = ""
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		rv[rel] = string(data)
+		return err
+	})
+	assert.Nil(t, err)
+	return rv
+}
+
+// every addon is snapshot with a manifest, Blizzard addons are skipped
+func Test_SnapshotAddonsDir(t *testing.T) {
+	addons_dir := snapshot_test_addons_dir(t)
+	snapshot_root := t.TempDir()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	snapshot, err := SnapshotAddonsDir(snapshot_root, addons_dir, now, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "addons-dir--20260102T030405Z.zip", filepath.Base(snapshot.Path))
+	assert.Equal(t, now, snapshot.Created)
+
+	expected := []SnapshotAddon{
+		{Label: "EveryAddon", DirNameList: []string{"EveryAddon", "EveryAddon_Config"}, GroupID: "https://everyaddon"},
+		{Label: "LocalAddon", DirNameList: []string{"LocalAddon"}, InstalledVersion: "1.2.3"},
+	}
+	assert.Equal(t, expected, snapshot.AddonList)
+
+	snapshot_list, err := ListAddonsDirSnapshots(snapshot_root, addons_dir.Path)
+	assert.Nil(t, err)
+	assert.Equal(t, []AddonsDirSnapshot{snapshot}, snapshot_list)
+}
+
+// only the newest snapshots are kept
+func Test_SnapshotAddonsDir__prune(t *testing.T) {
+	addons_dir := snapshot_test_addons_dir(t)
+	snapshot_root := t.TempDir()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	num_to_keep := uint8(2)
+
+	for i := range 4 {
+		_, err := SnapshotAddonsDir(snapshot_root, addons_dir, now.Add(time.Duration(i)*time.Hour), &num_to_keep)
+		assert.Nil(t, err)
+	}
+
+	snapshot_list, err := ListAddonsDirSnapshots(snapshot_root, addons_dir.Path)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(snapshot_list))
+	assert.Equal(t, now.Add(3*time.Hour), snapshot_list[0].Created)
+	assert.Equal(t, now.Add(2*time.Hour), snapshot_list[1].Created)
+}
+
+// an addons directory is restored exactly, addons installed since are removed
+func Test_RestoreAddonsDirSnapshot(t *testing.T) {
+	addons_dir := snapshot_test_addons_dir(t)
+	snapshot_root := t.TempDir()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	expected := snapshot_test_tree(t, addons_dir.Path)
+	snapshot, err := SnapshotAddonsDir(snapshot_root, addons_dir, now, nil)
+	assert.Nil(t, err)
+
+	// change things
+	make_file_tree(t, addons_dir.Path, map[string]string{
+		"EveryAddon/EveryAddon.lua":         "-- everyaddon, updated\n",
+		"EveryAddon/NewFile.lua":            "",
+		"NewAddon/NewAddon.toc":             "## Title: NewAddon\n## Interface: 110000\n",
+		"Blizzard_Foo/Blizzard_Foo.lua":     "",
+		"everyaddon--1-2-4.zip":             "",
+		"EveryAddon_Config/.strongbox.json": `{"group-id": "https://everyaddon", "primary?": true}`,
+	})
+	assert.Nil(t, os.RemoveAll(filepath.Join(addons_dir.Path, "LocalAddon")))
+
+	changed := snapshot_test_tree(t, addons_dir.Path)
+	safety_snapshot, err := RestoreAddonsDirSnapshot(snapshot_root, addons_dir, snapshot, now.Add(time.Hour), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(time.Hour), safety_snapshot.Created)
+
+	// Blizzard addons and files outside of addon directories are left alone
+	expected["Blizzard_Foo/Blizzard_Foo.lua"] = ""
+	expected["everyaddon--1-2-4.zip"] = ""
+	assert.Equal(t, expected, snapshot_test_tree(t, addons_dir.Path))
+
+	// the state before restoring can be restored too
+	_, err = RestoreAddonsDirSnapshot(snapshot_root, addons_dir, safety_snapshot, now.Add(2*time.Hour), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, changed, snapshot_test_tree(t, addons_dir.Path))
+}
+
+// symlinked addon directories are snapshot and restored as symlinks
+func Test_RestoreAddonsDirSnapshot__symlink(t *testing.T) {
+	addons_dir := snapshot_test_addons_dir(t)
+	snapshot_root := t.TempDir()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	origin := filepath.Join(t.TempDir(), "LinkedAddon")
+	make_file_tree(t, origin, map[string]string{
+		"LinkedAddon.toc": "## Title: LinkedAddon\n## Interface: 110000\n",
+	})
+	link := filepath.Join(addons_dir.Path, "LinkedAddon")
+	assert.Nil(t, os.Symlink(origin, link))
+
+	snapshot, err := SnapshotAddonsDir(snapshot_root, addons_dir, now, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(snapshot.AddonList))
+
+	assert.Nil(t, os.Remove(link))
+	_, err = RestoreAddonsDirSnapshot(snapshot_root, addons_dir, snapshot, now.Add(time.Hour), nil)
+	assert.Nil(t, err)
+
+	assert.True(t, is_symlink(link))
+	target, err := os.Readlink(link)
+	assert.Nil(t, err)
+	assert.Equal(t, origin, target)
+	assert.True(t, core.FileExists(filepath.Join(origin, "LinkedAddon.toc")))
+}
+
+// nfo data kept outside of the addons directory is restored too
+func Test_RestoreAddonsDirSnapshot__nfo_database(t *testing.T) {
+	addons_dir := snapshot_test_addons_dir(t)
+	snapshot_root := t.TempDir()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	db, err := OpenNFODatabase(filepath.Join(t.TempDir(), "nfo-db.json"))
+	assert.Nil(t, err)
+	_, err = MigrateNFO(NFOFileStore{}, db, addons_dir.Path)
+	assert.Nil(t, err)
+	set_nfo_store(db)
+	defer set_nfo_store(NFOFileStore{})
+
+	expected, err := db.Read(filepath.Join(addons_dir.Path, "EveryAddon"))
+	assert.Nil(t, err)
+
+	snapshot, err := SnapshotAddonsDir(snapshot_root, addons_dir, now, nil)
+	assert.Nil(t, err)
+
+	err = db.Remove(filepath.Join(addons_dir.Path, "EveryAddon"))
+	assert.Nil(t, err)
+
+	_, err = RestoreAddonsDirSnapshot(snapshot_root, addons_dir, snapshot, now.Add(time.Hour), nil)
+	assert.Nil(t, err)
+
+	actual, err := db.Read(filepath.Join(addons_dir.Path, "EveryAddon"))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+	assert.NoFileExists(t, filepath.Join(addons_dir.Path, "EveryAddon", NFO_FILENAME))
+}
@@ -0,0 +1,135 @@
+package strongbox
+
+import (
+	"bw/core"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// per-addons-dir preferences are used when present, otherwise the global preferences are used
+func Test_resolve_addons_dir_preferences(t *testing.T) {
+	prefs := NewSettings().Preferences
+	prefs.AddonZipsToKeep = new(uint8(2))
+	prefs.ReleaseChannel = ReleaseChannel{Stability: RELEASE_STABILITY_BETA, Type: RELEASE_TYPE_NOLIB}
+
+	ad := MakeAddonsDir("/path/to/addons")
+	expected := EffectivePreferences{
+		AddonZipsToKeep:       new(uint8(2)),
+		CheckForUpdate:        true,
+		ReleaseChannel:        ReleaseChannel{Stability: RELEASE_STABILITY_BETA, Type: RELEASE_TYPE_NOLIB},
+		BackupWTFBeforeUpdate: false,
+		ExcludedAddonList:     []string{},
+	}
+	assert.Equal(t, expected, resolve_addons_dir_preferences(prefs, ad))
+
+	ad.AddonZipsToKeep = new(uint8(0))
+	ad.CheckForUpdate = new(false)
+	ad.BackupWTFBeforeUpdate = new(true)
+	ad.ExcludedAddonList = []string{"everyaddon"}
+	ad.ReleaseChannel = ReleaseChannel{Stability: RELEASE_STABILITY_ALPHA}
+	expected = EffectivePreferences{
+		AddonZipsToKeep:       new(uint8(0)),
+		CheckForUpdate:        false,
+		ReleaseChannel:        ReleaseChannel{Stability: RELEASE_STABILITY_ALPHA, Type: RELEASE_TYPE_NOLIB},
+		BackupWTFBeforeUpdate: true,
+		ExcludedAddonList:     []string{"everyaddon"},
+	}
+	assert.Equal(t, expected, resolve_addons_dir_preferences(prefs, ad))
+}
+
+// addons are excluded by name or directory name
+func Test_EffectivePreferences_Excludes(t *testing.T) {
+	ep := EffectivePreferences{ExcludedAddonList: []string{"everyaddon", "EveryOtherAddon"}}
+	cases := []struct {
+		given    Addon
+		expected bool
+	}{
+		{Addon{Name: "everyaddon", DirName: "EveryAddon"}, true},
+		{Addon{Name: "everyotheraddon", DirName: "EveryOtherAddon"}, true},
+		{Addon{Name: "someaddon", DirName: "SomeAddon"}, false},
+		{Addon{}, false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, ep.Excludes(c.given), c.given.Name)
+	}
+}
+
+func Test_parse_addons_dir_preferences(t *testing.T) {
+	actual, err := parse_addons_dir_preferences("", "", PREFERENCE_CHOICE_DEFAULT, " ")
+	assert.Nil(t, err)
+	assert.Equal(t, AddonsDirPreferences{}, actual)
+
+	actual, err = parse_addons_dir_preferences("3", "false", "true", "everyaddon, EveryOtherAddon,,everyaddon")
+	assert.Nil(t, err)
+	expected := AddonsDirPreferences{
+		AddonZipsToKeep:       new(uint8(3)),
+		CheckForUpdate:        new(false),
+		BackupWTFBeforeUpdate: new(true),
+		ExcludedAddonList:     []string{"everyaddon", "EveryOtherAddon"},
+	}
+	assert.Equal(t, expected, actual)
+
+	bad_cases := [][]string{
+		{"-1", "", "", ""},
+		{"256", "", "", ""},
+		{"", "maybe", "", ""},
+		{"", "", "maybe", ""},
+	}
+	for _, c := range bad_cases {
+		_, err = parse_addons_dir_preferences(c[0], c[1], c[2], c[3])
+		assert.NotNil(t, err, c)
+	}
+}
+
+// per-addons-dir preferences and game tracks can be changed for existing addons dirs
+func Test_SetAddonsDirPreferences(t *testing.T) {
+	tmpdir := t.TempDir()
+	app, stopfn := DummyApp2(tmpdir)
+	defer stopfn()
+
+	ad := MakeAddonsDir(filepath.Join(tmpdir, "addons"))
+	AddAddonsDir(app, ad).Wait()
+
+	prefs := AddonsDirPreferences{CheckForUpdate: new(false), ExcludedAddonList: []string{"everyaddon"}}
+	SetAddonsDirPreferences(app, ad.Path, prefs).Wait()
+
+	// stale copies of the addons dir see the latest preferences
+	actual := addons_dir_preferences(app, ad)
+	assert.False(t, actual.CheckForUpdate)
+	assert.Equal(t, []string{"everyaddon"}, actual.ExcludedAddonList)
+
+	wg, err := SetAddonsDirGameTrack(app, ad.Path, GAMETRACK_CLASSIC, false)
+	assert.Nil(t, err)
+	wg.Wait()
+
+	actual_ad, err := find_selected_addon_dir(app, ad.Path)
+	assert.Nil(t, err)
+	assert.Equal(t, GAMETRACK_CLASSIC, actual_ad.GameTrackID)
+	assert.False(t, actual_ad.Strict)
+	assert.Equal(t, prefs, actual_ad.AddonsDirPreferences)
+
+	_, err = SetAddonsDirGameTrack(app, ad.Path, "foo", true)
+	assert.NotNil(t, err)
+}
+
+// the global release channel follows changes to the settings in app state
+func Test_default_release_channel(t *testing.T) {
+	tmpdir := t.TempDir()
+	app, stopfn := DummyApp2(tmpdir)
+	defer stopfn()
+	defer set_default_release_channel(ReleaseChannel{})
+
+	assert.Equal(t, ReleaseChannel{}, default_release_channel())
+
+	expected := ReleaseChannel{Stability: RELEASE_STABILITY_BETA}
+	app.UpdateResult(ID_SETTINGS, func(r core.Result) core.Result {
+		settings := r.Item.(Settings)
+		settings.Preferences.ReleaseChannel = expected
+		r.Item = settings
+		return r
+	}).Wait()
+
+	assert.Equal(t, expected, default_release_channel())
+}
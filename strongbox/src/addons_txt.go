@@ -0,0 +1,445 @@
+package strongbox
+
+import (
+	"bw/core"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// addons_txt.go reads and writes the per-character 'AddOns.txt' files WoW uses to enable and disable addons.
+// WoW rewrites AddOns.txt when a character logs out so changes should be made while WoW is not running.
+
+const ADDONS_TXT_FILENAME = "AddOns.txt"
+
+// a WoW character with it's own set of enabled addons.
+type Character struct {
+	Account string // "FOO"
+	Realm   string // "Nagrand"
+	Name    string // "Ogri"
+	Path    PathToDir
+}
+
+var _ core.ItemInfo = (*Character)(nil)
+
+// "FOO/Nagrand/Ogri"
+func (c Character) ID() string {
+	return c.Account + "/" + c.Realm + "/" + c.Name
+}
+
+func (c Character) ItemKeys() []string {
+	return []string{
+		core.ITEM_FIELD_NAME,
+		"realm",
+		"account",
+	}
+}
+
+func (c Character) ItemMap() map[string]string {
+	return map[string]string{
+		core.ITEM_FIELD_NAME: c.Name,
+		"realm":              c.Realm,
+		"account":            c.Account,
+	}
+}
+
+func (c Character) ItemHasChildren() core.ITEM_CHILDREN_LOAD {
+	return core.ITEM_CHILDREN_LOAD_FALSE
+}
+
+func (c Character) ItemChildren(_ *core.App) []core.Result {
+	return nil
+}
+
+// whether an addon is enabled for a character.
+type AddonCharacterState struct {
+	Character   Character
+	DirName     string   // "EveryAddon"
+	DirNameList []string // every directory in the addon's group, enabled and disabled together
+	Enabled     bool
+}
+
+var _ core.ItemInfo = (*AddonCharacterState)(nil)
+
+func (s AddonCharacterState) ItemKeys() []string {
+	return []string{
+		core.ITEM_FIELD_NAME,
+		"realm",
+		"enabled",
+	}
+}
+
+func (s AddonCharacterState) ItemMap() map[string]string {
+	return map[string]string{
+		core.ITEM_FIELD_NAME: s.Character.Name,
+		"realm":              s.Character.Realm,
+		"enabled":            map[bool]string{true: "enabled", false: "disabled"}[s.Enabled],
+	}
+}
+
+func (s AddonCharacterState) ItemHasChildren() core.ITEM_CHILDREN_LOAD {
+	return core.ITEM_CHILDREN_LOAD_FALSE
+}
+
+func (s AddonCharacterState) ItemChildren(_ *core.App) []core.Result {
+	return nil
+}
+
+// a named set of enabled and disabled addons that can be applied to many characters.
+// profiles belong to an addons directory.
+type AddonProfile struct {
+	AddonsDir PathToDir       `json:"addon-dir"`
+	Name      string          `json:"name"`   // "raid"
+	AddonIdx  map[string]bool `json:"addons"` // {"EveryAddon": true, "EveryOtherAddon": false}
+}
+
+// ---
+
+// a single 'Name: enabled' row in an AddOns.txt file.
+type addons_txt_entry struct {
+	name    string
+	enabled bool
+}
+
+// parses the contents of an AddOns.txt file, preserving order.
+// "EveryAddon: enabled\nEveryOtherAddon: disabled" => [{"EveryAddon", true}, {"EveryOtherAddon", false}]
+func parse_addons_txt(contents string) []addons_txt_entry {
+	rv := []addons_txt_entry{}
+	for _, row := range strings.Split(strings.ReplaceAll(contents, "\r\n", "\n"), "\n") {
+		name, state, found := strings.Cut(row, ":")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			continue
+		}
+		rv = append(rv, addons_txt_entry{
+			name:    name,
+			enabled: strings.TrimSpace(strings.ToLower(state)) == "enabled",
+		})
+	}
+	return rv
+}
+
+func format_addons_txt(entry_list []addons_txt_entry) string {
+	var b strings.Builder
+	for _, e := range entry_list {
+		b.WriteString(e.name)
+		b.WriteString(": ")
+		b.WriteString(map[bool]string{true: "enabled", false: "disabled"}[e.enabled])
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// reads the AddOns.txt file in the character's directory.
+// a missing AddOns.txt is not an error, the character just hasn't changed anything yet.
+func read_addons_txt(character Character) ([]addons_txt_entry, error) {
+	empty_response := []addons_txt_entry{}
+	path := filepath.Join(character.Path, ADDONS_TXT_FILENAME)
+	if !core.FileExists(path) {
+		return empty_response, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read %s: %w", ADDONS_TXT_FILENAME, err)
+	}
+	return parse_addons_txt(string(data)), nil
+}
+
+func write_addons_txt(character Character, entry_list []addons_txt_entry) error {
+	path := filepath.Join(character.Path, ADDONS_TXT_FILENAME)
+	tmp := path + ".tmp"
+	err := os.WriteFile(tmp, []byte(format_addons_txt(entry_list)), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", ADDONS_TXT_FILENAME, err)
+	}
+	err = os.Rename(tmp, path)
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", ADDONS_TXT_FILENAME, err)
+	}
+	forget_characters()
+	return nil
+}
+
+// returns `true` if the addon in `dir_name` is enabled.
+// addons missing from AddOns.txt are enabled, the same as the game.
+func addons_txt_enabled(entry_list []addons_txt_entry, dir_name string) bool {
+	for _, e := range entry_list {
+		if e.name == dir_name {
+			return e.enabled
+		}
+	}
+	return true
+}
+
+// sets each addon in `addon_idx` to it's given state, updating existing rows in place and appending new ones.
+func set_addons_txt_entries(entry_list []addons_txt_entry, addon_idx map[string]bool) []addons_txt_entry {
+	rv := slices.Clone(entry_list)
+	seen := map[string]bool{}
+	for i, e := range rv {
+		enabled, present := addon_idx[e.name]
+		if present {
+			rv[i].enabled = enabled
+			seen[e.name] = true
+		}
+	}
+
+	name_list := []string{}
+	for name := range addon_idx {
+		if !seen[name] {
+			name_list = append(name_list, name)
+		}
+	}
+	slices.Sort(name_list)
+	for _, name := range name_list {
+		rv = append(rv, addons_txt_entry{name: name, enabled: addon_idx[name]})
+	}
+	return rv
+}
+
+// ---
+
+// returns the characters with settings in the WTF directory of `addons_dir`, ordered by account, realm and name.
+func FindCharacters(addons_dir PathToDir) ([]Character, error) {
+	empty_response := []Character{}
+
+	wtf := wtf_dir(addons_dir)
+	if !core.DirExists(wtf) {
+		return empty_response, nil
+	}
+
+	// "WTF/Account/FOO/Nagrand/Ogri"
+	match_list, err := filepath.Glob(filepath.Join(wtf, "Account", "*", "*", "*"))
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to find characters: %w", err)
+	}
+
+	rv := []Character{}
+	for _, path := range match_list {
+		if !core.DirExists(path) {
+			continue
+		}
+		realm_dir := filepath.Dir(path)
+		if filepath.Base(realm_dir) == "SavedVariables" {
+			continue // "WTF/Account/FOO/SavedVariables/Something", account-wide settings
+		}
+		rv = append(rv, Character{
+			Account: filepath.Base(filepath.Dir(realm_dir)),
+			Realm:   filepath.Base(realm_dir),
+			Name:    filepath.Base(path),
+			Path:    path,
+		})
+	}
+
+	slices.SortStableFunc(rv, func(a, b Character) int {
+		return strings.Compare(a.ID(), b.ID())
+	})
+	return rv, nil
+}
+
+// returns the characters in `character_list` matching `character_id`, or all of them if `character_id` is empty.
+// matches "FOO/Nagrand/Ogri", "Nagrand/Ogri" or just "Ogri".
+func filter_characters(character_list []Character, character_id string) []Character {
+	character_id = strings.Trim(strings.TrimSpace(character_id), "/")
+	if character_id == "" {
+		return character_list
+	}
+	rv := []Character{}
+	for _, c := range character_list {
+		if c.ID() == character_id || strings.HasSuffix(c.ID(), "/"+character_id) {
+			rv = append(rv, c)
+		}
+	}
+	return rv
+}
+
+// returns the directory names of every installed addon in the addon's group.
+// a bundle of addons is enabled and disabled together.
+func addon_dir_name_list(a Addon) []string {
+	rv := []string{}
+	for _, ia := range a.InstalledAddonGroup {
+		rv = append(rv, ia.Name)
+	}
+	return rv
+}
+
+// returns whether the given addon is enabled for each character.
+// the state of the addon's primary directory is used.
+func AddonCharacterStates(character_list []Character, a Addon) []AddonCharacterState {
+	rv := []AddonCharacterState{}
+	for _, c := range character_list {
+		entry_list, err := read_addons_txt(c)
+		if err != nil {
+			slog.Warn("skipping character", "character", c.ID(), "error", err)
+			continue
+		}
+		rv = append(rv, addon_character_state(c, entry_list, a))
+	}
+	return rv
+}
+
+func addon_character_state(c Character, entry_list []addons_txt_entry, a Addon) AddonCharacterState {
+	return AddonCharacterState{
+		Character:   c,
+		DirName:     a.Primary.Name,
+		DirNameList: addon_dir_name_list(a),
+		Enabled:     addons_txt_enabled(entry_list, a.Primary.Name),
+	}
+}
+
+// the characters of an addons dir and the contents of their AddOns.txt files.
+type addons_dir_characters struct {
+	character_list []Character
+	entry_idx      map[string][]addons_txt_entry // character ID => AddOns.txt
+}
+
+// characters are found and their AddOns.txt files read once per addons dir rather than once per addon.
+// the cache is emptied when an addons dir is loaded and when strongbox writes an AddOns.txt file.
+var characters_cache = struct {
+	lock sync.Mutex
+	idx  map[PathToDir]addons_dir_characters
+}{idx: map[PathToDir]addons_dir_characters{}}
+
+// returns the characters of `addons_dir` and their AddOns.txt files, reading them if they haven't been read yet.
+func find_characters_cached(addons_dir PathToDir) (addons_dir_characters, error) {
+	characters_cache.lock.Lock()
+	defer characters_cache.lock.Unlock()
+
+	cached, present := characters_cache.idx[addons_dir]
+	if present {
+		return cached, nil
+	}
+
+	character_list, err := FindCharacters(addons_dir)
+	if err != nil {
+		return addons_dir_characters{}, err
+	}
+	rv := addons_dir_characters{character_list: []Character{}, entry_idx: map[string][]addons_txt_entry{}}
+	for _, c := range character_list {
+		entry_list, err := read_addons_txt(c)
+		if err != nil {
+			slog.Warn("skipping character", "character", c.ID(), "error", err)
+			continue
+		}
+		rv.character_list = append(rv.character_list, c)
+		rv.entry_idx[c.ID()] = entry_list
+	}
+	characters_cache.idx[addons_dir] = rv
+	return rv, nil
+}
+
+// empties the characters cache.
+func forget_characters() {
+	characters_cache.lock.Lock()
+	defer characters_cache.lock.Unlock()
+	clear(characters_cache.idx)
+}
+
+// like `AddonCharacterStates` but the characters of the addon's addons dir are found and read just once.
+func addon_character_states_cached(a Addon) []AddonCharacterState {
+	rv := []AddonCharacterState{}
+	if a.AddonsDir == nil {
+		return rv
+	}
+	characters, err := find_characters_cached(a.AddonsDir.Path)
+	if err != nil {
+		slog.Warn("failed to find characters", "error", err)
+		return rv
+	}
+	for _, c := range characters.character_list {
+		rv = append(rv, addon_character_state(c, characters.entry_idx[c.ID()], a))
+	}
+	return rv
+}
+
+// sets the addons in `addon_idx` to enabled or disabled for each character in `character_list`.
+func SetCharacterAddons(character_list []Character, addon_idx map[string]bool) error {
+	for _, c := range character_list {
+		entry_list, err := read_addons_txt(c)
+		if err != nil {
+			return err
+		}
+		err = write_addons_txt(c, set_addons_txt_entries(entry_list, addon_idx))
+		if err != nil {
+			return err
+		}
+		slog.Info("updated character addons", "character", c.ID(), "num-addons", len(addon_idx))
+	}
+	return nil
+}
+
+// enables or disables each addon in `addon_list` for each character in `character_list`.
+func SetAddonsEnabled(character_list []Character, addon_list []Addon, enabled bool) error {
+	addon_idx := map[string]bool{}
+	for _, a := range addon_list {
+		for _, dir_name := range addon_dir_name_list(a) {
+			addon_idx[dir_name] = enabled
+		}
+	}
+	return SetCharacterAddons(character_list, addon_idx)
+}
+
+// returns a new profile from the enabled and disabled addons of the given character.
+// addons missing from the character's AddOns.txt are included as enabled.
+func MakeAddonProfile(addons_dir PathToDir, name string, character Character, installed_dir_name_list []string) (AddonProfile, error) {
+	empty_response := AddonProfile{}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return empty_response, fmt.Errorf("profile name cannot be empty")
+	}
+	entry_list, err := read_addons_txt(character)
+	if err != nil {
+		return empty_response, err
+	}
+	addon_idx := map[string]bool{}
+	for _, dir_name := range installed_dir_name_list {
+		addon_idx[dir_name] = true
+	}
+	for _, e := range entry_list {
+		addon_idx[e.name] = e.enabled
+	}
+	return AddonProfile{AddonsDir: addons_dir, Name: name, AddonIdx: addon_idx}, nil
+}
+
+// applies the enabled and disabled addons in `profile` to each character in `character_list`.
+// addons not in the profile are left alone.
+func ApplyAddonProfile(character_list []Character, profile AddonProfile) error {
+	return SetCharacterAddons(character_list, profile.AddonIdx)
+}
+
+// returns the profile for `addons_dir` with the given `name` and `true`, or `false` if not found.
+func find_addon_profile(settings Settings, addons_dir PathToDir, name string) (AddonProfile, bool) {
+	for _, p := range settings.Preferences.AddonProfileList {
+		if p.AddonsDir == addons_dir && p.Name == name {
+			return p, true
+		}
+	}
+	return AddonProfile{}, false
+}
+
+// adds `profile` to the user's preferences, replacing any profile for the same addons dir with the same name.
+func set_addon_profile(app *core.App, profile AddonProfile) *sync.WaitGroup {
+	return app.UpdateState(func(old_state core.State) core.State {
+		for idx, r := range old_state.Root.Item.([]core.Result) {
+			if r.ID != ID_SETTINGS {
+				continue
+			}
+			s := r.Item.(Settings)
+			profile_list := []AddonProfile{}
+			for _, p := range s.Preferences.AddonProfileList {
+				if p.AddonsDir != profile.AddonsDir || p.Name != profile.Name {
+					profile_list = append(profile_list, p)
+				}
+			}
+			s.Preferences.AddonProfileList = append(profile_list, profile)
+			r.Item = s
+			old_state.Root.Item.([]core.Result)[idx] = r
+			break
+		}
+		return old_state
+	})
+}
@@ -0,0 +1,157 @@
+package strongbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// creates a wow installation with two characters, returning the path to the addons dir.
+func addons_txt_test_install(t *testing.T) PathToDir {
+	root := filepath.Join(t.TempDir(), "_retail_")
+	make_file_tree(t, root, map[string]string{
+		"Interface/AddOns/EveryAddon/EveryAddon.toc":                 local_test_toc,
+		"WTF/Account/FOO/SavedVariables/EveryAddon.lua":              "",
+		"WTF/Account/FOO/Nagrand/Ogri/AddOns.txt":                    "EveryAddon: disabled\r\nEveryOtherAddon: enabled\r\n",
+		"WTF/Account/FOO/Nagrand/Ogri/SavedVariables/EveryAddon.lua": "",
+		"WTF/Account/FOO/Nagrand/Orga/SavedVariables/EveryAddon.lua": "",
+	})
+	return filepath.Join(root, "Interface", "AddOns")
+}
+
+func Test_parse_addons_txt(t *testing.T) {
+	given := "EveryAddon: enabled\r\nEveryOtherAddon: disabled\r\n\r\nbad row\r\n"
+	expected := []addons_txt_entry{
+		{name: "EveryAddon", enabled: true},
+		{name: "EveryOtherAddon", enabled: false},
+	}
+	assert.Equal(t, expected, parse_addons_txt(given))
+}
+
+// existing rows are updated in place, new rows are appended
+func Test_set_addons_txt_entries(t *testing.T) {
+	given := []addons_txt_entry{
+		{name: "EveryAddon", enabled: true},
+		{name: "EveryOtherAddon", enabled: false},
+	}
+	expected := []addons_txt_entry{
+		{name: "EveryAddon", enabled: false},
+		{name: "EveryOtherAddon", enabled: false},
+		{name: "EveryNewAddon", enabled: true},
+	}
+	actual := set_addons_txt_entries(given, map[string]bool{"EveryAddon": false, "EveryNewAddon": true})
+	assert.Equal(t, expected, actual)
+}
+
+// characters are found beneath each realm, account-wide SavedVariables are not characters
+func Test_FindCharacters(t *testing.T) {
+	addons_dir := addons_txt_test_install(t)
+	character_list, err := FindCharacters(addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(character_list))
+	assert.Equal(t, "FOO/Nagrand/Ogri", character_list[0].ID())
+	assert.Equal(t, "FOO/Nagrand/Orga", character_list[1].ID())
+}
+
+func Test_filter_characters(t *testing.T) {
+	character_list := []Character{
+		{Account: "FOO", Realm: "Nagrand", Name: "Ogri"},
+		{Account: "FOO", Realm: "Nagrand", Name: "Orga"},
+		{Account: "FOO", Realm: "Khadgar", Name: "Ogri"},
+	}
+	assert.Equal(t, 3, len(filter_characters(character_list, "")))
+	assert.Equal(t, 2, len(filter_characters(character_list, "Ogri")))
+	assert.Equal(t, 1, len(filter_characters(character_list, "Nagrand/Ogri")))
+	assert.Equal(t, 1, len(filter_characters(character_list, "FOO/Khadgar/Ogri")))
+	assert.Equal(t, 0, len(filter_characters(character_list, "Ogr")))
+}
+
+// addons missing from AddOns.txt are enabled
+func Test_AddonCharacterStates(t *testing.T) {
+	addons_dir := addons_txt_test_install(t)
+	character_list, err := FindCharacters(addons_dir)
+	assert.Nil(t, err)
+
+	a := Addon{Primary: InstalledAddon{Name: "EveryAddon"}}
+	state_list := AddonCharacterStates(character_list, a)
+	assert.Equal(t, 2, len(state_list))
+	assert.False(t, state_list[0].Enabled)
+	assert.True(t, state_list[1].Enabled)
+}
+
+// characters are read once per addons dir until strongbox changes an AddOns.txt
+func Test_addon_character_states_cached(t *testing.T) {
+	addons_dir := addons_txt_test_install(t)
+	ad := AddonsDir{Path: addons_dir}
+	a := Addon{
+		AddonsDir:           &ad,
+		Primary:             InstalledAddon{Name: "EveryAddon"},
+		InstalledAddonGroup: []InstalledAddon{{Name: "EveryAddon"}, {Name: "EveryAddon_Config"}},
+	}
+	forget_characters()
+	defer forget_characters()
+
+	state_list := addon_character_states_cached(a)
+	assert.Equal(t, 2, len(state_list))
+	assert.False(t, state_list[0].Enabled)
+	assert.Equal(t, []string{"EveryAddon", "EveryAddon_Config"}, state_list[0].DirNameList)
+
+	// changed outside of strongbox, not seen until the addons dir is loaded again
+	ogri := state_list[0].Character
+	assert.Nil(t, os.WriteFile(filepath.Join(ogri.Path, ADDONS_TXT_FILENAME), []byte("EveryAddon: enabled\r\n"), 0644))
+	assert.False(t, addon_character_states_cached(a)[0].Enabled)
+
+	// changed by strongbox, everything is read again
+	assert.Nil(t, SetAddonsEnabled([]Character{ogri}, []Addon{a}, false))
+	assert.Nil(t, os.WriteFile(filepath.Join(ogri.Path, ADDONS_TXT_FILENAME), []byte("EveryAddon: enabled\r\n"), 0644))
+	assert.True(t, addon_character_states_cached(a)[0].Enabled)
+}
+
+// all addons in a group are enabled or disabled together
+func Test_SetAddonsEnabled(t *testing.T) {
+	addons_dir := addons_txt_test_install(t)
+	character_list, err := FindCharacters(addons_dir)
+	assert.Nil(t, err)
+
+	a := Addon{InstalledAddonGroup: []InstalledAddon{{Name: "EveryAddon"}, {Name: "EveryAddon_Config"}}}
+	err = SetAddonsEnabled(character_list, []Addon{a}, true)
+	assert.Nil(t, err)
+
+	data, err := os.ReadFile(filepath.Join(character_list[0].Path, ADDONS_TXT_FILENAME))
+	assert.Nil(t, err)
+	assert.Equal(t, "EveryAddon: enabled\nEveryOtherAddon: enabled\nEveryAddon_Config: enabled\n", string(data))
+
+	// an AddOns.txt file is created for characters without one
+	data, err = os.ReadFile(filepath.Join(character_list[1].Path, ADDONS_TXT_FILENAME))
+	assert.Nil(t, err)
+	assert.Equal(t, "EveryAddon: enabled\nEveryAddon_Config: enabled\n", string(data))
+}
+
+// a profile made from one character can be applied to another
+func Test_ApplyAddonProfile(t *testing.T) {
+	addons_dir := addons_txt_test_install(t)
+	character_list, err := FindCharacters(addons_dir)
+	assert.Nil(t, err)
+	ogri, orga := character_list[0], character_list[1]
+
+	profile, err := MakeAddonProfile(addons_dir, "raid", ogri, []string{"EveryAddon", "EveryThirdAddon"})
+	assert.Nil(t, err)
+	expected := map[string]bool{"EveryAddon": false, "EveryOtherAddon": true, "EveryThirdAddon": true}
+	assert.Equal(t, expected, profile.AddonIdx)
+
+	err = ApplyAddonProfile([]Character{orga}, profile)
+	assert.Nil(t, err)
+
+	entry_list, err := read_addons_txt(orga)
+	assert.Nil(t, err)
+	assert.False(t, addons_txt_enabled(entry_list, "EveryAddon"))
+	assert.True(t, addons_txt_enabled(entry_list, "EveryOtherAddon"))
+	assert.True(t, addons_txt_enabled(entry_list, "EveryThirdAddon"))
+}
+
+func Test_MakeAddonProfile__empty_name(t *testing.T) {
+	_, err := MakeAddonProfile("/path/to/addons", " ", Character{}, nil)
+	assert.NotNil(t, err)
+}
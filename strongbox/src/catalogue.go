@@ -72,6 +72,49 @@ func (ca *CatalogueAddon) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// writes catalogue addons the way strongbox 7 does.
+// strongbox 7 wrote int source IDs for some sources and left out empty optional fields.
+func (ca CatalogueAddon) MarshalJSON() ([]byte, error) {
+	format_timestamp := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+
+	tag_list := ca.TagList
+	if tag_list == nil {
+		tag_list = []string{}
+	}
+
+	data := struct {
+		URL             string        `json:"url"`
+		Name            string        `json:"name"`
+		Label           string        `json:"label"`
+		Description     string        `json:"description,omitempty"`
+		TagList         []string      `json:"tag-list"`
+		UpdatedDate     string        `json:"updated-date,omitempty"`
+		CreatedDate     string        `json:"created-date,omitempty"`
+		DownloadCount   int           `json:"download-count"`
+		Source          Source        `json:"source"`
+		SourceID        any           `json:"source-id"`
+		GameTrackIDList []GameTrackID `json:"game-track-list,omitempty"`
+	}{
+		URL:             ca.URL,
+		Name:            ca.Name,
+		Label:           ca.Label,
+		Description:     ca.Description,
+		TagList:         tag_list,
+		UpdatedDate:     format_timestamp(ca.UpdatedDate),
+		CreatedDate:     format_timestamp(ca.CreatedDate),
+		DownloadCount:   ca.DownloadCount,
+		Source:          ca.Source,
+		SourceID:        v7_source_id(ca.Source, ca.SourceID),
+		GameTrackIDList: ca.GameTrackIDList,
+	}
+	return json.Marshal(data)
+}
+
 // Helper function to parse timestamps in multiple formats
 func parseFlexibleTimestamp(dateStr string) time.Time {
 	if dateStr == "" {
@@ -408,6 +451,21 @@ func db_catalogue_empty(app *core.App) bool {
 	return len(cat.AddonSummaryList) > 0
 }
 
+// returns the addons in the loaded catalogue and the user catalogue.
+// returns an empty list if neither are loaded.
+func db_catalogue_addons(app *core.App) []CatalogueAddon {
+	rv := []CatalogueAddon{}
+	db_result := app.GetResult(ID_CATALOGUE)
+	if db_result != nil {
+		rv = append(rv, db_result.Item.(Catalogue).AddonSummaryList...)
+	}
+	user_db_result := app.GetResult(ID_USER_CATALOGUE)
+	if user_db_result != nil {
+		rv = append(rv, user_db_result.Item.(Catalogue).AddonSummaryList...)
+	}
+	return rv
+}
+
 func _db_load_catalogue(app *core.App) (Catalogue, error) {
 
 	var empty_catalogue Catalogue
@@ -449,16 +507,13 @@ func DBLoadCatalogue(app *core.App) {
 	}
 }
 
-// core.clj/get-user-catalogue
-// returns the contents of the user catalogue as a `Catalogue`, removing any disable hosts.
+// reads the user catalogue at `path` as-is.
 // returns an error when the catalogue is not found,
 // or the catalogue cannot be read,
 // or the catalogue data is bad json.
-func get_user_catalogue(app *core.App) (Catalogue, error) {
-
+func read_user_catalogue_file(path PathToFile) (Catalogue, error) {
 	empty_catalogue := Catalogue{}
 
-	path := app.State.GetKeyVal("strongbox.paths.user-catalogue-file")
 	if !core.FileExists(path) {
 		return empty_catalogue, errors.New("user-catalogue not found")
 	}
@@ -474,6 +529,54 @@ func get_user_catalogue(app *core.App) (Catalogue, error) {
 		return empty_catalogue, fmt.Errorf("failed to unmarshal user-catalogue-file: %w", err)
 	}
 
+	return cat, nil
+}
+
+// core.clj/write-user-catalogue!
+// writes `cat` to `path` in the format strongbox 7 reads and writes.
+// user catalogues have no location, so the location isn't written.
+func write_user_catalogue_file(cat Catalogue, path PathToFile) error {
+	data := struct {
+		Spec             CatalogueSpec    `json:"spec"`
+		Datestamp        string           `json:"datestamp"`
+		Total            int              `json:"total"`
+		AddonSummaryList []CatalogueAddon `json:"addon-summary-list"`
+	}{
+		Spec:             cat.Spec,
+		Datestamp:        cat.Datestamp,
+		Total:            len(cat.AddonSummaryList),
+		AddonSummaryList: cat.AddonSummaryList,
+	}
+	if data.AddonSummaryList == nil {
+		data.AddonSummaryList = []CatalogueAddon{}
+	}
+
+	b, err := json.MarshalIndent(data, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal user catalogue: %w", err)
+	}
+	err = core.MakeParents(path)
+	if err != nil {
+		return fmt.Errorf("failed to write user catalogue: %w", err)
+	}
+	return core.Spit(path, b)
+}
+
+// core.clj/get-user-catalogue
+// returns the contents of the user catalogue as a `Catalogue`, removing any disable hosts.
+// returns an error when the catalogue is not found,
+// or the catalogue cannot be read,
+// or the catalogue data is bad json.
+func get_user_catalogue(app *core.App) (Catalogue, error) {
+
+	empty_catalogue := Catalogue{}
+
+	path := app.State.GetKeyVal("strongbox.paths.user-catalogue-file")
+	cat, err := read_user_catalogue_file(path)
+	if err != nil {
+		return empty_catalogue, err
+	}
+
 	new_addon_list := []CatalogueAddon{}
 	for _, addon := range cat.AddonSummaryList {
 		if DISABLED_HOSTS.Contains(addon.Source) {
@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/sourcegraph/conc/pool"
@@ -77,6 +79,24 @@ func generate_path_map(config_dir PathToDir, data_dir PathToDir) map[string]stri
 		// "/home/$you/.config/strongbox/config.json"
 		"strongbox.paths.cfg-file": join(config_dir, "config.json"),
 
+		// "/home/$you/.local/share/strongbox/wtf-backups"
+		"strongbox.paths.wtf-backup-dir": join(data_dir, "wtf-backups"),
+
+		// "/home/$you/.local/share/strongbox/snapshots"
+		"strongbox.paths.snapshot-dir": join(data_dir, "snapshots"),
+
+		// "/home/$you/.local/share/strongbox/zips"
+		"strongbox.paths.zip-store-dir": join(data_dir, "zips"),
+
+		// "/home/$you/.local/share/strongbox/manifests"
+		"strongbox.paths.manifest-dir": join(data_dir, "manifests"),
+
+		// "/home/$you/.local/share/strongbox/nfo-backups"
+		"strongbox.paths.nfo-backup-dir": join(data_dir, "nfo-backups"),
+
+		// "/home/$you/.local/share/strongbox/nfo-db.json"
+		"strongbox.paths.nfo-db-file": join(data_dir, "nfo-db.json"),
+
 		// "/home/$you/.local/share/strongbox/etag-db.json"
 		"strongbox.paths.etag-db-file": join(data_dir, "etag-db.json"),
 
@@ -333,24 +353,32 @@ func installed_addons(app *core.App, addons_dir AddonsDir) []core.Result {
 	})
 }
 
-// returns all `Addon` results attached to the given `AddonsDir`.
+// returns all `Addon` results attached to the given `AddonsDir` that can be updated.
+// addons excluded by the addons dir preferences are never updateable.
 func updateable_addons(app *core.App, addons_dir AddonsDir) []core.Result {
+	prefs := addons_dir_preferences(app, addons_dir)
 	updateable_addons_list := []core.Result{}
 	for _, r := range installed_addons(app, addons_dir) {
 		a := r.Item.(Addon)
-		if Updateable(a) {
+		if Updateable(a) && !prefs.Excludes(a) {
 			updateable_addons_list = append(updateable_addons_list, r)
 		}
 	}
 	return updateable_addons_list
 }
 
-// wraps the individual .ExpandSummary() methods of an addon source implementing the AddonSource interface
-func ExpandSummary(app *core.App, source Source, source_id string) ([]SourceUpdate, error) {
+// wraps the individual .ExpandSummary() methods of an addon source implementing the AddonSource interface.
+// `ca` is optional and provides hints to sources that need them.
+func ExpandSummary(app *core.App, source Source, source_id string, ca *CatalogueAddon) ([]SourceUpdate, error) {
 	empty_response := []SourceUpdate{}
+	wowi := &WowinterfaceAPI{}
+	if ca != nil {
+		wowi.GameTrackIDList = ca.GameTrackIDList
+	}
 	api_map := map[Source]AddonSource{
-		SOURCE_GITHUB: &GithubAPI{},
-		SOURCE_WOWI:   &WowinterfaceAPI{},
+		SOURCE_GITHUB: &GithubAPI{Token: github_token(app)},
+		SOURCE_WOWI:   wowi,
+		SOURCE_LOCAL:  &LocalAPI{},
 	}
 	api, present := api_map[source]
 	if !present {
@@ -362,26 +390,41 @@ func ExpandSummary(app *core.App, source Source, source_id string) ([]SourceUpda
 // core.clj/check-for-updates
 // core.clj/check-for-updates-in-parallel
 // fetches updates for all installed addons from addon hosts, in parallel.
-func CheckForUpdates(app *core.App) {
+// returns a `GithubRateLimitError` if Github refused to check any addons, other failures are skipped over.
+func CheckForUpdates(app *core.App) error {
 	slog.Info("checking addons for updates")
 
 	addons_dir, err := selected_addon_dir(app)
 	if err != nil {
 		slog.Warn("no addons directory selected, not checking for updates")
-		return
+		return nil
 	}
 
 	installed_addon_list := installed_addons(app, addons_dir)
+	prefs := addons_dir_preferences(app, addons_dir)
+
+	var rate_limit_err error
+	var rate_limit_lock sync.Mutex
 
 	p := pool.New()
 	for _, r := range installed_addon_list {
+		if prefs.Excludes(r.Item.(Addon)) {
+			continue
+		}
 		p.Go(func() {
 			a := r.Item.(Addon)
 			// an ADDON can only be checked for updates if it is attached to a SOURCE.
 			// this happens during catalogue matching.
 			// a single SOURCE is chosen during the creation of an ADDON struct
 
-			source_update_list, err := ExpandSummary(app, a.Source, a.SourceID)
+			source_update_list, err := ExpandSummary(app, a.Source, a.SourceID, a.CatalogueAddon)
+
+			var gh_err GithubRateLimitError
+			if errors.As(err, &gh_err) {
+				rate_limit_lock.Lock()
+				rate_limit_err = err
+				rate_limit_lock.Unlock()
+			}
 
 			// if no errors, update addon result
 			if err == nil {
@@ -397,12 +440,14 @@ func CheckForUpdates(app *core.App) {
 		})
 	}
 	p.Wait() // necessary?
+
+	return rate_limit_err
 }
 
 // checks a single addon for updates and updates the result if an update is available.
 func CheckAddon(app *core.App, r *core.Result) {
 	a := r.Item.(Addon)
-	source_update_list, err := ExpandSummary(app, a.Source, a.SourceID)
+	source_update_list, err := ExpandSummary(app, a.Source, a.SourceID, a.CatalogueAddon)
 	if err != nil {
 		return
 	}
@@ -417,10 +462,39 @@ func CheckAddon(app *core.App, r *core.Result) {
 	wg.Wait()
 }
 
-// given an addon name (normalised/slugified), a version and a url,
-// constructs a safe output filename and downloads the addon to the data dir.
-func DownloadAddon(app *core.App, ad AddonsDir, addon_name string, addon_version string, url URL) (string, error) {
+// installs the update chosen for a single addon and reloads the addons dir.
+// addons from a local directory are re-synced with their origin.
+func UpdateAddon(app *core.App, r *core.Result) error {
+	a := r.Item.(Addon)
+	if !Updateable(a) {
+		return nil
+	}
+
+	addons_dir := *a.AddonsDir
+	if a.Source == SOURCE_LOCAL {
+		err := update_local_addon(addons_dir, a)
+		if err != nil {
+			return fmt.Errorf("failed to update addon: %w", err)
+		}
+		return LoadAllInstalledAddonsToState(app, addons_dir)
+	}
+
+	zipfile, err := download_addon_update(app, addons_dir, a)
+	if err != nil {
+		return fmt.Errorf("failed to update addon: %w", err)
+	}
+	return install_addon_guard(app, addons_dir, a, zipfile, InstallOpts{})
+}
+
+// given an addon name (normalised/slugified), a version, a url and an optional md5 checksum,
+// downloads the addon to the shared zip store, or to the addons dir when the zip store isn't available.
+// a zip file already in the zip store isn't downloaded again unless it fails the checksum.
+func DownloadAddon(app *core.App, ad AddonsDir, addon_name string, addon_version string, url URL, md5 string) (string, error) {
 	empty_response := ""
+	store := zip_store()
+	if store != nil {
+		return store.Download(app, url, addon_name, addon_version, md5)
+	}
 	//data_dir := app.DataDir() // um ... no, we're downloading it to the AddonsDir.
 	data_dir := ad.Path
 	output_file := downloaded_addon_fname(addon_name, addon_version)
@@ -437,7 +511,6 @@ func DownloadAddon(app *core.App, ad AddonsDir, addon_name string, addon_version
 // so this is really simple.
 // returns the path to the downloaded file.
 // NOTE: does not acquire locks, execution should be coordinated.
-// FUTURE: single zip repository
 func download_addon_update(app *core.App, addons_dir AddonsDir, a Addon) (PathToFile, error) {
 	empty_response := ""
 
@@ -449,25 +522,65 @@ func download_addon_update(app *core.App, addons_dir AddonsDir, a Addon) (PathTo
 		return empty_response, fmt.Errorf("no update to download")
 	}
 
-	return DownloadAddon(app, addons_dir, a.Name, a.SourceUpdate.Version, a.SourceUpdate.DownloadURL)
+	output_path, err := DownloadAddon(app, addons_dir, a.Name, a.SourceUpdate.Version, a.SourceUpdate.DownloadURL, a.SourceUpdate.MD5)
+	if err != nil {
+		return empty_response, err
+	}
+
+	err = verify_downloaded_addon(output_path, a.SourceUpdate.MD5)
+	if err != nil {
+		return empty_response, err
+	}
+
+	return output_path, nil
+}
+
+// checks the downloaded addon at `output_path` against the `md5` checksum of the release, if the source provided one.
+// a download that fails verification is removed.
+func verify_downloaded_addon(output_path PathToFile, md5 string) error {
+	if md5 == "" {
+		return nil
+	}
+	err := verify_md5(output_path, md5)
+	if err != nil {
+		// don't leave a bad download lying around to be installed later
+		rm_err := discard_zip_file(output_path)
+		if rm_err != nil {
+			slog.Warn("failed to remove download that failed verification", "path", output_path, "error", rm_err)
+		}
+		return fmt.Errorf("downloaded addon failed verification: %w", err)
+	}
+	return nil
 }
 
 // downloads an update for a CatalogueAddon to the given `addons_dir`.
 // CatalogueAddons need to be found, inspected and matched against the addons dir game track and any user preferences.
 // returns the path to the downloaded file.
 // NOTE: does not acquire locks, execution should be coordinated.
-// FUTURE: single zip repository
 func download_catalogue_addon(app *core.App, ad AddonsDir, ca CatalogueAddon) (PathToFile, error) {
 	empty_response := ""
-	summary_list, err := ExpandSummary(app, ca.Source, string(ca.SourceID))
+	summary_list, err := ExpandSummary(app, ca.Source, string(ca.SourceID), &ca)
 	if err != nil {
 		// problem downloading list of available updates. bail.
 		return empty_response, err
 	}
+	if len(summary_list) == 0 {
+		return empty_response, fmt.Errorf("no releases available: %s", ca.Name)
+	}
 	summary := summary_list[0]
 	addon_name := ca.Name
 	addon_version := summary.Version
-	return DownloadAddon(app, ad, addon_name, addon_version, summary.DownloadURL)
+	output_path, err := DownloadAddon(app, ad, addon_name, addon_version, summary.DownloadURL, summary.MD5)
+	if err != nil {
+		return empty_response, err
+	}
+
+	err = verify_downloaded_addon(output_path, summary.MD5)
+	if err != nil {
+		return empty_response, err
+	}
+
+	return output_path, nil
 }
 
 func remove_completely_overwritten_addons(addons_dir AddonsDir, addon Addon, toplevel_dirs mapset.Set[string]) error {
@@ -537,12 +650,167 @@ func update_nfo_files(addons_dir AddonsDir, addon Addon, toplevel_dirs mapset.Se
 	}
 }
 
+// applies `fn` to the nfo data of each addon directory in the addon's group and writes the results to disk.
+// only nfo data belonging to the addon's group is touched, nfo data of mutual dependencies is preserved.
+func update_group_nfo(addon Addon, fn func(NFO) NFO) error {
+	if addon.NFO == nil || addon.NFO.GroupID == "" {
+		return errors.New("addon has no nfo data, it must be installed or re-installed through strongbox first")
+	}
+	if addon.AddonsDir == nil {
+		slog.Error("addon is missing it's addons directory")
+		panic("programming error")
+	}
+
+	to_be_written := map[PathToAddon][]NFO{}
+	for _, ia := range addon.InstalledAddonGroup {
+		addon_path := filepath.Join(addon.AddonsDir.Path, ia.Name)
+		nfo_list, err := read_nfo(addon_path)
+		if err != nil {
+			return fmt.Errorf("failed to read nfo data: %w", err)
+		}
+		for i, nfo := range nfo_list {
+			if nfo.GroupID == addon.NFO.GroupID {
+				nfo_list[i] = fn(nfo)
+			}
+		}
+		to_be_written[addon_path] = nfo_list
+	}
+
+	for addon_path, nfo_list := range to_be_written {
+		err := write_nfo(addon_path, nfo_list)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sets the per-addon release channel preference and re-picks the addon's update.
+// an empty `release_channel` defers to the addons directory preference.
+func SetAddonReleaseChannel(app *core.App, r *core.Result, release_channel ReleaseChannel) error {
+	a := r.Item.(Addon)
+	err := update_group_nfo(a, func(nfo NFO) NFO {
+		nfo.ReleaseChannel = release_channel
+		return nfo
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set release channel: %w", err)
+	}
+
+	nfo := *a.NFO
+	nfo.ReleaseChannel = release_channel
+	app.UpdateResult(r.ID, func(x core.Result) core.Result {
+		a = MakeAddon(*a.AddonsDir, a.InstalledAddonGroup, a.Primary, &nfo, a.CatalogueAddon, a.SourceUpdateList)
+		x.Item = a
+		if Updateable(a) {
+			x.Tags.Add(core.TAG_HAS_UPDATE)
+		} else {
+			x.Tags.Remove(core.TAG_HAS_UPDATE)
+		}
+		return x
+	}).Wait()
+
+	return nil
+}
+
+// splits the addon's group into independent addons, one per directory.
+// each directory gets a new group ID derived from the old one and becomes the primary of its group.
+// the old primary keeps the source data, the other directories keep just enough to stay installed.
+// the manifest of installed files, if any, is split between the new groups.
+func UngroupAddon(a Addon) error {
+	if a.NFO == nil || a.NFO.GroupID == "" {
+		return errors.New("addon has no nfo data, it must be installed or re-installed through strongbox first")
+	}
+	if len(a.InstalledAddonGroup) < 2 {
+		return errors.New("addon is not part of a group")
+	}
+	if a.AddonsDir == nil {
+		slog.Error("addon is missing it's addons directory")
+		panic("programming error")
+	}
+	addons_dir := a.AddonsDir.Path
+	old_group_id := a.NFO.GroupID
+	new_group_id := func(dir_name string) string {
+		return old_group_id + "#" + dir_name
+	}
+
+	manifest, err := nfo_store().ReadManifest(addons_dir, old_group_id)
+	has_manifest := err == nil
+	if err != nil && !errors.Is(err, ErrManifestDNE) {
+		slog.Warn("failed to read manifest, it won't be split", "group-id", old_group_id, "error", err)
+	}
+
+	dir_name_list := addon_dir_name_list(a)
+	err = update_nfo_group(addons_dir, dir_name_list, old_group_id, func(dir_name string, nfo *NFO) bool {
+		if dir_name != a.Primary.Name {
+			*nfo = NFO{Ignored: nfo.Ignored, PinnedVersion: nfo.PinnedVersion}
+		}
+		nfo.GroupID = new_group_id(dir_name)
+		nfo.Primary = true
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("failed to ungroup addon: %w", err)
+	}
+
+	if has_manifest {
+		for _, dir_name := range dir_name_list {
+			dir_manifest := []ManifestEntry{}
+			for _, entry := range manifest {
+				if strings.HasPrefix(entry.Path, dir_name+"/") {
+					dir_manifest = append(dir_manifest, entry)
+				}
+			}
+			err = nfo_store().WriteManifest(addons_dir, new_group_id(dir_name), dir_manifest)
+			if err != nil {
+				return fmt.Errorf("failed to write manifest: %w", err)
+			}
+		}
+		err = nfo_store().RemoveManifest(addons_dir, old_group_id)
+		if err != nil {
+			return fmt.Errorf("failed to remove manifest: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// makes the addon directory `dir_name` the primary of the addon's group.
+// every other directory in the group is no longer a primary.
+func SetPrimaryAddon(a Addon, dir_name string) error {
+	if a.NFO == nil || a.NFO.GroupID == "" {
+		return errors.New("addon has no nfo data, it must be installed or re-installed through strongbox first")
+	}
+	if a.AddonsDir == nil {
+		slog.Error("addon is missing it's addons directory")
+		panic("programming error")
+	}
+	dir_name_list := addon_dir_name_list(a)
+	if !slices.Contains(dir_name_list, dir_name) {
+		return fmt.Errorf("addon directory is not part of the addon's group: %s", dir_name)
+	}
+
+	err := update_nfo_group(a.AddonsDir.Path, dir_name_list, a.NFO.GroupID, func(this_dir_name string, nfo *NFO) bool {
+		nfo.Primary = this_dir_name == dir_name
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set primary addon: %w", err)
+	}
+	return nil
+}
+
 // further options to tweak installation behaviour
 type InstallOpts struct {
 	OverwriteIgnored bool
 	UnpinPinned      bool
 }
 
+// further options to tweak removal behaviour
+type RemoveOpts struct {
+	KeepUserFiles bool // only remove the files strongbox installed
+}
+
 // `addon.clj/install-addon`.
 // file checks, addon checks, state checks, locks, cleanup all happen *elsewhere*.
 // at this point the only thing that will stop this function from installing an addon is:
@@ -562,13 +830,13 @@ func install_addon(addons_dir AddonsDir, addon Addon, zipfile string) error {
 	ignored := false
 	pinned := false
 	for _, toplevel_dir := range report.TopLevelDirs.ToSlice() {
-		nfo_data, err := read_nfo_file(filepath.Join(addons_dir.Path, toplevel_dir))
+		nfo_data, err := read_nfo(filepath.Join(addons_dir.Path, toplevel_dir))
 		if err != nil {
 			if errors.Is(err, ErrNFODNE) {
 				// new addon dir, all good
 			} else {
 				// nfo data exists but it cannot be read, bad json, whatever.
-				// TODO: delete the data if it was invalid/corrupt
+				// see `RepairAddonsDir` for fixing it.
 				slog.Error("failed to read .nfo data", "err", err)
 			}
 		}
@@ -578,16 +846,14 @@ func install_addon(addons_dir AddonsDir, addon Addon, zipfile string) error {
 	}
 
 	// primary-dirname (determine-primary-subdir toplevel-dirs)
-	primary_subdir, err := determine_primary_subdir(report.TopLevelDirs)
-	if err != nil {
-		slog.Warn("failed to determine a primary subdir", "toplevel-dirs", report.TopLevelDirs.ToSlice(), "error", err)
-	}
+	// every installation has exactly one primary directory, see `pick_primary_dir`.
+	primary_subdir := pick_primary_dir(report.TopLevelDirs.ToSlice())
 
 	// sus addon check
 	// . check zip paths for additional addons that will be installed and warn user
 	// . zip bomb check? always wanted to
 
-	err = remove_addon(addon, addons_dir)
+	err = remove_addon(addon, addons_dir, RemoveOpts{})
 	if err != nil {
 		slog.Error("failed to properly uninstall previously installed version of addon", "error", err)
 	}
@@ -602,14 +868,27 @@ func install_addon(addons_dir AddonsDir, addon Addon, zipfile string) error {
 	if err != nil {
 		slog.Error("failed to unzip file", "output-dir", addons_dir.Path, "zipfile", zipfile, "error", err, "extracted-files", extracted_files)
 	}
+	for _, toplevel_dir := range report.TopLevelDirs.ToSlice() {
+		forget_addon_dir_size(filepath.Join(addons_dir.Path, toplevel_dir))
+	}
 
 	// write nfo files
 	update_nfo_files(addons_dir, addon, report.TopLevelDirs, primary_subdir, ignored, pinned)
 
+	// record what was installed
+	if addon.NFO != nil && addon.NFO.GroupID != "" {
+		err = write_addon_manifest(addons_dir, addon.NFO.GroupID, extracted_files)
+		if err != nil {
+			slog.Error("failed to write manifest", "addon", addon.Label, "error", err)
+		}
+	}
+
 	return nil
 }
 
 // addons/remove-zip-files!
+// removes all but the newest `num_zips_to_keep` downloaded zip files for the given addon.
+// zip files in the zip store that are installed in any addons dir are always kept.
 func remove_zip_files(addons_dir AddonsDir, addon_name string, num_zips_to_keep *uint8) error {
 	if num_zips_to_keep == nil {
 		return nil
@@ -617,8 +896,42 @@ func remove_zip_files(addons_dir AddonsDir, addon_name string, num_zips_to_keep
 
 	slog.Info("pruning zip files", "addon-name", addon_name)
 
-	// ...
+	store := zip_store()
+	if store != nil {
+		return store.Prune(addon_name, num_zips_to_keep)
+	}
+
+	// "/path/to/addons/everyaddon--*.zip"
+	match_list, err := filepath.Glob(filepath.Join(addons_dir.Path, addon_name+"--*.zip"))
+	if err != nil {
+		return fmt.Errorf("failed to prune zip files: %w", err)
+	}
+
+	type zip_file struct {
+		path    PathToFile
+		modtime time.Time
+	}
+	zip_file_list := []zip_file{}
+	for _, path := range match_list {
+		finfo, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		zip_file_list = append(zip_file_list, zip_file{path, finfo.ModTime()})
+	}
+	slices.SortStableFunc(zip_file_list, func(a, b zip_file) int {
+		return b.modtime.Compare(a.modtime)
+	})
 
+	if len(zip_file_list) <= int(*num_zips_to_keep) {
+		return nil
+	}
+	for _, zf := range zip_file_list[*num_zips_to_keep:] {
+		err = os.Remove(zf.path)
+		if err != nil {
+			return fmt.Errorf("failed to prune zip files: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -704,6 +1017,11 @@ func install_addon_guard(app *core.App, addons_dir AddonsDir, addon Addon, zipfi
 		return fmt.Errorf("refusing to install: %w", err)
 	}
 
+	err = check_free_space(addons_dir.Path, report)
+	if err != nil {
+		return fmt.Errorf("refusing to install: %w", err)
+	}
+
 	al, err := LoadAllInstalledAddons(addons_dir)
 	if err != nil {
 		return fmt.Errorf("failed to install addon: error inspecting addons directory for ignored addons: %w", err)
@@ -718,9 +1036,7 @@ func install_addon_guard(app *core.App, addons_dir AddonsDir, addon Addon, zipfi
 	}
 
 	defer func() {
-		// problem here: `FindSettings` requires settings to have been loaded. this requires extra setup in testing
-		//post_install(addons_dir, addon, FindSettings(app).Preferences)
-		remove_zip_files(addons_dir, addon.Name, new(uint8(3))) //user_prefs.AddonZipsToKeep)
+		remove_zip_files(addons_dir, addon.Name, zips_to_keep(app, addons_dir))
 	}()
 
 	err = install_addon(addons_dir, addon, zipfile)
@@ -728,6 +1044,8 @@ func install_addon_guard(app *core.App, addons_dir AddonsDir, addon Addon, zipfi
 		return fmt.Errorf("failed to install addon: %w", err)
 	}
 
+	ref_zip_file(addons_dir, zipfile)
+
 	// update state. note: this might be causing flashing in the results
 	LoadAllInstalledAddonsToState(app, addons_dir)
 
@@ -738,7 +1056,7 @@ func install_addon_guard(app *core.App, addons_dir AddonsDir, addon Addon, zipfi
 // downloads and installs an addon from the catalogue.
 // NOTE: does not acquire locks, execution should be coordinated.
 func install_addon_from_catalogue(app *core.App, addons_dir AddonsDir, ca CatalogueAddon) error {
-	source_update_list, err := ExpandSummary(app, ca.Source, string(ca.SourceID))
+	source_update_list, err := ExpandSummary(app, ca.Source, string(ca.SourceID), &ca)
 	if err != nil {
 		// problem downloading list of available updates. bail.
 		return err
@@ -760,9 +1078,7 @@ func install_addon_from_catalogue(app *core.App, addons_dir AddonsDir, ca Catalo
 	}
 
 	opts := InstallOpts{}
-	install_addon_guard(app, addons_dir, a, zipfile, opts)
-
-	return nil
+	return install_addon_guard(app, addons_dir, a, zipfile, opts)
 }
 
 func install_many_addons_from_catalogue(app *core.App, addons_dir AddonsDir, cal []CatalogueAddon) {
@@ -775,18 +1091,20 @@ func install_many_addons_from_catalogue(app *core.App, addons_dir AddonsDir, cal
 }
 
 // removes addon from filesystem and application state
-func RemoveAddon(app *core.App, r *core.Result) error {
+func RemoveAddon(app *core.App, r *core.Result, opts RemoveOpts) error {
 	a := r.Item.(Addon)
 
 	if a.IsIgnored {
 		return fmt.Errorf("refusing to remove addon, addon is being ignored")
 	}
 
-	err := remove_addon(a, *a.AddonsDir)
+	err := remove_addon(a, *a.AddonsDir, opts)
 	if err != nil {
 		return fmt.Errorf("failed to remove addon: %w", err)
 	}
 
+	unref_zip_files(*a.AddonsDir, a.Name)
+
 	app.RemoveResult(r.ID).Wait()
 	return nil
 }
@@ -846,9 +1164,14 @@ func LoadAllInstalledAddonsToState(app *core.App, ad AddonsDir) error {
 	if err != nil {
 		return fmt.Errorf("failed to load addons dir: %w", err)
 	}
+	forget_characters()
 
 	// 2025-09-07: weird failure in main_test here when moving this section above `LoadAllInstalledAddons`
-	r := app.FindResultByItem(ad)
+	// note: `AddonsDir` isn't comparable so it's found by path rather than `app.FindResultByItem`.
+	r := app.FirstResult(func(r core.Result) bool {
+		addons_dir, is_addons_dir := r.Item.(AddonsDir)
+		return is_addons_dir && addons_dir.Path == ad.Path
+	})
 	if r == nil {
 		return fmt.Errorf("failed to find addons directory in application state: %s", ad.Path)
 	}
@@ -863,6 +1186,10 @@ func LoadAllInstalledAddonsToState(app *core.App, ad AddonsDir) error {
 
 	update_installed_addon_list(app, result_list)
 
+	background_tasks.Go(func() {
+		update_addon_sizes(app, result_list)
+	})
+
 	return nil
 }
 
@@ -892,7 +1219,13 @@ func Refresh(app *core.App) {
 		slog.Error("failed to reconcile addons", "error", err)
 	}
 
-	CheckForUpdates(app)
+	addons_dir, err := selected_addon_dir(app)
+	if err == nil && addons_dir_preferences(app, addons_dir).CheckForUpdate {
+		err = CheckForUpdates(app)
+		if err != nil {
+			slog.Warn("some addons were not checked for updates", "error", err)
+		}
+	}
 
 	SaveSettings(app)
 
@@ -951,6 +1284,12 @@ func Start(app *core.App) error {
 
 	// prune-http-cache
 
+	// first run, bring across strongbox 7 settings
+	ImportV7Config(app)
+
+	// settings can change at any time, the global release channel follows them.
+	app.AddObserver(release_channel_observer{})
+
 	LoadSettings(app) // get/create/migrate app config
 	SaveSettings(app)
 
@@ -963,8 +1302,13 @@ func Start(app *core.App) error {
 	return nil
 }
 
+// work done in the background, waited on when strongbox stops.
+var background_tasks sync.WaitGroup
+
 func Stop(app *core.App) {
 	slog.Debug("stopping strongbox")
+	// background tasks update application state and must finish before the app stops.
+	background_tasks.Wait()
 	// call cleanup fns
 	// when debug-mode,
 	//   dump-useful-info
@@ -3,6 +3,7 @@ package strongbox
 import (
 	"bw/core"
 	"bw/http_utils"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -110,6 +111,39 @@ func Test_install_addon__maximal(t *testing.T) {
 	assert.Equal(t, "7.8.9", toc_map["EveryAddon.toc"].InstalledVersion)
 }
 
+// a zip whose directories share no common prefix still has exactly one primary directory.
+func Test_install_addon__primary(t *testing.T) {
+	root := t.TempDir()
+	make_file_tree(t, root, map[string]string{
+		"EveryAddon/EveryAddon.toc": local_test_toc,
+		"OtherAddon/OtherAddon.toc": local_test_toc,
+	})
+	zipfile := filepath.Join(t.TempDir(), "everyaddon.zip")
+	addon_dir_map := map[string]PathToDir{
+		"EveryAddon": filepath.Join(root, "EveryAddon"),
+		"OtherAddon": filepath.Join(root, "OtherAddon"),
+	}
+	assert.Nil(t, write_addon_zipfile(zipfile, addon_dir_map, ""))
+
+	ad := AddonsDir{Path: t.TempDir()}
+	a, err := MakeAddonFromZipfile(ad, zipfile)
+	assert.Nil(t, err)
+
+	err = install_addon(ad, a, zipfile)
+	assert.Nil(t, err)
+
+	primary_list := []string{}
+	for _, dirname := range []string{"EveryAddon", "OtherAddon"} {
+		nfo_list, err := read_nfo_file(filepath.Join(ad.Path, dirname))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(nfo_list))
+		if nfo_list[0].Primary {
+			primary_list = append(primary_list, dirname)
+		}
+	}
+	assert.Equal(t, []string{"EveryAddon"}, primary_list)
+}
+
 // a zip can be installed into a populated, unmanaged, addons dir,
 // updating an addon.
 func Test_install_addon__update(t *testing.T) {
@@ -177,6 +211,21 @@ func Test_install_addon__mutual_dependency(t *testing.T) {
 
 // ---
 
+// downloads are checked against the release's md5 checksum when there is one,
+// bad downloads are removed.
+func Test_verify_downloaded_addon(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foo.zip")
+	err := os.WriteFile(path, []byte("foo"), 0644)
+	assert.Nil(t, err)
+
+	assert.Nil(t, verify_downloaded_addon(path, ""))
+	assert.Nil(t, verify_downloaded_addon(path, "acbd18db4cc2f85cedef654fccc4a4d8"))
+	assert.FileExists(t, path)
+
+	assert.NotNil(t, verify_downloaded_addon(path, "d41d8cd98f00b204e9800998ecf8427e"))
+	assert.NoFileExists(t, path)
+}
+
 // an Addon derived from a CatalogueAddon + zipfile can be installed
 func Test_install_addon__with_catalogue_addon(t *testing.T) {
 	ad := MakeAddonsDir(t.TempDir())
@@ -286,7 +335,7 @@ func TestRemoveAddon(t *testing.T) {
 	})
 	assert.NotNil(t, r)
 
-	err := RemoveAddon(app, r)
+	err := RemoveAddon(app, r, RemoveOpts{})
 	assert.Nil(t, err)
 
 	// result no longer present in state
@@ -377,3 +426,133 @@ func TestCheckAddon(t *testing.T) {
 	updated_addon2 := updated_r2.Item.(Addon)
 	assert.Equal(t, 0, len(updated_addon2.SourceUpdateList), "addon2 should still have 0 source updates")
 }
+
+// the nfo files of an installed addon can be updated in place
+func Test_update_group_nfo(t *testing.T) {
+	ad := AddonsDir{
+		Path: t.TempDir(),
+	}
+	zipfile := test_fixture_everyaddon_minimal_zip
+
+	a, err := MakeAddonFromZipfile(ad, zipfile)
+	assert.Nil(t, err)
+
+	err = install_addon(ad, a, zipfile)
+	assert.Nil(t, err)
+
+	addon_path := filepath.Join(ad.Path, "EveryAddon")
+	nfo_list, err := read_nfo_file(addon_path)
+	assert.Nil(t, err)
+
+	installed := Addon{
+		AddonsDir:           &ad,
+		InstalledAddonGroup: []InstalledAddon{{Name: "EveryAddon"}},
+		NFO:                 &nfo_list[0],
+	}
+
+	rc := ReleaseChannel{Stability: RELEASE_STABILITY_BETA, Type: RELEASE_TYPE_NOLIB}
+	err = update_group_nfo(installed, func(nfo NFO) NFO {
+		nfo.ReleaseChannel = rc
+		return nfo
+	})
+	assert.Nil(t, err)
+
+	nfo_list, err = read_nfo_file(addon_path)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(nfo_list))
+	assert.Equal(t, rc, nfo_list[0].ReleaseChannel)
+}
+
+// addons without nfo data can't be updated
+func Test_update_group_nfo__no_nfo(t *testing.T) {
+	ad := AddonsDir{Path: t.TempDir()}
+	err := update_group_nfo(Addon{AddonsDir: &ad}, func(nfo NFO) NFO { return nfo })
+	assert.NotNil(t, err)
+}
+
+// an addon made of many directories, without a sensible primary directory
+func grouped_test_addons_dir(t *testing.T, primary_list ...string) AddonsDir {
+	addons_dir := AddonsDir{Path: t.TempDir(), GameTrackID: GAMETRACK_RETAIL}
+	file_map := map[string]string{}
+	for _, dir_name := range []string{"Zed", "Alpha", "Mid"} {
+		file_map[dir_name+"/"+dir_name+".toc"] = "## Title: " + dir_name + "\n## Interface: 110000\n"
+		primary := "false"
+		for _, p := range primary_list {
+			if p == dir_name {
+				primary = "true"
+			}
+		}
+		file_map[dir_name+"/.strongbox.json"] = `{"group-id": "https://group", "primary?": ` + primary + `}`
+	}
+	make_file_tree(t, addons_dir.Path, file_map)
+	return addons_dir
+}
+
+// the primary of a group is picked the same way every time
+func Test_LoadAllInstalledAddons__group_primary(t *testing.T) {
+	cases := []struct {
+		primary_list []string
+		expected     string
+	}{
+		{[]string{"Mid"}, "Mid"},
+		{[]string{}, "Alpha"},
+		{[]string{"Zed", "Mid"}, "Mid"},
+	}
+	for _, c := range cases {
+		addons_dir := grouped_test_addons_dir(t, c.primary_list...)
+		for range 5 {
+			addon_list, err := LoadAllInstalledAddons(addons_dir)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(addon_list))
+			assert.Equal(t, c.expected, addon_list[0].Primary.Name, c.primary_list)
+		}
+	}
+}
+
+// a group can be split into independent addons
+func Test_UngroupAddon(t *testing.T) {
+	addons_dir := grouped_test_addons_dir(t, "Mid")
+	addon_list, err := LoadAllInstalledAddons(addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(addon_list))
+
+	err = UngroupAddon(addon_list[0])
+	assert.Nil(t, err)
+
+	addon_list, err = LoadAllInstalledAddons(addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(addon_list))
+	for _, a := range addon_list {
+		assert.Equal(t, 1, len(a.InstalledAddonGroup))
+		assert.Equal(t, "https://group#"+a.Primary.Name, a.NFO.GroupID)
+		assert.True(t, a.NFO.Primary)
+	}
+
+	// an addon that isn't grouped can't be ungrouped
+	err = UngroupAddon(addon_list[0])
+	assert.NotNil(t, err)
+}
+
+// the primary of a group can be changed
+func Test_SetPrimaryAddon(t *testing.T) {
+	addons_dir := grouped_test_addons_dir(t, "Mid", "Zed")
+	addon_list, err := LoadAllInstalledAddons(addons_dir)
+	assert.Nil(t, err)
+
+	err = SetPrimaryAddon(addon_list[0], "Zed")
+	assert.Nil(t, err)
+
+	addon_list, err = LoadAllInstalledAddons(addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(addon_list))
+	assert.Equal(t, "Zed", addon_list[0].Primary.Name)
+	for _, dir_name := range []string{"Alpha", "Mid", "Zed"} {
+		nfo_list, err := read_nfo_file(filepath.Join(addons_dir.Path, dir_name))
+		assert.Nil(t, err)
+		assert.Equal(t, dir_name == "Zed", nfo_list[0].Primary, dir_name)
+	}
+
+	// directories outside of the group can't be the primary
+	err = SetPrimaryAddon(addon_list[0], "Other")
+	assert.NotNil(t, err)
+}
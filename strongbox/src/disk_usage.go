@@ -0,0 +1,289 @@
+package strongbox
+
+import (
+	"bw/core"
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/conc/pool"
+)
+
+// disk_usage.go accounts for the disk space used by addons and the .zip files left behind after installing them.
+
+const (
+	DISK_USAGE_TOTAL = "total" // every addon and .zip file in an addons directory
+	DISK_USAGE_ADDON = "addon" // every directory of an addon
+	DISK_USAGE_ZIP   = "zip"   // a downloaded .zip file
+)
+
+// number of addons listed by `AddonsDirDiskUsage`
+const DISK_USAGE_NUM_LARGEST = 10
+
+var ErrFreeSpaceUnsupported = errors.New("free space can't be checked on this platform")
+
+// the disk space used by something in an addons directory.
+type DiskUsage struct {
+	Kind      string
+	Name      string
+	Path      string
+	SizeBytes int64
+}
+
+var _ core.ItemInfo = (*DiskUsage)(nil)
+
+func (du DiskUsage) ItemKeys() []string {
+	return []string{
+		core.ITEM_FIELD_NAME,
+		"kind",
+		"size",
+	}
+}
+
+func (du DiskUsage) ItemMap() map[string]string {
+	return map[string]string{
+		core.ITEM_FIELD_NAME: du.Name,
+		"kind":               du.Kind,
+		"size":               format_size_bytes(du.SizeBytes),
+	}
+}
+
+func (du DiskUsage) ItemHasChildren() core.ITEM_CHILDREN_LOAD {
+	return core.ITEM_CHILDREN_LOAD_FALSE
+}
+
+func (du DiskUsage) ItemChildren(_ *core.App) []core.Result {
+	return nil
+}
+
+// ---
+
+// "1.5 MiB"
+func format_size_bytes(size_bytes int64) string {
+	const unit = 1024
+	if size_bytes < unit {
+		return fmt.Sprintf("%d B", size_bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := size_bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size_bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// returns the sum of the sizes of every file beneath `path`.
+func dir_size(path PathToDir) (int64, error) {
+	var rv int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		finfo, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rv += finfo.Size()
+		return nil
+	})
+	return rv, err
+}
+
+// the size of an addon directory and the modification time of the directory when it was measured.
+type dir_usage struct {
+	mtime      time.Time
+	size_bytes int64
+}
+
+var disk_usage_cache = struct {
+	lock sync.Mutex
+	idx  map[PathToDir]dir_usage
+}{idx: map[PathToDir]dir_usage{}}
+
+// returns the size of the addon directory at `path`.
+// sizes are cached until the modification time of the directory changes or strongbox changes it.
+func addon_dir_size(path PathToDir) (int64, error) {
+	finfo, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	disk_usage_cache.lock.Lock()
+	cached, present := disk_usage_cache.idx[path]
+	disk_usage_cache.lock.Unlock()
+	if present && cached.mtime.Equal(finfo.ModTime()) {
+		return cached.size_bytes, nil
+	}
+
+	size_bytes, err := dir_size(path)
+	if err != nil {
+		return 0, err
+	}
+
+	disk_usage_cache.lock.Lock()
+	disk_usage_cache.idx[path] = dir_usage{mtime: finfo.ModTime(), size_bytes: size_bytes}
+	disk_usage_cache.lock.Unlock()
+	return size_bytes, nil
+}
+
+// drops the cached size of the addon directory at `path`.
+// files beneath an addon directory can change without changing the directory's modification time.
+func forget_addon_dir_size(path PathToDir) {
+	disk_usage_cache.lock.Lock()
+	defer disk_usage_cache.lock.Unlock()
+	delete(disk_usage_cache.idx, path)
+}
+
+// returns the cached size of every directory of the addon.
+// returns `false` if any directory hasn't been measured yet.
+func addon_size_cached(a Addon) (int64, bool) {
+	if a.AddonsDir == nil || len(a.InstalledAddonGroup) == 0 {
+		return 0, false
+	}
+	disk_usage_cache.lock.Lock()
+	defer disk_usage_cache.lock.Unlock()
+	var rv int64
+	for _, ia := range a.InstalledAddonGroup {
+		cached, present := disk_usage_cache.idx[filepath.Join(a.AddonsDir.Path, ia.Name)]
+		if !present {
+			return 0, false
+		}
+		rv += cached.size_bytes
+	}
+	return rv, true
+}
+
+// returns the size of every directory of the addon.
+func AddonSizeBytes(a Addon) (int64, error) {
+	if a.AddonsDir == nil {
+		slog.Error("addon is missing it's addons directory")
+		panic("programming error")
+	}
+	var rv int64
+	for _, ia := range a.InstalledAddonGroup {
+		size_bytes, err := addon_dir_size(filepath.Join(a.AddonsDir.Path, ia.Name))
+		if err != nil {
+			return 0, fmt.Errorf("failed to measure addon: %w", err)
+		}
+		rv += size_bytes
+	}
+	return rv, nil
+}
+
+func set_addon_size(a Addon, size_bytes int64) Addon {
+	a.SizeBytes = size_bytes
+	a.Size = format_size_bytes(size_bytes)
+	return a
+}
+
+// measures each installed addon in `result_list` and updates it's result.
+// addons directories can be large, this is intended to be run in the background.
+func update_addon_sizes(app *core.App, result_list []core.Result) {
+	p := pool.New()
+	for _, r := range result_list {
+		p.Go(func() {
+			a := r.Item.(Addon)
+			size_bytes, err := AddonSizeBytes(a)
+			if err != nil {
+				slog.Debug("failed to measure addon", "addon", a.Label, "error", err)
+				return
+			}
+			app.UpdateResult(r.ID, func(x core.Result) core.Result {
+				x.Item = set_addon_size(x.Item.(Addon), size_bytes)
+				return x
+			})
+		})
+	}
+	p.Wait()
+}
+
+// returns the .zip files in the addons directory, ordered by name.
+func zip_file_list(addons_dir PathToDir) ([]DiskUsage, error) {
+	entry_list, err := os.ReadDir(addons_dir)
+	if err != nil {
+		return nil, err
+	}
+	rv := []DiskUsage{}
+	for _, entry := range entry_list {
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".zip") {
+			continue
+		}
+		finfo, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, DiskUsage{
+			Kind:      DISK_USAGE_ZIP,
+			Name:      entry.Name(),
+			Path:      filepath.Join(addons_dir, entry.Name()),
+			SizeBytes: finfo.Size(),
+		})
+	}
+	return rv, nil
+}
+
+// returns the disk space used in the addons directory:
+// the total first, then the largest `num_largest` addons, then any .zip files.
+func AddonsDirDiskUsage(addons_dir AddonsDir, addon_list []Addon, num_largest int) ([]DiskUsage, error) {
+	empty_response := []DiskUsage{}
+	total := DiskUsage{Kind: DISK_USAGE_TOTAL, Name: addons_dir.Path, Path: addons_dir.Path}
+
+	addon_usage_list := []DiskUsage{}
+	for _, a := range addon_list {
+		size_bytes, err := AddonSizeBytes(a)
+		if err != nil {
+			return empty_response, err
+		}
+		total.SizeBytes += size_bytes
+		addon_usage_list = append(addon_usage_list, DiskUsage{
+			Kind:      DISK_USAGE_ADDON,
+			Name:      a.Label,
+			Path:      filepath.Join(addons_dir.Path, a.Primary.Name),
+			SizeBytes: size_bytes,
+		})
+	}
+	slices.SortStableFunc(addon_usage_list, func(a, b DiskUsage) int {
+		return cmp.Compare(b.SizeBytes, a.SizeBytes)
+	})
+
+	zip_usage_list, err := zip_file_list(addons_dir.Path)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to list .zip files: %w", err)
+	}
+	for _, du := range zip_usage_list {
+		total.SizeBytes += du.SizeBytes
+	}
+
+	rv := []DiskUsage{total}
+	rv = append(rv, core.Take(num_largest, addon_usage_list)...)
+	rv = append(rv, zip_usage_list...)
+	return rv, nil
+}
+
+// returns an error if there isn't enough free space in the addons directory to decompress the zip file.
+// the space used by any addons being replaced isn't counted.
+func check_free_space(addons_dir PathToDir, report ZipReport) error {
+	free_bytes, err := free_space_bytes(addons_dir)
+	if errors.Is(err, ErrFreeSpaceUnsupported) {
+		return nil
+	}
+	if err != nil {
+		slog.Warn("failed to check free space", "addons-dir", addons_dir, "error", err)
+		return nil
+	}
+	if report.DecompressedSizeBytes > free_bytes {
+		return fmt.Errorf("not enough free space: %s needed, %s available", format_size_bytes(report.DecompressedSizeBytes), format_size_bytes(free_bytes))
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !darwin && !windows
+
+package strongbox
+
+func free_space_bytes(_ string) (int64, error) {
+	return 0, ErrFreeSpaceUnsupported
+}
@@ -0,0 +1,119 @@
+package strongbox
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_format_size_bytes(t *testing.T) {
+	cases := []struct {
+		given    int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, format_size_bytes(c.given))
+	}
+}
+
+func disk_usage_test_addons_dir(t *testing.T) AddonsDir {
+	addons_dir := AddonsDir{Path: t.TempDir(), GameTrackID: GAMETRACK_RETAIL}
+	make_file_tree(t, addons_dir.Path, map[string]string{
+		"Big/Big.toc":                   "## Title: Big\n## Interface: 110000\n",
+		"Big/Big.lua":                   strings.Repeat("x", 1000),
+		"Small/Small.toc":               "## Title: Small\n## Interface: 110000\n",
+		"Small/.strongbox.json":         `{"group-id": "https://small", "primary?": true}`,
+		"Small_Config/Small_Config.toc": "## Title: Small Config\n## Interface: 110000\n",
+		"Small_Config/.strongbox.json":  `{"group-id": "https://small", "primary?": false}`,
+		"Small_Config/libs/Lib/Lib.lua": strings.Repeat("x", 100),
+		"everyaddon--1-2-3.zip":         strings.Repeat("x", 10),
+		"not-a-zip.txt":                 "",
+	})
+	return addons_dir
+}
+
+// every directory of an addon is measured, sizes are cached until forgotten
+func Test_AddonSizeBytes(t *testing.T) {
+	addons_dir := disk_usage_test_addons_dir(t)
+	addon_list, err := LoadAllInstalledAddons(addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(addon_list))
+	small := addon_list[1]
+	assert.Equal(t, "Small", small.Primary.Name)
+
+	_, present := addon_size_cached(small)
+	assert.False(t, present)
+
+	expected := int64(len("## Title: Small\n## Interface: 110000\n") + len(`{"group-id": "https://small", "primary?": true}`) +
+		len("## Title: Small Config\n## Interface: 110000\n") + len(`{"group-id": "https://small", "primary?": false}`) + 100)
+	actual, err := AddonSizeBytes(small)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+
+	cached, present := addon_size_cached(small)
+	assert.True(t, present)
+	assert.Equal(t, expected, cached)
+
+	// addons made after measuring have their size
+	addon_list, err = LoadAllInstalledAddons(addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, addon_list[1].SizeBytes)
+	assert.Equal(t, format_size_bytes(expected), addon_list[1].Size)
+
+	// a nested change doesn't touch the addon directory, the cached size is used until it's forgotten
+	lib_path := filepath.Join(addons_dir.Path, "Small_Config", "libs", "Lib", "Lib.lua")
+	err = os.WriteFile(lib_path, []byte(strings.Repeat("x", 200)), 0644)
+	assert.Nil(t, err)
+	actual, err = AddonSizeBytes(small)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+
+	forget_addon_dir_size(filepath.Join(addons_dir.Path, "Small_Config"))
+	actual, err = AddonSizeBytes(small)
+	assert.Nil(t, err)
+	assert.Equal(t, expected+100, actual)
+}
+
+// the total comes first, then the largest addons, then the leftover .zip files
+func Test_AddonsDirDiskUsage(t *testing.T) {
+	addons_dir := disk_usage_test_addons_dir(t)
+	addon_list, err := LoadAllInstalledAddons(addons_dir)
+	assert.Nil(t, err)
+
+	actual, err := AddonsDirDiskUsage(addons_dir, addon_list, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(actual))
+
+	assert.Equal(t, DISK_USAGE_TOTAL, actual[0].Kind)
+	assert.Equal(t, DISK_USAGE_ADDON, actual[1].Kind)
+	assert.Equal(t, "Big", actual[1].Name)
+	assert.Equal(t, DISK_USAGE_ZIP, actual[2].Kind)
+	assert.Equal(t, "everyaddon--1-2-3.zip", actual[2].Name)
+	assert.Equal(t, int64(10), actual[2].SizeBytes)
+
+	small_size, err := AddonSizeBytes(addon_list[1])
+	assert.Nil(t, err)
+	assert.Equal(t, actual[1].SizeBytes+small_size+10, actual[0].SizeBytes)
+}
+
+// zip files that would fill the disk aren't installed
+func Test_check_free_space(t *testing.T) {
+	addons_dir := t.TempDir()
+	assert.Nil(t, check_free_space(addons_dir, ZipReport{DecompressedSizeBytes: 1}))
+
+	free_bytes, err := free_space_bytes(addons_dir)
+	if err != nil {
+		t.Skip("free space can't be checked", err)
+	}
+	err = check_free_space(addons_dir, ZipReport{DecompressedSizeBytes: free_bytes + 1})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin
+
+package strongbox
+
+import "syscall"
+
+// returns the number of bytes available to unprivileged users on the filesystem containing `path`.
+func free_space_bytes(path string) (int64, error) {
+	var st syscall.Statfs_t
+	err := syscall.Statfs(path, &st)
+	if err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
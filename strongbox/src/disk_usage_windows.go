@@ -0,0 +1,19 @@
+//go:build windows
+
+package strongbox
+
+import "golang.org/x/sys/windows"
+
+// returns the number of bytes available to the current user on the volume containing `path`.
+func free_space_bytes(path string) (int64, error) {
+	path_ptr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free_bytes_available uint64
+	err = windows.GetDiskFreeSpaceEx(path_ptr, &free_bytes_available, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	return int64(free_bytes_available), nil
+}
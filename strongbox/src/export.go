@@ -0,0 +1,382 @@
+package strongbox
+
+import (
+	"bw/core"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// export.go writes the addons in an addons directory to a file and installs the addons in such a file into another.
+// exports are compatible with strongbox 7 exports, which have just 'name', 'source', 'source-id' and 'game-track'.
+
+// an installed addon as written to an export file.
+type ExportedAddon struct {
+	Source      Source      `json:"source"`
+	SourceID    FlexString  `json:"source-id"`
+	Name        string      `json:"name"`
+	Version     string      `json:"version,omitempty"`
+	Pinned      bool        `json:"pinned,omitempty"`
+	Ignored     bool        `json:"ignored,omitempty"`
+	GameTrackID GameTrackID `json:"game-track,omitempty"`
+}
+
+// csv column order
+var export_csv_header = []string{"source", "source-id", "name", "version", "pinned", "ignored", "game-track"}
+
+const (
+	IMPORT_STATUS_INSTALLED         = "installed"
+	IMPORT_STATUS_ALREADY_INSTALLED = "already-installed"
+	IMPORT_STATUS_NOT_FOUND         = "not-found" // no match in the catalogue
+	IMPORT_STATUS_FAILED            = "failed"
+)
+
+// the result of importing a single exported addon.
+type ImportedAddon struct {
+	ExportedAddon
+	Status string
+	Error  string // set when status is 'failed'
+
+	match *CatalogueAddon // the catalogue addon to install, if any
+}
+
+var _ core.ItemInfo = (*ImportedAddon)(nil)
+
+func (ia ImportedAddon) ItemKeys() []string {
+	return []string{
+		"source",
+		core.ITEM_FIELD_NAME,
+		core.ITEM_FIELD_DESC,
+		"status",
+	}
+}
+
+func (ia ImportedAddon) ItemMap() map[string]string {
+	return map[string]string{
+		"source":             ia.Source,
+		core.ITEM_FIELD_NAME: ia.Name,
+		core.ITEM_FIELD_DESC: ia.Error,
+		"status":             ia.Status,
+	}
+}
+
+func (ia ImportedAddon) ItemHasChildren() core.ITEM_CHILDREN_LOAD {
+	return core.ITEM_CHILDREN_LOAD_FALSE
+}
+
+func (ia ImportedAddon) ItemChildren(_ *core.App) []core.Result {
+	return nil
+}
+
+// ---
+
+func export_addon(a Addon) ExportedAddon {
+	game_track_id := GameTrackID("")
+	if a.NFO != nil && a.NFO.InstalledGameTrackID != "" {
+		game_track_id = a.NFO.InstalledGameTrackID
+	} else if a.AddonsDir != nil {
+		game_track_id = a.AddonsDir.GameTrackID
+	}
+	return ExportedAddon{
+		Source:      a.Source,
+		SourceID:    FlexString(a.SourceID),
+		Name:        a.Name,
+		Version:     a.InstalledVersion,
+		Pinned:      a.IsPinned,
+		Ignored:     a.IsIgnored,
+		GameTrackID: game_track_id,
+	}
+}
+
+// returns the exportable addons in `addon_list`, ordered by name.
+// addons without a source can't be installed elsewhere and are skipped.
+func ExportAddonList(addon_list []Addon) []ExportedAddon {
+	rv := []ExportedAddon{}
+	for _, a := range addon_list {
+		if a.Source == "" || a.SourceID == "" {
+			slog.Debug("skipping addon without a source", "addon", a.Name)
+			continue
+		}
+		rv = append(rv, export_addon(a))
+	}
+	slices.SortStableFunc(rv, func(a, b ExportedAddon) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return rv
+}
+
+func write_export_json(path PathToFile, exported_list []ExportedAddon) error {
+	data, err := json.MarshalIndent(exported_list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func write_export_csv(path PathToFile, exported_list []ExportedAddon) error {
+	fh, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	w := csv.NewWriter(fh)
+	err = w.Write(export_csv_header)
+	if err != nil {
+		return err
+	}
+	for _, e := range exported_list {
+		err = w.Write([]string{
+			e.Source,
+			string(e.SourceID),
+			e.Name,
+			e.Version,
+			strconv.FormatBool(e.Pinned),
+			strconv.FormatBool(e.Ignored),
+			string(e.GameTrackID),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writes `exported_list` to `path` as json or csv depending on the file extension.
+func write_export_file(path PathToFile, exported_list []ExportedAddon) error {
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = write_export_json(path, exported_list)
+	case ".csv":
+		err = write_export_csv(path, exported_list)
+	default:
+		return fmt.Errorf("export file must have a .json or .csv extension: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+	return nil
+}
+
+func read_export_json(data []byte) ([]ExportedAddon, error) {
+	rv := []ExportedAddon{}
+	err := json.Unmarshal(data, &rv)
+	return rv, err
+}
+
+// reads a csv export, columns may be in any order but the first row must be a header.
+func read_export_csv(data []byte) ([]ExportedAddon, error) {
+	empty_response := []ExportedAddon{}
+	row_list, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil {
+		return empty_response, err
+	}
+	if len(row_list) == 0 {
+		return empty_response, nil
+	}
+
+	col_idx := map[string]int{}
+	for i, col := range row_list[0] {
+		col_idx[strings.TrimSpace(strings.ToLower(col))] = i
+	}
+	for _, col := range []string{"source", "source-id", "name"} {
+		if _, present := col_idx[col]; !present {
+			return empty_response, fmt.Errorf("missing column: %s", col)
+		}
+	}
+
+	rv := []ExportedAddon{}
+	for _, row := range row_list[1:] {
+		get := func(col string) string {
+			i, present := col_idx[col]
+			if !present || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		pinned, _ := strconv.ParseBool(get("pinned"))
+		ignored, _ := strconv.ParseBool(get("ignored"))
+		rv = append(rv, ExportedAddon{
+			Source:      get("source"),
+			SourceID:    FlexString(get("source-id")),
+			Name:        get("name"),
+			Version:     get("version"),
+			Pinned:      pinned,
+			Ignored:     ignored,
+			GameTrackID: GameTrackID(get("game-track")),
+		})
+	}
+	return rv, nil
+}
+
+// reads a json or csv export file, depending on the file extension.
+func ReadExportFile(path PathToFile) ([]ExportedAddon, error) {
+	empty_response := []ExportedAddon{}
+	data, err := core.SlurpBytesUTF8(path)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read export file: %w", err)
+	}
+
+	var rv []ExportedAddon
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		rv, err = read_export_json(data)
+	case ".csv":
+		rv, err = read_export_csv(data)
+	default:
+		return empty_response, fmt.Errorf("export file must have a .json or .csv extension: %s", path)
+	}
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read export file: %w", err)
+	}
+	return rv, nil
+}
+
+// finds the catalogue addon for the given exported addon and `true`, or `false` if not found.
+// matches on source and source-id, falling back to the name and source for exports without a source-id.
+func match_exported_addon(e ExportedAddon, catalogue_addon_list []CatalogueAddon) (CatalogueAddon, bool) {
+	if e.SourceID != "" {
+		for _, ca := range catalogue_addon_list {
+			if ca.Source == e.Source && ca.SourceID == e.SourceID {
+				return ca, true
+			}
+		}
+	}
+	for _, ca := range catalogue_addon_list {
+		if ca.Name == e.Name && (e.Source == "" || ca.Source == e.Source) {
+			return ca, true
+		}
+	}
+	return CatalogueAddon{}, false
+}
+
+// returns `true` if the exported addon is already installed in `installed_addon_list`.
+func exported_addon_installed(e ExportedAddon, installed_addon_list []Addon) bool {
+	for _, a := range installed_addon_list {
+		if a.Source == e.Source && a.SourceID == string(e.SourceID) {
+			return true
+		}
+		if e.SourceID == "" && a.Name == e.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// compares the addons in an export against the installed addons and the catalogue.
+// addons to be installed have an empty status and a catalogue match.
+func reconcile_import(exported_list []ExportedAddon, installed_addon_list []Addon, catalogue_addon_list []CatalogueAddon) []ImportedAddon {
+	rv := []ImportedAddon{}
+	for _, e := range exported_list {
+		ia := ImportedAddon{ExportedAddon: e}
+		if exported_addon_installed(e, installed_addon_list) {
+			ia.Status = IMPORT_STATUS_ALREADY_INSTALLED
+		} else if ca, found := match_exported_addon(e, catalogue_addon_list); found {
+			ia.match = &ca
+		} else {
+			ia.Status = IMPORT_STATUS_NOT_FOUND
+		}
+		rv = append(rv, ia)
+	}
+	return rv
+}
+
+// ---
+
+// writes every addon in `addons_dir` to `path` as json or csv, depending on the file extension.
+func ExportAddonsDir(app *core.App, addons_dir AddonsDir, path PathToFile) ([]ExportedAddon, error) {
+	empty_response := []ExportedAddon{}
+	addon_list := []Addon{}
+	for _, r := range installed_addons(app, addons_dir) {
+		addon_list = append(addon_list, r.Item.(Addon))
+	}
+	exported_list := ExportAddonList(addon_list)
+	err := write_export_file(path, exported_list)
+	if err != nil {
+		return empty_response, err
+	}
+	slog.Info("exported addons", "addons-dir", addons_dir.Path, "path", path, "num-addons", len(exported_list))
+	return exported_list, nil
+}
+
+// reads the export file at `path` and installs any addons missing from `addons_dir` that can be found in the catalogue.
+// pins and/or ignores the installed addon matching the exported addon `e`.
+// an addon is pinned to the exported version, or to the installed version if there isn't one.
+func restore_exported_flags(addons_dir AddonsDir, e ExportedAddon) error {
+	installed_addon_list, err := LoadAllInstalledAddons(addons_dir)
+	if err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(installed_addon_list, func(a Addon) bool {
+		return a.Source == e.Source && a.SourceID == string(e.SourceID)
+	})
+	if idx == -1 {
+		return fmt.Errorf("installed addon not found: %s", e.Name)
+	}
+	a := installed_addon_list[idx]
+	if a.NFO == nil || a.NFO.GroupID == "" {
+		return fmt.Errorf("installed addon has no nfo data: %s", e.Name)
+	}
+
+	pinned_version := e.Version
+	if pinned_version == "" {
+		pinned_version = a.InstalledVersion
+	}
+
+	return update_nfo_group(addons_dir.Path, addon_dir_name_list(a), a.NFO.GroupID, func(_ string, nfo *NFO) bool {
+		if e.Ignored {
+			nfo.Ignored = new(true)
+		}
+		if e.Pinned {
+			nfo.PinnedVersion = pinned_version
+		}
+		return true
+	})
+}
+
+func ImportAddonList(app *core.App, addons_dir AddonsDir, path PathToFile) ([]ImportedAddon, error) {
+	empty_response := []ImportedAddon{}
+	exported_list, err := ReadExportFile(path)
+	if err != nil {
+		return empty_response, err
+	}
+
+	installed_addon_list := []Addon{}
+	for _, r := range installed_addons(app, addons_dir) {
+		installed_addon_list = append(installed_addon_list, r.Item.(Addon))
+	}
+
+	imported_list := reconcile_import(exported_list, installed_addon_list, db_catalogue_addons(app))
+	for i, ia := range imported_list {
+		if ia.match == nil {
+			continue
+		}
+		err := install_addon_from_catalogue(app, addons_dir, *ia.match)
+		if err != nil {
+			slog.Error("failed to install addon", "addon", ia.Name, "error", err)
+			imported_list[i].Status = IMPORT_STATUS_FAILED
+			imported_list[i].Error = err.Error()
+			continue
+		}
+		imported_list[i].Status = IMPORT_STATUS_INSTALLED
+
+		if ia.Pinned || ia.Ignored {
+			err = restore_exported_flags(addons_dir, ia.ExportedAddon)
+			if err != nil {
+				slog.Error("failed to restore pinned/ignored state of imported addon", "addon", ia.Name, "error", err)
+				imported_list[i].Status = IMPORT_STATUS_FAILED
+				imported_list[i].Error = err.Error()
+			}
+		}
+	}
+
+	return imported_list, nil
+}
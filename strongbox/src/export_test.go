@@ -0,0 +1,150 @@
+package strongbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var export_test_addon_list = []Addon{
+	{Source: "wowinterface", SourceID: "25079", Name: "every-other-addon", InstalledVersion: "1.0", IsIgnored: true, AddonsDir: &AddonsDir{GameTrackID: GAMETRACK_CLASSIC}},
+	{Source: "github", SourceID: "ogri-la/everyaddon", Name: "everyaddon", InstalledVersion: "1.2.3", IsPinned: true, NFO: &NFO{InstalledGameTrackID: GAMETRACK_RETAIL}},
+	{Name: "local-addon"}, // no source, not exported
+}
+
+// addons without a source are skipped, the rest are ordered by name
+func Test_ExportAddonList(t *testing.T) {
+	expected := []ExportedAddon{
+		{Source: "wowinterface", SourceID: "25079", Name: "every-other-addon", Version: "1.0", Ignored: true, GameTrackID: GAMETRACK_CLASSIC},
+		{Source: "github", SourceID: "ogri-la/everyaddon", Name: "everyaddon", Version: "1.2.3", Pinned: true, GameTrackID: GAMETRACK_RETAIL},
+	}
+	assert.Equal(t, expected, ExportAddonList(export_test_addon_list))
+}
+
+// exports can be written and read back as json and csv
+func Test_write_export_file__round_trip(t *testing.T) {
+	exported_list := ExportAddonList(export_test_addon_list)
+	for _, ext := range []string{".json", ".csv"} {
+		path := filepath.Join(t.TempDir(), "export"+ext)
+		err := write_export_file(path, exported_list)
+		assert.Nil(t, err)
+
+		actual, err := ReadExportFile(path)
+		assert.Nil(t, err)
+		assert.Equal(t, exported_list, actual, ext)
+	}
+}
+
+// pinned and ignored addons are pinned and ignored again after being exported and re-installed
+func Test_restore_exported_flags__round_trip(t *testing.T) {
+	for _, ext := range []string{".json", ".csv"} {
+		ad := MakeAddonsDir(t.TempDir())
+		a := MakeAddonFromCatalogueAddon(ad, test_fixture_catalogue.AddonSummaryList[0], []SourceUpdate{})
+		a.SourceUpdate = &SourceUpdate{Version: "1.2.3"}
+		err := install_addon(ad, a, test_fixture_everyaddon_minimal_zip)
+		assert.Nil(t, err)
+
+		exported_list := []ExportedAddon{
+			{Source: "github", SourceID: "ogri-la/everyaddon", Name: "everyaddon", Version: "1.2.3", Pinned: true, Ignored: true, GameTrackID: ad.GameTrackID},
+		}
+		path := filepath.Join(t.TempDir(), "export"+ext)
+		err = write_export_file(path, exported_list)
+		assert.Nil(t, err)
+		imported_list, err := ReadExportFile(path)
+		assert.Nil(t, err)
+
+		err = restore_exported_flags(ad, imported_list[0])
+		assert.Nil(t, err)
+
+		addon_list, err := LoadAllInstalledAddons(ad)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(addon_list))
+		assert.True(t, addon_list[0].IsPinned, ext)
+		assert.True(t, addon_list[0].IsIgnored, ext)
+		assert.Equal(t, "1.2.3", addon_list[0].NFO.PinnedVersion, ext)
+		assert.Equal(t, exported_list, ExportAddonList(addon_list), ext)
+	}
+}
+
+// an installed addon must exist before it can be pinned or ignored
+func Test_restore_exported_flags__not_installed(t *testing.T) {
+	ad := MakeAddonsDir(t.TempDir())
+	e := ExportedAddon{Source: "github", SourceID: "ogri-la/everyaddon", Name: "everyaddon", Pinned: true}
+	err := restore_exported_flags(ad, e)
+	assert.NotNil(t, err)
+}
+
+func Test_write_export_file__bad_extension(t *testing.T) {
+	err := write_export_file(filepath.Join(t.TempDir(), "export.txt"), nil)
+	assert.NotNil(t, err)
+}
+
+// strongbox 7 exports have integer source-ids and no version, pinned or ignored fields
+func Test_ReadExportFile__v7(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.json")
+	data := `[{"name": "every-other-addon", "source": "wowinterface", "source-id": 25079, "game-track": "classic"}]`
+	err := os.WriteFile(path, []byte(data), 0644)
+	assert.Nil(t, err)
+
+	expected := []ExportedAddon{
+		{Source: "wowinterface", SourceID: "25079", Name: "every-other-addon", GameTrackID: GAMETRACK_CLASSIC},
+	}
+	actual, err := ReadExportFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+// csv columns may be in any order, only source, source-id and name are required
+func Test_ReadExportFile__csv_columns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.csv")
+	data := "name,source-id,source\neveryaddon,ogri-la/everyaddon,github\n"
+	err := os.WriteFile(path, []byte(data), 0644)
+	assert.Nil(t, err)
+
+	expected := []ExportedAddon{
+		{Source: "github", SourceID: "ogri-la/everyaddon", Name: "everyaddon"},
+	}
+	actual, err := ReadExportFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+
+	err = os.WriteFile(path, []byte("name,version\neveryaddon,1.2.3\n"), 0644)
+	assert.Nil(t, err)
+	_, err = ReadExportFile(path)
+	assert.NotNil(t, err)
+}
+
+// installed addons are skipped, addons missing from the catalogue are reported
+func Test_reconcile_import(t *testing.T) {
+	catalogue_addon_list := []CatalogueAddon{
+		{Source: "wowinterface", SourceID: "25079", Name: "every-other-addon"},
+		{Source: "github", SourceID: "ogri-la/everyaddon", Name: "everyaddon"},
+		{Source: "wowinterface", SourceID: "123", Name: "every-third-addon"},
+	}
+	installed_addon_list := []Addon{
+		{Source: "github", SourceID: "ogri-la/everyaddon", Name: "everyaddon"},
+	}
+	exported_list := []ExportedAddon{
+		{Source: "wowinterface", SourceID: "25079", Name: "every-other-addon"},
+		{Source: "github", SourceID: "ogri-la/everyaddon", Name: "everyaddon"},
+		{Source: "wowinterface", Name: "every-third-addon"}, // no source-id, matched by name
+		{Source: "wowinterface", SourceID: "999", Name: "missing-addon"},
+	}
+
+	actual := reconcile_import(exported_list, installed_addon_list, catalogue_addon_list)
+	assert.Equal(t, 4, len(actual))
+
+	assert.Equal(t, "", actual[0].Status)
+	assert.Equal(t, catalogue_addon_list[0], *actual[0].match)
+
+	assert.Equal(t, IMPORT_STATUS_ALREADY_INSTALLED, actual[1].Status)
+	assert.Nil(t, actual[1].match)
+
+	assert.Equal(t, "", actual[2].Status)
+	assert.Equal(t, catalogue_addon_list[2], *actual[2].match)
+
+	assert.Equal(t, IMPORT_STATUS_NOT_FOUND, actual[3].Status)
+	assert.Nil(t, actual[3].match)
+}
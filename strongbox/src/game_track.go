@@ -0,0 +1,332 @@
+package strongbox
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+// game_track.go is a registry of WoW game tracks and everything strongbox knows about them.
+// the default registry is embedded (see `game_tracks.json`) and entries can be added or replaced in settings.
+// new classic expansions, anniversary realms, etc, should only need a change to the data file.
+
+//go:embed game_tracks.json
+var game_tracks_json []byte
+
+// an inclusive range of interface versions, 11500 - 11599.
+// a `Max` of zero has no upper bound.
+type InterfaceVersionRange struct {
+	Min int `json:"min"`
+	Max int `json:"max,omitempty"`
+}
+
+func (r InterfaceVersionRange) contains(interface_version int) bool {
+	return interface_version >= r.Min && (r.Max == 0 || interface_version <= r.Max)
+}
+
+// the number of interface versions covered by this range.
+// used to prefer the most specific range when ranges overlap.
+func (r InterfaceVersionRange) span() int {
+	if r.Max == 0 {
+		return math.MaxInt
+	}
+	return r.Max - r.Min
+}
+
+// extended information about a GameTrack
+type GameTrack struct {
+	ID                        GameTrackID             `json:"id"`                                     // "classic-tbc"
+	Label                     string                  `json:"label"`                                  // "Classic (TBC)"
+	AliasList                 []string                `json:"alias-list,omitempty"`                   // ["tbc", "bcc"]
+	ReleaseJSONFlavor         ReleaseJSONFlavor       `json:"release-json-flavor,omitempty"`          // "bcc"
+	InterfaceVersion          int                     `json:"interface-version,omitempty"`            // 20505, the interface version of the live game, used to find outdated addons
+	InterfaceVersionRangeList []InterfaceVersionRange `json:"interface-version-range-list,omitempty"` // [{"min": 20000, "max": 29999}]
+	TOCSuffixList             []string                `json:"toc-suffix-list,omitempty"`              // ["tbc", "bcc"], as in "EveryAddon_TBC.toc"
+	ProductDirList            []string                `json:"product-dir-list,omitempty"`             // ["_classic_"], as in "World of Warcraft/_classic_/Interface/AddOns"
+	ProductCodeMap            map[string]string       `json:"product-code-map,omitempty"`             // {"wow_classic": "_classic_"}, the product codes in a WoW installation's `.build.info` file and their product dir
+	GuessRegex                string                  `json:"guess-regex,omitempty"`                  // fuzzy matching of game tracks in release and file names
+
+	// when `strict?` is `false` and an addon fails to match against a given `game-track`, other game tracks will be checked.
+	// the strategy is to assume the next-best game tracks are the ones 'closest' to the given `game-track`, newest to oldest.
+	// for example, if a release for wotlk classic is not available and releases for cata, bcc and vanilla are, which to choose?
+	// this strategy prioritises cata, then bcc and finally vanilla.
+	FallbackList []GameTrackID `json:"fallback-list,omitempty"` // ["classic-tbc", "classic-wotlk", "classic-cata", "classic", "retail"]
+
+	guess_regex *regexp.Regexp
+}
+
+// an ordered collection of `GameTrack`s with indices for looking them up.
+type GameTrackRegistry struct {
+	GameTrackList []GameTrack `json:"game-track-list"`
+
+	idx       map[GameTrackID]int    // {"classic-tbc": 2, ...}
+	alias_idx map[string]GameTrackID // {"bcc": "classic-tbc", ...}
+}
+
+// returns a registry from the given list of game tracks,
+// validating each game track and building the lookup indices.
+func make_game_track_registry(game_track_list []GameTrack) (GameTrackRegistry, error) {
+	empty_response := GameTrackRegistry{}
+	reg := GameTrackRegistry{
+		GameTrackList: []GameTrack{},
+		idx:           map[GameTrackID]int{},
+		alias_idx:     map[string]GameTrackID{},
+	}
+
+	for _, gt := range game_track_list {
+		if strings.TrimSpace(gt.ID) == "" {
+			return empty_response, errors.New("game track is missing an ID")
+		}
+		_, present := reg.idx[gt.ID]
+		if present {
+			return empty_response, fmt.Errorf("game track defined more than once: %s", gt.ID)
+		}
+		if gt.Label == "" {
+			gt.Label = gt.ID
+		}
+		if gt.GuessRegex != "" {
+			re, err := regexp.Compile(gt.GuessRegex)
+			if err != nil {
+				return empty_response, fmt.Errorf("game track '%s' has a bad guess-regex: %w", gt.ID, err)
+			}
+			gt.guess_regex = re
+		}
+		if len(gt.FallbackList) == 0 {
+			gt.FallbackList = []GameTrackID{gt.ID}
+		}
+		for product_code, product_dir := range gt.ProductCodeMap {
+			if !slices.Contains(gt.ProductDirList, product_dir) {
+				return empty_response, fmt.Errorf("game track '%s' has product code '%s' for an unknown product dir: %s", gt.ID, product_code, product_dir)
+			}
+		}
+
+		reg.idx[gt.ID] = len(reg.GameTrackList)
+		reg.GameTrackList = append(reg.GameTrackList, gt)
+	}
+
+	for _, gt := range reg.GameTrackList {
+		for _, fallback := range gt.FallbackList {
+			_, present := reg.idx[fallback]
+			if !present {
+				return empty_response, fmt.Errorf("game track '%s' has an unknown game track in it's fallback list: %s", gt.ID, fallback)
+			}
+		}
+
+		alias_list := append([]string{gt.ID, gt.ReleaseJSONFlavor}, gt.AliasList...)
+		for _, alias := range alias_list {
+			alias = strings.ToLower(alias)
+			if alias == "" {
+				continue
+			}
+			existing, present := reg.alias_idx[alias]
+			if present && existing != gt.ID {
+				return empty_response, fmt.Errorf("game track alias '%s' used by both '%s' and '%s'", alias, existing, gt.ID)
+			}
+			reg.alias_idx[alias] = gt.ID
+		}
+	}
+
+	return reg, nil
+}
+
+// parses the given json bytes into a registry.
+func parse_game_track_registry(b []byte) (GameTrackRegistry, error) {
+	var reg GameTrackRegistry
+	err := json.Unmarshal(b, &reg)
+	if err != nil {
+		return GameTrackRegistry{}, fmt.Errorf("failed to parse game track data: %w", err)
+	}
+	return make_game_track_registry(reg.GameTrackList)
+}
+
+// returns the list of game tracks in `base` with any game tracks in `override_list` replacing them by ID.
+// game tracks in `override_list` not in `base` are appended.
+func merge_game_track_list(base []GameTrack, override_list []GameTrack) []GameTrack {
+	rv := slices.Clone(base)
+	for _, override := range override_list {
+		idx := slices.IndexFunc(rv, func(gt GameTrack) bool {
+			return gt.ID == override.ID
+		})
+		if idx == -1 {
+			rv = append(rv, override)
+		} else {
+			rv[idx] = override
+		}
+	}
+	return rv
+}
+
+// returns the registry of game tracks embedded in strongbox.
+func default_game_track_registry() GameTrackRegistry {
+	reg, err := parse_game_track_registry(game_tracks_json)
+	if err != nil {
+		slog.Error("failed to load the default game track registry", "error", err)
+		panic("programming error")
+	}
+	return reg
+}
+
+// the registry in use
+var game_track_registry atomic.Pointer[GameTrackRegistry]
+
+// returns the registry of game tracks currently in use.
+func game_tracks() *GameTrackRegistry {
+	reg := game_track_registry.Load()
+	if reg == nil {
+		default_reg := default_game_track_registry()
+		game_track_registry.CompareAndSwap(nil, &default_reg)
+		reg = game_track_registry.Load()
+	}
+	return reg
+}
+
+// replaces the registry in use with the default registry plus any game tracks in `override_list`.
+// if the result is invalid, the default registry is used and an error is returned.
+func set_game_track_registry(override_list []GameTrack) error {
+	default_reg := default_game_track_registry()
+	reg, err := make_game_track_registry(merge_game_track_list(default_reg.GameTrackList, override_list))
+	if err != nil {
+		game_track_registry.Store(&default_reg)
+		return err
+	}
+	game_track_registry.Store(&reg)
+	return nil
+}
+
+// ---
+
+// returns the game track with the given `game_track_id` and `true` if found.
+func (reg *GameTrackRegistry) Get(game_track_id GameTrackID) (GameTrack, bool) {
+	i, present := reg.idx[game_track_id]
+	if !present {
+		return GameTrack{}, false
+	}
+	return reg.GameTrackList[i], true
+}
+
+// returns `true` if the given `game_track_id` is known to the registry.
+func (reg *GameTrackRegistry) Supported(game_track_id GameTrackID) bool {
+	_, present := reg.idx[game_track_id]
+	return present
+}
+
+// returns an ordered list of all game track IDs.
+func (reg *GameTrackRegistry) IDList() []GameTrackID {
+	rv := []GameTrackID{}
+	for _, gt := range reg.GameTrackList {
+		rv = append(rv, gt.ID)
+	}
+	return rv
+}
+
+// returns a set of all game track IDs.
+func (reg *GameTrackRegistry) IDSet() mapset.Set[GameTrackID] {
+	return mapset.NewSet(reg.IDList()...)
+}
+
+// returns the order in which game tracks are checked when `game_track_id` isn't available.
+// returns an empty list for unknown game tracks.
+func (reg *GameTrackRegistry) FallbackList(game_track_id GameTrackID) []GameTrackID {
+	gt, present := reg.Get(game_track_id)
+	if !present {
+		return []GameTrackID{}
+	}
+	return gt.FallbackList
+}
+
+// returns the canonical game track for a known alias, like "bcc" or "mainline", and `true` if found.
+func (reg *GameTrackRegistry) FindAlias(alias string) (GameTrackID, bool) {
+	game_track_id, present := reg.alias_idx[strings.ToLower(alias)]
+	return game_track_id, present
+}
+
+// returns the game track for the given release.json `flavor` and `true` if found.
+func (reg *GameTrackRegistry) FindReleaseJSONFlavor(flavor ReleaseJSONFlavor) (GameTrackID, bool) {
+	for _, gt := range reg.GameTrackList {
+		if gt.ReleaseJSONFlavor != "" && strings.EqualFold(gt.ReleaseJSONFlavor, flavor) {
+			return gt.ID, true
+		}
+	}
+	return "", false
+}
+
+// returns the game track for the given .toc filename suffix, "TBC" in "EveryAddon_TBC.toc", and `true` if found.
+func (reg *GameTrackRegistry) FindTOCSuffix(suffix string) (GameTrackID, bool) {
+	for _, gt := range reg.GameTrackList {
+		for _, toc_suffix := range gt.TOCSuffixList {
+			if strings.EqualFold(toc_suffix, suffix) {
+				return gt.ID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// returns the game track for the given WoW product directory name, "_classic_era_", and `true` if found.
+func (reg *GameTrackRegistry) FindProductDir(dir_name string) (GameTrackID, bool) {
+	for _, gt := range reg.GameTrackList {
+		for _, product_dir := range gt.ProductDirList {
+			if strings.EqualFold(product_dir, dir_name) {
+				return gt.ID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// returns the game track and product dir for the given `.build.info` product code, "wow_classic_era", and `true` if found.
+func (reg *GameTrackRegistry) FindProductCode(product_code string) (GameTrackID, string, bool) {
+	for _, gt := range reg.GameTrackList {
+		product_dir, present := gt.ProductCodeMap[product_code]
+		if present {
+			return gt.ID, product_dir, true
+		}
+	}
+	return "", "", false
+}
+
+// returns the interface version of the live game for the given `game_track_id` and `true` if known.
+func (reg *GameTrackRegistry) CurrentInterfaceVersion(game_track_id GameTrackID) (int, bool) {
+	gt, present := reg.Get(game_track_id)
+	if !present || gt.InterfaceVersion == 0 {
+		return 0, false
+	}
+	return gt.InterfaceVersion, true
+}
+
+// returns the game track whose interface version ranges contain `interface_version` and `true` if found.
+// when ranges overlap, the game track with the narrowest range wins.
+func (reg *GameTrackRegistry) FindInterfaceVersion(interface_version int) (GameTrackID, bool) {
+	var game_track_id GameTrackID
+	best_span := -1
+	for _, gt := range reg.GameTrackList {
+		for _, r := range gt.InterfaceVersionRangeList {
+			if r.contains(interface_version) && (best_span == -1 || r.span() < best_span) {
+				game_track_id = gt.ID
+				best_span = r.span()
+			}
+		}
+	}
+	return game_track_id, best_span != -1
+}
+
+// returns the first game track whose guess-regex matches `val`.
+// game tracks are checked in reverse order, so later (more specific) game tracks are preferred.
+// returns an empty string if no game track matches.
+func (reg *GameTrackRegistry) Guess(val string) GameTrackID {
+	for _, gt := range slices.Backward(reg.GameTrackList) {
+		if gt.guess_regex != nil && gt.guess_regex.MatchString(val) {
+			return gt.ID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,205 @@
+package strongbox
+
+import (
+	"testing"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// the embedded game track data is valid and covers every well known game track.
+func Test_default_game_track_registry(t *testing.T) {
+	reg := default_game_track_registry()
+	expected := []GameTrackID{
+		GAMETRACK_RETAIL,
+		GAMETRACK_CLASSIC,
+		GAMETRACK_CLASSIC_TBC,
+		GAMETRACK_CLASSIC_WOTLK,
+		GAMETRACK_CLASSIC_CATA,
+		GAMETRACK_CLASSIC_MOP,
+	}
+	assert.Equal(t, expected, reg.IDList())
+
+	// dead game tracks are not supported
+	assert.False(t, reg.Supported(GAMETRACK_RETAIL_CLASSIC))
+	assert.False(t, reg.Supported(GAMETRACK_CLASSIC_RETAIL))
+}
+
+func Test_GameTrackRegistry__FallbackList(t *testing.T) {
+	reg := default_game_track_registry()
+	var cases = []struct {
+		given    GameTrackID
+		expected []GameTrackID
+	}{
+		{GAMETRACK_RETAIL, []GameTrackID{GAMETRACK_RETAIL, GAMETRACK_CLASSIC, GAMETRACK_CLASSIC_TBC, GAMETRACK_CLASSIC_WOTLK, GAMETRACK_CLASSIC_CATA, GAMETRACK_CLASSIC_MOP}},
+		{GAMETRACK_CLASSIC_WOTLK, []GameTrackID{GAMETRACK_CLASSIC_WOTLK, GAMETRACK_CLASSIC_CATA, GAMETRACK_CLASSIC_MOP, GAMETRACK_CLASSIC_TBC, GAMETRACK_CLASSIC, GAMETRACK_RETAIL}},
+		{GAMETRACK_CLASSIC_MOP, []GameTrackID{GAMETRACK_CLASSIC_MOP, GAMETRACK_CLASSIC_CATA, GAMETRACK_CLASSIC_WOTLK, GAMETRACK_CLASSIC_TBC, GAMETRACK_CLASSIC, GAMETRACK_RETAIL}},
+		{"foo", []GameTrackID{}},
+	}
+	for i, c := range cases {
+		assert.Equal(t, c.expected, reg.FallbackList(c.given), i)
+	}
+}
+
+func Test_GameTrackRegistry__lookups(t *testing.T) {
+	reg := default_game_track_registry()
+
+	gt, found := reg.FindAlias("BCC")
+	assert.True(t, found)
+	assert.Equal(t, GAMETRACK_CLASSIC_TBC, gt)
+
+	gt, found = reg.FindReleaseJSONFlavor(RELEASE_JSON_FLAVOR_WRATH)
+	assert.True(t, found)
+	assert.Equal(t, GAMETRACK_CLASSIC_WOTLK, gt)
+
+	gt, found = reg.FindTOCSuffix("Vanilla")
+	assert.True(t, found)
+	assert.Equal(t, GAMETRACK_CLASSIC, gt)
+
+	gt, found = reg.FindProductDir("_classic_era_")
+	assert.True(t, found)
+	assert.Equal(t, GAMETRACK_CLASSIC, gt)
+
+	gt, found = reg.FindProductDir("_classic_")
+	assert.True(t, found)
+	assert.Equal(t, GAMETRACK_CLASSIC_MOP, gt)
+
+	gt, found = reg.FindReleaseJSONFlavor(RELEASE_JSON_FLAVOR_MISTS)
+	assert.True(t, found)
+	assert.Equal(t, GAMETRACK_CLASSIC_MOP, gt)
+
+	gt, found = reg.FindTOCSuffix("Mists")
+	assert.True(t, found)
+	assert.Equal(t, GAMETRACK_CLASSIC_MOP, gt)
+
+	gt, product_dir, found := reg.FindProductCode("wow_classic")
+	assert.True(t, found)
+	assert.Equal(t, GAMETRACK_CLASSIC_MOP, gt)
+	assert.Equal(t, "_classic_", product_dir)
+
+	_, _, found = reg.FindProductCode("wow_unknown")
+	assert.False(t, found)
+
+	_, found = reg.FindTOCSuffix("Config")
+	assert.False(t, found)
+}
+
+// game tracks in settings replace game tracks by ID and new game tracks are added.
+func Test_merge_game_track_list(t *testing.T) {
+	base := []GameTrack{
+		{ID: "foo", Label: "Foo"},
+		{ID: "bar", Label: "Bar"},
+	}
+	override := []GameTrack{
+		{ID: "bar", Label: "Bar!"},
+		{ID: "baz", Label: "Baz"},
+	}
+	expected := []GameTrack{
+		{ID: "foo", Label: "Foo"},
+		{ID: "bar", Label: "Bar!"},
+		{ID: "baz", Label: "Baz"},
+	}
+	assert.Equal(t, expected, merge_game_track_list(base, override))
+}
+
+// a new classic game track can be added without code changes.
+func Test_make_game_track_registry__new_game_track(t *testing.T) {
+	wod := GameTrack{
+		ID:                        "classic-wod",
+		Label:                     "Classic (WoD)",
+		AliasList:                 []string{"wod"},
+		ReleaseJSONFlavor:         "wod",
+		InterfaceVersionRangeList: []InterfaceVersionRange{{Min: 60000, Max: 69999}},
+		TOCSuffixList:             []string{"wod"},
+		ProductDirList:            []string{"_classic_"},
+		ProductCodeMap:            map[string]string{"wow_classic": "_classic_"},
+		FallbackList:              []GameTrackID{"classic-wod", GAMETRACK_CLASSIC_MOP, GAMETRACK_RETAIL},
+	}
+	default_reg := default_game_track_registry()
+	mop, _ := default_reg.Get(GAMETRACK_CLASSIC_MOP)
+	mop.ProductDirList = []string{}
+	mop.ProductCodeMap = nil
+
+	gtl := merge_game_track_list(default_reg.GameTrackList, []GameTrack{mop, wod})
+	reg, err := make_game_track_registry(gtl)
+	assert.Nil(t, err)
+
+	// narrowest interface version range wins
+	gt, found := reg.FindInterfaceVersion(60200)
+	assert.True(t, found)
+	assert.Equal(t, "classic-wod", gt)
+
+	gt, found = reg.FindInterfaceVersion(110000)
+	assert.True(t, found)
+	assert.Equal(t, GAMETRACK_RETAIL, gt)
+
+	gt, _ = reg.FindAlias("wod")
+	assert.Equal(t, "classic-wod", gt)
+
+	gt, _ = reg.FindProductDir("_classic_")
+	assert.Equal(t, "classic-wod", gt)
+
+	gt, _, _ = reg.FindProductCode("wow_classic")
+	assert.Equal(t, "classic-wod", gt)
+
+	assert.True(t, reg.IDSet().Contains("classic-wod"))
+}
+
+func Test_make_game_track_registry__bad_cases(t *testing.T) {
+	var cases = [][]GameTrack{
+		// missing ID
+		{{Label: "Foo"}},
+		// duplicate ID
+		{{ID: "foo"}, {ID: "foo"}},
+		// unknown fallback
+		{{ID: "foo", FallbackList: []GameTrackID{"foo", "bar"}}},
+		// alias used twice
+		{{ID: "foo", AliasList: []string{"baz"}}, {ID: "bar", AliasList: []string{"baz"}}},
+		// bad regex
+		{{ID: "foo", GuessRegex: "("}},
+		// product code for a product dir the game track doesn't have
+		{{ID: "foo", ProductDirList: []string{"_foo_"}, ProductCodeMap: map[string]string{"wow_foo": "_bar_"}}},
+	}
+	for i, c := range cases {
+		_, err := make_game_track_registry(c)
+		assert.NotNil(t, err, i)
+	}
+}
+
+// a bad set of game tracks in settings falls back to the defaults.
+func Test_set_game_track_registry(t *testing.T) {
+	defer set_game_track_registry(nil)
+
+	err := set_game_track_registry([]GameTrack{{ID: "classic-wod", FallbackList: []GameTrackID{"classic-wod", GAMETRACK_RETAIL}}})
+	assert.Nil(t, err)
+	assert.True(t, gametrack_set().Contains("classic-wod"))
+
+	err = set_game_track_registry([]GameTrack{{ID: "foo", FallbackList: []GameTrackID{"bar"}}})
+	assert.NotNil(t, err)
+	assert.Equal(t, mapset.NewSet(GAMETRACK_RETAIL, GAMETRACK_CLASSIC, GAMETRACK_CLASSIC_TBC, GAMETRACK_CLASSIC_WOTLK, GAMETRACK_CLASSIC_CATA, GAMETRACK_CLASSIC_MOP), gametrack_set())
+}
+
+// MoP Classic interface versions belong to classic-mop, not retail.
+func Test_GameTrackRegistry__FindInterfaceVersion(t *testing.T) {
+	reg := default_game_track_registry()
+	var cases = []struct {
+		given    int
+		expected GameTrackID
+	}{
+		{11507, GAMETRACK_CLASSIC},
+		{40402, GAMETRACK_CLASSIC_CATA},
+		{50500, GAMETRACK_CLASSIC_MOP},
+		{50001, GAMETRACK_CLASSIC_MOP},
+		{60000, GAMETRACK_RETAIL},
+		{110200, GAMETRACK_RETAIL},
+	}
+	for _, c := range cases {
+		actual, found := reg.FindInterfaceVersion(c.given)
+		assert.True(t, found, c.given)
+		assert.Equal(t, c.expected, actual, c.given)
+	}
+
+	interface_version, found := reg.CurrentInterfaceVersion(GAMETRACK_CLASSIC_MOP)
+	assert.True(t, found)
+	assert.Equal(t, 50500, interface_version)
+}
@@ -2,16 +2,25 @@ package strongbox
 
 import (
 	"bw/core"
+	"bw/http_utils"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
 )
 
-type GithubAPI struct{}
+type GithubAPI struct {
+	Token string // optional personal access token, raises the rate limit from 60 to 5000 requests an hour
+}
 
 var _ AddonSource = (*GithubAPI)(nil)
 
@@ -37,6 +46,7 @@ type GithubReleaseAsset struct {
 type GithubRelease struct {
 	Name          string               `json:"name"`     // "1.2.3"
 	TagName       string               `json:"tag_name"` // "v1.2.3"
+	Body          string               `json:"body"`     // release notes, markdown
 	AssetList     []GithubReleaseAsset `json:"assets"`
 	PublishedDate time.Time            `json:"published_at"`
 	Draft         bool                 `json:"draft"`
@@ -45,9 +55,164 @@ type GithubRelease struct {
 
 // ---
 
+var github_api = "https://api.github.com"
+
+// environment variable a Github personal access token can be read from when one isn't present in the settings.
+const GITHUB_TOKEN_ENVVAR = "GITHUB_TOKEN"
+
+// the most pages of releases to fetch for a single addon.
+// releases are returned newest first and we rarely need more than the first page.
+const GITHUB_RELEASE_PAGE_LIMIT = 3
+
 // fetch the first page of releases for a Github repository
 func github_release_list_url(source_id string) string {
-	return fmt.Sprintf("https://api.github.com/repos/%s/releases?per-page=100&page=1", source_id)
+	return fmt.Sprintf("%s/repos/%s/releases?per_page=100&page=1", github_api, source_id)
+}
+
+// returns the Github personal access token from the settings, falling back to the `GITHUB_TOKEN` environment variable.
+// returns an empty string if neither are set.
+func github_token(app *core.App) string {
+	settings, err := find_settings(app.State)
+	if err == nil && strings.TrimSpace(settings.Preferences.GithubToken) != "" {
+		return strings.TrimSpace(settings.Preferences.GithubToken)
+	}
+	return strings.TrimSpace(os.Getenv(GITHUB_TOKEN_ENVVAR))
+}
+
+// returns the headers sent with each request to the Github API.
+func github_headers(token string) map[string]string {
+	headers := map[string]string{
+		"Accept":               "application/vnd.github+json",
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	return headers
+}
+
+// matches a single link in a Link header: `<https://api.github.com/...&page=2>; rel="next"`
+var link_header_regex = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?([^",;]+)"?`)
+
+// returns the URL of the next page from the given Link `header` value or an empty string if there is no next page.
+func github_next_page_url(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		match := link_header_regex.FindStringSubmatch(link)
+		if match != nil && match[2] == "next" {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// returns `true` if the given `url` has the same scheme and host as the Github API.
+func is_github_api_url(url string) bool {
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return false
+	}
+	api, err := neturl.Parse(github_api)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == api.Scheme && u.Host == api.Host
+}
+
+// returned when Github refuses a request because a rate limit was hit.
+type GithubRateLimitError struct {
+	Reset      time.Time     // when the limit resets, if known
+	RetryAfter time.Duration // how long to wait before trying again, if known
+	Token      bool          // `true` if the request was authenticated
+}
+
+func (e GithubRateLimitError) Error() string {
+	msg := "Github API rate limit exceeded"
+	if !e.Token {
+		msg += ", consider setting a Github personal access token"
+	}
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s, retry after %s", msg, e.RetryAfter)
+	}
+	if !e.Reset.IsZero() {
+		return fmt.Sprintf("%s, resets at %s", msg, e.Reset.Format(time.RFC3339))
+	}
+	return msg
+}
+
+// returns an error if the response from Github was unsuccessful,
+// including a `GithubRateLimitError` if a rate limit was hit.
+func check_github_response(resp *http_utils.ResponseWrapper, token string) error {
+	if resp == nil || resp.Response == nil {
+		// dummy responses during testing
+		return nil
+	}
+
+	retry_after := resp.Header.Get("Retry-After")
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	rate_limited := resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden && (remaining == "0" || retry_after != ""))
+
+	if rate_limited {
+		err := GithubRateLimitError{Token: token != ""}
+		seconds, perr := strconv.Atoi(retry_after)
+		if perr == nil {
+			err.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		reset, perr := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if perr == nil {
+			err.Reset = time.Unix(reset, 0)
+		}
+		return err
+	}
+
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("unsuccessful response from Github: %s", resp.Status)
+	}
+	return nil
+}
+
+// fetches each page of releases for the given Github `source_id`, up to `GITHUB_RELEASE_PAGE_LIMIT` pages.
+func download_github_release_list(app *core.App, source_id string, token string) ([]GithubRelease, error) {
+	empty_response := []GithubRelease{}
+	release_list := []GithubRelease{}
+	headers := github_headers(token)
+
+	url := github_release_list_url(source_id)
+	for page := 1; url != ""; page++ {
+		if page > GITHUB_RELEASE_PAGE_LIMIT {
+			slog.Debug("Github release page limit reached", "source-id", source_id, "limit", GITHUB_RELEASE_PAGE_LIMIT)
+			break
+		}
+
+		if page > 1 && !is_github_api_url(url) {
+			// the token is only ever sent to the Github API
+			headers = github_headers("")
+		}
+
+		resp, err := app.Download(url, headers)
+		if err != nil {
+			return empty_response, fmt.Errorf("failed to download Github release list: %w", err)
+		}
+
+		err = check_github_response(resp, token)
+		if err != nil {
+			return empty_response, err
+		}
+
+		var page_release_list []GithubRelease
+		err = json.Unmarshal(resp.Bytes, &page_release_list)
+		if err != nil {
+			return empty_response, fmt.Errorf("failed to parse Github release list: %w", err)
+		}
+		release_list = append(release_list, page_release_list...)
+
+		url = ""
+		if resp.Response != nil {
+			url = github_next_page_url(resp.Header.Get("Link"))
+		}
+	}
+
+	return release_list, nil
 }
 
 // ---
@@ -81,6 +246,39 @@ func pick_asset_version_name(release GithubRelease, asset GithubReleaseAsset) st
 	return ""
 }
 
+// matches 'alpha' and 'beta' in release names and tags like 'v1.2.3-alpha', '1.2.3beta2' and 'Beta 3',
+// but not in words like 'AlphaMap'
+var release_alpha_regex = regexp.MustCompile(`(?i)(^|[\W_\d])alpha([\W_\d]|$)`)
+var release_beta_regex = regexp.MustCompile(`(?i)(^|[\W_\d])beta([\W_\d]|$)`)
+
+// matches 'nolib' in asset names like 'Addon-v1.2.3-nolib.zip'
+var release_nolib_regex = regexp.MustCompile(`(?i)(^|[\W_])no-?lib([\W_]|$)`)
+
+// guess how stable a release is from it's name, tag and whether it was marked as a pre-release.
+// pre-releases that don't say otherwise are considered 'beta'.
+func guess_release_stability(release GithubRelease) ReleaseStability {
+	for _, val := range []string{release.Name, release.TagName} {
+		if release_alpha_regex.MatchString(val) {
+			return RELEASE_STABILITY_ALPHA
+		}
+		if release_beta_regex.MatchString(val) {
+			return RELEASE_STABILITY_BETA
+		}
+	}
+	if release.PreRelease {
+		return RELEASE_STABILITY_BETA
+	}
+	return RELEASE_STABILITY_STABLE
+}
+
+// guess the type of release from an asset's name.
+func guess_release_type(asset_name string) ReleaseType {
+	if release_nolib_regex.MatchString(asset_name) {
+		return RELEASE_TYPE_NOLIB
+	}
+	return RELEASE_TYPE_LIB
+}
+
 // convert a `release` and a filtered `asset_list` to an initial `SourceUpdate` list.
 // these updates are then further classified by the classify* functions
 func to_sul(release GithubRelease, asset_list []GithubReleaseAsset) []SourceUpdate {
@@ -94,6 +292,9 @@ func to_sul(release GithubRelease, asset_list []GithubReleaseAsset) []SourceUpda
 		// but we're not interested in that fine level of detail.
 		su.PublishedDate = release.PublishedDate
 		su.GameTrackIDSet = mapset.NewSet[GameTrackID]()
+		su.Stability = guess_release_stability(release)
+		su.Type = guess_release_type(a.Name)
+		su.ReleaseNotes = release.Body
 
 		sul = append(sul, su)
 	}
@@ -203,6 +404,8 @@ func download_release_json(app *core.App, url string) (ReleaseJSON, error) {
 func classify3(sul []SourceUpdate, release_json ReleaseJSON) []SourceUpdate {
 	// a map of asset-name => supported-game-tracks
 	m := ReleaseJSONGameTrackMap(release_json)
+	nolib_idx := ReleaseJSONNoLibMap(release_json)
+	interface_version_idx := ReleaseJSONInterfaceVersionMap(release_json)
 	for i, su := range sul {
 		gts, present := m[su.AssetName]
 		if !present {
@@ -210,6 +413,13 @@ func classify3(sul []SourceUpdate, release_json ReleaseJSON) []SourceUpdate {
 			continue
 		}
 		su.GameTrackIDSet = gts
+		ivs, present := interface_version_idx[su.AssetName]
+		if present {
+			su.InterfaceVersionSet = ivs
+		}
+		if nolib_idx[su.AssetName] {
+			su.Type = RELEASE_TYPE_NOLIB
+		}
 		sul[i] = su
 	}
 	return sul
@@ -219,15 +429,16 @@ func classify3(sul []SourceUpdate, release_json ReleaseJSON) []SourceUpdate {
 // returns a list of SourceUpdates.
 func process_github_release_list(app *core.App, release_list []GithubRelease) []SourceUpdate {
 	final_source_update_list := []SourceUpdate{}
-	for i, r := range release_list {
-		if r.PreRelease {
-			continue
-		}
-
+	release_json_stability_set := mapset.NewSet[ReleaseStability]() // stabilities we've fetched a release.json for
+	for _, r := range release_list {
 		if r.Draft {
 			continue
 		}
 
+		// pre-releases are kept and classified as 'beta' or 'alpha'.
+		// the user's release channel decides whether they're offered or not.
+		stability := guess_release_stability(r)
+
 		var release_json_asset *GithubReleaseAsset
 		asset_list := []GithubReleaseAsset{}
 		for _, a := range r.AssetList {
@@ -254,8 +465,9 @@ func process_github_release_list(app *core.App, release_list []GithubRelease) []
 		source_update_list = classify2(source_update_list)
 
 		// classify 3
-		// download release.json, but only for the latest releases
-		if i == 0 && release_json_asset != nil {
+		// download release.json, but only for the latest release of each stability
+		if release_json_asset != nil && !release_json_stability_set.Contains(stability) {
+			release_json_stability_set.Add(stability)
 			release_json, err := download_release_json(app, release_json_asset.BrowserDownloadURL)
 			if err != nil {
 				slog.Error("failed to download release.json asset, cannot classify release this way", "error", err)
@@ -297,16 +509,17 @@ func (g *GithubAPI) ExpandSummary(app *core.App, source_id string) ([]SourceUpda
 
 	empty_response := []SourceUpdate{}
 
-	github_headers := map[string]string{}
-	release_list_resp, err := app.Download(github_release_list_url(source_id), github_headers)
+	release_list, err := download_github_release_list(app, source_id, g.Token)
 	if err != nil {
-		slog.Error("failed to download Github release list", "error", err)
+		var rate_limit_err GithubRateLimitError
+		if errors.As(err, &rate_limit_err) {
+			slog.Warn("Github rate limit exceeded", "source-id", source_id, "error", err)
+		} else {
+			slog.Error("failed to download Github release list", "error", err)
+		}
 		return empty_response, err
 	}
 
-	var release_list []GithubRelease
-	json.Unmarshal(release_list_resp.Bytes, &release_list)
-
 	source_update_list := process_github_release_list(app, release_list)
 	return source_update_list, nil
 }
@@ -1,6 +1,11 @@
 package strongbox
 
 import (
+	"bw/core"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -14,7 +19,7 @@ var dummy_dt = time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC)
 //
 
 func Test_github_release_list_url(t *testing.T) {
-	expected := "https://api.github.com/repos/AdiAddons/AdiBags/releases?per-page=100&page=1"
+	expected := "https://api.github.com/repos/AdiAddons/AdiBags/releases?per_page=100&page=1"
 	source_id := "AdiAddons/AdiBags"
 	assert.Equal(t, expected, github_release_list_url(source_id))
 }
@@ -113,11 +118,82 @@ func Test_to_sul(t *testing.T) {
 			DownloadURL:    "https://example.org/foo/bar.zip",
 			PublishedDate:  dummy_dt,
 			GameTrackIDSet: mapset.NewSet[GameTrackID](),
+			Stability:      RELEASE_STABILITY_STABLE,
+			Type:           RELEASE_TYPE_LIB,
 		},
 	}
 	assert.Equal(t, expected, to_sul(r, al))
 }
 
+// the release body is carried onto each source update as release notes.
+func Test_to_sul__release_notes(t *testing.T) {
+	r := GithubRelease{
+		Name: "Addon-v1.2.3",
+		Body: "* fixed a thing",
+	}
+	al := []GithubReleaseAsset{
+		{Name: "Addon-v1.2.3.zip"},
+		{Name: "Addon-v1.2.3-nolib.zip"},
+	}
+	for _, su := range to_sul(r, al) {
+		assert.Equal(t, "* fixed a thing", su.ReleaseNotes)
+	}
+}
+
+// pre-releases and 'nolib' assets are classified.
+func Test_to_sul__prerelease_nolib(t *testing.T) {
+	r := GithubRelease{
+		Name:          "Addon-v1.2.3-alpha",
+		PublishedDate: dummy_dt,
+		PreRelease:    true,
+	}
+	al := []GithubReleaseAsset{
+		{Name: "Addon-v1.2.3-alpha.zip"},
+		{Name: "Addon-v1.2.3-alpha-nolib.zip"},
+	}
+	actual := to_sul(r, al)
+	assert.Equal(t, RELEASE_STABILITY_ALPHA, actual[0].Stability)
+	assert.Equal(t, RELEASE_TYPE_LIB, actual[0].Type)
+	assert.Equal(t, RELEASE_STABILITY_ALPHA, actual[1].Stability)
+	assert.Equal(t, RELEASE_TYPE_NOLIB, actual[1].Type)
+}
+
+func Test_guess_release_stability(t *testing.T) {
+	var cases = []struct {
+		given    GithubRelease
+		expected ReleaseStability
+	}{
+		{GithubRelease{}, RELEASE_STABILITY_STABLE},
+		{GithubRelease{Name: "1.2.3"}, RELEASE_STABILITY_STABLE},
+		{GithubRelease{Name: "AlphaMap 1.2.3"}, RELEASE_STABILITY_STABLE},
+		{GithubRelease{Name: "1.2.3", PreRelease: true}, RELEASE_STABILITY_BETA},
+		{GithubRelease{Name: "1.2.3-beta2"}, RELEASE_STABILITY_BETA},
+		{GithubRelease{Name: "Beta 3", PreRelease: true}, RELEASE_STABILITY_BETA},
+		{GithubRelease{TagName: "v10.2.3-alpha", PreRelease: true}, RELEASE_STABILITY_ALPHA},
+		{GithubRelease{Name: "1.2.3alpha1", PreRelease: true}, RELEASE_STABILITY_ALPHA},
+	}
+	for i, c := range cases {
+		assert.Equal(t, c.expected, guess_release_stability(c.given), i)
+	}
+}
+
+func Test_guess_release_type(t *testing.T) {
+	var cases = []struct {
+		given    string
+		expected ReleaseType
+	}{
+		{"", RELEASE_TYPE_LIB},
+		{"Addon-v1.2.3.zip", RELEASE_TYPE_LIB},
+		{"Addon-v1.2.3-nolib.zip", RELEASE_TYPE_NOLIB},
+		{"Addon_NoLib_v1.2.3.zip", RELEASE_TYPE_NOLIB},
+		{"Addon-no-lib.zip", RELEASE_TYPE_NOLIB},
+		{"Nolibrary.zip", RELEASE_TYPE_LIB},
+	}
+	for i, c := range cases {
+		assert.Equal(t, c.expected, guess_release_type(c.given), i)
+	}
+}
+
 func Test_classify1__unclassified(t *testing.T) {
 	r := GithubRelease{
 		Name: "Addon-v1.2.3",
@@ -301,3 +377,218 @@ func Test_classify3(t *testing.T) {
 	}
 	assert.Equal(t, expected, classify3(sul, rj))
 }
+
+// release.json 'nolib' flags are used to classify assets.
+func Test_classify3__nolib(t *testing.T) {
+	rj := ReleaseJSON{
+		ReleaseList: []ReleaseJSONRelease{
+			{
+				Filename:     "Addon-v1.2.3.zip",
+				MetadataList: []ReleaseJSONMetadata{{Flavor: RELEASE_JSON_FLAVOR_MAINLINE}},
+			},
+			{
+				Filename:     "Addon-v1.2.3-lite.zip",
+				NoLib:        true,
+				MetadataList: []ReleaseJSONMetadata{{Flavor: RELEASE_JSON_FLAVOR_MAINLINE}},
+			},
+		},
+	}
+	sul := []SourceUpdate{
+		{AssetName: "Addon-v1.2.3.zip", Type: RELEASE_TYPE_LIB, GameTrackIDSet: mapset.NewSet[GameTrackID]()},
+		{AssetName: "Addon-v1.2.3-lite.zip", Type: RELEASE_TYPE_LIB, GameTrackIDSet: mapset.NewSet[GameTrackID]()},
+	}
+	actual := classify3(sul, rj)
+	assert.Equal(t, RELEASE_TYPE_LIB, actual[0].Type)
+	assert.Equal(t, RELEASE_TYPE_NOLIB, actual[1].Type)
+}
+
+// ---
+
+// returns an app that makes real HTTP requests to a local stand-in for the Github API.
+// the `github_api` is pointed at the stand-in for the duration of the test.
+func github_stand_in(t *testing.T, handler http.HandlerFunc) *core.App {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	prev := github_api
+	github_api = server.URL
+	t.Cleanup(func() { github_api = prev })
+
+	app := DummyApp()
+	app.HTTPClient = &http.Client{}
+	app.Downloader = &core.HTTPDownloader{}
+	return app
+}
+
+func github_release_json(version string) string {
+	return fmt.Sprintf(`{"name": "%s", "tag_name": "v%s", "published_at": "2024-01-01T00:00:00Z",
+		"assets": [{"name": "EveryAddon-%s.zip", "state": "uploaded", "content_type": "application/zip",
+		            "browser_download_url": "https://example.org/EveryAddon-%s.zip"}]}`, version, version, version, version)
+}
+
+func Test_github_next_page_url(t *testing.T) {
+	var cases = []struct {
+		given    string
+		expected string
+	}{
+		{"", ""},
+		{`<https://api.github.com/repos/foo/bar/releases?page=2>; rel="next", <https://api.github.com/repos/foo/bar/releases?page=5>; rel="last"`,
+			"https://api.github.com/repos/foo/bar/releases?page=2"},
+		{`<https://api.github.com/repos/foo/bar/releases?page=1>; rel="prev", <https://api.github.com/repos/foo/bar/releases?page=1>; rel="first"`, ""},
+	}
+	for i, c := range cases {
+		assert.Equal(t, c.expected, github_next_page_url(c.given), i)
+	}
+}
+
+func Test_github_headers(t *testing.T) {
+	_, present := github_headers("")["Authorization"]
+	assert.False(t, present)
+	assert.Equal(t, "Bearer foo", github_headers("foo")["Authorization"])
+}
+
+// the token in the settings is preferred over the environment variable.
+func Test_github_token(t *testing.T) {
+	t.Setenv(GITHUB_TOKEN_ENVVAR, "from-env")
+
+	app, stopfn := DummyApp2(t.TempDir())
+	defer stopfn()
+	assert.Equal(t, "from-env", github_token(app))
+
+	settings := NewSettings()
+	settings.Preferences.GithubToken = "from-settings"
+	app.AddReplaceResults(core.MakeResult(NS_SETTINGS, settings, ID_SETTINGS)).Wait()
+	assert.Equal(t, "from-settings", github_token(app))
+}
+
+// all pages of releases are fetched and the token is sent with each request.
+func Test_download_github_release_list__pagination(t *testing.T) {
+	app := github_stand_in(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer foo", r.Header.Get("Authorization"))
+		page := r.URL.Query().Get("page")
+		if page == "1" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/foo/bar/releases?per_page=100&page=2>; rel="next"`, github_api))
+		}
+		fmt.Fprintf(w, "[%s]", github_release_json(page))
+	})
+
+	release_list, err := download_github_release_list(app, "foo/bar", "foo")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(release_list))
+	assert.Equal(t, "1", release_list[0].Name)
+	assert.Equal(t, "2", release_list[1].Name)
+}
+
+// the token is not sent to hosts other than the Github API when following pages.
+func Test_download_github_release_list__pagination_elsewhere(t *testing.T) {
+	elsewhere := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "", r.Header.Get("Authorization"))
+		fmt.Fprintf(w, "[%s]", github_release_json("2"))
+	}))
+	t.Cleanup(elsewhere.Close)
+
+	app := github_stand_in(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer foo", r.Header.Get("Authorization"))
+		w.Header().Set("Link", fmt.Sprintf(`<%s/repos/foo/bar/releases?per_page=100&page=2>; rel="next"`, elsewhere.URL))
+		fmt.Fprintf(w, "[%s]", github_release_json("1"))
+	})
+
+	release_list, err := download_github_release_list(app, "foo/bar", "foo")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(release_list))
+}
+
+func Test_is_github_api_url(t *testing.T) {
+	var cases = []struct {
+		given    string
+		expected bool
+	}{
+		{"https://api.github.com/repos/foo/bar/releases?page=2", true},
+		{"http://api.github.com/repos/foo/bar/releases?page=2", false},
+		{"https://api.github.com.example.org/repos/foo/bar/releases", false},
+		{"https://example.org/repos/foo/bar/releases", false},
+		{"", false},
+	}
+	for i, c := range cases {
+		assert.Equal(t, c.expected, is_github_api_url(c.given), i)
+	}
+}
+
+// pages beyond the page limit are not fetched.
+func Test_download_github_release_list__page_limit(t *testing.T) {
+	request_count := 0
+	app := github_stand_in(t, func(w http.ResponseWriter, r *http.Request) {
+		request_count += 1
+		w.Header().Set("Link", fmt.Sprintf(`<%s/repos/foo/bar/releases?per_page=100&page=%d>; rel="next"`, github_api, request_count+1))
+		fmt.Fprintf(w, "[%s]", github_release_json(fmt.Sprint(request_count)))
+	})
+
+	release_list, err := download_github_release_list(app, "foo/bar", "")
+	assert.Nil(t, err)
+	assert.Equal(t, GITHUB_RELEASE_PAGE_LIMIT, request_count)
+	assert.Equal(t, GITHUB_RELEASE_PAGE_LIMIT, len(release_list))
+}
+
+// hitting a rate limit is an error and not an empty list of releases.
+func Test_download_github_release_list__rate_limited(t *testing.T) {
+	app := github_stand_in(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message": "API rate limit exceeded"}`)
+	})
+
+	_, err := download_github_release_list(app, "foo/bar", "")
+	var rate_limit_err GithubRateLimitError
+	assert.True(t, errors.As(err, &rate_limit_err))
+	assert.Equal(t, time.Unix(1700000000, 0), rate_limit_err.Reset)
+	assert.False(t, rate_limit_err.Token)
+}
+
+func Test_download_github_release_list__retry_after(t *testing.T) {
+	app := github_stand_in(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	_, err := download_github_release_list(app, "foo/bar", "foo")
+	var rate_limit_err GithubRateLimitError
+	assert.True(t, errors.As(err, &rate_limit_err))
+	assert.Equal(t, 60*time.Second, rate_limit_err.RetryAfter)
+	assert.True(t, rate_limit_err.Token)
+}
+
+// unsuccessful responses and bad json are errors.
+func Test_download_github_release_list__errors(t *testing.T) {
+	app := github_stand_in(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/foo/dne/releases" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, "{not json")
+	})
+
+	_, err := download_github_release_list(app, "foo/dne", "")
+	assert.NotNil(t, err)
+
+	_, err = download_github_release_list(app, "foo/bar", "")
+	assert.NotNil(t, err)
+}
+
+// interface versions in the release.json are captured when present.
+func Test_classify3__interface_versions(t *testing.T) {
+	rj := ReleaseJSON{
+		ReleaseList: []ReleaseJSONRelease{
+			{
+				Filename: "Addon-v1.2.3.zip",
+				MetadataList: []ReleaseJSONMetadata{
+					{Flavor: RELEASE_JSON_FLAVOR_MAINLINE, Interface: 110200},
+					{Flavor: RELEASE_JSON_FLAVOR_CLASSIC, Interface: 11507},
+				},
+			},
+		},
+	}
+	sul := []SourceUpdate{{AssetName: "Addon-v1.2.3.zip"}}
+	actual := classify3(sul, rj)
+	assert.Equal(t, mapset.NewSet(110200, 11507), actual[0].InterfaceVersionSet)
+}
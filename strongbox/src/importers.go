@@ -0,0 +1,412 @@
+package strongbox
+
+import (
+	"bw/core"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// importers.go reads the addons managed by other addon managers (WowUp, CurseForge) and maps them to sources strongbox supports.
+// addons already on disk get strongbox nfo data so they can be updated without being re-installed.
+
+const (
+	ADDON_MANAGER_WOWUP      = "wowup"
+	ADDON_MANAGER_CURSEFORGE = "curseforge"
+)
+
+const (
+	FOREIGN_STATUS_MATCHED     = "matched"            // found in the catalogue under a supported source
+	FOREIGN_STATUS_UNSUPPORTED = "unsupported-source" // only available from a source strongbox doesn't support, like curseforge
+	FOREIGN_STATUS_NOT_FOUND   = "not-found"
+)
+
+// an addon as another addon manager knows it.
+type ForeignAddon struct {
+	Manager     string   // "wowup"
+	Name        string   // "Details! Damage Meter"
+	Version     string   // optional
+	Source      Source   // normalised provider, "curseforge", "wowinterface", "wago"
+	SourceID    string   // the provider's ID for the addon, "61284"
+	DirNameList []string // optional, the directories the addon was found in
+}
+
+// the result of importing a single foreign addon.
+type ForeignImport struct {
+	ForeignAddon
+	Status          string
+	CatalogueAddon  *CatalogueAddon  // the match, if any
+	NFODirNameList  []string         // directories nfo data was written to
+	ReplacementList []CatalogueAddon // suggested alternatives for addons from unsupported sources
+}
+
+var _ core.ItemInfo = (*ForeignImport)(nil)
+
+func (fi ForeignImport) ItemKeys() []string {
+	return []string{
+		"source",
+		core.ITEM_FIELD_NAME,
+		core.ITEM_FIELD_DESC,
+		"status",
+	}
+}
+
+func (fi ForeignImport) ItemMap() map[string]string {
+	desc := ""
+	if fi.CatalogueAddon != nil {
+		desc = fmt.Sprintf("%s %s", fi.CatalogueAddon.Source, fi.CatalogueAddon.SourceID)
+	}
+	return map[string]string{
+		"source":             fi.Source,
+		core.ITEM_FIELD_NAME: fi.Name,
+		core.ITEM_FIELD_DESC: desc,
+		"status":             fi.Status,
+	}
+}
+
+func (fi ForeignImport) ItemHasChildren() core.ITEM_CHILDREN_LOAD {
+	if len(fi.ReplacementList) > 0 {
+		return core.ITEM_CHILDREN_LOAD_TRUE
+	}
+	return core.ITEM_CHILDREN_LOAD_FALSE
+}
+
+// suggested replacements are catalogue addons and can be installed like any other.
+func (fi ForeignImport) ItemChildren(_ *core.App) []core.Result {
+	children := []core.Result{}
+	for _, ca := range fi.ReplacementList {
+		children = append(children, core.MakeResult(NS_CATALOGUE_ADDON, ca, core.UniqueID()))
+	}
+	return children
+}
+
+// ---
+
+// maps the provider names used by other addon managers to strongbox sources.
+// "Curse" => "curseforge", "WowInterface" => "wowinterface"
+func foreign_provider_source(provider string) Source {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	switch provider {
+	case "curse", "cursev2", "curseforge":
+		return SOURCE_CURSEFORGE
+	case "wowinterface", "wowi":
+		return SOURCE_WOWI
+	case "github":
+		return SOURCE_GITHUB
+	case "tukui":
+		return SOURCE_TUKUI
+	}
+	return provider // "wago", "hub", etc
+}
+
+// "https://github.com/ogri-la/strongbox.git" => "ogri-la/strongbox"
+func normalise_github_source_id(source_id string) string {
+	source_id = strings.TrimPrefix(source_id, "https://")
+	source_id = strings.TrimPrefix(source_id, "github.com/")
+	source_id = strings.TrimSuffix(source_id, "/")
+	return strings.TrimSuffix(source_id, ".git")
+}
+
+// a single addon in a WowUp export.
+type wowup_export_addon struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	ProviderName string `json:"providerName"`
+}
+
+type wowup_export struct {
+	AddonList []wowup_export_addon `json:"addons"`
+}
+
+// parses the text WowUp produces when exporting addons.
+// WowUp base64 encodes the json, plain json is also accepted.
+func parse_wowup_export(data []byte) ([]ForeignAddon, error) {
+	empty_response := []ForeignAddon{}
+
+	data = []byte(strings.TrimSpace(string(data)))
+	if len(data) == 0 {
+		return empty_response, errors.New("WowUp export is empty")
+	}
+	if data[0] != '{' {
+		decoded, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil {
+			decoded, err = base64.RawStdEncoding.DecodeString(string(data))
+			if err != nil {
+				return empty_response, fmt.Errorf("WowUp export is not json or base64 encoded json: %w", err)
+			}
+		}
+		data = decoded
+	}
+
+	var export wowup_export
+	err := json.Unmarshal(data, &export)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to parse WowUp export: %w", err)
+	}
+
+	rv := []ForeignAddon{}
+	for _, a := range export.AddonList {
+		source := foreign_provider_source(a.ProviderName)
+		source_id := a.ID
+		if source == SOURCE_GITHUB {
+			source_id = normalise_github_source_id(source_id)
+		}
+		rv = append(rv, ForeignAddon{
+			Manager:  ADDON_MANAGER_WOWUP,
+			Name:     a.Name,
+			Version:  a.Version,
+			Source:   source,
+			SourceID: source_id,
+		})
+	}
+	return rv, nil
+}
+
+// the .toc keys written by the curseforge packager, most preferred first.
+var curseforge_toc_source_keys = []struct {
+	key    string
+	source Source
+}{
+	{"x-wowi-id", SOURCE_WOWI},
+	{"x-curse-project-id", SOURCE_CURSEFORGE},
+	{"x-wago-id", "wago"},
+}
+
+// returns the addons in `addons_dir` installed by the CurseForge app.
+// the app keeps it's own state elsewhere in a format we don't read,
+// but the addons it installs carry their project IDs in their .toc files.
+// addons in many directories sharing a project ID are one addon.
+func curseforge_addons(addons_dir PathToDir) ([]ForeignAddon, error) {
+	empty_response := []ForeignAddon{}
+	dir_list, err := core.DirList(addons_dir)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read addons dir: %w", err)
+	}
+
+	idx := map[string]*ForeignAddon{} // "curseforge/61284" => addon
+	key_list := []string{}
+	for _, addon_path := range dir_list {
+		if BlizzardAddon(addon_path) {
+			continue
+		}
+		toc_path_list, err := find_toc_files(addon_path)
+		if err != nil || len(toc_path_list) == 0 {
+			continue
+		}
+		slices.Sort(toc_path_list) // deterministic
+		toc_data, err := core.SlurpBytesUTF8(toc_path_list[0])
+		if err != nil {
+			slog.Warn("failed to read .toc file, skipping", "path", toc_path_list[0], "error", err)
+			continue
+		}
+		kvs := parse_toc_file(string(toc_data))
+		toc := coerce_toc_data(kvs, toc_path_list[0])
+
+		for _, ts := range curseforge_toc_source_keys {
+			source_id := strings.TrimSpace(kvs[ts.key])
+			if source_id == "" {
+				continue
+			}
+			key := ts.source + "/" + source_id
+			fa, present := idx[key]
+			if !present {
+				fa = &ForeignAddon{
+					Manager:  ADDON_MANAGER_CURSEFORGE,
+					Name:     RemoveEscapeSequences(toc.Label),
+					Version:  toc.InstalledVersion,
+					Source:   ts.source,
+					SourceID: source_id,
+				}
+				idx[key] = fa
+				key_list = append(key_list, key)
+			}
+			fa.DirNameList = append(fa.DirNameList, filepath.Base(addon_path))
+			break
+		}
+	}
+
+	rv := []ForeignAddon{}
+	for _, key := range key_list {
+		rv = append(rv, *idx[key])
+	}
+	return rv, nil
+}
+
+// finds the catalogue addon for the given foreign addon and `true`, or `false` if not found.
+// matches on source and source-id for supported sources, then on name across supported sources.
+func match_foreign_addon(fa ForeignAddon, catalogue_addon_list []CatalogueAddon) (CatalogueAddon, bool) {
+	if SUPPORTED_HOSTS.Contains(fa.Source) && fa.SourceID != "" {
+		for _, ca := range catalogue_addon_list {
+			if ca.Source == fa.Source && string(ca.SourceID) == fa.SourceID {
+				return ca, true
+			}
+		}
+	}
+	name := slugify(fa.Name)
+	for _, ca := range catalogue_addon_list {
+		if SUPPORTED_HOSTS.Contains(ca.Source) && ca.Name == name {
+			return ca, true
+		}
+	}
+	return CatalogueAddon{}, false
+}
+
+// returns the installed addon the foreign addon refers to and `true`, or `false` if it isn't installed.
+func find_foreign_addon_installed(fa ForeignAddon, ca *CatalogueAddon, installed_addon_list []Addon) (Addon, bool) {
+	for _, a := range installed_addon_list {
+		for _, dir_name := range fa.DirNameList {
+			for _, ia := range a.InstalledAddonGroup {
+				if ia.Name == dir_name {
+					return a, true
+				}
+			}
+		}
+	}
+	name := slugify(fa.Name)
+	for _, a := range installed_addon_list {
+		if a.Name == name || (ca != nil && a.Name == ca.Name) {
+			return a, true
+		}
+	}
+	return Addon{}, false
+}
+
+// maps each foreign addon to the catalogue, suggesting replacements for those only available from unsupported sources.
+func reconcile_foreign_addons(foreign_list []ForeignAddon, catalogue_addon_list []CatalogueAddon) []ForeignImport {
+	rv := []ForeignImport{}
+	for _, fa := range foreign_list {
+		fi := ForeignImport{ForeignAddon: fa}
+		ca, found := match_foreign_addon(fa, catalogue_addon_list)
+		switch {
+		case found:
+			fi.Status = FOREIGN_STATUS_MATCHED
+			fi.CatalogueAddon = &ca
+		case !SUPPORTED_HOSTS.Contains(fa.Source):
+			fi.Status = FOREIGN_STATUS_UNSUPPORTED
+			stand_in := Addon{Name: slugify(fa.Name), Label: fa.Name, Source: fa.Source, SourceID: fa.SourceID}
+			fi.ReplacementList = similar_addons(stand_in, catalogue_addon_list, time.Time{}, STALE_REPLACEMENT_LIMIT)
+		default:
+			fi.Status = FOREIGN_STATUS_NOT_FOUND
+		}
+		rv = append(rv, fi)
+	}
+	return rv
+}
+
+// writes nfo data for the matched, installed addon `a` so strongbox can update it.
+// the directories the foreign addon manager found the addon in are grouped with `a` under a single primary.
+// addons that already have strongbox nfo data with a source are left alone.
+// returns the directories nfo data was written to.
+func write_foreign_nfo(addons_dir AddonsDir, a Addon, fi ForeignImport) ([]string, error) {
+	empty_response := []string{}
+	if fi.CatalogueAddon == nil {
+		return empty_response, nil
+	}
+	if a.NFO != nil && a.NFO.Source != "" {
+		return empty_response, nil
+	}
+
+	ca := fi.CatalogueAddon
+	version := a.InstalledVersion
+	if version == "" {
+		version = fi.Version
+	}
+
+	dir_name_list := []string{}
+	for _, ia := range a.InstalledAddonGroup {
+		dir_name_list = append(dir_name_list, ia.Name)
+	}
+	for _, dir_name := range fi.DirNameList {
+		if !slices.Contains(dir_name_list, dir_name) && core.DirExists(filepath.Join(addons_dir.Path, dir_name)) {
+			dir_name_list = append(dir_name_list, dir_name)
+		}
+	}
+	primary := a.Primary.Name
+	if len(dir_name_list) > len(a.InstalledAddonGroup) {
+		primary = pick_primary_dir(dir_name_list)
+	}
+
+	rv := []string{}
+	for _, dir_name := range dir_name_list {
+		nfo := NFO{
+			GroupID:              ca.URL,
+			Primary:              dir_name == primary,
+			Name:                 ca.Name,
+			Source:               ca.Source,
+			SourceID:             ca.SourceID,
+			InstalledVersion:     version,
+			InstalledGameTrackID: addons_dir.GameTrackID,
+			SourceMapList:        []SourceMap{{Source: ca.Source, SourceID: ca.SourceID}},
+		}
+		addon_path := filepath.Join(addons_dir.Path, dir_name)
+		nfo_list, _, err := add_nfo(addon_path, nfo)
+		if err != nil {
+			return rv, fmt.Errorf("failed to write nfo data: %w", err)
+		}
+		err = write_nfo(addon_path, nfo_list)
+		if err != nil {
+			return rv, err
+		}
+		rv = append(rv, dir_name)
+	}
+	return rv, nil
+}
+
+// maps the foreign addons to the catalogue and writes nfo data for those already installed in `addons_dir`.
+func ImportForeignAddons(addons_dir AddonsDir, foreign_list []ForeignAddon, installed_addon_list []Addon, catalogue_addon_list []CatalogueAddon) []ForeignImport {
+	rv := reconcile_foreign_addons(foreign_list, catalogue_addon_list)
+	for i, fi := range rv {
+		if fi.Status != FOREIGN_STATUS_MATCHED {
+			continue
+		}
+		a, installed := find_foreign_addon_installed(fi.ForeignAddon, fi.CatalogueAddon, installed_addon_list)
+		if !installed {
+			continue
+		}
+		dir_name_list, err := write_foreign_nfo(addons_dir, a, fi)
+		if err != nil {
+			slog.Warn("failed to write nfo data for imported addon", "addon", fi.Name, "error", err)
+		}
+		rv[i].NFODirNameList = dir_name_list
+	}
+	return rv
+}
+
+// reads the addons managed by `manager` and imports them into `addons_dir`.
+// WowUp addons are read from an export file at `path`, CurseForge addons are read from `addons_dir` itself.
+func ImportFromAddonManager(app *core.App, addons_dir AddonsDir, manager string, path PathToFile) ([]ForeignImport, error) {
+	empty_response := []ForeignImport{}
+
+	var foreign_list []ForeignAddon
+	var err error
+	switch manager {
+	case ADDON_MANAGER_WOWUP:
+		var data []byte
+		data, err = core.SlurpBytesUTF8(path)
+		if err != nil {
+			return empty_response, fmt.Errorf("failed to read WowUp export: %w", err)
+		}
+		foreign_list, err = parse_wowup_export(data)
+	case ADDON_MANAGER_CURSEFORGE:
+		foreign_list, err = curseforge_addons(addons_dir.Path)
+	default:
+		return empty_response, fmt.Errorf("unsupported addon manager: %s", manager)
+	}
+	if err != nil {
+		return empty_response, err
+	}
+
+	installed_addon_list := []Addon{}
+	for _, r := range installed_addons(app, addons_dir) {
+		installed_addon_list = append(installed_addon_list, r.Item.(Addon))
+	}
+
+	return ImportForeignAddons(addons_dir, foreign_list, installed_addon_list, db_catalogue_addons(app)), nil
+}
@@ -0,0 +1,178 @@
+package strongbox
+
+import (
+	"encoding/base64"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const importers_test_wowup_export = `{"addons": [
+  {"id": "61284", "name": "Details! Damage Meter", "version": "1.0", "providerName": "Curse"},
+  {"id": "25079", "name": "EveryAddon", "version": "1.2.3", "providerName": "WowInterface"},
+  {"id": "https://github.com/ogri-la/every-other-addon", "name": "EveryOtherAddon", "version": "2.0", "providerName": "GitHub"}
+]}`
+
+var importers_test_catalogue = []CatalogueAddon{
+	{Source: SOURCE_WOWI, SourceID: "25079", Name: "everyaddon", URL: "https://www.wowinterface.com/downloads/info25079"},
+	{Source: SOURCE_GITHUB, SourceID: "ogri-la/every-other-addon", Name: "everyotheraddon", URL: "https://github.com/ogri-la/every-other-addon"},
+	{Source: SOURCE_WOWI, SourceID: "123", Name: "details-damage-meter-classic", Label: "Details! Damage Meter Classic", URL: "https://www.wowinterface.com/downloads/info123"},
+}
+
+func Test_parse_wowup_export(t *testing.T) {
+	expected := []ForeignAddon{
+		{Manager: ADDON_MANAGER_WOWUP, Name: "Details! Damage Meter", Version: "1.0", Source: SOURCE_CURSEFORGE, SourceID: "61284"},
+		{Manager: ADDON_MANAGER_WOWUP, Name: "EveryAddon", Version: "1.2.3", Source: SOURCE_WOWI, SourceID: "25079"},
+		{Manager: ADDON_MANAGER_WOWUP, Name: "EveryOtherAddon", Version: "2.0", Source: SOURCE_GITHUB, SourceID: "ogri-la/every-other-addon"},
+	}
+
+	// plain json
+	actual, err := parse_wowup_export([]byte(importers_test_wowup_export))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+
+	// base64 encoded json, as WowUp exports it
+	encoded := base64.StdEncoding.EncodeToString([]byte(importers_test_wowup_export))
+	actual, err = parse_wowup_export([]byte(encoded + "\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func Test_parse_wowup_export__bad(t *testing.T) {
+	for _, given := range []string{"", "   ", "!!not-base64!!", "{not json"} {
+		_, err := parse_wowup_export([]byte(given))
+		assert.NotNil(t, err, given)
+	}
+}
+
+// addons sharing a project id are a single addon across many directories
+func Test_curseforge_addons(t *testing.T) {
+	addons_dir := t.TempDir()
+	make_file_tree(t, addons_dir, map[string]string{
+		"EveryAddon/EveryAddon.toc":               "## Title: EveryAddon\n## Version: 1.2.3\n## X-Curse-Project-ID: 1000\n",
+		"EveryAddon_Config/EveryAddon_Config.toc": "## Title: EveryAddon Config\n## X-Curse-Project-ID: 1000\n",
+		"EveryOtherAddon/EveryOtherAddon.toc":     "## Title: EveryOtherAddon\n## X-Curse-Project-ID: 2000\n## X-WoWI-ID: 25079\n",
+		"LocalAddon/LocalAddon.toc":               "## Title: LocalAddon\n",
+	})
+
+	expected := []ForeignAddon{
+		{Manager: ADDON_MANAGER_CURSEFORGE, Name: "EveryAddon", Version: "1.2.3", Source: SOURCE_CURSEFORGE, SourceID: "1000", DirNameList: []string{"EveryAddon", "EveryAddon_Config"}},
+		{Manager: ADDON_MANAGER_CURSEFORGE, Name: "EveryOtherAddon", Source: SOURCE_WOWI, SourceID: "25079", DirNameList: []string{"EveryOtherAddon"}},
+	}
+	actual, err := curseforge_addons(addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+// foreign addons are matched by source and id, then by name.
+// curseforge-only addons come with suggested replacements.
+func Test_reconcile_foreign_addons(t *testing.T) {
+	foreign_list, err := parse_wowup_export([]byte(importers_test_wowup_export))
+	assert.Nil(t, err)
+	foreign_list = append(foreign_list, ForeignAddon{Name: "EveryAddon", Source: SOURCE_CURSEFORGE, SourceID: "1000"})
+	foreign_list = append(foreign_list, ForeignAddon{Name: "Missing", Source: SOURCE_WOWI, SourceID: "999"})
+
+	actual := reconcile_foreign_addons(foreign_list, importers_test_catalogue)
+	assert.Equal(t, 5, len(actual))
+
+	assert.Equal(t, FOREIGN_STATUS_UNSUPPORTED, actual[0].Status)
+	assert.Nil(t, actual[0].CatalogueAddon)
+	assert.Equal(t, []CatalogueAddon{importers_test_catalogue[2]}, actual[0].ReplacementList)
+
+	assert.Equal(t, FOREIGN_STATUS_MATCHED, actual[1].Status)
+	assert.Equal(t, importers_test_catalogue[0], *actual[1].CatalogueAddon)
+
+	assert.Equal(t, FOREIGN_STATUS_MATCHED, actual[2].Status)
+	assert.Equal(t, importers_test_catalogue[1], *actual[2].CatalogueAddon)
+
+	// a curseforge addon also available from a supported source is matched by name
+	assert.Equal(t, FOREIGN_STATUS_MATCHED, actual[3].Status)
+	assert.Equal(t, importers_test_catalogue[0], *actual[3].CatalogueAddon)
+
+	assert.Equal(t, FOREIGN_STATUS_NOT_FOUND, actual[4].Status)
+}
+
+// matched addons already on disk get nfo data, addons with nfo data are left alone
+func Test_ImportForeignAddons(t *testing.T) {
+	addons_dir := AddonsDir{Path: t.TempDir(), GameTrackID: GAMETRACK_RETAIL, Strict: true}
+	make_file_tree(t, addons_dir.Path, map[string]string{
+		"EveryAddon/EveryAddon.toc":           "## Title: EveryAddon\n## Version: 1.2.3\n## Interface: 110000\n## X-Curse-Project-ID: 1000\n",
+		"EveryOtherAddon/EveryOtherAddon.toc": "## Title: EveryOtherAddon\n## Version: 2.0\n## Interface: 110000\n",
+	})
+
+	installed_addon_list, err := LoadAllInstalledAddons(addons_dir)
+	assert.Nil(t, err)
+
+	foreign_list, err := parse_wowup_export([]byte(importers_test_wowup_export))
+	assert.Nil(t, err)
+
+	actual := ImportForeignAddons(addons_dir, foreign_list, installed_addon_list, importers_test_catalogue)
+	assert.Equal(t, 3, len(actual))
+	assert.Equal(t, []string(nil), actual[0].NFODirNameList)
+	assert.Equal(t, []string{"EveryAddon"}, actual[1].NFODirNameList)
+	assert.Equal(t, []string{"EveryOtherAddon"}, actual[2].NFODirNameList)
+
+	nfo_list, err := read_nfo_file(filepath.Join(addons_dir.Path, "EveryAddon"))
+	assert.Nil(t, err)
+	expected := []NFO{
+		{
+			GroupID:              "https://www.wowinterface.com/downloads/info25079",
+			Primary:              true,
+			Name:                 "everyaddon",
+			Source:               SOURCE_WOWI,
+			SourceID:             "25079",
+			InstalledVersion:     "1.2.3",
+			InstalledGameTrackID: GAMETRACK_RETAIL,
+			SourceMapList:        []SourceMap{{Source: SOURCE_WOWI, SourceID: "25079"}},
+		},
+	}
+	assert.Equal(t, expected, nfo_list)
+
+	// importing again doesn't touch addons that now have nfo data
+	installed_addon_list, err = LoadAllInstalledAddons(addons_dir)
+	assert.Nil(t, err)
+	actual = ImportForeignAddons(addons_dir, foreign_list, installed_addon_list, importers_test_catalogue)
+	assert.Equal(t, []string{}, actual[1].NFODirNameList)
+}
+
+// a curseforge addon in many directories, matched by name, is grouped under a single primary with nfo data in every directory
+func Test_ImportForeignAddons__multi_dir(t *testing.T) {
+	addons_dir := AddonsDir{Path: t.TempDir(), GameTrackID: GAMETRACK_RETAIL, Strict: true}
+	make_file_tree(t, addons_dir.Path, map[string]string{
+		"EveryAddon/EveryAddon.toc":               "## Title: EveryAddon\n## Version: 1.2.3\n## Interface: 110000\n## X-Curse-Project-ID: 1000\n",
+		"EveryAddon_Config/EveryAddon_Config.toc": "## Title: EveryAddon Config\n## Interface: 110000\n## X-Curse-Project-ID: 1000\n",
+		"EveryAddon_Data/EveryAddon_Data.toc":     "## Title: EveryAddon Data\n## Interface: 110000\n## X-Curse-Project-ID: 1000\n",
+	})
+
+	installed_addon_list, err := LoadAllInstalledAddons(addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(installed_addon_list))
+
+	foreign_list, err := curseforge_addons(addons_dir.Path)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(foreign_list))
+
+	actual := ImportForeignAddons(addons_dir, foreign_list, installed_addon_list, importers_test_catalogue)
+	assert.Equal(t, 1, len(actual))
+	assert.Equal(t, FOREIGN_STATUS_MATCHED, actual[0].Status)
+	assert.ElementsMatch(t, []string{"EveryAddon", "EveryAddon_Config", "EveryAddon_Data"}, actual[0].NFODirNameList)
+
+	primary_list := []string{}
+	for _, dir_name := range actual[0].NFODirNameList {
+		nfo_list, err := read_nfo_file(filepath.Join(addons_dir.Path, dir_name))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(nfo_list))
+		assert.Equal(t, "https://www.wowinterface.com/downloads/info25079", nfo_list[0].GroupID)
+		if nfo_list[0].Primary {
+			primary_list = append(primary_list, dir_name)
+		}
+	}
+	assert.Equal(t, []string{"EveryAddon"}, primary_list)
+
+	// the directories are now a single addon
+	installed_addon_list, err = LoadAllInstalledAddons(addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(installed_addon_list))
+	assert.Equal(t, "EveryAddon", installed_addon_list[0].Primary.Name)
+}
@@ -0,0 +1,368 @@
+package strongbox
+
+import (
+	"bw/core"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+// local_source.go is an addon source for addons that live in a directory or git working tree outside of the addons dir.
+// this is mostly for addon developers working from a checkout.
+
+// the origin directory is the `SourceID` of the addon and it's recorded in the nfo data like any other source.
+// an origin may be a single addon (has .toc files) or a collection of addons (has sub-directories with .toc files).
+
+// addons installed from a local origin can be copied or symlinked into the addons dir.
+// symlinked addons are always up to date, updating them just updates the nfo data.
+// nfo files are written to the addon directory, which for a symlinked addon is it's origin,
+// so addons can only be symlinked when nfo data is kept in the nfo database.
+
+type LocalAPI struct{}
+
+var _ AddonSource = (*LocalAPI)(nil)
+
+// returns the group ID for addons installed from the given `origin`.
+func local_group_id(origin PathToDir) string {
+	return "file://" + origin
+}
+
+// returns the commit hash that HEAD points to in the git working tree at `dir`.
+// returns an error if `dir` is not a git working tree or HEAD can't be resolved.
+// git isn't called, the .git directory is read directly.
+func git_head(dir PathToDir) (string, error) {
+	empty_response := ""
+	git_dir := filepath.Join(dir, ".git")
+
+	// worktrees and submodules have a .git *file* pointing to the real git dir: "gitdir: /path/to/.git/worktrees/foo"
+	if core.FileExists(git_dir) {
+		b, err := os.ReadFile(git_dir)
+		if err != nil {
+			return empty_response, fmt.Errorf("failed to read .git file: %w", err)
+		}
+		gitdir, found := strings.CutPrefix(strings.TrimSpace(string(b)), "gitdir:")
+		if !found {
+			return empty_response, fmt.Errorf("unrecognised .git file: %s", git_dir)
+		}
+		git_dir = strings.TrimSpace(gitdir)
+		if !filepath.IsAbs(git_dir) {
+			git_dir = filepath.Join(dir, git_dir)
+		}
+	}
+
+	if !core.DirExists(git_dir) {
+		return empty_response, fmt.Errorf("not a git working tree: %s", dir)
+	}
+
+	b, err := os.ReadFile(filepath.Join(git_dir, "HEAD"))
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read git HEAD: %w", err)
+	}
+	head := strings.TrimSpace(string(b))
+
+	ref, is_ref := strings.CutPrefix(head, "ref:")
+	if !is_ref {
+		return head, nil // detached HEAD
+	}
+	ref = strings.TrimSpace(ref) // "refs/heads/main"
+
+	// worktrees keep their refs in the 'common' git dir
+	ref_dir_list := []PathToDir{git_dir}
+	common_dir, err := os.ReadFile(filepath.Join(git_dir, "commondir"))
+	if err == nil {
+		common := strings.TrimSpace(string(common_dir))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(git_dir, common)
+		}
+		ref_dir_list = append(ref_dir_list, common)
+	}
+
+	for _, ref_dir := range ref_dir_list {
+		b, err := os.ReadFile(filepath.Join(ref_dir, filepath.FromSlash(ref)))
+		if err == nil {
+			return strings.TrimSpace(string(b)), nil
+		}
+
+		// refs may have been packed: "<hash> refs/heads/main"
+		b, err = os.ReadFile(filepath.Join(ref_dir, "packed-refs"))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(b), "\n") {
+			hash, name, found := strings.Cut(strings.TrimSpace(line), " ")
+			if found && name == ref {
+				return hash, nil
+			}
+		}
+	}
+
+	return empty_response, fmt.Errorf("failed to resolve git ref '%s': %s", ref, dir)
+}
+
+// returns the most recent modification time of any file beneath `dir`, ignoring VCS directories and nfo files.
+func latest_mtime(dir PathToDir) (time.Time, error) {
+	var latest time.Time
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && VCS_DIR_SET.Contains(d.Name()) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == NFO_FILENAME {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest, err
+}
+
+// returns a version for the addon(s) at `origin`.
+// git working trees use the first 12 characters of the HEAD commit, everything else uses the latest file modification time.
+func local_version(origin PathToDir) (string, time.Time, error) {
+	mtime, err := latest_mtime(origin)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read local addon: %w", err)
+	}
+
+	head, err := git_head(origin)
+	if err == nil {
+		if len(head) > 12 {
+			head = head[:12]
+		}
+		return head, mtime, nil
+	}
+
+	return mtime.UTC().Format("20060102T150405Z"), mtime, nil // "20240102T030405Z"
+}
+
+// returns a map of addon directory names to their paths within the given `origin`.
+// an origin with .toc files is a single addon, otherwise each sub-directory with .toc files is an addon.
+func local_addon_dirs(origin PathToDir) (map[string]PathToDir, error) {
+	rv := map[string]PathToDir{}
+
+	// an origin without any files at the top level is fine, it may be a collection of addons
+	toc_list, _ := find_toc_files(origin)
+	if len(toc_list) > 0 {
+		rv[filepath.Base(origin)] = origin
+		return rv, nil
+	}
+
+	dir_list, err := core.DirList(origin)
+	if err != nil {
+		return rv, fmt.Errorf("failed to read local addon: %w", err)
+	}
+	for _, dir := range dir_list {
+		if VCS_DIR_SET.Contains(filepath.Base(dir)) {
+			continue
+		}
+		toc_list, err := find_toc_files(dir)
+		if err == nil && len(toc_list) > 0 {
+			rv[filepath.Base(dir)] = dir
+		}
+	}
+
+	if len(rv) == 0 {
+		return rv, fmt.Errorf("no addons found in directory: %s", origin)
+	}
+	return rv, nil
+}
+
+// ExpandSummary implements AddonSource.
+// a local origin has a single 'update', it's current state.
+func (l *LocalAPI) ExpandSummary(app *core.App, source_id string) ([]SourceUpdate, error) {
+	empty_response := []SourceUpdate{}
+	origin := source_id
+
+	if !core.DirExists(origin) {
+		return empty_response, fmt.Errorf("local addon directory not found: %s", origin)
+	}
+
+	version, mtime, err := local_version(origin)
+	if err != nil {
+		return empty_response, err
+	}
+
+	su := NewSourceUpdate()
+	su.Version = version
+	su.DownloadURL = origin
+	su.PublishedDate = mtime
+	// whatever is in the origin is what the developer wants installed, regardless of game track.
+	su.GameTrackIDSet = game_tracks().IDSet()
+
+	return []SourceUpdate{su}, nil
+}
+
+// copies the directory `src` to `dest`, skipping VCS directories and nfo files.
+func copy_dir(src PathToDir, dest PathToDir) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+
+		if d.IsDir() {
+			if path != src && VCS_DIR_SET.Contains(d.Name()) {
+				return fs.SkipDir
+			}
+			return os.MkdirAll(target, 0755)
+		}
+		if d.Name() == NFO_FILENAME || !d.Type().IsRegular() {
+			return nil
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		_, err = io.Copy(out, in)
+		return err
+	})
+}
+
+// returns `true` if the given path is a symlink.
+func is_symlink(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.Mode()&os.ModeSymlink != 0
+}
+
+// returns an `Addon` that can be installed from the given local `origin`.
+func MakeAddonFromLocal(addons_dir AddonsDir, origin PathToDir, sul []SourceUpdate) Addon {
+	ial := []InstalledAddon{}
+	pa := InstalledAddon{}
+	nfo := NFO{
+		GroupID:  local_group_id(origin),
+		Source:   SOURCE_LOCAL,
+		SourceID: FlexString(origin),
+		Name:     slugify(filepath.Base(origin)),
+	}
+	return MakeAddon(addons_dir, ial, pa, &nfo, nil, sul)
+}
+
+// 'installs' the addon(s) in `origin` into the `addons_dir` by copying or symlinking them.
+// any previously installed version of `addon` is removed first.
+// ignored addons are not replaced unless `opts.OverwriteIgnored` is set.
+func install_local_addon(addons_dir AddonsDir, addon Addon, origin PathToDir, symlink bool, opts InstallOpts) error {
+	_, nfo_files := nfo_store().(NFOFileStore)
+	if symlink && nfo_files {
+		return fmt.Errorf("refusing to symlink local addon, nfo files would be written to it's origin: use the '%s' nfo store or copy the addon instead", NFO_STORE_DATABASE)
+	}
+
+	addon_dir_map, err := local_addon_dirs(origin)
+	if err != nil {
+		return fmt.Errorf("failed to install local addon: %w", err)
+	}
+
+	toplevel_dirs := mapset.NewSet[string]()
+	ignored := false
+	pinned := false
+	for dir_name := range addon_dir_map {
+		toplevel_dirs.Add(dir_name)
+		nfo_data, err := read_nfo(filepath.Join(addons_dir.Path, dir_name))
+		if err != nil && !errors.Is(err, ErrNFODNE) {
+			slog.Error("failed to read .nfo data", "err", err)
+		}
+		nfo, _ := pick_nfo(nfo_data)
+		pinned = pinned || nfo.PinnedVersion != ""
+		ignored = ignored || nfo_ignored(nfo)
+	}
+
+	if ignored && !opts.OverwriteIgnored {
+		return fmt.Errorf("refusing to install addon that will overwrite an ignored addon")
+	}
+
+	primary_subdir := pick_primary_dir(toplevel_dirs.ToSlice())
+
+	err = remove_addon(addon, addons_dir, RemoveOpts{})
+	if err != nil {
+		slog.Error("failed to properly uninstall previously installed version of addon", "error", err)
+	}
+
+	for dir_name, src := range addon_dir_map {
+		dest := filepath.Join(addons_dir.Path, dir_name)
+		if filepath.Clean(src) == filepath.Clean(dest) {
+			return fmt.Errorf("refusing to install local addon over itself: %s", src)
+		}
+
+		// replace whatever was there before
+		err = os.RemoveAll(dest)
+		if err != nil {
+			return fmt.Errorf("failed to remove existing addon directory: %w", err)
+		}
+
+		if symlink {
+			err = os.Symlink(src, dest)
+		} else {
+			err = copy_dir(src, dest)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to install local addon: %w", err)
+		}
+	}
+
+	update_nfo_files(addons_dir, addon, toplevel_dirs, primary_subdir, ignored, pinned)
+
+	return nil
+}
+
+// installs the addon(s) in the local `origin` directory into the `addons_dir`.
+func InstallLocalAddon(app *core.App, addons_dir AddonsDir, origin PathToDir, symlink bool, opts InstallOpts) error {
+	origin, err := filepath.Abs(origin)
+	if err != nil {
+		return fmt.Errorf("failed to install local addon: %w", err)
+	}
+
+	sul, err := (&LocalAPI{}).ExpandSummary(app, origin)
+	if err != nil {
+		return fmt.Errorf("failed to install local addon: %w", err)
+	}
+
+	a := MakeAddonFromLocal(addons_dir, origin, sul)
+	err = install_local_addon(addons_dir, a, origin, symlink, opts)
+	if err != nil {
+		return err
+	}
+
+	return LoadAllInstalledAddonsToState(app, addons_dir)
+}
+
+// re-syncs an installed local addon with it's origin.
+// symlinked addons stay symlinked, copied addons are copied again.
+func update_local_addon(addons_dir AddonsDir, a Addon) error {
+	if a.SourceUpdate == nil {
+		return fmt.Errorf("no update to install")
+	}
+	origin := a.SourceID
+	symlink := is_symlink(filepath.Join(addons_dir.Path, a.DirName))
+	return install_local_addon(addons_dir, a, origin, symlink, InstallOpts{})
+}
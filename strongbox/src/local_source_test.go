@@ -0,0 +1,253 @@
+package strongbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writes each of the given `file_map` paths beneath `root` with their contents.
+func make_file_tree(t *testing.T, root string, file_map map[string]string) {
+	for path, contents := range file_map {
+		full_path := filepath.Join(root, path)
+		err := os.MkdirAll(filepath.Dir(full_path), 0755)
+		assert.Nil(t, err)
+		err = os.WriteFile(full_path, []byte(contents), 0644)
+		assert.Nil(t, err)
+	}
+}
+
+const local_test_toc = "## Title: EveryAddon\n## Version: 1.2.3\n## Interface: 110000\n"
+
+func Test_git_head(t *testing.T) {
+	hash := "0123456789abcdef0123456789abcdef01234567"
+	root := t.TempDir()
+	make_file_tree(t, root, map[string]string{
+		"ref/.git/HEAD":                    "ref: refs/heads/main\n",
+		"ref/.git/refs/heads/main":         hash + "\n",
+		"packed/.git/HEAD":                 "ref: refs/heads/main\n",
+		"packed/.git/packed-refs":          "# pack-refs with: peeled fully-peeled sorted\n" + hash + " refs/heads/main\n",
+		"detached/.git/HEAD":               hash + "\n",
+		"worktree/.git":                    "gitdir: ../ref/.git/worktrees/foo\n",
+		"ref/.git/worktrees/foo/HEAD":      "ref: refs/heads/main\n",
+		"ref/.git/worktrees/foo/commondir": "../..\n",
+		"notgit/EveryAddon.toc":            local_test_toc,
+	})
+
+	for _, dir := range []string{"ref", "packed", "detached", "worktree"} {
+		actual, err := git_head(filepath.Join(root, dir))
+		assert.Nil(t, err, dir)
+		assert.Equal(t, hash, actual, dir)
+	}
+
+	_, err := git_head(filepath.Join(root, "notgit"))
+	assert.NotNil(t, err)
+}
+
+// a git working tree is versioned by it's HEAD commit, everything else by the latest modification time.
+func Test_local_version(t *testing.T) {
+	root := t.TempDir()
+	make_file_tree(t, root, map[string]string{
+		"git/EveryAddon.toc":            local_test_toc,
+		"git/.git/HEAD":                 "0123456789abcdef0123456789abcdef01234567\n",
+		"plain/EveryAddon.toc":          local_test_toc,
+		"plain/" + NFO_FILENAME:         "{}",
+		"plain/.git-not-really/foo.lua": "",
+	})
+
+	version, _, err := local_version(filepath.Join(root, "git"))
+	assert.Nil(t, err)
+	assert.Equal(t, "0123456789ab", version)
+
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, path := range []string{"plain/EveryAddon.toc", "plain/.git-not-really/foo.lua"} {
+		err = os.Chtimes(filepath.Join(root, path), mtime, mtime)
+		assert.Nil(t, err)
+	}
+	version, _, err = local_version(filepath.Join(root, "plain"))
+	assert.Nil(t, err)
+	assert.Equal(t, "20240102T030405Z", version)
+}
+
+func Test_local_addon_dirs(t *testing.T) {
+	root := t.TempDir()
+	make_file_tree(t, root, map[string]string{
+		"single/EveryAddon.toc":                     local_test_toc,
+		"multi/EveryAddon/EveryAddon.toc":           local_test_toc,
+		"multi/EveryOtherAddon/EveryOtherAddon.toc": local_test_toc,
+		"multi/docs/readme.md":                      "",
+		"empty/readme.md":                           "",
+	})
+
+	actual, err := local_addon_dirs(filepath.Join(root, "single"))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]PathToDir{"single": filepath.Join(root, "single")}, actual)
+
+	actual, err = local_addon_dirs(filepath.Join(root, "multi"))
+	assert.Nil(t, err)
+	expected := map[string]PathToDir{
+		"EveryAddon":      filepath.Join(root, "multi", "EveryAddon"),
+		"EveryOtherAddon": filepath.Join(root, "multi", "EveryOtherAddon"),
+	}
+	assert.Equal(t, expected, actual)
+
+	_, err = local_addon_dirs(filepath.Join(root, "empty"))
+	assert.NotNil(t, err)
+}
+
+// a local addon can be copied into an addons dir, VCS dirs are not copied and the origin is recorded.
+func Test_install_local_addon__copy(t *testing.T) {
+	origin := filepath.Join(t.TempDir(), "EveryAddon")
+	make_file_tree(t, origin, map[string]string{
+		"EveryAddon.toc": local_test_toc,
+		"EveryAddon.lua": "",
+		".git/HEAD":      "0123456789abcdef0123456789abcdef01234567\n",
+	})
+	ad := AddonsDir{Path: t.TempDir(), GameTrackID: GAMETRACK_RETAIL, Strict: true}
+
+	sul, err := (&LocalAPI{}).ExpandSummary(nil, origin)
+	assert.Nil(t, err)
+
+	a := MakeAddonFromLocal(ad, origin, sul)
+	err = install_local_addon(ad, a, origin, false, InstallOpts{})
+	assert.Nil(t, err)
+
+	assert.FileExists(t, filepath.Join(ad.Path, "EveryAddon", "EveryAddon.toc"))
+	assert.FileExists(t, filepath.Join(ad.Path, "EveryAddon", "EveryAddon.lua"))
+	assert.NoDirExists(t, filepath.Join(ad.Path, "EveryAddon", ".git"))
+	assert.False(t, is_symlink(filepath.Join(ad.Path, "EveryAddon")))
+
+	nfo_list, err := read_nfo_file(filepath.Join(ad.Path, "EveryAddon"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(nfo_list))
+	assert.Equal(t, SOURCE_LOCAL, nfo_list[0].Source)
+	assert.Equal(t, FlexString(origin), nfo_list[0].SourceID)
+	assert.Equal(t, "0123456789ab", nfo_list[0].InstalledVersion)
+
+	// the origin is left alone
+	assert.NoFileExists(t, filepath.Join(origin, NFO_FILENAME))
+}
+
+// ignored addons are not replaced by a local addon unless explicitly overwritten.
+func Test_install_local_addon__ignored(t *testing.T) {
+	origin := filepath.Join(t.TempDir(), "EveryAddon")
+	make_file_tree(t, origin, map[string]string{
+		"EveryAddon.toc": local_test_toc,
+		"EveryAddon.lua": "-- from origin",
+	})
+	ad := AddonsDir{Path: t.TempDir(), GameTrackID: GAMETRACK_RETAIL, Strict: true}
+	make_file_tree(t, filepath.Join(ad.Path, "EveryAddon"), map[string]string{
+		"EveryAddon.toc": local_test_toc,
+		"EveryAddon.lua": "-- the user's own",
+	})
+	nfo := NFO{Name: "everyaddon", GroupID: "https://example.org/everyaddon", Primary: true, Ignored: new(true)}
+	assert.Nil(t, write_nfo(filepath.Join(ad.Path, "EveryAddon"), []NFO{nfo}))
+
+	sul, err := (&LocalAPI{}).ExpandSummary(nil, origin)
+	assert.Nil(t, err)
+	a := MakeAddonFromLocal(ad, origin, sul)
+
+	err = install_local_addon(ad, a, origin, false, InstallOpts{})
+	assert.NotNil(t, err)
+	data, err := os.ReadFile(filepath.Join(ad.Path, "EveryAddon", "EveryAddon.lua"))
+	assert.Nil(t, err)
+	assert.Equal(t, "-- the user's own", string(data))
+
+	err = install_local_addon(ad, a, origin, false, InstallOpts{OverwriteIgnored: true})
+	assert.Nil(t, err)
+	data, err = os.ReadFile(filepath.Join(ad.Path, "EveryAddon", "EveryAddon.lua"))
+	assert.Nil(t, err)
+	assert.Equal(t, "-- from origin", string(data))
+}
+
+// a local addon whose directories share no common prefix still has exactly one primary directory.
+func Test_install_local_addon__primary(t *testing.T) {
+	origin := filepath.Join(t.TempDir(), "origin")
+	make_file_tree(t, origin, map[string]string{
+		"EveryAddon/EveryAddon.toc": local_test_toc,
+		"OtherAddon/OtherAddon.toc": local_test_toc,
+	})
+	ad := AddonsDir{Path: t.TempDir(), GameTrackID: GAMETRACK_RETAIL, Strict: true}
+
+	sul, err := (&LocalAPI{}).ExpandSummary(nil, origin)
+	assert.Nil(t, err)
+
+	err = install_local_addon(ad, MakeAddonFromLocal(ad, origin, sul), origin, false, InstallOpts{})
+	assert.Nil(t, err)
+
+	primary_list := []string{}
+	for _, dirname := range []string{"EveryAddon", "OtherAddon"} {
+		nfo_list, err := read_nfo_file(filepath.Join(ad.Path, dirname))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(nfo_list))
+		if nfo_list[0].Primary {
+			primary_list = append(primary_list, dirname)
+		}
+	}
+	assert.Equal(t, []string{"EveryAddon"}, primary_list)
+}
+
+// a local addon can be symlinked into an addons dir and re-synced when it changes.
+func Test_install_local_addon__symlink(t *testing.T) {
+	origin := filepath.Join(t.TempDir(), "EveryAddon")
+	make_file_tree(t, origin, map[string]string{
+		"EveryAddon.toc":       local_test_toc,
+		".git/HEAD":            "ref: refs/heads/main\n",
+		".git/refs/heads/main": "0123456789abcdef0123456789abcdef01234567\n",
+	})
+	ad := AddonsDir{Path: t.TempDir(), GameTrackID: GAMETRACK_RETAIL, Strict: true}
+
+	sul, err := (&LocalAPI{}).ExpandSummary(nil, origin)
+	assert.Nil(t, err)
+
+	// nfo files would be written to the origin
+	err = install_local_addon(ad, MakeAddonFromLocal(ad, origin, sul), origin, true, InstallOpts{})
+	assert.NotNil(t, err)
+	assert.NoDirExists(t, filepath.Join(ad.Path, "EveryAddon"))
+
+	db, err := OpenNFODatabase(filepath.Join(t.TempDir(), "nfo-db.json"))
+	assert.Nil(t, err)
+	set_nfo_store(db)
+	defer set_nfo_store(NFOFileStore{})
+
+	err = install_local_addon(ad, MakeAddonFromLocal(ad, origin, sul), origin, true, InstallOpts{})
+	assert.Nil(t, err)
+	assert.True(t, is_symlink(filepath.Join(ad.Path, "EveryAddon")))
+
+	addon_list, err := LoadAllInstalledAddons(ad)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(addon_list))
+	a := addon_list[0]
+	assert.Equal(t, SOURCE_LOCAL, a.Source)
+	assert.Equal(t, "0123456789ab", a.InstalledVersion)
+
+	// a new commit
+	make_file_tree(t, origin, map[string]string{
+		".git/refs/heads/main": "fedcba9876543210fedcba9876543210fedcba98\n",
+	})
+	sul, err = (&LocalAPI{}).ExpandSummary(nil, origin)
+	assert.Nil(t, err)
+	a = MakeAddon(ad, a.InstalledAddonGroup, a.Primary, a.NFO, nil, sul)
+	assert.True(t, Updateable(a))
+
+	err = update_local_addon(ad, a)
+	assert.Nil(t, err)
+	assert.True(t, is_symlink(filepath.Join(ad.Path, "EveryAddon")))
+
+	addon_list, err = LoadAllInstalledAddons(ad)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(addon_list))
+	assert.Equal(t, "fedcba987654", addon_list[0].InstalledVersion)
+	assert.FileExists(t, filepath.Join(origin, "EveryAddon.toc"))
+
+	// the origin is left alone
+	assert.NoFileExists(t, filepath.Join(origin, NFO_FILENAME))
+}
+
+func Test_LocalAPI__ExpandSummary__missing(t *testing.T) {
+	_, err := (&LocalAPI{}).ExpandSummary(nil, filepath.Join(t.TempDir(), "dne"))
+	assert.NotNil(t, err)
+}
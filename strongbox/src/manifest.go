@@ -0,0 +1,236 @@
+package strongbox
+
+import (
+	"bw/core"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// manifest.go records the files extracted when an addon is installed so they can be checked and removed later.
+// manifests live in the nfo store alongside the nfo data, see `nfo_store.go`.
+
+const (
+	FILE_STATUS_MODIFIED = "modified" // installed file has a different size or checksum
+	FILE_STATUS_MISSING  = "missing"  // installed file no longer exists
+	FILE_STATUS_EXTRA    = "extra"    // file exists but wasn't installed by strongbox
+)
+
+// a single difference between an addon's manifest and the addon directories on disk.
+type AddonFileDiff struct {
+	Path   string // "EveryAddon/EveryAddon.lua"
+	Status string
+}
+
+var _ core.ItemInfo = (*AddonFileDiff)(nil)
+
+func (d AddonFileDiff) ItemKeys() []string {
+	return []string{
+		core.ITEM_FIELD_NAME,
+		"status",
+	}
+}
+
+func (d AddonFileDiff) ItemMap() map[string]string {
+	return map[string]string{
+		core.ITEM_FIELD_NAME: d.Path,
+		"status":             d.Status,
+	}
+}
+
+func (d AddonFileDiff) ItemHasChildren() core.ITEM_CHILDREN_LOAD {
+	return core.ITEM_CHILDREN_LOAD_FALSE
+}
+
+func (d AddonFileDiff) ItemChildren(_ *core.App) []core.Result {
+	return nil
+}
+
+// ---
+
+func file_sha256(path PathToFile) (string, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer fh.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, fh)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// returns a manifest entry for the file at `rel_path` within `addons_dir`.
+func make_manifest_entry(addons_dir PathToDir, rel_path string) (ManifestEntry, error) {
+	path := filepath.Join(addons_dir, rel_path)
+	finfo, err := os.Stat(path)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	checksum, err := file_sha256(path)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	return ManifestEntry{Path: filepath.ToSlash(rel_path), SizeBytes: finfo.Size(), SHA256: checksum}, nil
+}
+
+// returns a manifest of the files in `extracted_file_list` as they are now in `addons_dir`, ordered by path.
+// directories are skipped.
+func make_manifest(addons_dir PathToDir, extracted_file_list []string) ([]ManifestEntry, error) {
+	rv := []ManifestEntry{}
+	for _, rel_path := range extracted_file_list {
+		if strings.HasSuffix(rel_path, "/") {
+			continue
+		}
+		entry, err := make_manifest_entry(addons_dir, rel_path)
+		if err != nil {
+			return []ManifestEntry{}, fmt.Errorf("failed to create manifest: %w", err)
+		}
+		rv = append(rv, entry)
+	}
+	slices.SortFunc(rv, func(a, b ManifestEntry) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return rv, nil
+}
+
+// records the files extracted into `addons_dir` for the addon group `group_id`.
+func write_addon_manifest(addons_dir AddonsDir, group_id string, extracted_file_list []string) error {
+	manifest, err := make_manifest(addons_dir.Path, extracted_file_list)
+	if err != nil {
+		return err
+	}
+	return nfo_store().WriteManifest(addons_dir.Path, group_id, manifest)
+}
+
+// returns the files within the given addon directory, relative to the addons directory.
+// nfo files are strongbox's and are skipped.
+func addon_dir_file_list(addons_dir PathToDir, dir_name string) ([]string, error) {
+	rv := []string{}
+	err := filepath.WalkDir(filepath.Join(addons_dir, dir_name), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() == NFO_FILENAME {
+			return nil
+		}
+		rel_path, err := filepath.Rel(addons_dir, path)
+		if err != nil {
+			return err
+		}
+		rv = append(rv, filepath.ToSlash(rel_path))
+		return nil
+	})
+	return rv, err
+}
+
+// compares the manifest for the given addon against the addon directories on disk.
+// differences are ordered by path.
+func VerifyAddon(a Addon) ([]AddonFileDiff, error) {
+	empty_response := []AddonFileDiff{}
+	if a.AddonsDir == nil || a.NFO == nil || a.NFO.GroupID == "" {
+		return empty_response, errors.New("addon wasn't installed by strongbox")
+	}
+	addons_dir := a.AddonsDir.Path
+
+	manifest, err := nfo_store().ReadManifest(addons_dir, a.NFO.GroupID)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	on_disk := map[string]bool{}
+	for _, ia := range a.InstalledAddonGroup {
+		file_list, err := addon_dir_file_list(addons_dir, ia.Name)
+		if err != nil {
+			return empty_response, fmt.Errorf("failed to read addon directory: %w", err)
+		}
+		for _, rel_path := range file_list {
+			on_disk[rel_path] = true
+		}
+	}
+
+	rv := []AddonFileDiff{}
+	for _, entry := range manifest {
+		path := filepath.Join(addons_dir, filepath.FromSlash(entry.Path))
+		if !core.FileExists(path) {
+			rv = append(rv, AddonFileDiff{Path: entry.Path, Status: FILE_STATUS_MISSING})
+			continue
+		}
+		delete(on_disk, entry.Path)
+
+		actual, err := make_manifest_entry(addons_dir, entry.Path)
+		if err != nil {
+			return empty_response, fmt.Errorf("failed to read installed file: %w", err)
+		}
+		if actual.SizeBytes != entry.SizeBytes || actual.SHA256 != entry.SHA256 {
+			rv = append(rv, AddonFileDiff{Path: entry.Path, Status: FILE_STATUS_MODIFIED})
+		}
+	}
+	for rel_path := range on_disk {
+		rv = append(rv, AddonFileDiff{Path: rel_path, Status: FILE_STATUS_EXTRA})
+	}
+
+	slices.SortStableFunc(rv, func(a, b AddonFileDiff) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return rv, nil
+}
+
+// removes the files in `manifest` beneath the addon directory `dir_name` and then any empty directories left behind.
+// files not in the manifest are kept.
+// returns `true` if the addon directory no longer exists.
+func remove_manifest_files(addons_dir PathToDir, dir_name string, manifest []ManifestEntry) (bool, error) {
+	addon_path := filepath.Join(addons_dir, dir_name)
+	for _, entry := range manifest {
+		if !strings.HasPrefix(entry.Path, dir_name+"/") {
+			continue
+		}
+		path := filepath.Join(addons_dir, filepath.FromSlash(entry.Path))
+		if !strings.HasPrefix(path, addon_path+string(os.PathSeparator)) {
+			return false, fmt.Errorf("manifest path is outside of the addon directory: %s", entry.Path)
+		}
+		err := os.Remove(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return false, err
+		}
+	}
+
+	// remove empty directories, deepest first
+	dir_list := []string{}
+	err := filepath.WalkDir(addon_path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			dir_list = append(dir_list, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	slices.Reverse(dir_list)
+	for _, dir := range dir_list {
+		entry_list, err := os.ReadDir(dir)
+		if err != nil {
+			return false, err
+		}
+		if len(entry_list) == 0 {
+			err = os.Remove(dir)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+
+	return !core.DirExists(addon_path), nil
+}
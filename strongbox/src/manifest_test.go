@@ -0,0 +1,142 @@
+package strongbox
+
+import (
+	"bw/core"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// installs the minimal EveryAddon into a new addons dir, returning the installed addon's result.
+func manifest_test_install(t *testing.T) (*core.App, AddonsDir, *core.Result, func()) {
+	tmpdir := t.TempDir()
+	app, stopfn := DummyApp2(tmpdir)
+
+	ad := MakeAddonsDir(filepath.Join(tmpdir, "addons"))
+	_, wg := app.AddItem(NS_ADDONS_DIR, ad)
+	wg.Wait()
+
+	assert.Nil(t, InstallAddonHelper(app, ad))
+
+	r := app.FirstResult(func(r core.Result) bool {
+		return r.NS == NS_ADDON
+	})
+	assert.NotNil(t, r)
+	return app, ad, r, stopfn
+}
+
+// directories are skipped, files are ordered by path
+func Test_make_manifest(t *testing.T) {
+	addons_dir := t.TempDir()
+	make_file_tree(t, addons_dir, map[string]string{
+		"EveryAddon/EveryAddon.toc": "## Title: EveryAddon\n",
+		"EveryAddon/EveryAddon.lua": "",
+	})
+	expected := []ManifestEntry{
+		{Path: "EveryAddon/EveryAddon.lua", SizeBytes: 0, SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}, // sha256 of nothing
+		{Path: "EveryAddon/EveryAddon.toc", SizeBytes: 21},
+	}
+	actual, err := make_manifest(addons_dir, []string{"EveryAddon/", "EveryAddon/EveryAddon.toc", "EveryAddon/EveryAddon.lua"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(actual))
+	assert.Equal(t, expected[0], actual[0])
+	assert.Equal(t, expected[1].Path, actual[1].Path)
+	assert.Equal(t, expected[1].SizeBytes, actual[1].SizeBytes)
+	assert.Len(t, actual[1].SHA256, 64)
+}
+
+// an unmodified addon has no differences, modified, missing and extra files are reported
+func Test_VerifyAddon(t *testing.T) {
+	_, ad, r, stopfn := manifest_test_install(t)
+	defer stopfn()
+	a := r.Item.(Addon)
+
+	diff_list, err := VerifyAddon(a)
+	assert.Nil(t, err)
+	assert.Equal(t, []AddonFileDiff{}, diff_list)
+
+	err = os.WriteFile(filepath.Join(ad.Path, "EveryAddon", "EveryAddon.toc"), []byte("## Title: Modified\n"), 0644)
+	assert.Nil(t, err)
+	err = os.Remove(filepath.Join(ad.Path, "EveryAddon", "EveryAddon.lua"))
+	assert.Nil(t, err)
+	err = os.WriteFile(filepath.Join(ad.Path, "EveryAddon", "UserAdded.lua"), []byte("-- mine"), 0644)
+	assert.Nil(t, err)
+
+	expected := []AddonFileDiff{
+		{Path: "EveryAddon/EveryAddon.lua", Status: FILE_STATUS_MISSING},
+		{Path: "EveryAddon/EveryAddon.toc", Status: FILE_STATUS_MODIFIED},
+		{Path: "EveryAddon/UserAdded.lua", Status: FILE_STATUS_EXTRA},
+	}
+	diff_list, err = VerifyAddon(a)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, diff_list)
+}
+
+// an addon is verified from a result ID, a bad selection is an error and not silently ignored
+func Test_VerifyAddonService(t *testing.T) {
+	app, ad, r, stopfn := manifest_test_install(t)
+	defer stopfn()
+
+	err := os.Remove(filepath.Join(ad.Path, "EveryAddon", "EveryAddon.lua"))
+	assert.Nil(t, err)
+
+	res := VerifyAddonService(app, core.MakeServiceFnArgs("selected", r.ID))
+	assert.Nil(t, res.Err)
+	assert.Equal(t, 1, len(res.Result))
+
+	res = VerifyAddonService(app, core.MakeServiceFnArgs("selected", "foo"))
+	assert.NotNil(t, res.Err)
+}
+
+// only installed files are removed when asked to keep user files
+func Test_RemoveAddon__keep_user_files(t *testing.T) {
+	app, ad, r, stopfn := manifest_test_install(t)
+	defer stopfn()
+
+	user_file := filepath.Join(ad.Path, "EveryAddon", "Config", "UserAdded.lua")
+	make_file_tree(t, ad.Path, map[string]string{
+		"EveryAddon/Config/UserAdded.lua": "-- mine",
+	})
+
+	err := RemoveAddon(app, r, RemoveOpts{KeepUserFiles: true})
+	assert.Nil(t, err)
+
+	assert.FileExists(t, user_file)
+	assert.NoFileExists(t, filepath.Join(ad.Path, "EveryAddon", "EveryAddon.toc"))
+	assert.NoFileExists(t, filepath.Join(ad.Path, "EveryAddon", "EveryAddon.lua"))
+	assert.NoFileExists(t, filepath.Join(ad.Path, "EveryAddon", NFO_FILENAME))
+
+	_, err = nfo_store().ReadManifest(ad.Path, r.Item.(Addon).NFO.GroupID)
+	assert.ErrorIs(t, err, ErrManifestDNE)
+}
+
+// empty directories are removed along with the installed files
+func Test_remove_manifest_files(t *testing.T) {
+	addons_dir := t.TempDir()
+	make_file_tree(t, addons_dir, map[string]string{
+		"EveryAddon/EveryAddon.toc":  "",
+		"EveryAddon/Libs/Lib.lua":    "",
+		"EveryOtherAddon/Other.toc":  "",
+		"EveryAddon_Config/Foo.toc":  "",
+		"EveryAddon_Config/User.lua": "",
+	})
+	manifest := []ManifestEntry{
+		{Path: "EveryAddon/EveryAddon.toc"},
+		{Path: "EveryAddon/Libs/Lib.lua"},
+		{Path: "EveryAddon_Config/Foo.toc"},
+	}
+
+	removed, err := remove_manifest_files(addons_dir, "EveryAddon", manifest)
+	assert.Nil(t, err)
+	assert.True(t, removed)
+	assert.NoDirExists(t, filepath.Join(addons_dir, "EveryAddon"))
+
+	removed, err = remove_manifest_files(addons_dir, "EveryAddon_Config", manifest)
+	assert.Nil(t, err)
+	assert.False(t, removed)
+	assert.FileExists(t, filepath.Join(addons_dir, "EveryAddon_Config", "User.lua"))
+
+	assert.FileExists(t, filepath.Join(addons_dir, "EveryOtherAddon", "Other.toc"))
+}
@@ -65,83 +65,25 @@ type URL = string         // "https://example.org/path/to/file#anchor?foo=bar&ba
 
 type GameTrackID = string
 
+// well known game tracks.
+// everything else strongbox knows about a game track lives in the game track registry, see `game_track.go`.
 const (
 	GAMETRACK_RETAIL        GameTrackID = "retail"
 	GAMETRACK_CLASSIC       GameTrackID = "classic"
 	GAMETRACK_CLASSIC_TBC   GameTrackID = "classic-tbc"
 	GAMETRACK_CLASSIC_WOTLK GameTrackID = "classic-wotlk"
 	GAMETRACK_CLASSIC_CATA  GameTrackID = "classic-cata"
+	GAMETRACK_CLASSIC_MOP   GameTrackID = "classic-mop"
 
-	// dead
+	// dead, see `convert_compound_game_track`
 	GAMETRACK_RETAIL_CLASSIC GameTrackID = "retail-classic"
 	GAMETRACK_CLASSIC_RETAIL GameTrackID = "classic-retail"
 )
 
-var ALL_GAME_TRACKS = mapset.NewSet(
-	GAMETRACK_RETAIL,
-	GAMETRACK_CLASSIC,
-	GAMETRACK_CLASSIC_TBC,
-	GAMETRACK_CLASSIC_WOTLK,
-	GAMETRACK_CLASSIC_CATA,
-	GAMETRACK_RETAIL_CLASSIC,
-	GAMETRACK_CLASSIC_RETAIL,
-)
-
-var SUPPORTED_GAME_TRACKS = mapset.NewSet(
-	GAMETRACK_RETAIL,
-	GAMETRACK_CLASSIC,
-	GAMETRACK_CLASSIC_TBC,
-	GAMETRACK_CLASSIC_WOTLK,
-	GAMETRACK_CLASSIC_CATA,
-)
-
-var SUPPORTED_GAME_TRACKS_LIST = ALL_GAME_TRACKS.ToSlice()
-
-// when game track matching is not-strict, this is the lookup order
-/*
-;; take all of the game tracks to the right of your position
-;; then all to the left.
-;; [1 2 3 4 5 6] => 6 => [6 5 4 3 2 1]
-;; [1 2 3 4 5 6] => 5 => [5 6 4 3 2 1]
-;; [1 2 3 4 5 6] => 4 => [4 5 6 3 2 1]
-;; [1 2 3 4 5 6] => 3 => [3 4 5 6 2 1]
-;; [1 2 3 4 5 6] => 2 => [2 3 4 5 6 1]
-;; [1 2 3 4 5 6] => 1 => [1 2 3 4 5 6]
-*/
-// when `strict?` is `false` and an addon fails to match against a given `game-track`, other game tracks will be checked.
-// the strategy is to assume the next-best game tracks are the ones 'closest' to the given `game-track`, newest to oldest.
-// for example, if a release for wotlk classic is not available and releases for cata, bcc and vanilla are, which to choose?
-// this strategy prioritises cata, then bcc and finally vanilla."
-var GAMETRACK_PREF_MAP = map[GameTrackID][]GameTrackID{
-	GAMETRACK_RETAIL:        {GAMETRACK_RETAIL, GAMETRACK_CLASSIC, GAMETRACK_CLASSIC_TBC, GAMETRACK_CLASSIC_WOTLK, GAMETRACK_CLASSIC_CATA},
-	GAMETRACK_CLASSIC:       {GAMETRACK_CLASSIC, GAMETRACK_CLASSIC_TBC, GAMETRACK_CLASSIC_WOTLK, GAMETRACK_CLASSIC_CATA, GAMETRACK_RETAIL},
-	GAMETRACK_CLASSIC_TBC:   {GAMETRACK_CLASSIC_TBC, GAMETRACK_CLASSIC_WOTLK, GAMETRACK_CLASSIC_CATA, GAMETRACK_CLASSIC, GAMETRACK_RETAIL},
-	GAMETRACK_CLASSIC_WOTLK: {GAMETRACK_CLASSIC_WOTLK, GAMETRACK_CLASSIC_CATA, GAMETRACK_CLASSIC_TBC, GAMETRACK_CLASSIC, GAMETRACK_RETAIL},
-	GAMETRACK_CLASSIC_CATA:  {GAMETRACK_CLASSIC_CATA, GAMETRACK_CLASSIC_WOTLK, GAMETRACK_CLASSIC_TBC, GAMETRACK_CLASSIC, GAMETRACK_RETAIL},
-}
-
+// returns the set of game tracks strongbox supports.
+// see `game_track.go`.
 func gametrack_set() mapset.Set[GameTrackID] {
-	return mapset.NewSetFromMapKeys(GAMETRACK_PREF_MAP)
-}
-
-// mapping of known gametrack aliases to strongbox canonical version
-var GAMETRACK_ALIAS_MAP = map[string]GameTrackID{
-	GAMETRACK_RETAIL: GAMETRACK_RETAIL,
-	"mainline":       GAMETRACK_RETAIL,
-
-	GAMETRACK_CLASSIC: GAMETRACK_CLASSIC,
-	"vanilla":         GAMETRACK_CLASSIC,
-
-	GAMETRACK_CLASSIC_TBC: GAMETRACK_CLASSIC_TBC,
-	"tbc":                 GAMETRACK_CLASSIC_TBC,
-	"bcc":                 GAMETRACK_CLASSIC_TBC,
-
-	GAMETRACK_CLASSIC_WOTLK: GAMETRACK_CLASSIC_WOTLK,
-	"wrath":                 GAMETRACK_CLASSIC_WOTLK,
-	"wotlk":                 GAMETRACK_CLASSIC_WOTLK,
-
-	GAMETRACK_CLASSIC_CATA: GAMETRACK_CLASSIC_CATA,
-	"cata":                 GAMETRACK_CLASSIC_CATA,
+	return game_tracks().IDSet()
 }
 
 // deterministic, unique, IDs for finding strongbox data
@@ -164,24 +106,27 @@ var (
 	NS_ADDON           = core.NS{Major: "strongbox", Minor: "addon", Type: ""}                // a merging of different addon data
 	NS_INSTALLED_ADDON = core.NS{Major: "strongbox", Minor: "addon", Type: "installed-addon"} // an addon within an addons-dir
 	NS_TOC             = core.NS{Major: "strongbox", Minor: "addon", Type: "toc"}             // a .toc file within an installed-addon
+	NS_TOC_FINDING     = core.NS{Major: "strongbox", Minor: "addon", Type: "toc-finding"}     // a problem with a .toc file
+	NS_STALE_ADDON     = core.NS{Major: "strongbox", Minor: "addon", Type: "stale"}           // an addon that looks abandoned
+	NS_ADDON_FILE_DIFF = core.NS{Major: "strongbox", Minor: "addon", Type: "file-diff"}       // a difference between an addon's files and those installed
 
-	NS_SETTINGS = core.NS{Major: "strongbox", Minor: "settings", Type: "preference"} // a mapping of user preferences
-)
+	NS_FOREIGN_ADDON  = core.NS{Major: "strongbox", Minor: "addon", Type: "foreign"}  // an addon managed by another addon manager
+	NS_IMPORTED_ADDON = core.NS{Major: "strongbox", Minor: "addon", Type: "imported"} // the result of importing an exported addon
 
-// extended information about a GameTrack
-type GameTrack struct {
-	ID    GameTrackID // Retail
-	Label string
-	// description ?
-	// original release date ?
-	// classic release date ?
-}
+	NS_CHARACTER       = core.NS{Major: "strongbox", Minor: "wtf", Type: "character"}         // a character with it's own set of enabled addons
+	NS_ADDON_CHARACTER = core.NS{Major: "strongbox", Minor: "addon", Type: "character-state"} // whether an addon is enabled for a character
 
-var (
-	GT_RETAIL        = GameTrack{GAMETRACK_RETAIL, "Retail"}
-	GT_CLASSIC       = GameTrack{GAMETRACK_CLASSIC, "Classic"}
-	GT_CLASSIC_TBC   = GameTrack{GAMETRACK_CLASSIC_TBC, "Classic (TBC)"}
-	GT_CLASSIC_WOTLK = GameTrack{GAMETRACK_CLASSIC_WOTLK, "Classic (WotLK)"}
+	NS_NFO_PROBLEM = core.NS{Major: "strongbox", Minor: "addons-dir", Type: "nfo-problem"} // a problem with the nfo data in an addons-dir
+	NS_DISK_USAGE  = core.NS{Major: "strongbox", Minor: "addons-dir", Type: "disk-usage"}  // the disk space used by something in an addons-dir
+
+	NS_WTF_BACKUP = core.NS{Major: "strongbox", Minor: "wtf", Type: "backup"} // a snapshot of the SavedVariables of an addons-dir
+	NS_WTF_DIFF   = core.NS{Major: "strongbox", Minor: "wtf", Type: "diff"}   // a difference between a snapshot and the current SavedVariables
+
+	NS_ADDONS_DIR_SNAPSHOT = core.NS{Major: "strongbox", Minor: "addons-dir", Type: "snapshot"} // a snapshot of every addon in an addons-dir
+
+	NS_ZIP_STORE_ENTRY = core.NS{Major: "strongbox", Minor: "zip-store", Type: "zip"} // a downloaded addon zip file shared by every addons-dir
+
+	NS_SETTINGS = core.NS{Major: "strongbox", Minor: "settings", Type: "preference"} // a mapping of user preferences
 )
 
 type Source = string
@@ -190,6 +135,7 @@ const (
 	SOURCE_GITHUB Source = "github"
 	SOURCE_GITLAB Source = "gitlab"
 	SOURCE_WOWI   Source = "wowinterface"
+	SOURCE_LOCAL  Source = "local" // a directory or git working tree on the local filesystem. new in 8.0
 
 	// dead
 	SOURCE_CURSEFORGE          Source = "curseforge"
@@ -203,6 +149,7 @@ var SUPPORTED_HOSTS = mapset.NewSet(
 	SOURCE_GITHUB,
 	SOURCE_GITLAB,
 	SOURCE_WOWI,
+	SOURCE_LOCAL,
 )
 
 var SUPPORTED_HOSTS_LIST = SUPPORTED_HOSTS.ToSlice()
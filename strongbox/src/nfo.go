@@ -22,6 +22,7 @@ import (
 // a solution might be to not store these per-directory and instead keep a central database.
 // should that happen we still may not have enough data to create a valid nfo file as we need
 // a catalogue match.
+// 2026-10: a central database now exists, see `nfo_store.go`. it's optional and the per-directory files are still the default.
 
 type NFO struct {
 	InstalledVersion     string      `json:"installed-version,omitempty"`
@@ -30,10 +31,104 @@ type NFO struct {
 	Primary              bool        `json:"primary?"` // TODO: rename IsPrimary
 	Source               Source      `json:"source,omitempty"`
 	InstalledGameTrackID GameTrackID `json:"installed-game-track,omitempty"`
-	SourceID             FlexString  `json:"source-id,omitempty"` // ints become strings, new in v8
+	SourceID             FlexString  `json:"source-id,omitempty"` // ints become strings, new in v8. written as ints for strongbox 7, see `v7_source_id`
 	SourceMapList        []SourceMap `json:"source-map-list,omitempty"`
 	Ignored              *bool       `json:"ignore?,omitempty"` // null means the user hasn't explicitly ignored or explicitly un-ignored it
 	PinnedVersion        string      `json:"pinned-version,omitempty"`
+
+	// new in 8.0, the releases the user wants for this addon.
+	// empty values defer to the addons dir.
+	ReleaseChannel
+}
+
+// nfo values strongbox 7 doesn't accept.
+// strongbox 7 deletes nfo files it considers invalid, so these are kept under a key it ignores, see `NFO.MarshalJSON`.
+type nfo_v8 struct {
+	Source               Source      `json:"source,omitempty"`
+	SourceID             FlexString  `json:"source-id,omitempty"`
+	SourceMapList        []SourceMap `json:"source-map-list,omitempty"`
+	InstalledGameTrackID GameTrackID `json:"installed-game-track,omitempty"`
+}
+
+// a `SourceMap` as strongbox 7 writes it, with int source IDs where strongbox 7 used them.
+type v7_source_map struct {
+	Source   Source `json:"source"`
+	SourceID any    `json:"source-id"`
+}
+
+// writes nfo data strongbox 7 can read.
+// sources and game tracks unknown to strongbox 7 are moved beneath the 'strongbox8' key.
+func (n NFO) MarshalJSON() ([]byte, error) {
+	type nfo_plain NFO
+	plain := nfo_plain(n)
+	v8 := nfo_v8{}
+
+	if n.Source != "" && !V7_SOURCE_SET.Contains(n.Source) {
+		v8.Source = n.Source
+		v8.SourceID = n.SourceID
+		plain.Source = ""
+	}
+
+	if n.InstalledGameTrackID != "" && !V7_GAME_TRACK_SET.Contains(n.InstalledGameTrackID) {
+		v8.InstalledGameTrackID = n.InstalledGameTrackID
+		plain.InstalledGameTrackID = ""
+	}
+
+	source_map_list := []v7_source_map{}
+	for _, sm := range n.SourceMapList {
+		if !V7_SOURCE_SET.Contains(sm.Source) {
+			// the full list is kept, strongbox 7 gets what it can use.
+			v8.SourceMapList = n.SourceMapList
+			continue
+		}
+		source_map_list = append(source_map_list, v7_source_map{Source: sm.Source, SourceID: v7_source_id(sm.Source, sm.SourceID)})
+	}
+
+	data := struct {
+		nfo_plain
+		SourceID      any             `json:"source-id,omitempty"`
+		SourceMapList []v7_source_map `json:"source-map-list,omitempty"`
+		V8            *nfo_v8         `json:"strongbox8,omitempty"`
+	}{nfo_plain: plain, SourceMapList: source_map_list}
+
+	if plain.Source != "" {
+		data.SourceID = v7_source_id(n.Source, n.SourceID)
+	}
+
+	if v8.Source != "" || v8.InstalledGameTrackID != "" || len(v8.SourceMapList) > 0 {
+		data.V8 = &v8
+	}
+
+	return json.Marshal(data)
+}
+
+// reads nfo data written by strongbox 7 or strongbox 8.
+func (n *NFO) UnmarshalJSON(b []byte) error {
+	type nfo_plain NFO
+	data := struct {
+		*nfo_plain
+		V8 *nfo_v8 `json:"strongbox8"`
+	}{nfo_plain: (*nfo_plain)(n)}
+
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+
+	if data.V8 == nil {
+		return nil
+	}
+	if data.V8.Source != "" {
+		n.Source = data.V8.Source
+		n.SourceID = data.V8.SourceID
+	}
+	if len(data.V8.SourceMapList) > 0 {
+		n.SourceMapList = data.V8.SourceMapList
+	}
+	if data.V8.InstalledGameTrackID != "" {
+		n.InstalledGameTrackID = data.V8.InstalledGameTrackID
+	}
+	return nil
 }
 
 func NewNFO() NFO {
@@ -125,6 +220,11 @@ func read_nfo_file(addon_dir PathToAddon) ([]NFO, error) {
 		nfo_list = append(nfo_list, data)
 	}
 
+	return normalise_nfo_list(addon_dir, nfo_list), nil
+}
+
+// basic transformations of nfo data as it's read, regardless of where it was read from.
+func normalise_nfo_list(addon_dir PathToAddon, nfo_list []NFO) []NFO {
 	for _, nfo := range nfo_list {
 		// add a SourceMapList if one isn't present
 		// new in v8: previously only applied to top-level nfo
@@ -135,33 +235,21 @@ func read_nfo_file(addon_dir PathToAddon) ([]NFO, error) {
 
 		// implicitly ignore addon when VCS directory present
 		vcs := version_controlled(addon_dir)
-		if nfo.Ignored != nil && err == nil && vcs {
+		if nfo.Ignored != nil && vcs {
 			slog.Warn("addon directory contains a .git/.hg/.svn folder, ignoring", "addon-dir", addon_dir)
 			ignored := true
 			nfo.Ignored = &ignored
 		}
 	}
-
-	return nfo_list, nil
+	return nfo_list
 }
 
-// "parses the contents of the .nfo file and checks if addon should be ignored or not"
-// failure to load the json results in the file being deleted.
-// failure to validate the json data results in the file being deleted."
-/*
+// reads the nfo data for the given `addon_dir` from the nfo store in use.
+// returns `ErrNFODNE` if there is no nfo data.
+// todo: previous behaviour was to delete file if it contains bad/invalid data
 func read_nfo(addon_dir PathToAddon) ([]NFO, error) {
-	empty_response := []NFO{}
-	nfo_data_list, err := read_nfo_file(addon_dir)
-	if err != nil {
-		// todo: previous behaviour was to delete file if it contains bad/invalid data
-		return empty_response, fmt.Errorf("failed to read NFO data: %w", err)
-	}
-	if len(nfo_data_list) == 0 {
-		slog.Warn("NFO data was empty", "path", addon_dir)
-	}
-	return nfo_data_list, nil
+	return nfo_store().Read(addon_dir)
 }
-*/
 
 func nfo_ignored(nfo NFO) bool {
 	if nfo.Ignored == nil {
@@ -194,7 +282,7 @@ func is_mutual_dependency(nfo_data []NFO) bool {
 // * reading empty nfo from a nfo file was an error until I just commented it out...
 func rm_nfo(addon_path PathToAddon, group_id string) ([]NFO, error) {
 	empty_response := []NFO{}
-	nfo_data_list, err := read_nfo_file(addon_path)
+	nfo_data_list, err := read_nfo(addon_path)
 	if err != nil {
 		// cannot remove nfo data for whatever reason
 		return empty_response, fmt.Errorf("failed to remove nfo data: %w", err)
@@ -212,7 +300,7 @@ func rm_nfo(addon_path PathToAddon, group_id string) ([]NFO, error) {
 // if the nfo doesn't exist, it will be created.
 func add_nfo(addon_path PathToAddon, nfo NFO) ([]NFO, string, error) {
 	empty_response := []NFO{}
-	extant_nfo_list, err := read_nfo_file(addon_path)
+	extant_nfo_list, err := read_nfo(addon_path)
 	if err != nil {
 		if errors.Is(err, ErrNFODNE) {
 			// we can recover!
@@ -264,7 +352,7 @@ func add_nfo(addon_path PathToAddon, nfo NFO) ([]NFO, string, error) {
 	return new_nfo, user_msg, nil
 }
 
-// given an installation directory and an addon, select the neccessary bits (`prune`) and write them to a nfo file
+// given an installation directory and an addon, select the neccessary bits (`prune`) and write them to the nfo store
 func write_nfo(addon_path PathToAddon, nfo_data_list []NFO) error {
 	if len(nfo_data_list) == 0 {
 		return fmt.Errorf("refusing to write nfo data to disk: nfo data is empty")
@@ -283,14 +371,7 @@ func write_nfo(addon_path PathToAddon, nfo_data_list []NFO) error {
 		return fmt.Errorf("refusing to write nfo data to disk: nfo data is invalid: %w", err)
 	}
 
-	path := nfo_path(addon_path)
-
-	bytes, err := json.Marshal(nfo_data_list)
-	if err != nil {
-		return fmt.Errorf("failed to marshal nfo data: %w", err)
-	}
-
-	err = core.Spit(path, bytes)
+	err := nfo_store().Write(addon_path, nfo_data_list)
 	if err != nil {
 		return fmt.Errorf("failed to write nfo data to disk: %w", err)
 	}
@@ -321,6 +402,9 @@ func derive_nfo(a Addon, is_primary bool) NFO {
 
 	nfo.PinnedVersion = a.PinnedVersion
 
+	// the user's preferred releases for this addon
+	nfo.ReleaseChannel = a.ReleaseChannel
+
 	if a.Source == "" || a.SourceID == "" || a.SourceUpdate == nil {
 		// any one of these conditions means we can't generate a complete NFO file - we're missing vital data.
 		// our next best bet is a 'just grouped' nfo file that contains just enough information to group related addons together.
@@ -0,0 +1,379 @@
+package strongbox
+
+import (
+	"bw/core"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+// nfo_repair.go finds problems with the nfo data in an addons directory and fixes them.
+// fixes are only applied when confirmed and the original nfo data is backed up first.
+
+const (
+	NFO_PROBLEM_BAD_JSON               = "bad-json"               // nfo data can't be read at all
+	NFO_PROBLEM_INVALID                = "invalid"                // nfo data is valid under neither the full nor the 'just grouped' schema
+	NFO_PROBLEM_MULTIPLE_PRIMARIES     = "multiple-primaries"     // more than one directory in a group is the primary
+	NFO_PROBLEM_ORPHAN_GROUP           = "orphan-group"           // no directory in a group is the primary
+	NFO_PROBLEM_UNSUPPORTED_GAME_TRACK = "unsupported-game-track" // installed game track isn't known to the game track registry
+)
+
+const (
+	NFO_REPAIR_FIXED  = "fixed"
+	NFO_REPAIR_FAILED = "failed"
+)
+
+// a problem with the nfo data of one or more addon directories and how it will be fixed.
+type NFOProblem struct {
+	AddonsDir   PathToDir
+	DirNameList []string // affected addon directories, "EveryAddon", "EveryAddon_Config"
+	GroupID     string   // empty for problems with the whole file
+	Problem     string
+	Detail      string
+	Fix         string // what will happen when the problem is fixed
+	Status      string // empty until a fix is attempted
+	Error       string // set when status is 'failed'
+
+	primary string // the directory to make the primary, if any
+}
+
+var _ core.ItemInfo = (*NFOProblem)(nil)
+
+func (p NFOProblem) ItemKeys() []string {
+	return []string{
+		core.ITEM_FIELD_NAME,
+		"problem",
+		core.ITEM_FIELD_DESC,
+		"fix",
+		"status",
+	}
+}
+
+func (p NFOProblem) ItemMap() map[string]string {
+	desc := p.Detail
+	if p.Error != "" {
+		desc = p.Error
+	}
+	return map[string]string{
+		core.ITEM_FIELD_NAME: strings.Join(p.DirNameList, ", "),
+		"problem":            p.Problem,
+		core.ITEM_FIELD_DESC: desc,
+		"fix":                p.Fix,
+		"status":             p.Status,
+	}
+}
+
+func (p NFOProblem) ItemHasChildren() core.ITEM_CHILDREN_LOAD {
+	return core.ITEM_CHILDREN_LOAD_FALSE
+}
+
+func (p NFOProblem) ItemChildren(_ *core.App) []core.Result {
+	return nil
+}
+
+// ---
+
+// returns `true` if `nfo` is valid under the full or the 'just grouped' schema.
+func nfo_valid(nfo NFO) (bool, string) {
+	err1 := _nfo_schema.Validate(&nfo)
+	if err1 == nil {
+		return true, ""
+	}
+	err2 := _nfo_just_grouped_schema.Validate(&nfo)
+	if err2 == nil {
+		return true, ""
+	}
+	return false, format_spec_err(err1)
+}
+
+// finds problems with the nfo data of every addon in `addons_dir`.
+// problems are ordered by directory name.
+func ScanNFO(addons_dir AddonsDir) ([]NFOProblem, error) {
+	empty_response := []NFOProblem{}
+	dir_list, err := core.DirList(addons_dir.Path)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to scan nfo data: %w", err)
+	}
+
+	rv := []NFOProblem{}
+
+	type group_member struct {
+		dir_name string
+		primary  bool
+		owner    bool // group is the last, and so the effective, nfo in the directory
+	}
+	group_idx := map[string][]group_member{}
+	group_id_list := []string{}
+
+	for _, addon_dir := range dir_list {
+		if BlizzardAddon(addon_dir) {
+			continue
+		}
+		dir_name := filepath.Base(addon_dir)
+		nfo_list, err := nfo_store().Read(addon_dir)
+		if err != nil {
+			if errors.Is(err, ErrNFODNE) {
+				continue
+			}
+			rv = append(rv, NFOProblem{
+				AddonsDir:   addons_dir.Path,
+				DirNameList: []string{dir_name},
+				Problem:     NFO_PROBLEM_BAD_JSON,
+				Detail:      err.Error(),
+				Fix:         "delete nfo data",
+			})
+			continue
+		}
+
+		for i, nfo := range nfo_list {
+			if nfo.InstalledGameTrackID != "" && !game_tracks().Supported(nfo.InstalledGameTrackID) {
+				rv = append(rv, NFOProblem{
+					AddonsDir:   addons_dir.Path,
+					DirNameList: []string{dir_name},
+					GroupID:     nfo.GroupID,
+					Problem:     NFO_PROBLEM_UNSUPPORTED_GAME_TRACK,
+					Detail:      fmt.Sprintf("unsupported game track: %s", nfo.InstalledGameTrackID),
+					Fix:         fmt.Sprintf("set game track to %s", addons_dir.GameTrackID),
+				})
+				// may be valid once fixed, the next scan will tell
+				continue
+			}
+
+			valid, detail := nfo_valid(nfo)
+			if !valid {
+				rv = append(rv, NFOProblem{
+					AddonsDir:   addons_dir.Path,
+					DirNameList: []string{dir_name},
+					GroupID:     nfo.GroupID,
+					Problem:     NFO_PROBLEM_INVALID,
+					Detail:      detail,
+					Fix:         "remove invalid nfo data",
+				})
+				continue
+			}
+
+			if _, present := group_idx[nfo.GroupID]; !present {
+				group_id_list = append(group_id_list, nfo.GroupID)
+			}
+			group_idx[nfo.GroupID] = append(group_idx[nfo.GroupID], group_member{
+				dir_name: dir_name,
+				primary:  nfo.Primary,
+				owner:    i == len(nfo_list)-1,
+			})
+		}
+	}
+
+	for _, group_id := range group_id_list {
+		member_list := group_idx[group_id]
+		dir_name_list := []string{}
+		primary_list := []string{}
+		owner_list := []string{}
+		for _, m := range member_list {
+			dir_name_list = append(dir_name_list, m.dir_name)
+			if m.primary {
+				primary_list = append(primary_list, m.dir_name)
+			}
+			if m.owner {
+				owner_list = append(owner_list, m.dir_name)
+			}
+		}
+
+		switch {
+		case len(primary_list) > 1:
+			primary := pick_primary_dir(primary_list)
+			rv = append(rv, NFOProblem{
+				AddonsDir:   addons_dir.Path,
+				DirNameList: dir_name_list,
+				GroupID:     group_id,
+				Problem:     NFO_PROBLEM_MULTIPLE_PRIMARIES,
+				Detail:      fmt.Sprintf("primary directories: %s", strings.Join(primary_list, ", ")),
+				Fix:         fmt.Sprintf("make %s the only primary", primary),
+				primary:     primary,
+			})
+
+		case len(primary_list) == 0 && len(owner_list) > 0:
+			primary := pick_primary_dir(owner_list)
+			rv = append(rv, NFOProblem{
+				AddonsDir:   addons_dir.Path,
+				DirNameList: dir_name_list,
+				GroupID:     group_id,
+				Problem:     NFO_PROBLEM_ORPHAN_GROUP,
+				Detail:      "no primary directory",
+				Fix:         fmt.Sprintf("make %s the primary", primary),
+				primary:     primary,
+			})
+
+		case len(primary_list) == 0:
+			// the group's primary directory is gone and what's left is overridden by other addons
+			rv = append(rv, NFOProblem{
+				AddonsDir:   addons_dir.Path,
+				DirNameList: dir_name_list,
+				GroupID:     group_id,
+				Problem:     NFO_PROBLEM_ORPHAN_GROUP,
+				Detail:      "no primary directory and every directory belongs to another addon",
+				Fix:         "remove the group's nfo data",
+			})
+		}
+	}
+
+	slices.SortStableFunc(rv, func(a, b NFOProblem) int {
+		return strings.Compare(a.DirNameList[0], b.DirNameList[0])
+	})
+	return rv, nil
+}
+
+// writes `nfo_list` for `addon_dir`, removing the nfo data entirely if the list is empty.
+func write_or_remove_nfo(addon_dir PathToAddon, nfo_list []NFO) error {
+	if len(nfo_list) == 0 {
+		return nfo_store().Remove(addon_dir)
+	}
+	return nfo_store().Write(addon_dir, nfo_list)
+}
+
+// applies `fn` to every nfo in the given addon directories belonging to `group_id`.
+// nfo for which `fn` returns `false` are dropped.
+func update_nfo_group(addons_dir PathToDir, dir_name_list []string, group_id string, fn func(string, *NFO) bool) error {
+	for _, dir_name := range dir_name_list {
+		addon_dir := filepath.Join(addons_dir, dir_name)
+		nfo_list, err := nfo_store().Read(addon_dir)
+		if err != nil {
+			return err
+		}
+		new_nfo_list := []NFO{}
+		for _, nfo := range nfo_list {
+			if nfo.GroupID == group_id && !fn(dir_name, &nfo) {
+				continue
+			}
+			new_nfo_list = append(new_nfo_list, nfo)
+		}
+		err = write_or_remove_nfo(addon_dir, new_nfo_list)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fixes the given problem.
+func fix_nfo_problem(addons_dir AddonsDir, p NFOProblem) error {
+	switch p.Problem {
+	case NFO_PROBLEM_BAD_JSON:
+		return nfo_store().Remove(filepath.Join(p.AddonsDir, p.DirNameList[0]))
+
+	case NFO_PROBLEM_INVALID:
+		return update_nfo_group(p.AddonsDir, p.DirNameList, p.GroupID, func(_ string, nfo *NFO) bool {
+			valid, _ := nfo_valid(*nfo)
+			return valid
+		})
+
+	case NFO_PROBLEM_UNSUPPORTED_GAME_TRACK:
+		return update_nfo_group(p.AddonsDir, p.DirNameList, p.GroupID, func(_ string, nfo *NFO) bool {
+			nfo.InstalledGameTrackID = addons_dir.GameTrackID
+			return true
+		})
+
+	case NFO_PROBLEM_MULTIPLE_PRIMARIES, NFO_PROBLEM_ORPHAN_GROUP:
+		if p.primary == "" {
+			// nothing to keep
+			return update_nfo_group(p.AddonsDir, p.DirNameList, p.GroupID, func(_ string, _ *NFO) bool {
+				return false
+			})
+		}
+		return update_nfo_group(p.AddonsDir, p.DirNameList, p.GroupID, func(dir_name string, nfo *NFO) bool {
+			nfo.Primary = dir_name == p.primary
+			return true
+		})
+	}
+	slog.Error("unhandled nfo problem", "problem", p.Problem)
+	panic("programming error")
+}
+
+// copies the nfo data of the given addon directories into `backup_dir`.
+// nfo files are copied as-is, including any that can't be read.
+func backup_nfo(backup_dir PathToDir, addons_dir PathToDir, dir_name_list []string) error {
+	err := os.MkdirAll(backup_dir, 0755)
+	if err != nil {
+		return err
+	}
+	for _, dir_name := range dir_name_list {
+		addon_dir := filepath.Join(addons_dir, dir_name)
+		output := filepath.Join(backup_dir, dir_name+".json")
+
+		var data []byte
+		if core.FileExists(nfo_path(addon_dir)) {
+			data, err = os.ReadFile(nfo_path(addon_dir))
+		} else {
+			var nfo_list []NFO
+			nfo_list, err = nfo_store().Read(addon_dir)
+			if err == nil {
+				data, err = json.Marshal(nfo_list)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to back up nfo data for %s: %w", dir_name, err)
+		}
+		err = os.WriteFile(output, data, 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backs up the nfo data affected by `problem_list` to a new directory beneath `backup_root` and fixes each problem.
+// returns the backup directory and the problems with their status.
+func RepairNFO(backup_root PathToDir, addons_dir AddonsDir, problem_list []NFOProblem, now time.Time) (PathToDir, []NFOProblem, error) {
+	if len(problem_list) == 0 {
+		return "", problem_list, nil
+	}
+
+	dir_name_set := mapset.NewSet[string]()
+	for _, p := range problem_list {
+		dir_name_set.Append(p.DirNameList...)
+	}
+	dir_name_list := dir_name_set.ToSlice()
+	slices.Sort(dir_name_list)
+
+	backup_dir := filepath.Join(backup_root, slugify(addons_dir.Path), now.UTC().Format(BACKUP_TIME_FORMAT))
+	err := backup_nfo(backup_dir, addons_dir.Path, dir_name_list)
+	if err != nil {
+		return "", problem_list, fmt.Errorf("refusing to repair nfo data, backup failed: %w", err)
+	}
+	slog.Info("backed up nfo data", "addons-dir", addons_dir.Path, "backup-dir", backup_dir, "num-dirs", len(dir_name_list))
+
+	rv := []NFOProblem{}
+	for _, p := range problem_list {
+		err := fix_nfo_problem(addons_dir, p)
+		if err != nil {
+			slog.Error("failed to repair nfo data", "dir-list", p.DirNameList, "problem", p.Problem, "error", err)
+			p.Status = NFO_REPAIR_FAILED
+			p.Error = err.Error()
+		} else {
+			p.Status = NFO_REPAIR_FIXED
+		}
+		rv = append(rv, p)
+	}
+	return backup_dir, rv, nil
+}
+
+// scans the nfo data in `addons_dir` for problems, fixing them if `confirmed`.
+func RepairAddonsDir(app *core.App, addons_dir AddonsDir, confirmed bool) ([]NFOProblem, error) {
+	problem_list, err := ScanNFO(addons_dir)
+	if err != nil {
+		return []NFOProblem{}, err
+	}
+	if !confirmed || len(problem_list) == 0 {
+		return problem_list, nil
+	}
+	backup_root := app.State.GetKeyVal("strongbox.paths.nfo-backup-dir")
+	_, problem_list, err = RepairNFO(backup_root, addons_dir, problem_list, time.Now())
+	return problem_list, err
+}
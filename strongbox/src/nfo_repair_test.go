@@ -0,0 +1,121 @@
+package strongbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func nfo_repair_test_addons_dir(t *testing.T) AddonsDir {
+	addons_dir := AddonsDir{Path: t.TempDir(), GameTrackID: GAMETRACK_RETAIL}
+	make_file_tree(t, addons_dir.Path, map[string]string{
+		"Bad/.strongbox.json":           `{not json`,
+		"Invalid/.strongbox.json":       `{"group-id": "https://invalid", "name": "invalid"}`,
+		"Multi/.strongbox.json":         `{"group-id": "https://multi", "primary?": true}`,
+		"Multi_Config/.strongbox.json":  `{"group-id": "https://multi", "primary?": true}`,
+		"OldTrack/.strongbox.json":      `{"installed-version": "1.0", "name": "oldtrack", "group-id": "https://oldtrack", "primary?": true, "source": "wowinterface", "installed-game-track": "foo", "source-id": "1", "source-map-list": [{"source": "wowinterface", "source-id": "1"}]}`,
+		"Orphan_Config/.strongbox.json": `{"group-id": "https://orphan", "primary?": false}`,
+		"Fine/.strongbox.json":          `{"group-id": "https://fine", "primary?": true}`,
+		"NoNFO/NoNFO.toc":               "## Title: NoNFO\n",
+	})
+	return addons_dir
+}
+
+// each kind of problem is found and a fix proposed
+func Test_ScanNFO(t *testing.T) {
+	addons_dir := nfo_repair_test_addons_dir(t)
+	problem_list, err := ScanNFO(addons_dir)
+	assert.Nil(t, err)
+
+	type short struct {
+		dir_name_list []string
+		problem       string
+		primary       string
+	}
+	expected := []short{
+		{[]string{"Bad"}, NFO_PROBLEM_BAD_JSON, ""},
+		{[]string{"Invalid"}, NFO_PROBLEM_INVALID, ""},
+		{[]string{"Multi", "Multi_Config"}, NFO_PROBLEM_MULTIPLE_PRIMARIES, "Multi"},
+		{[]string{"OldTrack"}, NFO_PROBLEM_UNSUPPORTED_GAME_TRACK, ""},
+		{[]string{"Orphan_Config"}, NFO_PROBLEM_ORPHAN_GROUP, "Orphan_Config"},
+	}
+	actual := []short{}
+	for _, p := range problem_list {
+		assert.NotEmpty(t, p.Fix)
+		assert.Empty(t, p.Status)
+		actual = append(actual, short{p.DirNameList, p.Problem, p.primary})
+	}
+	assert.Equal(t, expected, actual)
+}
+
+// original nfo data is backed up and a second scan finds nothing
+func Test_RepairNFO(t *testing.T) {
+	addons_dir := nfo_repair_test_addons_dir(t)
+	problem_list, err := ScanNFO(addons_dir)
+	assert.Nil(t, err)
+
+	backup_root := t.TempDir()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	backup_dir, fixed_list, err := RepairNFO(backup_root, addons_dir, problem_list, now)
+	assert.Nil(t, err)
+	assert.Equal(t, len(problem_list), len(fixed_list))
+	for _, p := range fixed_list {
+		assert.Equal(t, NFO_REPAIR_FIXED, p.Status, p.Problem)
+	}
+
+	// originals are kept as-is, even the unreadable ones
+	assert.Equal(t, filepath.Join(backup_root, slugify(addons_dir.Path), "20260102T030405Z"), backup_dir)
+	data, err := os.ReadFile(filepath.Join(backup_dir, "Bad.json"))
+	assert.Nil(t, err)
+	assert.Equal(t, "{not json", string(data))
+	assert.FileExists(t, filepath.Join(backup_dir, "Multi_Config.json"))
+	assert.NoFileExists(t, filepath.Join(backup_dir, "Fine.json"))
+
+	problem_list, err = ScanNFO(addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []NFOProblem{}, problem_list)
+
+	// unreadable and invalid nfo data is gone
+	assert.NoFileExists(t, filepath.Join(addons_dir.Path, "Bad", NFO_FILENAME))
+	assert.NoFileExists(t, filepath.Join(addons_dir.Path, "Invalid", NFO_FILENAME))
+
+	nfo_list, err := read_nfo(filepath.Join(addons_dir.Path, "Multi_Config"))
+	assert.Nil(t, err)
+	assert.False(t, nfo_list[0].Primary)
+
+	nfo_list, err = read_nfo(filepath.Join(addons_dir.Path, "OldTrack"))
+	assert.Nil(t, err)
+	assert.Equal(t, GAMETRACK_RETAIL, nfo_list[0].InstalledGameTrackID)
+}
+
+// groups that only exist beneath the nfo of other addons are removed
+func Test_RepairNFO__shadowed_orphan(t *testing.T) {
+	addons_dir := AddonsDir{Path: t.TempDir(), GameTrackID: GAMETRACK_RETAIL}
+	make_file_tree(t, addons_dir.Path, map[string]string{
+		"Libs/.strongbox.json": `[{"group-id": "https://gone", "primary?": false}, {"group-id": "https://libs", "primary?": true}]`,
+	})
+	problem_list, err := ScanNFO(addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(problem_list))
+	assert.Equal(t, NFO_PROBLEM_ORPHAN_GROUP, problem_list[0].Problem)
+	assert.Equal(t, "https://gone", problem_list[0].GroupID)
+
+	_, _, err = RepairNFO(t.TempDir(), addons_dir, problem_list, time.Now())
+	assert.Nil(t, err)
+
+	nfo_list, err := read_nfo(filepath.Join(addons_dir.Path, "Libs"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(nfo_list))
+	assert.Equal(t, "https://libs", nfo_list[0].GroupID)
+}
+
+// invalid nfo data comes with the reasons it is invalid
+func Test_nfo_valid(t *testing.T) {
+	nfo := NFO{GroupID: "https://foo", Name: "foo"}
+	valid, detail := nfo_valid(nfo)
+	assert.False(t, valid)
+	assert.Contains(t, detail, "InstalledVersion:")
+}
@@ -0,0 +1,531 @@
+package strongbox
+
+import (
+	"bw/core"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// nfo_store.go is where nfo data lives.
+// by default it's a .strongbox.json file in each addon directory,
+// but it can also be kept in a single database file in the data directory.
+// a central database survives users deleting addon directories by hand and isn't left behind in the addons directory.
+
+const (
+	NFO_STORE_FILE     = "file"     // .strongbox.json files in each addon directory, the default
+	NFO_STORE_DATABASE = "database" // a single file in the strongbox data directory
+)
+
+var ErrManifestDNE = errors.New("manifest does not exist")
+
+// a file extracted into the addons directory when an addon was installed.
+type ManifestEntry struct {
+	Path      string `json:"path"` // relative to the addons directory, "EveryAddon/EveryAddon.toc"
+	SizeBytes int64  `json:"size-bytes"`
+	SHA256    string `json:"sha256,omitempty"`
+}
+
+// somewhere to read and write nfo data and file manifests.
+// nfo data is per addon directory, manifests are per group of addon directories.
+type NFOStore interface {
+	Read(addon_dir PathToAddon) ([]NFO, error) // returns `ErrNFODNE` if there is no nfo data
+	Write(addon_dir PathToAddon, nfo_list []NFO) error
+	Remove(addon_dir PathToAddon) error
+	ReadManifest(addons_dir PathToDir, group_id string) ([]ManifestEntry, error) // returns `ErrManifestDNE` if there is no manifest
+	WriteManifest(addons_dir PathToDir, group_id string, manifest []ManifestEntry) error
+	RemoveManifest(addons_dir PathToDir, group_id string) error
+}
+
+// --- file store
+
+// nfo data in .strongbox.json files in each addon directory.
+// manifests can't live in any one addon directory so they live in `ManifestDir`.
+type NFOFileStore struct {
+	ManifestDir PathToDir
+}
+
+var _ NFOStore = (*NFOFileStore)(nil)
+
+func (fs NFOFileStore) Read(addon_dir PathToAddon) ([]NFO, error) {
+	return read_nfo_file(addon_dir)
+}
+
+// a single nfo is written as an object rather than a list, like strongbox 7.
+func (fs NFOFileStore) Write(addon_dir PathToAddon, nfo_list []NFO) error {
+	var data any = nfo_list
+	if len(nfo_list) == 1 {
+		data = nfo_list[0]
+	}
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal nfo data: %w", err)
+	}
+	return core.Spit(nfo_path(addon_dir), bytes)
+}
+
+func (fs NFOFileStore) Remove(addon_dir PathToAddon) error {
+	err := os.Remove(nfo_path(addon_dir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// "/path/to/data/manifests/path-to-addons-dir/https-github-com-ogri-la-everyaddon.json"
+func (fs NFOFileStore) manifest_path(addons_dir PathToDir, group_id string) (PathToFile, error) {
+	if fs.ManifestDir == "" {
+		return "", errors.New("no manifest directory")
+	}
+	return filepath.Join(fs.ManifestDir, slugify(addons_dir), slugify(group_id)+".json"), nil
+}
+
+func (fs NFOFileStore) ReadManifest(addons_dir PathToDir, group_id string) ([]ManifestEntry, error) {
+	empty_response := []ManifestEntry{}
+	path, err := fs.manifest_path(addons_dir, group_id)
+	if err != nil {
+		return empty_response, err
+	}
+	if !core.FileExists(path) {
+		return empty_response, ErrManifestDNE
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return empty_response, err
+	}
+	rv := []ManifestEntry{}
+	err = json.Unmarshal(data, &rv)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return rv, nil
+}
+
+func (fs NFOFileStore) WriteManifest(addons_dir PathToDir, group_id string, manifest []ManifestEntry) error {
+	path, err := fs.manifest_path(addons_dir, group_id)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	return core.Spit(path, data)
+}
+
+func (fs NFOFileStore) RemoveManifest(addons_dir PathToDir, group_id string) error {
+	path, err := fs.manifest_path(addons_dir, group_id)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// --- database store
+
+// the contents of the nfo database file.
+type nfo_db_data struct {
+	NFOIdx      map[PathToDir]map[string][]NFO           `json:"nfo"`      // {addons-dir: {addon-dir-name: [nfo, ...]}}
+	ManifestIdx map[PathToDir]map[string][]ManifestEntry `json:"manifest"` // {addons-dir: {group-id: [entry, ...]}}
+}
+
+// nfo data and manifests for every addons directory in a single json file.
+// the whole file is read when opened and written on every change.
+type NFODatabase struct {
+	path PathToFile
+	lock sync.Mutex
+	data nfo_db_data
+}
+
+var _ NFOStore = (*NFODatabase)(nil)
+
+// reads the nfo database at `path`, creating an empty one if it doesn't exist.
+func OpenNFODatabase(path PathToFile) (*NFODatabase, error) {
+	db := &NFODatabase{
+		path: path,
+		data: nfo_db_data{
+			NFOIdx:      map[PathToDir]map[string][]NFO{},
+			ManifestIdx: map[PathToDir]map[string][]ManifestEntry{},
+		},
+	}
+	if !core.FileExists(path) {
+		return db, nil
+	}
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nfo database: %w", err)
+	}
+	err = json.Unmarshal(bytes, &db.data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nfo database: %w", err)
+	}
+	if db.data.NFOIdx == nil {
+		db.data.NFOIdx = map[PathToDir]map[string][]NFO{}
+	}
+	if db.data.ManifestIdx == nil {
+		db.data.ManifestIdx = map[PathToDir]map[string][]ManifestEntry{}
+	}
+	return db, nil
+}
+
+// writes the database to disk.
+// the lock must be held.
+func (db *NFODatabase) save() error {
+	bytes, err := json.Marshal(db.data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal nfo database: %w", err)
+	}
+	err = os.MkdirAll(filepath.Dir(db.path), 0755)
+	if err != nil {
+		return err
+	}
+	tmp := db.path + ".tmp"
+	err = os.WriteFile(tmp, bytes, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write nfo database: %w", err)
+	}
+	return os.Rename(tmp, db.path)
+}
+
+// "/path/to/addons/EveryAddon" => "/path/to/addons", "EveryAddon"
+func nfo_db_key(addon_dir PathToAddon) (PathToDir, string) {
+	addon_dir = filepath.Clean(addon_dir)
+	return filepath.Dir(addon_dir), filepath.Base(addon_dir)
+}
+
+func (db *NFODatabase) Read(addon_dir PathToAddon) ([]NFO, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	addons_dir, dir_name := nfo_db_key(addon_dir)
+	nfo_list, present := db.data.NFOIdx[addons_dir][dir_name]
+	if !present {
+		return []NFO{}, ErrNFODNE
+	}
+	return normalise_nfo_list(addon_dir, clone_nfo_list(nfo_list)), nil
+}
+
+func (db *NFODatabase) Write(addon_dir PathToAddon, nfo_list []NFO) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	addons_dir, dir_name := nfo_db_key(addon_dir)
+	if db.data.NFOIdx[addons_dir] == nil {
+		db.data.NFOIdx[addons_dir] = map[string][]NFO{}
+	}
+	db.data.NFOIdx[addons_dir][dir_name] = clone_nfo_list(nfo_list)
+	return db.save()
+}
+
+func (db *NFODatabase) Remove(addon_dir PathToAddon) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	addons_dir, dir_name := nfo_db_key(addon_dir)
+	if _, present := db.data.NFOIdx[addons_dir][dir_name]; !present {
+		return nil
+	}
+	delete(db.data.NFOIdx[addons_dir], dir_name)
+	if len(db.data.NFOIdx[addons_dir]) == 0 {
+		delete(db.data.NFOIdx, addons_dir)
+	}
+	return db.save()
+}
+
+func (db *NFODatabase) ReadManifest(addons_dir PathToDir, group_id string) ([]ManifestEntry, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	manifest, present := db.data.ManifestIdx[filepath.Clean(addons_dir)][group_id]
+	if !present {
+		return []ManifestEntry{}, ErrManifestDNE
+	}
+	return append([]ManifestEntry{}, manifest...), nil
+}
+
+func (db *NFODatabase) WriteManifest(addons_dir PathToDir, group_id string, manifest []ManifestEntry) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	addons_dir = filepath.Clean(addons_dir)
+	if db.data.ManifestIdx[addons_dir] == nil {
+		db.data.ManifestIdx[addons_dir] = map[string][]ManifestEntry{}
+	}
+	db.data.ManifestIdx[addons_dir][group_id] = append([]ManifestEntry{}, manifest...)
+	return db.save()
+}
+
+func (db *NFODatabase) RemoveManifest(addons_dir PathToDir, group_id string) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	addons_dir = filepath.Clean(addons_dir)
+	if _, present := db.data.ManifestIdx[addons_dir][group_id]; !present {
+		return nil
+	}
+	delete(db.data.ManifestIdx[addons_dir], group_id)
+	if len(db.data.ManifestIdx[addons_dir]) == 0 {
+		delete(db.data.ManifestIdx, addons_dir)
+	}
+	return db.save()
+}
+
+// copies `nfo_list` so callers can't modify the database's copy.
+func clone_nfo_list(nfo_list []NFO) []NFO {
+	rv := make([]NFO, len(nfo_list))
+	for i, nfo := range nfo_list {
+		if nfo.SourceMapList != nil {
+			nfo.SourceMapList = append([]SourceMap{}, nfo.SourceMapList...)
+		}
+		if nfo.Ignored != nil {
+			nfo.Ignored = new(*nfo.Ignored)
+		}
+		rv[i] = nfo
+	}
+	return rv
+}
+
+// --- the store in use
+
+var nfo_store_in_use atomic.Pointer[NFOStore]
+
+// returns the nfo store currently in use.
+func nfo_store() NFOStore {
+	s := nfo_store_in_use.Load()
+	if s == nil {
+		var default_store NFOStore = NFOFileStore{}
+		nfo_store_in_use.CompareAndSwap(nil, &default_store)
+		s = nfo_store_in_use.Load()
+	}
+	return *s
+}
+
+// replaces the nfo store in use with `store`.
+func set_nfo_store(store NFOStore) {
+	nfo_store_in_use.Store(&store)
+}
+
+// returns a new nfo store of the given `store_type`.
+// an empty `store_type` is a file store.
+func make_nfo_store(app *core.App, store_type string) (NFOStore, error) {
+	paths := get_paths(app)
+	switch store_type {
+	case "", NFO_STORE_FILE:
+		return NFOFileStore{ManifestDir: paths["strongbox.paths.manifest-dir"]}, nil
+	case NFO_STORE_DATABASE:
+		return OpenNFODatabase(paths["strongbox.paths.nfo-db-file"])
+	}
+	return nil, fmt.Errorf("unknown nfo store: %s", store_type)
+}
+
+// returns true if the nfo database at `path` has nfo data for any addons directory.
+func nfo_database_has_data(path PathToFile) bool {
+	if !core.FileExists(path) {
+		return false
+	}
+	db, err := OpenNFODatabase(path)
+	if err != nil {
+		slog.Warn("failed to read nfo database", "path", path, "error", err)
+		return false
+	}
+	return len(db.data.NFOIdx) > 0
+}
+
+// sets the nfo store in use from the user's preferences, falling back to a file store.
+// returns the nfo store preference, which may differ from `store_type` if the preference was lost.
+func configure_nfo_store(app *core.App, store_type string) string {
+	// strongbox 7 drops the strongbox 8 settings when it saves a shared settings file, including the nfo store preference.
+	// switching back to nfo files empties the database, so a database with nfo data means the preference was lost.
+	db_file := get_paths(app)["strongbox.paths.nfo-db-file"]
+	if store_type == "" && nfo_database_has_data(db_file) {
+		slog.Warn("nfo store preference missing but the nfo database has nfo data, using the nfo database. settings may have been saved by strongbox 7", "nfo-db-file", db_file)
+		store_type = NFO_STORE_DATABASE
+	}
+
+	store, err := make_nfo_store(app, store_type)
+	if err != nil {
+		slog.Error("failed to open nfo store, using nfo files", "nfo-store", store_type, "error", err)
+		store, _ = make_nfo_store(app, NFO_STORE_FILE)
+	}
+	set_nfo_store(store)
+	return store_type
+}
+
+// --- migration
+
+// the addon directories and groups whose nfo data and manifests were copied from one store to another.
+type nfo_migration struct {
+	addons_dir     PathToDir
+	addon_dir_list []PathToAddon
+	group_id_list  []string
+}
+
+// copies the nfo data and manifests of every addon in `addons_dir` from one store to another.
+// nothing is removed from `from`, see `remove_nfo`.
+// if copying fails, whatever was copied is removed from `to` again.
+func copy_nfo(from NFOStore, to NFOStore, addons_dir PathToDir) (nfo_migration, error) {
+	migration := nfo_migration{addons_dir: addons_dir}
+	dir_list, err := core.DirList(addons_dir)
+	if err != nil {
+		return migration, fmt.Errorf("failed to migrate nfo data: %w", err)
+	}
+
+	rollback := func(err error) (nfo_migration, error) {
+		remove_nfo(to, migration)
+		return nfo_migration{addons_dir: addons_dir}, err
+	}
+
+	group_id_set := map[string]bool{}
+	for _, addon_dir := range dir_list {
+		if BlizzardAddon(addon_dir) {
+			continue
+		}
+		nfo_list, err := from.Read(addon_dir)
+		if err != nil {
+			if errors.Is(err, ErrNFODNE) {
+				continue
+			}
+			return rollback(fmt.Errorf("failed to migrate nfo data: %w", err))
+		}
+		err = to.Write(addon_dir, nfo_list)
+		if err != nil {
+			return rollback(fmt.Errorf("failed to migrate nfo data: %w", err))
+		}
+		migration.addon_dir_list = append(migration.addon_dir_list, addon_dir)
+		for _, nfo := range nfo_list {
+			group_id_set[nfo.GroupID] = true
+		}
+	}
+
+	group_id_list := []string{}
+	for group_id := range group_id_set {
+		group_id_list = append(group_id_list, group_id)
+	}
+	slices.Sort(group_id_list)
+
+	for _, group_id := range group_id_list {
+		manifest, err := from.ReadManifest(addons_dir, group_id)
+		if err != nil {
+			if !errors.Is(err, ErrManifestDNE) {
+				slog.Warn("failed to read manifest, skipping", "addons-dir", addons_dir, "group-id", group_id, "error", err)
+			}
+			continue
+		}
+		err = to.WriteManifest(addons_dir, group_id, manifest)
+		if err != nil {
+			return rollback(fmt.Errorf("failed to migrate manifest: %w", err))
+		}
+		migration.group_id_list = append(migration.group_id_list, group_id)
+	}
+
+	return migration, nil
+}
+
+// removes the nfo data and manifests in `migration` from `store`.
+// failures are logged and skipped over.
+func remove_nfo(store NFOStore, migration nfo_migration) {
+	for _, group_id := range migration.group_id_list {
+		err := store.RemoveManifest(migration.addons_dir, group_id)
+		if err != nil {
+			slog.Warn("failed to remove manifest", "addons-dir", migration.addons_dir, "group-id", group_id, "error", err)
+		}
+	}
+	for _, addon_dir := range migration.addon_dir_list {
+		err := store.Remove(addon_dir)
+		if err != nil {
+			slog.Warn("failed to remove nfo data", "addon-dir", addon_dir, "error", err)
+		}
+	}
+}
+
+// moves the nfo data and manifests of every addon in `addons_dir` from one store to another.
+// data is copied before it's removed from `from`.
+// returns the number of addon directories migrated.
+func MigrateNFO(from NFOStore, to NFOStore, addons_dir PathToDir) (int, error) {
+	migration, err := copy_nfo(from, to, addons_dir)
+	if err != nil {
+		return 0, err
+	}
+	remove_nfo(from, migration)
+	return len(migration.addon_dir_list), nil
+}
+
+// migrates the nfo data in every addons directory to a store of the given `store_type` and starts using it.
+// the data in every addons directory is copied before the store is switched and the originals removed,
+// if anything fails the copies are removed and the original store is kept.
+func SetNFOStore(app *core.App, store_type string) error {
+	settings := FindSettings(app)
+	current_store_type := settings.Preferences.NFOStore
+	if current_store_type == "" {
+		current_store_type = NFO_STORE_FILE
+	}
+	if current_store_type == store_type {
+		return nil
+	}
+
+	to, err := make_nfo_store(app, store_type)
+	if err != nil {
+		return err
+	}
+	from := nfo_store()
+
+	migration_list := []nfo_migration{}
+	rollback := func() {
+		for _, migration := range migration_list {
+			remove_nfo(to, migration)
+		}
+	}
+
+	for _, addons_dir := range settings.AddonsDirList {
+		migration, err := copy_nfo(from, to, addons_dir.Path)
+		if err != nil {
+			rollback()
+			return err
+		}
+		migration_list = append(migration_list, migration)
+	}
+
+	set_nfo_store(to)
+	err = set_nfo_store_preference(app, store_type)
+	if err != nil {
+		set_nfo_store(from)
+		set_nfo_store_preference(app, current_store_type)
+		rollback()
+		return err
+	}
+
+	// everything has been copied and the new store is in use, now remove the originals
+	for _, migration := range migration_list {
+		remove_nfo(from, migration)
+		slog.Info("migrated nfo data", "addons-dir", migration.addons_dir, "nfo-store", store_type, "num-addons", len(migration.addon_dir_list))
+	}
+
+	return nil
+}
+
+// sets the nfo store preference and saves the settings.
+func set_nfo_store_preference(app *core.App, store_type string) error {
+	app.UpdateState(func(old_state core.State) core.State {
+		for idx, r := range old_state.Root.Item.([]core.Result) {
+			if r.ID != ID_SETTINGS {
+				continue
+			}
+			s := r.Item.(Settings)
+			s.Preferences.NFOStore = store_type
+			r.Item = s
+			old_state.Root.Item.([]core.Result)[idx] = r
+			break
+		}
+		return old_state
+	}).Wait()
+
+	return SaveSettings(app)
+}
@@ -0,0 +1,167 @@
+package strongbox
+
+import (
+	"bw/core"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// nfo data and manifests written to the database can be read back after re-opening it
+func Test_NFODatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nfo-db.json")
+	db, err := OpenNFODatabase(path)
+	assert.Nil(t, err)
+
+	addon_dir := "/path/to/addons/EveryAddon"
+	_, err = db.Read(addon_dir)
+	assert.ErrorIs(t, err, ErrNFODNE)
+
+	nfo_list := []NFO{{GroupID: "https://foo.bar", Primary: true, Name: "everyaddon"}}
+	err = db.Write(addon_dir, nfo_list)
+	assert.Nil(t, err)
+
+	manifest := []ManifestEntry{{Path: "EveryAddon/EveryAddon.toc", SizeBytes: 123}}
+	err = db.WriteManifest("/path/to/addons", "https://foo.bar", manifest)
+	assert.Nil(t, err)
+
+	db, err = OpenNFODatabase(path)
+	assert.Nil(t, err)
+
+	actual, err := db.Read(addon_dir + "/") // trailing slashes are ignored
+	assert.Nil(t, err)
+	assert.Equal(t, nfo_list, actual)
+
+	actual_manifest, err := db.ReadManifest("/path/to/addons", "https://foo.bar")
+	assert.Nil(t, err)
+	assert.Equal(t, manifest, actual_manifest)
+
+	// nfo data is per addon directory
+	_, err = db.Read("/path/to/other/addons/EveryAddon")
+	assert.ErrorIs(t, err, ErrNFODNE)
+
+	err = db.Remove(addon_dir)
+	assert.Nil(t, err)
+	_, err = db.Read(addon_dir)
+	assert.ErrorIs(t, err, ErrNFODNE)
+
+	err = db.RemoveManifest("/path/to/addons", "https://foo.bar")
+	assert.Nil(t, err)
+	_, err = db.ReadManifest("/path/to/addons", "https://foo.bar")
+	assert.ErrorIs(t, err, ErrManifestDNE)
+}
+
+// nothing is left in the destination store and nothing is removed from the source store when copying fails
+func Test_copy_nfo__rollback(t *testing.T) {
+	tmpdir := t.TempDir()
+	addons_dir := filepath.Join(tmpdir, "addons")
+	make_file_tree(t, addons_dir, map[string]string{
+		"EveryAddon/.strongbox.json": `[{"group-id": "https://foo.bar", "primary?": true, "name": "everyaddon"}]`,
+		"ZAddon/.strongbox.json":     `[{"group-id": `,
+	})
+
+	file_store := NFOFileStore{ManifestDir: filepath.Join(tmpdir, "manifests")}
+	db, err := OpenNFODatabase(filepath.Join(tmpdir, "nfo-db.json"))
+	assert.Nil(t, err)
+
+	_, err = copy_nfo(file_store, db, addons_dir)
+	assert.NotNil(t, err)
+
+	_, err = db.Read(filepath.Join(addons_dir, "EveryAddon"))
+	assert.ErrorIs(t, err, ErrNFODNE)
+	assert.True(t, core.FileExists(filepath.Join(addons_dir, "EveryAddon", NFO_FILENAME)))
+
+	// and MigrateNFO removes nothing
+	_, err = MigrateNFO(file_store, db, addons_dir)
+	assert.NotNil(t, err)
+	assert.True(t, core.FileExists(filepath.Join(addons_dir, "EveryAddon", NFO_FILENAME)))
+}
+
+// nfo data and manifests can be moved from nfo files to the database and back again
+func Test_MigrateNFO(t *testing.T) {
+	tmpdir := t.TempDir()
+	addons_dir := filepath.Join(tmpdir, "addons")
+	make_file_tree(t, addons_dir, map[string]string{
+		"EveryAddon/.strongbox.json":        `[{"group-id": "https://foo.bar", "primary?": true, "name": "everyaddon"}]`,
+		"EveryAddon_Config/.strongbox.json": `{"group-id": "https://foo.bar", "primary?": false, "name": "everyaddon"}`,
+		"LocalAddon/LocalAddon.toc":         "## Title: LocalAddon\n",
+	})
+
+	file_store := NFOFileStore{ManifestDir: filepath.Join(tmpdir, "manifests")}
+	manifest := []ManifestEntry{{Path: "EveryAddon/EveryAddon.toc", SizeBytes: 123}}
+	err := file_store.WriteManifest(addons_dir, "https://foo.bar", manifest)
+	assert.Nil(t, err)
+
+	db, err := OpenNFODatabase(filepath.Join(tmpdir, "nfo-db.json"))
+	assert.Nil(t, err)
+
+	expected, err := file_store.Read(filepath.Join(addons_dir, "EveryAddon"))
+	assert.Nil(t, err)
+
+	num_migrated, err := MigrateNFO(file_store, db, addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, num_migrated)
+
+	assert.False(t, core.FileExists(filepath.Join(addons_dir, "EveryAddon", NFO_FILENAME)))
+	_, err = file_store.ReadManifest(addons_dir, "https://foo.bar")
+	assert.ErrorIs(t, err, ErrManifestDNE)
+
+	actual, err := db.Read(filepath.Join(addons_dir, "EveryAddon"))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+	actual_manifest, err := db.ReadManifest(addons_dir, "https://foo.bar")
+	assert.Nil(t, err)
+	assert.Equal(t, manifest, actual_manifest)
+
+	// and back again
+	num_migrated, err = MigrateNFO(db, file_store, addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, num_migrated)
+
+	actual, err = file_store.Read(filepath.Join(addons_dir, "EveryAddon"))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+	actual_manifest, err = file_store.ReadManifest(addons_dir, "https://foo.bar")
+	assert.Nil(t, err)
+	assert.Equal(t, manifest, actual_manifest)
+	_, err = db.Read(filepath.Join(addons_dir, "EveryAddon"))
+	assert.ErrorIs(t, err, ErrNFODNE)
+}
+
+// addons can be installed and removed with nfo data kept in the database
+func Test_install_addon__nfo_database(t *testing.T) {
+	tmpdir := t.TempDir()
+	app, stopfn := DummyApp2(tmpdir)
+	defer stopfn()
+
+	db, err := OpenNFODatabase(filepath.Join(tmpdir, "nfo-db.json"))
+	assert.Nil(t, err)
+	set_nfo_store(db)
+	defer set_nfo_store(NFOFileStore{})
+
+	ad := MakeAddonsDir(filepath.Join(tmpdir, "addons"))
+	_, wg := app.AddItem(NS_ADDONS_DIR, ad)
+	wg.Wait()
+
+	assert.Nil(t, InstallAddonHelper(app, ad))
+
+	r := app.FirstResult(func(r core.Result) bool {
+		return r.NS == NS_ADDON
+	})
+	assert.NotNil(t, r)
+	a := r.Item.(Addon)
+	assert.NotNil(t, a.NFO)
+
+	// no nfo files in the addon directories
+	addon_dir := filepath.Join(ad.Path, a.Primary.Name)
+	assert.False(t, core.FileExists(filepath.Join(addon_dir, NFO_FILENAME)))
+	nfo_list, err := db.Read(addon_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, a.NFO.GroupID, nfo_list[0].GroupID)
+
+	err = RemoveAddon(app, r, RemoveOpts{})
+	assert.Nil(t, err)
+	_, err = db.Read(addon_dir)
+	assert.ErrorIs(t, err, ErrNFODNE)
+}
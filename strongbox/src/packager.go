@@ -0,0 +1,263 @@
+package strongbox
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+// packager.go builds a distributable .zip file and release.json from a local addon project.
+// it uses the same .toc parsing and .zip checks strongbox uses when installing,
+// so an addon that packages cleanly will install cleanly.
+
+// a report of a successfully packaged addon.
+type PackageReport struct {
+	Zipfile         PathToFile
+	ReleaseJSONFile PathToFile
+	ReleaseJSON     ReleaseJSON
+}
+
+// returns a list of problems with the given .toc data that would prevent the addon being released.
+func lint_toc_for_release(toc TOC) []error {
+	error_list := []error{}
+	if strings.TrimSpace(toc.Title) == "" {
+		error_list = append(error_list, fmt.Errorf("%s: missing 'Title'", toc.FileName))
+	}
+	if toc.InterfaceVersionSet.IsEmpty() {
+		error_list = append(error_list, fmt.Errorf("%s: missing 'Interface'", toc.FileName))
+	}
+	// WoW only loads "EveryAddon/EveryAddon.toc" and "EveryAddon/EveryAddon_Suffix.toc"
+	if !strings.HasPrefix(strings.TrimSuffix(toc.FileName, ".toc"), toc.DirName) {
+		error_list = append(error_list, fmt.Errorf("%s: file name doesn't match it's directory name: %s", toc.FileName, toc.DirName))
+	}
+	if toc.Ignored {
+		error_list = append(error_list, fmt.Errorf("%s: 'Version' is unrendered: %s", toc.FileName, toc.InstalledVersion))
+	}
+	return error_list
+}
+
+// returns the release.json metadata for the given list of .toc data, one entry per flavor.
+// when many interface versions map to the same flavor, the highest is used.
+// flavors are ordered as they are in the game track registry.
+func release_json_metadata(toc_list []TOC) []ReleaseJSONMetadata {
+	idx := map[ReleaseJSONFlavor]int{}
+	for _, toc := range toc_list {
+		for _, interface_version := range toc.InterfaceVersionSet.ToSlice() {
+			game_track_id, err := InterfaceVersionToGameTrack(interface_version)
+			if err != nil {
+				continue
+			}
+			game_track, present := game_tracks().Get(game_track_id)
+			if !present || game_track.ReleaseJSONFlavor == "" {
+				continue
+			}
+			if interface_version > idx[game_track.ReleaseJSONFlavor] {
+				idx[game_track.ReleaseJSONFlavor] = interface_version
+			}
+		}
+	}
+
+	metadata_list := []ReleaseJSONMetadata{}
+	for _, game_track := range game_tracks().GameTrackList {
+		interface_version, present := idx[game_track.ReleaseJSONFlavor]
+		if present {
+			metadata_list = append(metadata_list, ReleaseJSONMetadata{Flavor: game_track.ReleaseJSONFlavor, Interface: interface_version})
+			delete(idx, game_track.ReleaseJSONFlavor)
+		}
+	}
+	return metadata_list
+}
+
+// writes the addon directories in `addon_dir_map` to a new .zip file at `zipfile`.
+// VCS directories, nfo files and the `exclude` directory (where the .zip file is being written) are skipped.
+func write_addon_zipfile(zipfile PathToFile, addon_dir_map map[string]PathToDir, exclude PathToDir) error {
+	fh, err := os.Create(zipfile)
+	if err != nil {
+		return fmt.Errorf("failed to create .zip file: %w", err)
+	}
+	defer fh.Close()
+
+	zw := zip.NewWriter(fh)
+
+	dir_name_list := []string{}
+	for dir_name := range addon_dir_map {
+		dir_name_list = append(dir_name_list, dir_name)
+	}
+	slices.Sort(dir_name_list)
+
+	for _, dir_name := range dir_name_list {
+		src := addon_dir_map[dir_name]
+		err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(src, path)
+			if err != nil {
+				return err
+			}
+			zip_path := filepath.ToSlash(filepath.Join(dir_name, rel)) // "EveryAddon/EveryAddon.toc"
+
+			if d.IsDir() {
+				if path != src && (VCS_DIR_SET.Contains(d.Name()) || filepath.Clean(path) == filepath.Clean(exclude)) {
+					return fs.SkipDir
+				}
+				// directories are explicit entries, `inspect_zipfile` relies on them
+				_, err = zw.Create(zip_path + "/")
+				return err
+			}
+			if d.Name() == NFO_FILENAME || !d.Type().IsRegular() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			header, err := zip.FileInfoHeader(info)
+			if err != nil {
+				return err
+			}
+			header.Name = zip_path
+			header.Method = zip.Deflate
+
+			w, err := zw.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			in, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			_, err = io.Copy(w, in)
+			return err
+		})
+		if err != nil {
+			zw.Close()
+			return fmt.Errorf("failed to write .zip file: %w", err)
+		}
+	}
+
+	return zw.Close()
+}
+
+// packages the addon(s) in the `project` directory into a .zip file and release.json in `output_dir`.
+// `version` is optional and defaults to the 'Version' in the primary addon's .toc file.
+func PackageAddon(project PathToDir, output_dir PathToDir, version string) (PackageReport, error) {
+	empty_response := PackageReport{}
+
+	project, err := filepath.Abs(project)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to package addon: %w", err)
+	}
+	output_dir, err = filepath.Abs(output_dir)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to package addon: %w", err)
+	}
+
+	addon_dir_map, err := local_addon_dirs(project)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to package addon: %w", err)
+	}
+
+	// parse and check every .toc file
+	error_list := []error{}
+	all_toc_list := []TOC{}
+	toc_idx := map[string][]TOC{} // {"EveryAddon": [TOC{...}, ...], ...}
+	for dir_name, dir := range addon_dir_map {
+		toc_map, err := ParseAllAddonTocFiles(dir)
+		if err != nil {
+			error_list = append(error_list, err)
+			continue
+		}
+		for _, toc := range toc_map {
+			error_list = append(error_list, lint_toc_for_release(toc)...)
+			toc_idx[dir_name] = append(toc_idx[dir_name], toc)
+			all_toc_list = append(all_toc_list, toc)
+		}
+	}
+	if len(error_list) > 0 {
+		return empty_response, fmt.Errorf("failed to package addon: %w", errors.Join(error_list...))
+	}
+
+	primary_subdir, err := determine_primary_subdir(mapset.NewSetFromMapKeys(addon_dir_map))
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to package addon: %w", err)
+	}
+
+	// the .toc file without a game track suffix, if there is one
+	primary_toc_list := toc_idx[primary_subdir]
+	slices.SortFunc(primary_toc_list, func(a, b TOC) int {
+		return len(a.FileName) - len(b.FileName)
+	})
+	primary_toc := primary_toc_list[0]
+
+	if version == "" {
+		version = primary_toc.InstalledVersion
+	}
+	if strings.TrimSpace(version) == "" {
+		return empty_response, fmt.Errorf("failed to package addon: no version given and no 'Version' found in %s", primary_toc.FileName)
+	}
+
+	metadata_list := release_json_metadata(all_toc_list)
+	if len(metadata_list) == 0 {
+		return empty_response, fmt.Errorf("failed to package addon: no supported game tracks found in .toc files")
+	}
+
+	err = os.MkdirAll(output_dir, 0755)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	zipfile := filepath.Join(output_dir, downloaded_addon_fname(primary_toc.Name, version)) // "/path/to/dist/everyaddon--1-2-3.zip"
+	err = write_addon_zipfile(zipfile, addon_dir_map, output_dir)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to package addon: %w", err)
+	}
+
+	// the same checks made before installing
+	report, err := inspect_zipfile(zipfile)
+	if err == nil {
+		err = valid_addon_zip_file(report)
+	}
+	if err != nil {
+		os.Remove(zipfile)
+		return empty_response, fmt.Errorf("failed to package addon: %w", err)
+	}
+
+	release_json := ReleaseJSON{
+		ReleaseList: []ReleaseJSONRelease{
+			{
+				Name:         primary_toc.Label,
+				Version:      version,
+				Filename:     filepath.Base(zipfile),
+				NoLib:        false,
+				MetadataList: metadata_list,
+			},
+		},
+	}
+	release_json_bytes, err := json.MarshalIndent(release_json, "", "    ")
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to marshal release.json: %w", err)
+	}
+	release_json_file := filepath.Join(output_dir, "release.json")
+	err = os.WriteFile(release_json_file, release_json_bytes, 0644)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to write release.json: %w", err)
+	}
+
+	return PackageReport{
+		Zipfile:         zipfile,
+		ReleaseJSONFile: release_json_file,
+		ReleaseJSON:     release_json,
+	}, nil
+}
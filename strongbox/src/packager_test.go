@@ -0,0 +1,104 @@
+package strongbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// a single addon project is packaged into a .zip file and release.json that strongbox can install.
+func Test_PackageAddon(t *testing.T) {
+	project := filepath.Join(t.TempDir(), "EveryAddon")
+	make_file_tree(t, project, map[string]string{
+		"EveryAddon.toc":         "## Title: EveryAddon\n## Version: 1.2.3\n## Interface: 110000\n",
+		"EveryAddon_Classic.toc": "## Title: EveryAddon\n## Version: 1.2.3\n## Interface: 11502, 11503\n",
+		"EveryAddon.lua":         "",
+		".git/HEAD":              "ref: refs/heads/main\n",
+		NFO_FILENAME:             "{}",
+	})
+	output_dir := filepath.Join(project, "dist")
+
+	report, err := PackageAddon(project, output_dir, "")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(output_dir, "everyaddon--1-2-3.zip"), report.Zipfile)
+
+	zip_report, err := inspect_zipfile(report.Zipfile)
+	assert.Nil(t, err)
+	assert.Nil(t, valid_addon_zip_file(zip_report))
+	assert.Equal(t, []string{"EveryAddon"}, zip_report.TopLevelDirs.ToSlice())
+
+	b, err := os.ReadFile(report.ReleaseJSONFile)
+	assert.Nil(t, err)
+	release_json, err := ParseReleaseJSON(b)
+	assert.Nil(t, err)
+
+	expected := []ReleaseJSONMetadata{
+		{Flavor: "mainline", Interface: 110000},
+		{Flavor: "classic", Interface: 11503},
+	}
+	assert.Equal(t, 1, len(release_json.ReleaseList))
+	assert.Equal(t, "1.2.3", release_json.ReleaseList[0].Version)
+	assert.Equal(t, "everyaddon--1-2-3.zip", release_json.ReleaseList[0].Filename)
+	assert.ElementsMatch(t, expected, release_json.ReleaseList[0].MetadataList)
+
+	// packaging again doesn't package the previous package
+	report, err = PackageAddon(project, output_dir, "1.2.4")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(output_dir, "everyaddon--1-2-4.zip"), report.Zipfile)
+	zip_report, err = inspect_zipfile(report.Zipfile)
+	assert.Nil(t, err)
+	for _, path := range zip_report.Contents {
+		assert.NotContains(t, path, "dist")
+		assert.NotContains(t, path, ".git")
+		assert.NotContains(t, path, NFO_FILENAME)
+	}
+}
+
+// a project with many addons is packaged with the primary addon determining the name and version.
+func Test_PackageAddon__many_addons(t *testing.T) {
+	project := t.TempDir()
+	make_file_tree(t, project, map[string]string{
+		"EveryAddon/EveryAddon.toc":                 local_test_toc,
+		"EveryAddon_Options/EveryAddon_Options.toc": "## Title: EveryAddon Options\n## Version: 0.0.1\n## Interface: 110000\n",
+		"docs/readme.md":                            "",
+	})
+	output_dir := t.TempDir()
+
+	report, err := PackageAddon(project, output_dir, "")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(output_dir, "everyaddon--1-2-3.zip"), report.Zipfile)
+
+	zip_report, err := inspect_zipfile(report.Zipfile)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"EveryAddon", "EveryAddon_Options"}, zip_report.TopLevelDirs.ToSlice())
+}
+
+// .toc files that aren't ready for release are rejected and nothing is written.
+func Test_PackageAddon__bad_toc(t *testing.T) {
+	cases := map[string]string{
+		"no interface": "## Title: EveryAddon\n## Version: 1.2.3\n",
+		"no title":     "## Version: 1.2.3\n## Interface: 110000\n",
+		"unrendered":   "## Title: EveryAddon\n## Version: @project-version@\n## Interface: 110000\n",
+	}
+	for desc, toc := range cases {
+		project := filepath.Join(t.TempDir(), "EveryAddon")
+		make_file_tree(t, project, map[string]string{"EveryAddon.toc": toc})
+		output_dir := t.TempDir()
+
+		_, err := PackageAddon(project, output_dir, "")
+		assert.NotNil(t, err, desc)
+		assert.NoFileExists(t, filepath.Join(output_dir, "release.json"), desc)
+	}
+}
+
+// a .toc file whose name doesn't match it's directory is never loaded by the game.
+func Test_lint_toc_for_release__mismatched_name(t *testing.T) {
+	toc := NewTOC()
+	toc.FileName = "Other.toc"
+	toc.DirName = "EveryAddon"
+	toc.Title = "EveryAddon"
+	toc.InterfaceVersionSet.Add(110000)
+	assert.Equal(t, 1, len(lint_toc_for_release(toc)))
+}
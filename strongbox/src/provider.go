@@ -2,12 +2,19 @@ package strongbox
 
 import (
 	"bw/core"
+	"errors"
 	"fmt"
 	"log/slog"
+	"path/filepath"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const TAB_LABEL_INSTALLED = "installed"
+const TAB_LABEL_OUTDATED = "outdated"
 
 // provider.go pulls together the logic from the rest of the strongbox logic and presents an
 // interface to the rest of the app.
@@ -110,13 +117,46 @@ func RefreshService(app *core.App, _ core.ServiceFnArgs) core.ServiceResult {
 	return core.ServiceResult{}
 }
 
+// moves nfo data between per-directory .strongbox.json files and a central database.
+func SetNFOStoreService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	store_type, is_string := fnargs.ArgList[0].Val.(string)
+	if !is_string {
+		return core.MakeServiceResultError(fmt.Errorf("nfo store must be a string, not %T", fnargs.ArgList[0].Val), "failed to change where nfo data is stored")
+	}
+	err := SetNFOStore(app, store_type)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to change where nfo data is stored")
+	}
+	Refresh(app)
+	return core.ServiceResult{}
+}
+
 func UpdateAddonsService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := selected_addon_dir(app)
+	if err != nil {
+		return core.ServiceResult{}
+	}
+	// nothing to update, nothing to backup
+	if len(updateable_addons(app, addons_dir)) == 0 {
+		return core.ServiceResult{}
+	}
+	err = backup_wtf_before_update(app, addons_dir)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to update addons, nothing updated")
+	}
+	err = snapshot_before_bulk_operation(app, addons_dir, "update-all")
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to update addons, nothing updated")
+	}
 	//update_all_addons(app) // todo: finish implementing
 	return core.ServiceResult{}
 }
 
 func CheckForUpdatesService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
-	CheckForUpdates(app)
+	err := CheckForUpdates(app)
+	if err != nil {
+		return core.MakeServiceResultError(err, "some addons were not checked for updates")
+	}
 	return core.ServiceResult{}
 }
 
@@ -128,6 +168,40 @@ func NewAddonsDirService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceR
 	return core.ServiceResult{}
 }
 
+// looks for WoW installations beneath the given directory and in common locations in the user's home directory.
+// new addons dirs are returned as results and only added to settings if confirmed.
+func DetectAddonsDirsService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	root, _ := fnargs.ArgList[0].Val.(PathToDir)
+	confirmed, err := bool_arg(fnargs, 1, false)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to detect addons directories")
+	}
+
+	addons_dir_list := new_addons_dirs(FindSettings(app), DetectAddonsDirs(root, core.HomePath("")))
+
+	result_list := []core.Result{}
+	for _, ad := range addons_dir_list {
+		slog.Info("found addons dir", "path", ad.Path, "game-track", ad.GameTrackID)
+		result_list = append(result_list, MakeAddonsDirResult(ad))
+	}
+
+	if !confirmed || len(addons_dir_list) == 0 {
+		return core.MakeServiceResult(result_list...)
+	}
+
+	for _, ad := range addons_dir_list {
+		AddAddonsDir(app, ad).Wait()
+	}
+	SelectAddonsDir(app, addons_dir_list[0].Path).Wait()
+	err = SaveSettings(app)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to save settings")
+	}
+	Refresh(app)
+
+	return core.MakeServiceResult(result_list...)
+}
+
 // https://vitaneri.com/posts/implementing-map-filter-and-reduce-using-generic-in-go
 func Map[T1, T2 any](s []T1, f func(T1) T2) []T2 {
 	r := make([]T2, len(s))
@@ -165,19 +239,18 @@ func InstallCatalogueAddonService(app *core.App, fnargs core.ServiceFnArgs) core
 	return core.ServiceResult{}
 }
 
-func RemoveAddonsService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
-	switch t := fnargs.ArgList[0].Val.(type) {
-	case *core.Result:
-		// single Addon
-		RemoveAddon(app, t)
+// removes each selected addon.
+func remove_addons_service(app *core.App, fnargs core.ServiceFnArgs, opts RemoveOpts) core.ServiceResult {
+	result_list, err := addon_results_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to remove addons")
+	}
 
-	case []*core.Result:
-		for _, r := range t {
-			RemoveAddon(app, r)
+	for _, r := range result_list {
+		err := RemoveAddon(app, r, opts)
+		if err != nil {
+			slog.Error("failed to remove addon", "error", err)
 		}
-
-	default:
-		slog.Error("expected a list of Addons", "got", t)
 	}
 
 	Refresh(app)
@@ -185,6 +258,14 @@ func RemoveAddonsService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceR
 	return core.ServiceResult{}
 }
 
+func RemoveAddonsService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	return remove_addons_service(app, fnargs, RemoveOpts{})
+}
+
+func RemoveAddonsKeepUserFilesService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	return remove_addons_service(app, fnargs, RemoveOpts{KeepUserFiles: true})
+}
+
 func CheckAddonService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
 	switch t := fnargs.ArgList[0].Val.(type) {
 	case *core.Result:
@@ -202,240 +283,1720 @@ func CheckAddonService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceRes
 	return core.ServiceResult{}
 }
 
-// ---
+func UpdateAddonService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	result_list := []*core.Result{}
+	switch t := fnargs.ArgList[0].Val.(type) {
+	case *core.Result:
+		result_list = append(result_list, t)
+	case []*core.Result:
+		result_list = t
+	default:
+		slog.Error("expected a Result or list of Results", "got", t)
+	}
+
+	for _, r := range result_list {
+		err := UpdateAddon(app, r)
+		if err != nil {
+			slog.Error("failed to update addon", "error", err)
+		}
+	}
 
-func StopService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
-	Stop(app)
 	return core.ServiceResult{}
 }
 
-func StartService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
-	err := Start(app)
+func InstallLocalAddonService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
 	if err != nil {
-		return core.MakeServiceResultError(err, "failed to start provider")
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
+
+	origin, _ := fnargs.ArgList[1].Val.(PathToDir)
+	symlink, err := bool_arg(fnargs, 2, false)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to install addon from directory")
 	}
+
+	app.DispatchAction(core.Action{Type: core.ACTION_SWITCH_TAB, Payload: TAB_LABEL_INSTALLED})
+	err = InstallLocalAddon(app, addons_dir, origin, symlink, InstallOpts{})
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to install addon from directory")
+	}
+
 	return core.ServiceResult{}
 }
 
-// common args
+// finds problems with the nfo data in an addons directory, fixing them only when confirmed.
+func RepairAddonsDirService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
+	confirmed, err := bool_arg(fnargs, 1, false)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to repair addons directory")
+	}
 
-// a simple 'are you sure?' argument
-func confirm_argdef() core.ArgDef {
-	return core.ArgDef{
-		ID:      "confirm",
-		Label:   "Confirm",
-		Default: "false",
-		ValidatorList: []core.PredicateFn{
-			core.IsTruthyFalsey,
-		},
-		Parser: core.ParseTruthyFalseyAsBool,
+	problem_list, err := RepairAddonsDir(app, addons_dir, confirmed)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to repair addons directory")
+	}
+
+	result_list := []core.Result{}
+	for _, p := range problem_list {
+		slog.Info("nfo problem", "dir-list", p.DirNameList, "problem", p.Problem, "detail", p.Detail, "fix", p.Fix, "status", p.Status)
+		result_list = append(result_list, core.MakeResult(NS_NFO_PROBLEM, p, core.UniqueID()))
+	}
+	slog.Info("checked nfo data", "addons-dir", addons_dir.Path, "num-problems", len(problem_list), "confirmed", confirmed)
+
+	if confirmed && len(problem_list) > 0 {
+		Refresh(app)
 	}
+
+	return core.MakeServiceResult(result_list...)
 }
 
-func settings_file_argdef() core.ArgDef {
-	return core.ArgDef{
-		ID:      "settings-file",
-		Label:   "Settings file",
-		Default: core.HomePath("/.config/strongbox/config.json"), // todo: pull this from keyvals.strongbox.paths.cfg-file
-		Parser:  core.ParseStringAsPath,                          // todo: create a settings file if one doesn't exist
-		ValidatorList: []core.PredicateFn{
-			core.IsFilenameValidator,
-			core.FileDirIsWriteableValidator,
-			core.FileIsWriteableValidator,
-		},
+func DiskUsageService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
+
+	addon_list, err := LoadAllInstalledAddons(addons_dir)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to load addons directory")
 	}
+
+	usage_list, err := AddonsDirDiskUsage(addons_dir, addon_list, DISK_USAGE_NUM_LARGEST)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to measure addons directory")
+	}
+
+	result_list := []core.Result{}
+	for _, du := range usage_list {
+		slog.Info("disk usage", "kind", du.Kind, "name", du.Name, "size", format_size_bytes(du.SizeBytes))
+		result_list = append(result_list, core.MakeResult(NS_DISK_USAGE, du, core.UniqueID()))
+	}
+	return core.MakeServiceResult(result_list...)
 }
 
-// select an existing addons dir from a list of choices.
-func extant_addons_dir_argdef() core.ArgDef {
+func LintAddonsDirService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
 
-	return core.ArgDef{
-		ID:    "addons-dir",
-		Label: "Addons Directory",
+	finding_list, err := LintAddonsDir(addons_dir)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to check .toc files")
+	}
 
-		// valid input is constrained to just these
-		// todo: shouldn't these also be in the validator?
-		// todo: should this even be a thing? why don't we just give them a drop down widget?
+	result_list := []core.Result{}
+	for _, f := range finding_list {
+		slog.Info("toc finding", "severity", f.Severity, "check", f.Check, "dir-name", f.DirName, "file-name", f.FileName, "message", f.Message)
+		result_list = append(result_list, core.MakeResult(NS_TOC_FINDING, f, core.UniqueID()))
+	}
+	slog.Info("checked .toc files", "addons-dir", addons_dir.Path, "num-findings", len(finding_list))
 
-		Widget: core.InputWidgetSelection,
+	return core.MakeServiceResult(result_list...)
+}
 
-		Choice: &core.ArgChoice{
-			ChoiceFn: func(app *core.App) []any {
-				// hrm, this is what I want but it's kinda sucky
-				choice_list := []any{}
-				for _, i := range app.FilterResultListByNS(NS_ADDONS_DIR) {
-					choice_list = append(choice_list, i)
-				}
-				return choice_list
-			},
-			Exclusivity: core.ArgChoiceExclusive,
-		},
-		DefaultFn: func(app *core.App) string {
-			cur_selected, _ := selected_addon_dir(app)
-			return cur_selected.Path // on error, .Path is empty string
-		},
-		ValidatorList: []core.PredicateFn{
-			// todo: ensure directory is one of the given choices
-			core.IsDirValidator, // ensure directory actually is a directory
-			// todo: ensure directory is also readable/writeable?
-		},
+func StaleAddonsService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
+
+	months, err := int_arg(fnargs, 1, STALE_MONTHS_DEFAULT)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find stale addons")
+	}
+	if months < 1 {
+		return core.MakeServiceResultError(nil, "failed to find stale addons: 'months' must be greater than zero")
+	}
+
+	stale_addon_list, err := FindStaleAddons(app, addons_dir, months)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find stale addons")
 	}
+
+	result_list := []core.Result{}
+	for _, sa := range stale_addon_list {
+		slog.Info("stale addon", "addon", sa.Addon.Label, "reasons", sa.ReasonList, "num-replacements", len(sa.ReplacementList))
+		result_list = append(result_list, core.MakeResult(NS_STALE_ADDON, sa, core.UniqueID()))
+	}
+
+	return core.MakeServiceResult(result_list...)
 }
 
-// ---
+// returns the addons dir from an AddonsDir service argument.
+// services called from the context menu are given a `Result`, services called from a form are given a path.
+func addons_dir_arg(app *core.App, fnargs core.ServiceFnArgs) (AddonsDir, error) {
+	switch t := fnargs.ArgList[0].Val.(type) {
+	case AddonsDir:
+		return t, nil
+	case *core.Result:
+		addons_dir, is_addons_dir := t.Item.(AddonsDir)
+		if !is_addons_dir {
+			return AddonsDir{}, fmt.Errorf("expected an addons directory, got: %T", t.Item)
+		}
+		return addons_dir, nil
+	case PathToDir:
+		path := filepath.Clean(strings.TrimSpace(t))
+		for _, r := range app.FilterResultListByNS(NS_ADDONS_DIR) {
+			addons_dir, is_addons_dir := r.Item.(AddonsDir)
+			if is_addons_dir && filepath.Clean(addons_dir.Path) == path {
+				return addons_dir, nil
+			}
+		}
+		return AddonsDir{}, fmt.Errorf("addons directory not found: %s", t)
+	default:
+		return AddonsDir{}, fmt.Errorf("expected an addons directory, got: %T", t)
+	}
+}
 
-const SERVICE_ID_NEW_ADDONS_DIR = "new-addons-dir"
+// returns the WTF backup from a WTFBackup service argument.
+// services called from the context menu are given a `Result`, services called from a form are given a result ID.
+func wtf_backup_arg(app *core.App, fnargs core.ServiceFnArgs) (WTFBackup, error) {
+	var r *core.Result
+	switch t := fnargs.ArgList[0].Val.(type) {
+	case WTFBackup:
+		return t, nil
+	case *core.Result:
+		r = t
+	case string:
+		r = app.GetResult(strings.TrimSpace(t))
+		if r == nil {
+			return WTFBackup{}, fmt.Errorf("WTF backup not found: %s", t)
+		}
+	default:
+		return WTFBackup{}, fmt.Errorf("expected a WTF backup, got: %T", t)
+	}
+	backup, is_backup := r.Item.(WTFBackup)
+	if !is_backup {
+		return WTFBackup{}, fmt.Errorf("expected a WTF backup, got: %T", r.Item)
+	}
+	return backup, nil
+}
 
-func provider() []core.ServiceGroup {
-	// the absolute bare minimum to get strongbox bootstrapped and running.
-	// everything else is optional and can be disabled without breaking anything.
-	required_services := core.ServiceGroup{
-		NS: core.NS{Major: "strongbox", Minor: "state", Type: "required"},
-		ServiceList: []core.Service{
-			core.StartProviderService(StartService),
-			core.StopProviderService(StopService),
-		},
+func BackupWTFService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
 	}
-	state_services := core.ServiceGroup{
-		NS: core.NS{Major: "strongbox", Minor: "state", Type: "service"},
-		ServiceList: []core.Service{
-			{
-				Label:       "Load settings",
-				Description: "Reads the settings file, creating one if it doesn't exist, and loads the contents into state.",
-				Interface: core.ServiceInterface{
-					ArgDefList: []core.ArgDef{
-						settings_file_argdef(),
-					},
-				},
-				Fn: LoadSettingsService,
-			},
-			{
-				Label:       "Save settings",
-				Description: "Writes a settings file to disk.",
-				Interface: core.ServiceInterface{
-					ArgDefList: []core.ArgDef{
-						settings_file_argdef(),
-					},
-				},
-				Fn: SaveSettingsService,
-			},
-			/*
-				{
-					Label:       "Default settings",
-					Description: "Replace current settings with default settings. Does not save unless you 'save settings'!",
-				},
-				{
-					Label: "Set preference",
+	backup, err := backup_wtf(app, addons_dir)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to backup WTF")
+	}
+	return core.MakeServiceResult(core.MakeResult(NS_WTF_BACKUP, backup, core.UniqueID()))
+}
+
+func ListWTFBackupsService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
+	backup_list, err := ListWTFBackups(wtf_backup_root(app), addons_dir.Path)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to list WTF backups")
+	}
+	result_list := []core.Result{}
+	for _, backup := range backup_list {
+		slog.Info("WTF backup", "path", backup.Path, "num-files", backup.NumFiles)
+		result_list = append(result_list, core.MakeResult(NS_WTF_BACKUP, backup, core.UniqueID()))
+	}
+	return core.MakeServiceResult(result_list...)
+}
+
+func DiffWTFBackupService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	backup, err := wtf_backup_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to compare WTF backup")
+	}
+	diff_list, err := DiffWTFBackup(backup)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to compare WTF backup")
+	}
+	result_list := []core.Result{}
+	for _, d := range diff_list {
+		slog.Info("WTF difference", "path", d.Path, "status", d.Status)
+		result_list = append(result_list, core.MakeResult(NS_WTF_DIFF, d, core.UniqueID()))
+	}
+	return core.MakeServiceResult(result_list...)
+}
+
+func RestoreWTFBackupService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	backup, err := wtf_backup_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to restore WTF backup")
+	}
+	prefs := FindSettings(app).Preferences
+	safety_backup, err := RestoreWTFBackup(wtf_backup_root(app), backup, time.Now(), prefs.WTFBackupsToKeep)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to restore WTF backup")
+	}
+	return core.MakeServiceResult(core.MakeResult(NS_WTF_BACKUP, safety_backup, core.UniqueID()))
+}
+
+// returns the addons directory snapshot from an AddonsDirSnapshot service argument.
+// services called from the context menu are given a `Result`, services called from a form are given a result ID.
+func addons_dir_snapshot_arg(app *core.App, fnargs core.ServiceFnArgs) (AddonsDirSnapshot, error) {
+	var r *core.Result
+	switch t := fnargs.ArgList[0].Val.(type) {
+	case AddonsDirSnapshot:
+		return t, nil
+	case *core.Result:
+		r = t
+	case string:
+		r = app.GetResult(strings.TrimSpace(t))
+		if r == nil {
+			return AddonsDirSnapshot{}, fmt.Errorf("addons directory snapshot not found: %s", t)
+		}
+	default:
+		return AddonsDirSnapshot{}, fmt.Errorf("expected an addons directory snapshot, got: %T", t)
+	}
+	snapshot, is_snapshot := r.Item.(AddonsDirSnapshot)
+	if !is_snapshot {
+		return AddonsDirSnapshot{}, fmt.Errorf("expected an addons directory snapshot, got: %T", r.Item)
+	}
+	return snapshot, nil
+}
+
+func SnapshotAddonsDirService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
+	snapshot, err := snapshot_addons_dir(app, addons_dir)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to snapshot addons directory")
+	}
+	return core.MakeServiceResult(core.MakeResult(NS_ADDONS_DIR_SNAPSHOT, snapshot, core.UniqueID()))
+}
+
+func ListAddonsDirSnapshotsService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
+	snapshot_list, err := ListAddonsDirSnapshots(snapshot_root(app), addons_dir.Path)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to list addons directory snapshots")
+	}
+	result_list := []core.Result{}
+	for _, snapshot := range snapshot_list {
+		slog.Info("addons directory snapshot", "path", snapshot.Path, "num-addons", len(snapshot.AddonList))
+		result_list = append(result_list, core.MakeResult(NS_ADDONS_DIR_SNAPSHOT, snapshot, core.UniqueID()))
+	}
+	return core.MakeServiceResult(result_list...)
+}
+
+func RestoreAddonsDirSnapshotService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	snapshot, err := addons_dir_snapshot_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to restore addons directory snapshot")
+	}
+	addons_dir, err := find_selected_addon_dir(app, snapshot.AddonsDir)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory of snapshot")
+	}
+	prefs := FindSettings(app).Preferences
+	safety_snapshot, err := RestoreAddonsDirSnapshot(snapshot_root(app), addons_dir, snapshot, time.Now(), prefs.AddonsDirSnapshotsToKeep)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to restore addons directory snapshot")
+	}
+	Refresh(app)
+	return core.MakeServiceResult(core.MakeResult(NS_ADDONS_DIR_SNAPSHOT, safety_snapshot, core.UniqueID()))
+}
+
+// returns the characters of the given addons dir matching the optional character argument at `idx`.
+func characters_from_args(addons_dir PathToDir, fnargs core.ServiceFnArgs, idx int) ([]Character, error) {
+	character_list, err := FindCharacters(addons_dir)
+	if err != nil {
+		return nil, err
+	}
+	character_id := ""
+	if len(fnargs.ArgList) > idx {
+		character_id, _ = fnargs.ArgList[idx].Val.(string)
+	}
+	character_list = filter_characters(character_list, character_id)
+	if len(character_list) == 0 {
+		return nil, fmt.Errorf("no characters found: %s", character_id)
+	}
+	return character_list, nil
+}
+
+func ListCharactersService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
+	character_list, err := FindCharacters(addons_dir.Path)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find characters")
+	}
+	result_list := []core.Result{}
+	for _, c := range character_list {
+		result_list = append(result_list, core.MakeResult(NS_CHARACTER, c, c.ID()))
+	}
+	return core.MakeServiceResult(result_list...)
+}
+
+// enables or disables the selected addons for every character,
+// or just those matching the optional second argument when called directly.
+func set_addons_enabled_service(app *core.App, fnargs core.ServiceFnArgs, enabled bool) core.ServiceResult {
+	result_list, err := addon_results_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to update character addons")
+	}
+
+	// addons are grouped by addons dir, each with their own characters
+	addons_dir_idx := map[PathToDir][]Addon{}
+	for _, r := range result_list {
+		a, is_addon := r.Item.(Addon)
+		if !is_addon || a.AddonsDir == nil {
+			continue
+		}
+		addons_dir_idx[a.AddonsDir.Path] = append(addons_dir_idx[a.AddonsDir.Path], a)
+	}
+
+	for addons_dir, addon_list := range addons_dir_idx {
+		character_list, err := characters_from_args(addons_dir, fnargs, 1)
+		if err != nil {
+			return core.MakeServiceResultError(err, "failed to find characters")
+		}
+		err = SetAddonsEnabled(character_list, addon_list, enabled)
+		if err != nil {
+			return core.MakeServiceResultError(err, "failed to update character addons")
+		}
+	}
+
+	Refresh(app)
+
+	return core.ServiceResult{}
+}
+
+func EnableAddonService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	return set_addons_enabled_service(app, fnargs, true)
+}
+
+func DisableAddonService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	return set_addons_enabled_service(app, fnargs, false)
+}
+
+// enables or disables an addon for the character of each selected `AddonCharacterState`.
+func set_character_addon_enabled_service(app *core.App, fnargs core.ServiceFnArgs, enabled bool) core.ServiceResult {
+	state_list := []AddonCharacterState{}
+	switch t := fnargs.ArgList[0].Val.(type) {
+	case *core.Result:
+		state, is_state := t.Item.(AddonCharacterState)
+		if is_state {
+			state_list = append(state_list, state)
+		}
+	case []*core.Result:
+		for _, r := range t {
+			state, is_state := r.Item.(AddonCharacterState)
+			if is_state {
+				state_list = append(state_list, state)
+			}
+		}
+	}
+	if len(state_list) == 0 {
+		return core.MakeServiceResultError(nil, "failed to update character addons: no addon characters selected")
+	}
+
+	for _, state := range state_list {
+		addon_idx := map[string]bool{}
+		for _, dir_name := range state.DirNameList {
+			addon_idx[dir_name] = enabled
+		}
+		err := SetCharacterAddons([]Character{state.Character}, addon_idx)
+		if err != nil {
+			return core.MakeServiceResultError(err, "failed to update character addons")
+		}
+	}
+
+	Refresh(app)
+
+	return core.ServiceResult{}
+}
+
+func EnableCharacterAddonService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	return set_character_addon_enabled_service(app, fnargs, true)
+}
+
+func DisableCharacterAddonService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	return set_character_addon_enabled_service(app, fnargs, false)
+}
+
+func SaveAddonProfileService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
+	name, _ := fnargs.ArgList[1].Val.(string)
+
+	character_list, err := characters_from_args(addons_dir.Path, fnargs, 2)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find character")
+	}
+	if len(character_list) > 1 {
+		return core.MakeServiceResultError(fmt.Errorf("many characters found, a profile is made from a single character"), "failed to save addon profile")
+	}
+
+	installed_dir_name_list := []string{}
+	for _, r := range installed_addons(app, addons_dir) {
+		installed_dir_name_list = append(installed_dir_name_list, addon_dir_name_list(r.Item.(Addon))...)
+	}
+
+	profile, err := MakeAddonProfile(addons_dir.Path, name, character_list[0], installed_dir_name_list)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to save addon profile")
+	}
+
+	set_addon_profile(app, profile).Wait()
+
+	err = SaveSettings(app)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to save settings")
+	}
+
+	return core.ServiceResult{}
+}
+
+func ApplyAddonProfileService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
+	name, _ := fnargs.ArgList[1].Val.(string)
+
+	profile, found := find_addon_profile(FindSettings(app), addons_dir.Path, strings.TrimSpace(name))
+	if !found {
+		return core.MakeServiceResultError(fmt.Errorf("addon profile not found: %s", name), "failed to apply addon profile")
+	}
+
+	character_list, err := characters_from_args(addons_dir.Path, fnargs, 2)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find characters")
+	}
+
+	err = ApplyAddonProfile(character_list, profile)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to apply addon profile")
+	}
+
+	Refresh(app)
+
+	return core.ServiceResult{}
+}
+
+func ExportAddonsService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
+	path, _ := fnargs.ArgList[1].Val.(PathToFile)
+
+	_, err = ExportAddonsDir(app, addons_dir, path)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to export addons")
+	}
+	return core.ServiceResult{}
+}
+
+func ImportAddonsService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
+	path, _ := fnargs.ArgList[1].Val.(PathToFile)
+	if path == "" {
+		return core.MakeServiceResultError(errors.New("no export file given"), "failed to import addons")
+	}
+
+	app.DispatchAction(core.Action{Type: core.ACTION_SWITCH_TAB, Payload: TAB_LABEL_INSTALLED})
+
+	err = snapshot_before_bulk_operation(app, addons_dir, "import")
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to import addons, nothing imported")
+	}
+	imported_list, err := ImportAddonList(app, addons_dir, path)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to import addons")
+	}
+
+	result_list := []core.Result{}
+	for _, ia := range imported_list {
+		slog.Info("imported addon", "addon", ia.Name, "source", ia.Source, "status", ia.Status)
+		result_list = append(result_list, core.MakeResult(NS_IMPORTED_ADDON, ia, core.UniqueID()))
+	}
+
+	Reconcile(app)
+
+	return core.MakeServiceResult(result_list...)
+}
+
+func ImportFromAddonManagerService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
+	manager, _ := fnargs.ArgList[1].Val.(string)
+	manager = strings.TrimSpace(manager)
+	if manager == "" {
+		manager = ADDON_MANAGER_WOWUP
+	}
+	path := ""
+	if len(fnargs.ArgList) > 2 {
+		path, _ = fnargs.ArgList[2].Val.(PathToFile)
+	}
+
+	err = snapshot_before_bulk_operation(app, addons_dir, "import-from-addon-manager")
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to import addons, nothing imported")
+	}
+	foreign_import_list, err := ImportFromAddonManager(app, addons_dir, manager, path)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to import addons")
+	}
+
+	result_list := []core.Result{}
+	for _, fi := range foreign_import_list {
+		slog.Info("imported addon", "manager", fi.Manager, "addon", fi.Name, "source", fi.Source, "status", fi.Status, "num-replacements", len(fi.ReplacementList))
+		result_list = append(result_list, core.MakeResult(NS_FOREIGN_ADDON, fi, core.UniqueID()))
+	}
+
+	Refresh(app)
+
+	return core.MakeServiceResult(result_list...)
+}
+
+// splits the selected addon made of many directories into independent addons.
+func UngroupAddonService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	a, err := addon_result_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to ungroup addon")
+	}
+
+	err = UngroupAddon(a)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to ungroup addon")
+	}
+	Refresh(app)
+	return core.ServiceResult{}
+}
+
+// makes the selected directory of an addon it's primary.
+// the directory is an `InstalledAddon` child of the `Addon`.
+func SetPrimaryAddonService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	r, is_result := fnargs.ArgList[0].Val.(*core.Result)
+	if !is_result {
+		return core.MakeServiceResultError(fmt.Errorf("expected a single addon directory, got: %T", fnargs.ArgList[0].Val), "failed to set primary addon")
+	}
+	ia, is_installed_addon := r.Item.(InstalledAddon)
+	if !is_installed_addon {
+		return core.MakeServiceResultError(fmt.Errorf("expected an addon directory, got: %T", r.Item), "failed to set primary addon")
+	}
+	parent := app.GetResult(r.ParentID)
+	if parent == nil {
+		return core.MakeServiceResultError(fmt.Errorf("addon not found for addon directory: %s", ia.Name), "failed to set primary addon")
+	}
+	a, is_addon := parent.Item.(Addon)
+	if !is_addon {
+		return core.MakeServiceResultError(fmt.Errorf("expected an addon, got: %T", parent.Item), "failed to set primary addon")
+	}
+
+	err := SetPrimaryAddon(a, ia.Name)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to set primary addon")
+	}
+	Refresh(app)
+	return core.ServiceResult{}
+}
+
+// lists the zip files of an addon in the zip store, newest first.
+func ListStoredAddonZipsService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	a, err := addon_result_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to list stored zip files")
+	}
+
+	store := zip_store()
+	if store == nil {
+		return core.MakeServiceResultError(ErrZipStoreUnavailable, "failed to list stored zip files")
+	}
+	result_list := []core.Result{}
+	for _, entry := range store.EntryList(a.Name) {
+		result_list = append(result_list, core.MakeResult(NS_ZIP_STORE_ENTRY, entry, core.UniqueID()))
+	}
+	return core.MakeServiceResult(result_list...)
+}
+
+// reinstalls each selected addon from the zip store without downloading it again.
+// called directly, an optional version rolls the addon back to an earlier version.
+func ReinstallAddonFromStoreService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	result_list, err := addon_results_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to reinstall addon from zip store")
+	}
+	version := ""
+	if len(fnargs.ArgList) > 1 {
+		version, _ = fnargs.ArgList[1].Val.(string)
+	}
+
+	for _, r := range result_list {
+		err := ReinstallAddonFromStore(app, r.Item.(Addon), strings.TrimSpace(version))
+		if err != nil {
+			return core.MakeServiceResultError(err, "failed to reinstall addon from zip store")
+		}
+	}
+	Refresh(app)
+	return core.ServiceResult{}
+}
+
+// rolls each selected addon back to the version in the zip store before the installed version.
+func RollbackAddonFromStoreService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	result_list, err := addon_results_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to roll back addon")
+	}
+
+	for _, r := range result_list {
+		err := RollbackAddonFromStore(app, r.Item.(Addon))
+		if err != nil {
+			return core.MakeServiceResultError(err, "failed to roll back addon")
+		}
+	}
+	Refresh(app)
+	return core.ServiceResult{}
+}
+
+// compares the files of an installed addon against those recorded when it was installed.
+func VerifyAddonService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	a, err := addon_result_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to verify addon")
+	}
+
+	diff_list, err := VerifyAddon(a)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to verify addon")
+	}
+
+	result_list := []core.Result{}
+	for _, d := range diff_list {
+		slog.Info("addon file differs from installed file", "addon", a.Label, "path", d.Path, "status", d.Status)
+		result_list = append(result_list, core.MakeResult(NS_ADDON_FILE_DIFF, d, core.UniqueID()))
+	}
+	if len(diff_list) == 0 {
+		slog.Info("addon files match installed files", "addon", a.Label)
+	}
+	return core.MakeServiceResult(result_list...)
+}
+
+func FindSimilarAddonsService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	a, err := addon_result_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find similar addons")
+	}
+
+	since := time.Now().AddDate(0, -STALE_MONTHS_DEFAULT, 0)
+	result_list := []core.Result{}
+	for _, ca := range similar_addons(a, db_catalogue_addons(app), since, STALE_REPLACEMENT_LIMIT) {
+		result_list = append(result_list, core.MakeResult(NS_CATALOGUE_ADDON, ca, core.UniqueID()))
+	}
+	return core.MakeServiceResult(result_list...)
+}
+
+func PackageAddonService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	project, _ := fnargs.ArgList[0].Val.(PathToDir)
+	output_dir := ""
+	if len(fnargs.ArgList) > 1 {
+		output_dir, _ = fnargs.ArgList[1].Val.(PathToDir)
+	}
+	version := ""
+	if len(fnargs.ArgList) > 2 {
+		version, _ = fnargs.ArgList[2].Val.(string)
+	}
+	if strings.TrimSpace(output_dir) == "" {
+		output_dir = filepath.Join(project, "dist")
+	}
+
+	report, err := PackageAddon(project, output_dir, strings.TrimSpace(version))
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to package addon")
+	}
+
+	slog.Info("packaged addon", "zipfile", report.Zipfile, "release-json", report.ReleaseJSONFile)
+	return core.ServiceResult{}
+}
+
+// reads the release channel and release type choices from the service arguments, starting at `offset`.
+// 'default' choices become empty values that defer to the next preference.
+// unknown choices are an error.
+func release_channel_from_args(fnargs core.ServiceFnArgs, offset int) (ReleaseChannel, error) {
+	choice := func(i int) string {
+		if len(fnargs.ArgList) <= i {
+			return ""
+		}
+		val, _ := fnargs.ArgList[i].Val.(string)
+		val = strings.TrimSpace(val)
+		if val == RELEASE_CHOICE_DEFAULT {
+			return ""
+		}
+		return val
+	}
+	rc := ReleaseChannel{
+		Stability: choice(offset),
+		Type:      choice(offset + 1),
+	}
+	issues := rc.Valid()
+	if len(issues) > 0 {
+		return ReleaseChannel{}, fmt.Errorf("unknown release channel or release type: %v", rc)
+	}
+	return rc, nil
+}
+
+// returns the addon results given as the first service argument.
+// services called from the context menu are given a `Result` or a list of `Result`s,
+// services called from a form are given the ID of a `Result`.
+func addon_results_arg(app *core.App, fnargs core.ServiceFnArgs) ([]*core.Result, error) {
+	empty_response := []*core.Result{}
+	result_list := []*core.Result{}
+	switch t := fnargs.ArgList[0].Val.(type) {
+	case *core.Result:
+		result_list = append(result_list, t)
+	case []*core.Result:
+		result_list = t
+	case string:
+		r := app.GetResult(strings.TrimSpace(t))
+		if r == nil {
+			return empty_response, fmt.Errorf("addon not found: %s", t)
+		}
+		result_list = append(result_list, r)
+	default:
+		return empty_response, fmt.Errorf("expected an addon, got: %T", t)
+	}
+
+	for _, r := range result_list {
+		_, is_addon := r.Item.(Addon)
+		if !is_addon {
+			return empty_response, fmt.Errorf("expected an addon, got: %T", r.Item)
+		}
+	}
+	return result_list, nil
+}
+
+// like `addon_results_arg` but for services that work on exactly one addon.
+func addon_result_arg(app *core.App, fnargs core.ServiceFnArgs) (Addon, error) {
+	if len(fnargs.ArgList) == 0 {
+		return Addon{}, fmt.Errorf("expected an addon, got nothing")
+	}
+	result_list, err := addon_results_arg(app, fnargs)
+	if err != nil {
+		return Addon{}, err
+	}
+	if len(result_list) != 1 {
+		return Addon{}, fmt.Errorf("expected a single addon, got: %d", len(result_list))
+	}
+	return result_list[0].Item.(Addon), nil
+}
+
+// sets the release channel of each given addon to whatever `update_fn` returns for it's current release channel.
+func set_addon_release_channel_service(app *core.App, fnargs core.ServiceFnArgs, update_fn func(ReleaseChannel) ReleaseChannel) core.ServiceResult {
+	result_list, err := addon_results_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to set addon release channel")
+	}
+
+	for _, r := range result_list {
+		a := r.Item.(Addon)
+		if a.NFO == nil {
+			return core.MakeServiceResultError(nil, fmt.Sprintf("addon must be installed or re-installed through strongbox first: %s", a.Label))
+		}
+		err := SetAddonReleaseChannel(app, r, update_fn(a.NFO.ReleaseChannel))
+		if err != nil {
+			return core.MakeServiceResultError(err, "failed to set addon release channel")
+		}
+	}
+
+	return core.ServiceResult{}
+}
+
+func SetAddonReleaseChannelService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	release_channel, err := release_channel_from_args(fnargs, 1)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to set addon release channel")
+	}
+	return set_addon_release_channel_service(app, fnargs, func(_ ReleaseChannel) ReleaseChannel {
+		return release_channel
+	})
+}
+
+// a single-argument service for the addon context menu that changes part of an addon's release channel.
+func addon_release_channel_service(id string, label string, description string, update_fn func(ReleaseChannel) ReleaseChannel) core.Service {
+	return core.Service{
+		ID:          id,
+		Label:       label,
+		Description: description,
+		Interface: core.ServiceInterface{
+			ArgDefList: []core.ArgDef{
+				{
+					ID:     "selected",
+					Label:  "Selected Addons",
+					Widget: core.InputWidgetTextField,
+				},
+			},
+		},
+		Fn: func(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+			return set_addon_release_channel_service(app, fnargs, update_fn)
+		},
+	}
+}
+
+func SetAddonsDirPreferencesService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
+	arg := func(i int) string {
+		if len(fnargs.ArgList) <= i {
+			return ""
+		}
+		val, _ := fnargs.ArgList[i].Val.(string)
+		return val
+	}
+
+	prefs, err := parse_addons_dir_preferences(arg(1), arg(2), arg(3), arg(4))
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to set addons directory preferences")
+	}
+
+	release_channel, err := release_channel_from_args(fnargs, 5)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to set addons directory preferences")
+	}
+
+	SetAddonsDirPreferences(app, addons_dir.Path, prefs).Wait()
+	SetAddonsDirReleaseChannel(app, addons_dir.Path, release_channel).Wait()
+
+	err = SaveSettings(app)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to save settings")
+	}
+
+	Refresh(app)
+
+	return core.ServiceResult{}
+}
+
+func SetAddonsDirGameTrackService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
+	game_track_id, _ := fnargs.ArgList[1].Val.(string)
+	strict, err := bool_arg(fnargs, 2, true)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to set addons directory game track")
+	}
+
+	wg, err := SetAddonsDirGameTrack(app, addons_dir.Path, GameTrackID(strings.TrimSpace(game_track_id)), strict)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to set addons directory game track")
+	}
+	wg.Wait()
+
+	err = SaveSettings(app)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to save settings")
+	}
+
+	Refresh(app)
+
+	return core.ServiceResult{}
+}
+
+func SetAddonsDirReleaseChannelService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	addons_dir, err := addons_dir_arg(app, fnargs)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to find addons directory")
+	}
+
+	release_channel, err := release_channel_from_args(fnargs, 1)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to set addons directory release channel")
+	}
+
+	SetAddonsDirReleaseChannel(app, addons_dir.Path, release_channel).Wait()
+
+	err = SaveSettings(app)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to save settings")
+	}
+
+	Refresh(app)
+
+	return core.ServiceResult{}
+}
+
+// ---
+
+func StopService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	Stop(app)
+	return core.ServiceResult{}
+}
+
+func StartService(app *core.App, fnargs core.ServiceFnArgs) core.ServiceResult {
+	err := Start(app)
+	if err != nil {
+		return core.MakeServiceResultError(err, "failed to start provider")
+	}
+	return core.ServiceResult{}
+}
+
+// common args
+
+// a simple 'are you sure?' argument
+func confirm_argdef() core.ArgDef {
+	return core.ArgDef{
+		ID:      "confirm",
+		Label:   "Confirm",
+		Default: "false",
+		Widget:  core.InputWidgetTextField,
+		ValidatorList: []core.PredicateFn{
+			core.IsTruthyFalsey,
+		},
+		Parser: core.ParseTruthyFalseyAsBool,
+	}
+}
+
+// returns the optional whole number argument at `idx`, `default_val` if missing or empty.
+// services called directly are given an int, services called from a form are given a string.
+func int_arg(fnargs core.ServiceFnArgs, idx int, default_val int) (int, error) {
+	if len(fnargs.ArgList) <= idx {
+		return default_val, nil
+	}
+	switch t := fnargs.ArgList[idx].Val.(type) {
+	case int:
+		return t, nil
+	case string:
+		t = strings.TrimSpace(t)
+		if t == "" {
+			return default_val, nil
+		}
+		val, err := strconv.Atoi(t)
+		if err != nil {
+			return 0, fmt.Errorf("'%s' is not a whole number: %s", fnargs.ArgList[idx].Key, t)
+		}
+		return val, nil
+	case nil:
+		return default_val, nil
+	default:
+		return 0, fmt.Errorf("'%s' is not a whole number", fnargs.ArgList[idx].Key)
+	}
+}
+
+// returns the optional true/false argument at `idx`, `default_val` if missing or empty.
+// services called directly are given a bool, services called from a form are given a string.
+func bool_arg(fnargs core.ServiceFnArgs, idx int, default_val bool) (bool, error) {
+	if len(fnargs.ArgList) <= idx {
+		return default_val, nil
+	}
+	switch t := fnargs.ArgList[idx].Val.(type) {
+	case bool:
+		return t, nil
+	case string:
+		// the same values the `core.IsTruthyFalsey` validator accepts
+		switch strings.ToLower(strings.TrimSpace(t)) {
+		case "":
+			return default_val, nil
+		case "yes":
+			return true, nil
+		case "no":
+			return false, nil
+		}
+		val, err := strconv.ParseBool(strings.TrimSpace(t))
+		if err != nil {
+			return false, fmt.Errorf("'%s' is not true or false", fnargs.ArgList[idx].Key)
+		}
+		return val, nil
+	case nil:
+		return default_val, nil
+	default:
+		return false, fmt.Errorf("'%s' is not true or false", fnargs.ArgList[idx].Key)
+	}
+}
+
+func settings_file_argdef() core.ArgDef {
+	return core.ArgDef{
+		ID:      "settings-file",
+		Label:   "Settings file",
+		Default: core.HomePath("/.config/strongbox/config.json"), // todo: pull this from keyvals.strongbox.paths.cfg-file
+		Parser:  core.ParseStringAsPath,                          // todo: create a settings file if one doesn't exist
+		ValidatorList: []core.PredicateFn{
+			core.IsFilenameValidator,
+			core.FileDirIsWriteableValidator,
+			core.FileIsWriteableValidator,
+		},
+	}
+}
+
+// a release channel choice that defers to the addons dir (for addons) or to the global preference (for addons dirs)
+const RELEASE_CHOICE_DEFAULT = "default"
+
+// a per-addons-dir preference choice that defers to the global preference
+const PREFERENCE_CHOICE_DEFAULT = "default"
+
+// choose between overriding a global true/false preference or using it
+func preference_choice_argdef(id string, label string) core.ArgDef {
+	choice_list := []string{PREFERENCE_CHOICE_DEFAULT, "true", "false"}
+	return core.ArgDef{
+		ID:          id,
+		Label:       label,
+		Description: strings.Join(choice_list, ", "),
+		Default:     PREFERENCE_CHOICE_DEFAULT,
+		Widget:      core.InputWidgetTextField,
+		ValidatorList: []core.PredicateFn{
+			one_of_validator(choice_list...),
+		},
+	}
+}
+
+// returns a validator that accepts just the given choices, or an empty value.
+// for text fields standing in for a selection widget.
+func one_of_validator(choice_list ...string) core.PredicateFn {
+	return func(val any) error {
+		str, is_str := val.(string)
+		if !is_str {
+			return errors.New("value must be a string")
+		}
+		str = strings.TrimSpace(str)
+		if str != "" && !slices.Contains(choice_list, str) {
+			return fmt.Errorf("value must be one of: %s", strings.Join(choice_list, ", "))
+		}
+		return nil
+	}
+}
+
+// accepts any game track in the game track registry.
+func game_track_validator(val any) error {
+	game_track_id, _ := val.(string)
+	game_track_id = strings.TrimSpace(game_track_id)
+	if !game_tracks().Supported(GameTrackID(game_track_id)) {
+		return fmt.Errorf("unsupported game track: %s", game_track_id)
+	}
+	return nil
+}
+
+// choose the least stable release to be offered
+func release_channel_argdef() core.ArgDef {
+	choice_list := []string{RELEASE_CHOICE_DEFAULT, RELEASE_STABILITY_STABLE, RELEASE_STABILITY_BETA, RELEASE_STABILITY_ALPHA}
+	return core.ArgDef{
+		ID:          "release-channel",
+		Label:       "Release channel",
+		Description: strings.Join(choice_list, ", "),
+		Default:     RELEASE_CHOICE_DEFAULT,
+		Widget:      core.InputWidgetTextField,
+		ValidatorList: []core.PredicateFn{
+			one_of_validator(choice_list...),
+		},
+	}
+}
+
+// choose between releases with and without bundled libraries
+func release_type_argdef() core.ArgDef {
+	choice_list := []string{RELEASE_CHOICE_DEFAULT, RELEASE_TYPE_LIB, RELEASE_TYPE_NOLIB}
+	return core.ArgDef{
+		ID:          "release-type",
+		Label:       "Release type",
+		Description: strings.Join(choice_list, ", "),
+		Default:     RELEASE_CHOICE_DEFAULT,
+		Widget:      core.InputWidgetTextField,
+		ValidatorList: []core.PredicateFn{
+			one_of_validator(choice_list...),
+		},
+	}
+}
+
+// select an existing addons dir from a list of choices.
+func extant_addons_dir_argdef() core.ArgDef {
+
+	return core.ArgDef{
+		ID:    "addons-dir",
+		Label: "Addons Directory",
+
+		// valid input is constrained to just these
+		// todo: shouldn't these also be in the validator?
+		// todo: should this even be a thing? why don't we just give them a drop down widget?
+
+		Widget: core.InputWidgetSelection,
+
+		Choice: &core.ArgChoice{
+			ChoiceFn: func(app *core.App) []any {
+				// hrm, this is what I want but it's kinda sucky
+				choice_list := []any{}
+				for _, i := range app.FilterResultListByNS(NS_ADDONS_DIR) {
+					choice_list = append(choice_list, i)
+				}
+				return choice_list
+			},
+			Exclusivity: core.ArgChoiceExclusive,
+		},
+		DefaultFn: func(app *core.App) string {
+			cur_selected, _ := selected_addon_dir(app)
+			return cur_selected.Path // on error, .Path is empty string
+		},
+		ValidatorList: []core.PredicateFn{
+			// todo: ensure directory is one of the given choices
+			core.IsDirValidator, // ensure directory actually is a directory
+			// todo: ensure directory is also readable/writeable?
+		},
+	}
+}
+
+// ---
+
+const SERVICE_ID_NEW_ADDONS_DIR = "new-addons-dir"
+const SERVICE_ID_INSTALL_LOCAL_ADDON = "install-local-addon"
+const SERVICE_ID_PACKAGE_ADDON = "package-addon"
+const SERVICE_ID_EXPORT_ADDONS = "export-addons"
+const SERVICE_ID_IMPORT_ADDONS = "import-addons"
+const SERVICE_ID_IMPORT_FROM_ADDON_MANAGER = "import-from-addon-manager"
+const SERVICE_ID_SET_NFO_STORE = "set-nfo-store"
+
+func provider() []core.ServiceGroup {
+	// the absolute bare minimum to get strongbox bootstrapped and running.
+	// everything else is optional and can be disabled without breaking anything.
+	required_services := core.ServiceGroup{
+		NS: core.NS{Major: "strongbox", Minor: "state", Type: "required"},
+		ServiceList: []core.Service{
+			core.StartProviderService(StartService),
+			core.StopProviderService(StopService),
+		},
+	}
+	state_services := core.ServiceGroup{
+		NS: core.NS{Major: "strongbox", Minor: "state", Type: "service"},
+		ServiceList: []core.Service{
+			{
+				Label:       "Load settings",
+				Description: "Reads the settings file, creating one if it doesn't exist, and loads the contents into state.",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						settings_file_argdef(),
+					},
+				},
+				Fn: LoadSettingsService,
+			},
+			{
+				Label:       "Save settings",
+				Description: "Writes a settings file to disk.",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						settings_file_argdef(),
+					},
+				},
+				Fn: SaveSettingsService,
+			},
+			/*
+				{
+					Label:       "Default settings",
+					Description: "Replace current settings with default settings. Does not save unless you 'save settings'!",
+				},
+				{
+					Label: "Set preference",
+				},
+			*/
+			{
+				Label:       "Refresh",
+				Description: "Reload addons, reload catalogues, check addons for updates, flush settings to disk, etc",
+				Fn:          RefreshService,
+			},
+			{
+				ID:          SERVICE_ID_SET_NFO_STORE,
+				Label:       "Set NFO store",
+				Description: "Keep addon data in .strongbox.json files in each addon directory or in a single database. Existing data is migrated.",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:          "nfo-store",
+							Label:       "NFO store",
+							Description: NFO_STORE_FILE + ", " + NFO_STORE_DATABASE,
+							Default:     NFO_STORE_FILE,
+							Widget:      core.InputWidgetTextField,
+							ValidatorList: []core.PredicateFn{
+								one_of_validator(NFO_STORE_FILE, NFO_STORE_DATABASE),
+							},
+						},
+					},
+				},
+				Fn: SetNFOStoreService,
+			},
+		},
+	}
+
+	catalogue_services := core.ServiceGroup{
+		NS: core.NS{Major: "strongbox", Minor: "catalogue", Type: "service"},
+		ServiceList: []core.Service{
+			{
+				Label:       "Catalogue info",
+				Description: "Displays information about each available catalogue, including the emergency catalogue.",
+			},
+			{
+				Label: "Update catalogues",
+			},
+			{
+				Label: "Switch active catalogue",
+			},
+			{
+				ID:          "install-catalogue-addon",
+				Label:       "Install catalogue addon",
+				Description: "Install an addon from the catalogue.",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+					},
+				},
+				Fn: InstallCatalogueAddonService,
+			},
+		},
+	}
+
+	addons_dir_services := core.ServiceGroup{
+		NS: core.NS{Major: "strongbox", Minor: "addons-dir", Type: "service"},
+		ServiceList: []core.Service{
+			{
+				ID:          SERVICE_ID_NEW_ADDONS_DIR,
+				Label:       "New addons directory",
+				Description: "Create a new addons directory",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:    "addons-dir",
+							Label: "Addons Directory",
+							//Widget:        core.InputWidgetDirSelection,
+							Widget:        core.InputWidgetTextField,
+							ValidatorList: []core.PredicateFn{core.IsDirValidator},
+						},
+					},
+				},
+				Fn: NewAddonsDirService,
+			},
+			{
+				ID:          "detect-addons-dirs",
+				Label:       "Detect addons directories",
+				Description: "Look for WoW installations and propose an addons directory for each game found",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:          "dir",
+							Label:       "Directory",
+							Description: "Directory to search in addition to the usual places. Optional.",
+							Widget:      core.InputWidgetTextField,
+						},
+						confirm_argdef(),
+					},
+				},
+				Fn: DetectAddonsDirsService,
+			},
+			{
+				ID:          "remove-addons-dir",
+				Label:       "Remove addons directory",
+				Description: "Remove an addons directory",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+						//confirm_argdef(),
+					},
+				},
+				Fn: RemoveAddonsDirService,
+			},
+			/*
+				{
+					ID:          "load-addons-dir",
+					Label:       "Load addons directory",
+					Description: "Loads a list of addons within an addons directory",
+					Interface: core.ServiceInterface{
+						ArgDefList: []core.ArgDef{
+							{
+								ID:    "addons-dir",
+								Label: "Addons Directory",
+								Choice: &core.ArgChoice{
+									ChoiceFn: func(app *core.App) []any {
+										// hrm, this is what I want but it's kinda sucky
+										choice_list := []any{}
+										for _, i := range app.FilterResultListByNS(NS_ADDONS_DIR) {
+											choice_list = append(choice_list, i)
+										}
+										return choice_list
+									},
+									Exclusivity: core.ArgChoiceExclusive,
+								},
+								//Parser:        nil,                  // todo: needs to select from known addon dirs
+								//ValidatorList: []core.PredicateFn{}, // todo: ensure directory is readable?
+							},
+						},
+					},
+					Fn: LoadAddonDirService,
+				},
+			*/
+			{
+				ID:          "select-addons-dir",
+				Label:       "Select addons directory",
+				Description: "Selects an addons directory to check for updates",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+					},
+				},
+				Fn: SelectAddonsDirService,
+			},
+
+			{
+				ID:          "set-addons-dir-release-channel",
+				Label:       "Set release channel",
+				Description: "Choose whether addons in an addons directory are offered stable, beta or alpha releases, with or without libraries",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+						release_channel_argdef(),
+						release_type_argdef(),
+					},
+				},
+				Fn: SetAddonsDirReleaseChannelService,
+			},
+			{
+				ID:          "set-addons-dir-preferences",
+				Label:       "Set addons directory preferences",
+				Description: "Override the global preferences for just this addons directory. 'default' uses the global preference.",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+						{
+							ID:          "addon-zips-to-keep",
+							Label:       "Zip files to keep",
+							Description: "Number of downloaded zip files to keep per addon. Leave empty to use the global preference.",
+							Widget:      core.InputWidgetTextField,
+						},
+						preference_choice_argdef("check-for-update", "Check for updates"),
+						preference_choice_argdef("backup-wtf-before-update", "Backup WTF before updating"),
+						{
+							ID:          "excluded-addons",
+							Label:       "Excluded addons",
+							Description: "Comma separated names of addons that are never checked for updates or updated, like 'everyaddon, EveryOtherAddon'.",
+							Widget:      core.InputWidgetTextField,
+						},
+						release_channel_argdef(),
+						release_type_argdef(),
+					},
+				},
+				Fn: SetAddonsDirPreferencesService,
+			},
+			{
+				ID:          "set-addons-dir-game-track",
+				Label:       "Set game track",
+				Description: "Change the game track of an addons directory and whether addons for other game tracks may be installed",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+						{
+							ID:          "game-track",
+							Label:       "Game track",
+							Description: "A game track like 'retail', 'classic' or 'classic-mop'",
+							Widget:      core.InputWidgetTextField,
+							ValidatorList: []core.PredicateFn{
+								game_track_validator,
+							},
+						},
+						{
+							ID:          "strict",
+							Label:       "Strict",
+							Description: "Only install addons for this game track",
+							Default:     "true",
+							Widget:      core.InputWidgetTextField,
+							ValidatorList: []core.PredicateFn{
+								core.IsTruthyFalsey,
+							},
+							Parser: core.ParseTruthyFalseyAsBool,
+						},
+					},
+				},
+				Fn: SetAddonsDirGameTrackService,
+			},
+			{
+				ID:          "lint-addons-dir",
+				Label:       "Check .toc files",
+				Description: "Check the .toc files of every addon in an addons directory for problems",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+					},
+				},
+				Fn: LintAddonsDirService,
+			},
+			{
+				ID:          "repair-addons-dir",
+				Label:       "Repair addons directory",
+				Description: "Find problems with the nfo data of every addon in an addons directory and propose fixes. Fixes are applied when confirmed, after backing up the original data.",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+						confirm_argdef(),
+					},
+				},
+				Fn: RepairAddonsDirService,
+			},
+			{
+				ID:          "disk-usage",
+				Label:       "Disk usage",
+				Description: "Show the disk space used by an addons directory, its largest addons and any leftover .zip files",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+					},
+				},
+				Fn: DiskUsageService,
+			},
+			{
+				ID:          "stale-addons",
+				Label:       "Find stale addons",
+				Description: "Find addons that haven't been updated in a while or don't support the current game version, and suggest replacements",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+						{
+							ID:          "months",
+							Label:       "Months",
+							Description: "Addons without a release in this many months are stale",
+							Default:     core.IntToString(STALE_MONTHS_DEFAULT),
+							Widget:      core.InputWidgetTextField,
+							Parser: func(app *core.App, val string) (any, error) {
+								return core.ParseStringAsInt(app, strings.TrimSpace(val))
+							},
+						},
+					},
+				},
+				Fn: StaleAddonsService,
+			},
+			{
+				ID:          "backup-wtf",
+				Label:       "Backup addon settings",
+				Description: "Backup the SavedVariables in the WTF directory of an addons directory",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+					},
+				},
+				Fn: BackupWTFService,
+			},
+			{
+				ID:          "list-wtf-backups",
+				Label:       "List addon settings backups",
+				Description: "List the backups of the SavedVariables in the WTF directory of an addons directory",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+					},
+				},
+				Fn: ListWTFBackupsService,
+			},
+			{
+				ID:          "diff-wtf-backup",
+				Label:       "Compare addon settings backup",
+				Description: "List the SavedVariables added, removed or changed since a backup was taken",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:     "selected",
+							Label:  "Selected Backup",
+							Widget: core.InputWidgetTextField,
+						},
+					},
+				},
+				Fn: DiffWTFBackupService,
+			},
+			{
+				ID:          "restore-wtf-backup",
+				Label:       "Restore addon settings backup",
+				Description: "Restore the SavedVariables in a backup. The current SavedVariables are backed up first. WoW should not be running.",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:     "selected",
+							Label:  "Selected Backup",
+							Widget: core.InputWidgetTextField,
+						},
+					},
 				},
-			*/
+				Fn: RestoreWTFBackupService,
+			},
 			{
-				Label:       "Refresh",
-				Description: "Reload addons, reload catalogues, check addons for updates, flush settings to disk, etc",
-				Fn:          RefreshService,
+				ID:          "snapshot-addons-dir",
+				Label:       "Snapshot addons directory",
+				Description: "Snapshot every addon in an addons directory, along with it's nfo data and versions",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+					},
+				},
+				Fn: SnapshotAddonsDirService,
 			},
-		},
-	}
-
-	catalogue_services := core.ServiceGroup{
-		NS: core.NS{Major: "strongbox", Minor: "catalogue", Type: "service"},
-		ServiceList: []core.Service{
 			{
-				Label:       "Catalogue info",
-				Description: "Displays information about each available catalogue, including the emergency catalogue.",
+				ID:          "list-addons-dir-snapshots",
+				Label:       "List addons directory snapshots",
+				Description: "List the snapshots of an addons directory",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+					},
+				},
+				Fn: ListAddonsDirSnapshotsService,
 			},
 			{
-				Label: "Update catalogues",
+				ID:          "restore-addons-dir-snapshot",
+				Label:       "Restore addons directory snapshot",
+				Description: "Restore an addons directory to exactly how it was when a snapshot was taken, removing addons installed since. The addons directory is snapshot first.",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:     "selected",
+							Label:  "Selected Snapshot",
+							Widget: core.InputWidgetTextField,
+						},
+					},
+				},
+				Fn: RestoreAddonsDirSnapshotService,
 			},
 			{
-				Label: "Switch active catalogue",
+				ID:          SERVICE_ID_EXPORT_ADDONS,
+				Label:       "Export addons",
+				Description: "Write the addons in an addons directory to a .json or .csv file that can be imported elsewhere",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+						{
+							ID:          "file",
+							Label:       "Export file",
+							Description: "A file ending in .json or .csv",
+							Default:     core.HomePath("/strongbox-addons.json"),
+							Widget:      core.InputWidgetTextField,
+							Parser:      core.ParseStringAsPath,
+							ValidatorList: []core.PredicateFn{
+								core.IsFilenameValidator,
+								core.FileDirIsWriteableValidator,
+							},
+						},
+					},
+				},
+				Fn: ExportAddonsService,
 			},
 			{
-				ID:          "install-catalogue-addon",
-				Label:       "Install catalogue addon",
-				Description: "Install an addon from the catalogue.",
+				ID:          SERVICE_ID_IMPORT_ADDONS,
+				Label:       "Import addons",
+				Description: "Install the addons in a .json or .csv export file that are missing from an addons directory",
 				Interface: core.ServiceInterface{
 					ArgDefList: []core.ArgDef{
 						extant_addons_dir_argdef(),
+						{
+							ID:          "file",
+							Label:       "Export file",
+							Description: "A .json or .csv file exported from strongbox",
+							Widget:      core.InputWidgetTextField,
+							Parser:      core.ParseStringAsPath,
+							ValidatorList: []core.PredicateFn{
+								func(val any) error {
+									path, _ := val.(string)
+									return core.FileExistsValidator(path)
+								},
+							},
+						},
 					},
 				},
-				Fn: InstallCatalogueAddonService,
+				Fn: ImportAddonsService,
 			},
-		},
-	}
-
-	addons_dir_services := core.ServiceGroup{
-		NS: core.NS{Major: "strongbox", Minor: "addons-dir", Type: "service"},
-		ServiceList: []core.Service{
 			{
-				ID:          SERVICE_ID_NEW_ADDONS_DIR,
-				Label:       "New addons directory",
-				Description: "Create a new addons directory",
+				ID:          SERVICE_ID_IMPORT_FROM_ADDON_MANAGER,
+				Label:       "Import from another addon manager",
+				Description: "Find addons installed by WowUp or CurseForge in the catalogue so strongbox can update them. Addons only available from CurseForge are listed with suggested alternatives.",
 				Interface: core.ServiceInterface{
 					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
 						{
-							ID:    "addons-dir",
-							Label: "Addons Directory",
-							//Widget:        core.InputWidgetDirSelection,
-							Widget:        core.InputWidgetTextField,
-							ValidatorList: []core.PredicateFn{core.IsDirValidator},
+							ID:          "manager",
+							Label:       "Addon manager",
+							Description: ADDON_MANAGER_WOWUP + ", " + ADDON_MANAGER_CURSEFORGE,
+							Default:     ADDON_MANAGER_WOWUP,
+							Widget:      core.InputWidgetTextField,
+							ValidatorList: []core.PredicateFn{
+								one_of_validator(ADDON_MANAGER_WOWUP, ADDON_MANAGER_CURSEFORGE),
+							},
+						},
+						{
+							ID:          "file",
+							Label:       "WowUp export file",
+							Description: "A file containing the text WowUp produces when exporting addons. Not used for CurseForge.",
+							Widget:      core.InputWidgetTextField,
+							Parser:      core.ParseStringAsPath,
 						},
 					},
 				},
-				Fn: NewAddonsDirService,
+				Fn: ImportFromAddonManagerService,
 			},
 			{
-				ID:          "remove-addons-dir",
-				Label:       "Remove addons directory",
-				Description: "Remove an addons directory",
+				ID:          "list-characters",
+				Label:       "List characters",
+				Description: "List the characters with settings in the WTF directory of an addons directory",
 				Interface: core.ServiceInterface{
 					ArgDefList: []core.ArgDef{
 						extant_addons_dir_argdef(),
-						//confirm_argdef(),
 					},
 				},
-				Fn: RemoveAddonsDirService,
+				Fn: ListCharactersService,
 			},
-			/*
-				{
-					ID:          "load-addons-dir",
-					Label:       "Load addons directory",
-					Description: "Loads a list of addons within an addons directory",
-					Interface: core.ServiceInterface{
-						ArgDefList: []core.ArgDef{
-							{
-								ID:    "addons-dir",
-								Label: "Addons Directory",
-								Choice: &core.ArgChoice{
-									ChoiceFn: func(app *core.App) []any {
-										// hrm, this is what I want but it's kinda sucky
-										choice_list := []any{}
-										for _, i := range app.FilterResultListByNS(NS_ADDONS_DIR) {
-											choice_list = append(choice_list, i)
-										}
-										return choice_list
-									},
-									Exclusivity: core.ArgChoiceExclusive,
-								},
-								//Parser:        nil,                  // todo: needs to select from known addon dirs
-								//ValidatorList: []core.PredicateFn{}, // todo: ensure directory is readable?
-							},
+			{
+				ID:          "save-addon-profile",
+				Label:       "Save addon profile",
+				Description: "Save the enabled and disabled addons of a character as a named profile, like 'raid' or 'leveling'",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+						{
+							ID:     "name",
+							Label:  "Profile name",
+							Widget: core.InputWidgetTextField,
+						},
+						{
+							ID:          "character",
+							Label:       "Character",
+							Description: "'Realm/Character' or just 'Character'",
+							Widget:      core.InputWidgetTextField,
 						},
 					},
-					Fn: LoadAddonDirService,
 				},
-			*/
+				Fn: SaveAddonProfileService,
+			},
 			{
-				ID:          "select-addons-dir",
-				Label:       "Select addons directory",
-				Description: "Selects an addons directory to check for updates",
+				ID:          "apply-addon-profile",
+				Label:       "Apply addon profile",
+				Description: "Enable and disable addons for characters using a named profile. WoW should not be running.",
 				Interface: core.ServiceInterface{
 					ArgDefList: []core.ArgDef{
 						extant_addons_dir_argdef(),
+						{
+							ID:     "name",
+							Label:  "Profile name",
+							Widget: core.InputWidgetTextField,
+						},
+						{
+							ID:          "character",
+							Label:       "Character",
+							Description: "'Realm/Character' or just 'Character'. Leave empty for all characters.",
+							Widget:      core.InputWidgetTextField,
+						},
 					},
 				},
-				Fn: SelectAddonsDirService,
+				Fn: ApplyAddonProfileService,
 			},
-
 			{
 				Label:       "Browse an addons directory",
 				Description: "Opens an addons directory in a file browser",
@@ -481,6 +2042,22 @@ func provider() []core.ServiceGroup {
 				},
 				Fn: RemoveAddonsService,
 			},
+			{
+				ID:          "uninstall-addon-keep-user-files",
+				Label:       "Un-install addon, keeping user files",
+				Description: "Remove an addon, including any bundled addons, but only the files installed by strongbox",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:            "selected",
+							Label:         "Selected Addons",
+							Widget:        core.InputWidgetTextField,
+							ValidatorList: []core.PredicateFn{},
+						},
+					},
+				},
+				Fn: RemoveAddonsKeepUserFilesService,
+			},
 			{
 				Label:       "Re-install addon",
 				Description: "Install an addon again, possibly for the first time through Strongbox",
@@ -491,10 +2068,176 @@ func provider() []core.ServiceGroup {
 				Description: "Check online for any updates but do not install them",
 				Fn:          CheckAddonService,
 			},
+			{
+				ID:          SERVICE_ID_PACKAGE_ADDON,
+				Label:       "Package addon",
+				Description: "Build a distributable .zip file and release.json from an addon project directory",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:            "dir",
+							Label:         "Project directory",
+							Description:   "An addon directory or a directory of addons",
+							Widget:        core.InputWidgetTextField,
+							ValidatorList: []core.PredicateFn{core.IsDirValidator},
+						},
+						{
+							ID:          "output-dir",
+							Label:       "Output directory",
+							Description: "Where to write the .zip file and release.json. Defaults to 'dist' in the project directory.",
+							Widget:      core.InputWidgetTextField,
+						},
+						{
+							ID:          "version",
+							Label:       "Version",
+							Description: "Defaults to the 'Version' in the addon's .toc file",
+							Widget:      core.InputWidgetTextField,
+						},
+					},
+				},
+				Fn: PackageAddonService,
+			},
 			{
 				ID:          "update-addon",
 				Label:       "Update addon",
 				Description: "Download and install any updates for the selected addon",
+				Fn:          UpdateAddonService,
+			},
+			{
+				ID:          SERVICE_ID_INSTALL_LOCAL_ADDON,
+				Label:       "Install addon from directory",
+				Description: "Install an addon from a directory or git checkout outside of the addons directory. Checking for updates compares the directory's current state.",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						extant_addons_dir_argdef(),
+						{
+							ID:            "dir",
+							Label:         "Directory",
+							Description:   "An addon directory or a directory of addons",
+							Widget:        core.InputWidgetTextField,
+							ValidatorList: []core.PredicateFn{core.IsDirValidator},
+						},
+						{
+							ID:          "symlink",
+							Label:       "Symlink",
+							Description: "Link to the directory rather than copying it. Requires the 'database' nfo store.",
+							Default:     "false",
+							Widget:      core.InputWidgetTextField,
+							ValidatorList: []core.PredicateFn{
+								core.IsTruthyFalsey,
+							},
+							Parser: core.ParseTruthyFalseyAsBool,
+						},
+					},
+				},
+				Fn: InstallLocalAddonService,
+			},
+			{
+				ID:          "set-addon-release-channel",
+				Label:       "Set release channel",
+				Description: "Choose whether an addon is offered stable, beta or alpha releases, with or without libraries. 'default' uses the addons directory setting.",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:          "selected",
+							Label:       "Selected Addons",
+							Description: "The ID of an installed addon.",
+							Widget:      core.InputWidgetTextField,
+						},
+						release_channel_argdef(),
+						release_type_argdef(),
+					},
+				},
+				Fn: SetAddonReleaseChannelService,
+			},
+			addon_release_channel_service("use-stable-releases", "Use stable releases", "Offer the addon just stable releases",
+				func(rc ReleaseChannel) ReleaseChannel {
+					rc.Stability = RELEASE_STABILITY_STABLE
+					return rc
+				}),
+			addon_release_channel_service("use-beta-releases", "Use beta releases", "Offer the addon beta and stable releases",
+				func(rc ReleaseChannel) ReleaseChannel {
+					rc.Stability = RELEASE_STABILITY_BETA
+					return rc
+				}),
+			addon_release_channel_service("use-alpha-releases", "Use alpha releases", "Offer the addon alpha, beta and stable releases",
+				func(rc ReleaseChannel) ReleaseChannel {
+					rc.Stability = RELEASE_STABILITY_ALPHA
+					return rc
+				}),
+			addon_release_channel_service("use-lib-releases", "Prefer releases with libraries", "Prefer releases of the addon that bundle their libraries",
+				func(rc ReleaseChannel) ReleaseChannel {
+					rc.Type = RELEASE_TYPE_LIB
+					return rc
+				}),
+			addon_release_channel_service("use-nolib-releases", "Prefer releases without libraries", "Prefer releases of the addon that don't bundle their libraries",
+				func(rc ReleaseChannel) ReleaseChannel {
+					rc.Type = RELEASE_TYPE_NOLIB
+					return rc
+				}),
+			addon_release_channel_service("use-default-release-channel", "Use default release channel", "Offer the addon releases using the addons directory release channel",
+				func(_ ReleaseChannel) ReleaseChannel {
+					return ReleaseChannel{}
+				}),
+			{
+				ID:          "enable-addon",
+				Label:       "Enable addon",
+				Description: "Enable an addon for every character. WoW should not be running.",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:     "selected",
+							Label:  "Selected Addons",
+							Widget: core.InputWidgetTextField,
+						},
+					},
+				},
+				Fn: EnableAddonService,
+			},
+			{
+				ID:          "disable-addon",
+				Label:       "Disable addon",
+				Description: "Disable an addon for every character. WoW should not be running.",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:     "selected",
+							Label:  "Selected Addons",
+							Widget: core.InputWidgetTextField,
+						},
+					},
+				},
+				Fn: DisableAddonService,
+			},
+			{
+				ID:          "enable-character-addon",
+				Label:       "Enable addon for character",
+				Description: "Enable an addon for just this character. WoW should not be running.",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:     "selected",
+							Label:  "Selected Characters",
+							Widget: core.InputWidgetTextField,
+						},
+					},
+				},
+				Fn: EnableCharacterAddonService,
+			},
+			{
+				ID:          "disable-character-addon",
+				Label:       "Disable addon for character",
+				Description: "Disable an addon for just this character. WoW should not be running.",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:     "selected",
+							Label:  "Selected Characters",
+							Widget: core.InputWidgetTextField,
+						},
+					},
+				},
+				Fn: DisableCharacterAddonService,
 			},
 			{
 				Label:       "Pin addon",
@@ -513,9 +2256,111 @@ func provider() []core.ServiceGroup {
 				Description: "If an addon is being ignored, this will stop ignoring it.",
 			},
 
-			// ungroup addon
-			// set primary addon
-			// find similar addons
+			{
+				ID:          "ungroup-addon",
+				Label:       "Ungroup addon",
+				Description: "Split an addon made of many directories into independent addons",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:     "selected",
+							Label:  "Selected Addon",
+							Widget: core.InputWidgetTextField,
+						},
+					},
+				},
+				Fn: UngroupAddonService,
+			},
+			{
+				ID:          "set-primary-addon",
+				Label:       "Set primary addon",
+				Description: "Make the selected directory of an addon made of many directories its primary",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:     "selected",
+							Label:  "Selected addon directory",
+							Widget: core.InputWidgetTextField,
+						},
+					},
+				},
+				Fn: SetPrimaryAddonService,
+			},
+			{
+				ID:          "list-stored-addon-zips",
+				Label:       "List stored zip files",
+				Description: "List the downloaded zip files of an addon that can be reinstalled without downloading them again",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:     "selected",
+							Label:  "Selected Addon",
+							Widget: core.InputWidgetTextField,
+						},
+					},
+				},
+				Fn: ListStoredAddonZipsService,
+			},
+			{
+				ID:          "reinstall-addon-from-store",
+				Label:       "Reinstall addon from zip store",
+				Description: "Reinstall the installed version of an addon from it's previously downloaded zip file, without using the network",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:     "selected",
+							Label:  "Selected Addon",
+							Widget: core.InputWidgetTextField,
+						},
+					},
+				},
+				Fn: ReinstallAddonFromStoreService,
+			},
+			{
+				ID:          "rollback-addon-from-store",
+				Label:       "Roll back addon",
+				Description: "Reinstall the version of an addon downloaded before the installed version, without using the network",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:     "selected",
+							Label:  "Selected Addon",
+							Widget: core.InputWidgetTextField,
+						},
+					},
+				},
+				Fn: RollbackAddonFromStoreService,
+			},
+			{
+				ID:          "find-similar-addons",
+				Label:       "Find similar addons",
+				Description: "Find maintained addons in the catalogue with similar tags and names",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:     "selected",
+							Label:  "Selected Addon",
+							Widget: core.InputWidgetTextField,
+						},
+					},
+				},
+				Fn: FindSimilarAddonsService,
+			},
+			{
+				ID:          "verify-addon",
+				Label:       "Verify addon",
+				Description: "Compare an addon's files with those installed, listing modified, missing and extra files",
+				Interface: core.ServiceInterface{
+					ArgDefList: []core.ArgDef{
+						{
+							ID:     "selected",
+							Label:  "Selected Addon",
+							Widget: core.InputWidgetTextField,
+						},
+					},
+				},
+				Fn: VerifyAddonService,
+			},
 			// switch source
 		},
 	}
@@ -591,16 +2436,75 @@ func (sp *StrongboxProvider) ItemHandlerMap() map[reflect.Type][]core.Service {
 		//revidx["new-addons-directory"],
 		GetKey("select-addons-dir", service_idx), // this is better, but overall it's still too manual
 		GetKey("remove-addons-dir", service_idx),
+		GetKey("set-addons-dir-release-channel", service_idx),
+		GetKey("set-addons-dir-preferences", service_idx),
+		GetKey("set-addons-dir-game-track", service_idx),
+		GetKey("lint-addons-dir", service_idx),
+		GetKey("repair-addons-dir", service_idx),
+		GetKey("disk-usage", service_idx),
+		GetKey("stale-addons", service_idx),
+		GetKey("backup-wtf", service_idx),
+		GetKey("list-wtf-backups", service_idx),
+		GetKey("snapshot-addons-dir", service_idx),
+		GetKey("list-addons-dir-snapshots", service_idx),
+		GetKey(SERVICE_ID_EXPORT_ADDONS, service_idx),
+		GetKey(SERVICE_ID_IMPORT_ADDONS, service_idx),
+		GetKey(SERVICE_ID_IMPORT_FROM_ADDON_MANAGER, service_idx),
+		GetKey("list-characters", service_idx),
+		GetKey("save-addon-profile", service_idx),
+		GetKey("apply-addon-profile", service_idx),
+	}
+	rv[reflect.TypeFor[WTFBackup]()] = []core.Service{
+		GetKey("diff-wtf-backup", service_idx),
+		GetKey("restore-wtf-backup", service_idx),
+	}
+	rv[reflect.TypeFor[AddonsDirSnapshot]()] = []core.Service{
+		GetKey("restore-addons-dir-snapshot", service_idx),
 	}
 	rv[reflect.TypeFor[Addon]()] = []core.Service{
 		GetKey("check-addon", service_idx),
 		GetKey("update-addon", service_idx),
 		GetKey("uninstall-addon", service_idx),
+		GetKey("uninstall-addon-keep-user-files", service_idx),
+		GetKey("use-stable-releases", service_idx),
+		GetKey("use-beta-releases", service_idx),
+		GetKey("use-alpha-releases", service_idx),
+		GetKey("use-lib-releases", service_idx),
+		GetKey("use-nolib-releases", service_idx),
+		GetKey("use-default-release-channel", service_idx),
+		GetKey("ungroup-addon", service_idx),
+		GetKey("list-stored-addon-zips", service_idx),
+		GetKey("reinstall-addon-from-store", service_idx),
+		GetKey("rollback-addon-from-store", service_idx),
+		GetKey("find-similar-addons", service_idx),
+		GetKey("verify-addon", service_idx),
+		GetKey("enable-addon", service_idx),
+		GetKey("disable-addon", service_idx),
 	}
 	rv[reflect.TypeFor[[]Addon]()] = []core.Service{
 		GetKey("check-addon", service_idx),
 		GetKey("update-addon", service_idx),
 		GetKey("uninstall-addon", service_idx),
+		GetKey("uninstall-addon-keep-user-files", service_idx),
+		GetKey("use-stable-releases", service_idx),
+		GetKey("use-beta-releases", service_idx),
+		GetKey("use-alpha-releases", service_idx),
+		GetKey("use-lib-releases", service_idx),
+		GetKey("use-nolib-releases", service_idx),
+		GetKey("use-default-release-channel", service_idx),
+		GetKey("enable-addon", service_idx),
+		GetKey("disable-addon", service_idx),
+	}
+	rv[reflect.TypeFor[InstalledAddon]()] = []core.Service{
+		GetKey("set-primary-addon", service_idx),
+	}
+	rv[reflect.TypeFor[AddonCharacterState]()] = []core.Service{
+		GetKey("enable-character-addon", service_idx),
+		GetKey("disable-character-addon", service_idx),
+	}
+	rv[reflect.TypeFor[[]AddonCharacterState]()] = []core.Service{
+		GetKey("enable-character-addon", service_idx),
+		GetKey("disable-character-addon", service_idx),
 	}
 	rv[reflect.TypeFor[CatalogueAddon]()] = []core.Service{
 		GetKey("install-catalogue-addon", service_idx),
@@ -619,10 +2523,17 @@ func (sp *StrongboxProvider) Menu() []core.Menu {
 	return []core.Menu{
 		{Name: "File", MenuItemList: []core.MenuItem{
 			{Name: "Install Addon From File", Fn: donothing},
+			{Name: "Install Addon From Directory", ServiceID: SERVICE_ID_INSTALL_LOCAL_ADDON},
 			{Name: "Import Addon", Fn: donothing},
+			{Name: "Import Addon List", ServiceID: SERVICE_ID_IMPORT_ADDONS},
+			{Name: "Export Addon List", ServiceID: SERVICE_ID_EXPORT_ADDONS},
+			{Name: "Import From WowUp or CurseForge", ServiceID: SERVICE_ID_IMPORT_FROM_ADDON_MANAGER},
 			core.MENU_SEP,
 			{Name: "New Addons Directory", ServiceID: SERVICE_ID_NEW_ADDONS_DIR},
+			{Name: "Detect Addons Directories", ServiceID: "detect-addons-dirs"},
 			{Name: "Update All", Fn: donothing},
+			core.MENU_SEP,
+			{Name: "Package Addon", ServiceID: SERVICE_ID_PACKAGE_ADDON},
 		}},
 		{Name: "Edit", MenuItemList: []core.MenuItem{
 			{Name: "Columns", Fn: donothing},
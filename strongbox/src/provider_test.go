@@ -0,0 +1,291 @@
+package strongbox
+
+import (
+	"bw/core"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// true/false arguments are accepted as bools when called directly and as strings when called from a form
+func Test_bool_arg(t *testing.T) {
+	cases := []struct {
+		given    any
+		expected bool
+	}{
+		{true, true},
+		{false, false},
+		{nil, false},
+		{"", false},
+		{"true", true},
+		{" True ", true},
+		{"false", false},
+		{"yes", true},
+		{"NO", false},
+	}
+	for _, c := range cases {
+		fnargs := core.ServiceFnArgs{ArgList: []core.KeyVal{{Key: "dir", Val: ""}, {Key: "confirm", Val: c.given}}}
+		actual, err := bool_arg(fnargs, 1, false)
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, actual, c.given)
+	}
+
+	// missing
+	actual, err := bool_arg(core.MakeServiceFnArgs("dir", ""), 1, false)
+	assert.Nil(t, err)
+	assert.False(t, actual)
+
+	// missing or empty, with a default
+	actual, err = bool_arg(core.MakeServiceFnArgs("dir", ""), 1, true)
+	assert.Nil(t, err)
+	assert.True(t, actual)
+	actual, err = bool_arg(core.ServiceFnArgs{ArgList: []core.KeyVal{{Key: "strict", Val: " "}}}, 0, true)
+	assert.Nil(t, err)
+	assert.True(t, actual)
+
+	// bad values
+	for _, given := range []any{"maybe", 1} {
+		_, err := bool_arg(core.ServiceFnArgs{ArgList: []core.KeyVal{{Key: "confirm", Val: given}}}, 0, false)
+		assert.NotNil(t, err)
+	}
+}
+
+// 'default' release channel choices defer to the next preference, unknown choices are rejected
+func Test_release_channel_from_args(t *testing.T) {
+	args := func(stability, release_type any) core.ServiceFnArgs {
+		return core.ServiceFnArgs{ArgList: []core.KeyVal{
+			{Key: "selected", Val: nil},
+			{Key: "release-channel", Val: stability},
+			{Key: "release-type", Val: release_type},
+		}}
+	}
+
+	actual, err := release_channel_from_args(args("beta", "nolib"), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, ReleaseChannel{Stability: RELEASE_STABILITY_BETA, Type: RELEASE_TYPE_NOLIB}, actual)
+
+	actual, err = release_channel_from_args(args(RELEASE_CHOICE_DEFAULT, ""), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, ReleaseChannel{}, actual)
+
+	_, err = release_channel_from_args(args("unstable", ""), 1)
+	assert.NotNil(t, err)
+
+	_, err = release_channel_from_args(args("", "some"), 1)
+	assert.NotNil(t, err)
+}
+
+// addons dirs are accepted as results from the context menu and as paths from a form
+func Test_addons_dir_arg(t *testing.T) {
+	app, stopfn := DummyApp2(t.TempDir())
+	defer stopfn()
+	ad := AddonsDir{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_RETAIL}
+	r := MakeAddonsDirResult(ad)
+	app.AddReplaceResults(r, MakeAddonsDirResult(AddonsDir{Path: "/tmp/.strongbox-bar"})).Wait()
+
+	for _, given := range []any{ad, &r, "/tmp/.strongbox-foo", " /tmp/.strongbox-foo/ "} {
+		actual, err := addons_dir_arg(app, core.MakeServiceFnArgs("addons-dir", given))
+		assert.Nil(t, err)
+		assert.Equal(t, ad.Path, actual.Path)
+	}
+
+	bad_result := core.MakeResult(NS_ADDON, Addon{}, "foo")
+	for _, given := range []any{"/tmp/.strongbox-baz", "", 1, &bad_result} {
+		_, err := addons_dir_arg(app, core.MakeServiceFnArgs("addons-dir", given))
+		assert.NotNil(t, err)
+	}
+}
+
+// WTF backups are accepted as results from the context menu and as result IDs from a form
+func Test_wtf_backup_arg(t *testing.T) {
+	app, stopfn := DummyApp2(t.TempDir())
+	defer stopfn()
+	backup := WTFBackup{AddonsDir: "/tmp/.strongbox-foo", Path: "/tmp/wtf--foo.zip"}
+	r := core.MakeResult(NS_WTF_BACKUP, backup, "backup-1")
+	bad_result := core.MakeResult(NS_ADDON, Addon{}, "foo")
+	app.AddReplaceResults(r, bad_result).Wait()
+
+	for _, given := range []any{backup, &r, "backup-1", " backup-1 "} {
+		actual, err := wtf_backup_arg(app, core.MakeServiceFnArgs("selected", given))
+		assert.Nil(t, err)
+		assert.Equal(t, backup, actual)
+	}
+
+	for _, given := range []any{"backup-2", "", "foo", 1, &bad_result} {
+		_, err := wtf_backup_arg(app, core.MakeServiceFnArgs("selected", given))
+		assert.NotNil(t, err)
+	}
+}
+
+// addons dir snapshots are accepted as results from the context menu and as result IDs from a form
+func Test_addons_dir_snapshot_arg(t *testing.T) {
+	app, stopfn := DummyApp2(t.TempDir())
+	defer stopfn()
+	snapshot := AddonsDirSnapshot{AddonsDir: "/tmp/.strongbox-foo", Path: "/tmp/snapshot--foo.zip"}
+	r := core.MakeResult(NS_ADDONS_DIR_SNAPSHOT, snapshot, "snapshot-1")
+	bad_result := core.MakeResult(NS_ADDON, Addon{}, "foo")
+	app.AddReplaceResults(r, bad_result).Wait()
+
+	for _, given := range []any{snapshot, &r, "snapshot-1", " snapshot-1 "} {
+		actual, err := addons_dir_snapshot_arg(app, core.MakeServiceFnArgs("selected", given))
+		assert.Nil(t, err)
+		assert.Equal(t, snapshot, actual)
+	}
+
+	for _, given := range []any{"snapshot-2", "", "foo", 1, &bad_result} {
+		_, err := addons_dir_snapshot_arg(app, core.MakeServiceFnArgs("selected", given))
+		assert.NotNil(t, err)
+	}
+}
+
+// the game tracks given as examples in the game track argument's description are accepted
+// a single addon is accepted as a result from the context menu and as a result ID from a form
+func Test_addon_result_arg(t *testing.T) {
+	app, stopfn := DummyApp2(t.TempDir())
+	defer stopfn()
+	a := Addon{Label: "EveryAddon"}
+	r := core.MakeResult(NS_ADDON, a, "everyaddon")
+	bad_result := core.MakeResult(NS_WTF_BACKUP, WTFBackup{}, "foo")
+	app.AddReplaceResults(r, bad_result).Wait()
+
+	for _, given := range []any{&r, []*core.Result{&r}, "everyaddon", " everyaddon "} {
+		actual, err := addon_result_arg(app, core.MakeServiceFnArgs("selected", given))
+		assert.Nil(t, err)
+		assert.Equal(t, a, actual)
+	}
+
+	for _, given := range []any{"everyotheraddon", "", "foo", 1, a, &bad_result, []*core.Result{&r, &r}, []*core.Result{}} {
+		_, err := addon_result_arg(app, core.MakeServiceFnArgs("selected", given))
+		assert.NotNil(t, err)
+	}
+
+	_, err := addon_result_arg(app, core.ServiceFnArgs{})
+	assert.NotNil(t, err)
+}
+
+func Test_game_track_validator(t *testing.T) {
+	for _, given := range []string{"retail", "classic", "classic-mop", " classic-cata "} {
+		assert.Nil(t, game_track_validator(given), given)
+	}
+	for _, given := range []any{"", "foo", "retail-classic", 1} {
+		assert.NotNil(t, game_track_validator(given), given)
+	}
+}
+
+// whole number arguments are accepted as ints when called directly and as strings when called from a form
+func Test_int_arg(t *testing.T) {
+	cases := []struct {
+		given    any
+		expected int
+	}{
+		{6, 6},
+		{nil, 12},
+		{"", 12},
+		{" 3 ", 3},
+		{"-1", -1},
+	}
+	for _, c := range cases {
+		fnargs := core.ServiceFnArgs{ArgList: []core.KeyVal{{Key: "addons-dir", Val: ""}, {Key: "months", Val: c.given}}}
+		actual, err := int_arg(fnargs, 1, 12)
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, actual, c.given)
+	}
+
+	// missing
+	actual, err := int_arg(core.MakeServiceFnArgs("addons-dir", ""), 1, 12)
+	assert.Nil(t, err)
+	assert.Equal(t, 12, actual)
+
+	// bad values
+	for _, given := range []any{"six", "1.5", true} {
+		_, err := int_arg(core.ServiceFnArgs{ArgList: []core.KeyVal{{Key: "months", Val: given}}}, 0, 12)
+		assert.NotNil(t, err)
+	}
+}
+
+// the primary directory of an addon is set from the selected addon directory
+func Test_SetPrimaryAddonService(t *testing.T) {
+	app, stopfn := DummyApp2(t.TempDir())
+	defer stopfn()
+
+	addons_dir := grouped_test_addons_dir(t, "Mid", "Zed")
+	addon_list, err := LoadAllInstalledAddons(addons_dir)
+	assert.Nil(t, err)
+	app.AddReplaceResults(core.MakeResult(NS_ADDON, addon_list[0], "everyaddon")).Wait()
+
+	var selected *core.Result
+	for _, r := range app.FilterResultListByNS(NS_INSTALLED_ADDON) {
+		if r.Item.(InstalledAddon).Name == "Zed" {
+			selected = &r
+		}
+	}
+	assert.NotNil(t, selected)
+
+	res := SetPrimaryAddonService(app, core.MakeServiceFnArgs("selected", selected))
+	assert.Nil(t, res.Err)
+
+	addon_list, err = LoadAllInstalledAddons(addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, "Zed", addon_list[0].Primary.Name)
+
+	// an addon can't be the primary of itself
+	res = SetPrimaryAddonService(app, core.MakeServiceFnArgs("selected", app.GetResult("everyaddon")))
+	assert.NotNil(t, res.Err)
+}
+
+// an addon is ungrouped from a result ID, a bad selection is an error and not silently ignored
+func Test_UngroupAddonService(t *testing.T) {
+	app, stopfn := DummyApp2(t.TempDir())
+	defer stopfn()
+
+	addons_dir := grouped_test_addons_dir(t, "Mid", "Zed")
+	addon_list, err := LoadAllInstalledAddons(addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(addon_list))
+	app.AddReplaceResults(core.MakeResult(NS_ADDON, addon_list[0], "everyaddon")).Wait()
+
+	res := UngroupAddonService(app, core.MakeServiceFnArgs("selected", "everyotheraddon"))
+	assert.NotNil(t, res.Err)
+
+	res = UngroupAddonService(app, core.MakeServiceFnArgs("selected", "everyaddon"))
+	assert.Nil(t, res.Err)
+
+	addon_list, err = LoadAllInstalledAddons(addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(addon_list))
+}
+
+// the WTF directory is backed up and the addons dir snapshot only when there is something to update,
+// a failed backup stops the update
+func Test_UpdateAddonsService__backup(t *testing.T) {
+	tmpdir := t.TempDir()
+	app, stopfn := DummyApp2(tmpdir)
+	defer stopfn()
+
+	// no WTF directory to backup
+	ad := MakeAddonsDir(filepath.Join(tmpdir, "_retail_", "Interface", "AddOns"))
+	ad.BackupWTFBeforeUpdate = new(true)
+	assert.Nil(t, os.MkdirAll(ad.Path, 0755))
+	settings := FindSettings(app)
+	settings.AddonsDirList = []AddonsDir{ad}
+	settings.Preferences.SelectedAddonsDir = ad.Path
+	settings.Preferences.SnapshotBeforeBulkOperation = new(true)
+	app.AddReplaceResults(core.MakeResult(NS_SETTINGS, settings, ID_SETTINGS), MakeAddonsDirResult(ad)).Wait()
+
+	res := UpdateAddonsService(app, core.ServiceFnArgs{})
+	assert.Nil(t, res.Err)
+
+	su := SourceUpdate{Version: "1.2.4", DownloadURL: "https://example.org/everyaddon-1.2.4.zip"}
+	a := Addon{Name: "everyaddon", AddonsDir: &ad, InstalledVersion: "1.2.3", SourceUpdateList: []SourceUpdate{su}, SourceUpdate: &su}
+	assert.True(t, Updateable(a))
+	app.AddReplaceResults(core.MakeResult(NS_ADDON, a, "everyaddon")).Wait()
+
+	res = UpdateAddonsService(app, core.ServiceFnArgs{})
+	assert.NotNil(t, res.Err)
+
+	snapshot_list, err := ListAddonsDirSnapshots(snapshot_root(app), ad.Path)
+	assert.Nil(t, err)
+	assert.Empty(t, snapshot_list)
+}
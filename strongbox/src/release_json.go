@@ -16,16 +16,17 @@ var (
 	RELEASE_JSON_FLAVOR_BCC      ReleaseJSONFlavor = "bcc"
 	RELEASE_JSON_FLAVOR_WRATH    ReleaseJSONFlavor = "wrath"
 	RELEASE_JSON_FLAVOR_CATA     ReleaseJSONFlavor = "cata"
+	RELEASE_JSON_FLAVOR_MISTS    ReleaseJSONFlavor = "mists"
 )
 
-// mapping of release.json flavors/gametracks to strongbox canonical gametracks.
-// note: these are also captured entirely in the `GAMETRACK_ALIAS_MAP`
-var RELEASE_JSON_GAMETRACK_MAP = map[ReleaseJSONFlavor]GameTrackID{
-	RELEASE_JSON_FLAVOR_MAINLINE: GAMETRACK_RETAIL,
-	RELEASE_JSON_FLAVOR_CLASSIC:  GAMETRACK_CLASSIC,
-	RELEASE_JSON_FLAVOR_BCC:      GAMETRACK_CLASSIC_TBC,
-	RELEASE_JSON_FLAVOR_WRATH:    GAMETRACK_CLASSIC_WOTLK,
-	RELEASE_JSON_FLAVOR_CATA:     GAMETRACK_CLASSIC_CATA,
+// returns the strongbox canonical game track for a release.json `flavor`, using the game track registry.
+// unknown flavors are guessed.
+func release_json_flavor_game_track(flavor ReleaseJSONFlavor) GameTrackID {
+	game_track_id, present := game_tracks().FindReleaseJSONFlavor(flavor)
+	if present {
+		return game_track_id
+	}
+	return GuessGameTrack(flavor)
 }
 
 type ReleaseJSONMetadata struct {
@@ -61,7 +62,7 @@ func ReleaseJSONGameTrackList(rj ReleaseJSON) mapset.Set[GameTrackID] {
 	set := mapset.NewSet[GameTrackID]()
 	for _, rl := range rj.ReleaseList {
 		for _, md := range rl.MetadataList {
-			set.Add(GuessGameTrack(md.Flavor))
+			set.Add(release_json_flavor_game_track(md.Flavor))
 		}
 	}
 	return set
@@ -72,9 +73,36 @@ func ReleaseJSONGameTrackMap(rj ReleaseJSON) map[string]mapset.Set[GameTrackID]
 	for _, rl := range rj.ReleaseList {
 		set := mapset.NewSet[GameTrackID]()
 		for _, md := range rl.MetadataList {
-			set.Add(GuessGameTrack(md.Flavor))
+			set.Add(release_json_flavor_game_track(md.Flavor))
 		}
 		m[rl.Filename] = set
 	}
 	return m
 }
+
+// returns a map of asset filename => set of supported interface versions.
+// assets without any interface versions are excluded.
+func ReleaseJSONInterfaceVersionMap(rj ReleaseJSON) map[string]mapset.Set[int] {
+	m := map[string]mapset.Set[int]{}
+	for _, rl := range rj.ReleaseList {
+		set := mapset.NewSet[int]()
+		for _, md := range rl.MetadataList {
+			if md.Interface > 0 {
+				set.Add(md.Interface)
+			}
+		}
+		if !set.IsEmpty() {
+			m[rl.Filename] = set
+		}
+	}
+	return m
+}
+
+// returns a map of asset filename => `true` if the release.json says the asset doesn't bundle it's libraries.
+func ReleaseJSONNoLibMap(rj ReleaseJSON) map[string]bool {
+	m := map[string]bool{}
+	for _, rl := range rj.ReleaseList {
+		m[rl.Filename] = rl.NoLib
+	}
+	return m
+}
@@ -25,6 +25,13 @@ const (
 	GUI_THEME_DARK_ORANGE GUITheme = "dark-orange"
 )
 
+var GUI_THEME_LIST = []GUITheme{
+	GUI_THEME_LIGHT,
+	GUI_THEME_DARK,
+	GUI_THEME_DARK_GREEN,
+	GUI_THEME_DARK_ORANGE,
+}
+
 // if the user provides their own catalogue list in their config file, it will override these defaults entirely.
 // if the `catalogue-location-list` entry is *missing* in the user config file, these will be used instead.
 // to use strongbox with no catalogues at all, use `catalogue-location-list []` (empty list) in the user config.
@@ -83,11 +90,12 @@ var COL_LIST_KNOWN = []string{
 	"tag-list",
 	"created-date",
 	"updated-date",
-	"dirsize",
+	"size", // "dirsize" in strongbox 7
 	"installed-version",
 	"available-version",
 	"combined-version",
 	"game-version",
+	"outdated",
 }
 
 // specs.clj/default-column-list--v1
@@ -134,10 +142,11 @@ var COL_LIST_FAT = []string{
 	"tag-list",
 	"created-date",
 	"updated-date",
-	"dirsize",
+	"size",
 	"installed-version",
 	"available-version",
 	"game-version",
+	"outdated",
 	"uber-version",
 }
 
@@ -150,24 +159,43 @@ var COL_PRESET_LIST = map[string][]string{
 // ---
 
 type Preferences struct {
-	AddonZipsToKeep          *uint8   `json:"addon-zips-to-keep,omitempty"`          // nil is 'keep all', 0 is 'keep zero', 1 is 'keep one', etc
-	CheckForUpdate           *bool    `json:"check-for-update,omitempty"`            // future: false
-	KeepUserCatalogueUpdated *bool    `json:"keep-user-catalogue-updated,omitempty"` // todo: "keep-user-catalogue-updated?"
-	SelectedAddonsDir        string   `json:"selected-addon-dir"`
-	SelectedCatalogue        string   `json:"selected-catalogue"` // todo: enum
-	SelectedColumns          []string `json:"ui-selected-columns"`
-	SelectedGUITheme         GUITheme `json:"selected-gui-theme"`
+	AddonZipsToKeep          *uint8         `json:"addon-zips-to-keep,omitempty"`          // nil is 'keep all', 0 is 'keep zero', 1 is 'keep one', etc
+	CheckForUpdate           *bool          `json:"check-for-update,omitempty"`            // future: false
+	KeepUserCatalogueUpdated *bool          `json:"keep-user-catalogue-updated,omitempty"` // todo: "keep-user-catalogue-updated?"
+	SelectedAddonsDir        string         `json:"selected-addon-dir"`
+	SelectedCatalogue        string         `json:"selected-catalogue"` // todo: enum
+	SelectedColumns          []string       `json:"ui-selected-columns"`
+	SelectedGUITheme         GUITheme       `json:"selected-gui-theme"`
+	GithubToken              string         `json:"github-token,omitempty"`             // optional. falls back to the GITHUB_TOKEN environment variable
+	WTFBackupsToKeep         *uint8         `json:"wtf-backups-to-keep,omitempty"`      // per addons dir
+	BackupWTFBeforeUpdate    *bool          `json:"backup-wtf-before-update,omitempty"` // snapshot SavedVariables before updating all addons
+	AddonProfileList         []AddonProfile `json:"addon-profile-list,omitempty"`       // named sets of enabled addons per addons dir
+	NFOStore                 string         `json:"nfo-store,omitempty"`                // where nfo data is kept, 'file' (default) or 'database'
+
+	AddonsDirSnapshotsToKeep    *uint8 `json:"addons-dir-snapshots-to-keep,omitempty"`   // per addons dir
+	SnapshotBeforeBulkOperation *bool  `json:"snapshot-before-bulk-operation,omitempty"` // snapshot the addons dir before updating all or importing addons
+
+	// the releases addons should be offered when neither the addon nor it's addons dir have a preference.
+	ReleaseChannel
 }
 
 // ---
 
 type Settings struct {
+	// new in 8.0, the version of the settings format. settings files without one are version 0.
+	// see `settings_migration.go`
+	SpecVersion int `json:"spec-version"`
+
 	AddonsDirList         []AddonsDir         `json:"addon-dir-list"` // note: do not rename 'addons-dir-list'
 	CatalogueLocationList []CatalogueLocation `json:"catalogue-location-list"`
 	Preferences           Preferences         `json:"preferences"`
 
+	// new in 8.0, game tracks that extend or replace those in the default game track registry.
+	// see `game_tracks.json`
+	GameTrackList []GameTrack `json:"game-track-list,omitempty"`
+
 	// deprecated.
-	// do not preserve these values when writing settings files
+	// ignored once migrated. strongbox 7 reads these from the top of the settings file, see `v7_settings`.
 
 	DeprecatedGUITheme          GUITheme `json:"gui-theme,omitempty"`          // moved to Preferences.SelectedGUITheme in 8.0
 	DeprecatedSelectedCatalog   string   `json:"selected-catalog,omitempty"`   // moved to Preferences.SelectedCatalogue circa 1.0
@@ -177,6 +205,7 @@ type Settings struct {
 
 func NewSettings() Settings {
 	c := Settings{
+		SpecVersion:           SETTINGS_SPEC_VERSION,
 		AddonsDirList:         []AddonsDir{},
 		CatalogueLocationList: DEFAULT_CATALOGUE_LOC_LIST,
 		Preferences: Preferences{
@@ -187,6 +216,11 @@ func NewSettings() Settings {
 			SelectedCatalogue:        DEFAULT_CATALOGUE_LOC.Name,
 			SelectedColumns:          COL_LIST_DEFAULT,
 			SelectedGUITheme:         GUI_THEME_LIGHT,
+			WTFBackupsToKeep:         new(WTF_BACKUPS_TO_KEEP_DEFAULT),
+			BackupWTFBeforeUpdate:    new(false),
+
+			AddonsDirSnapshotsToKeep:    new(ADDONS_DIR_SNAPSHOTS_TO_KEEP_DEFAULT),
+			SnapshotBeforeBulkOperation: new(true),
 		},
 
 		DeprecatedGUITheme: GUI_THEME_LIGHT, // deprecated
@@ -218,7 +252,12 @@ func read_settings_file(path PathToFile) (Settings, error) {
 		return empty_result, err
 	}
 
-	var settings Settings
+	settings, present := v8_settings(data)
+	if present {
+		return settings, nil
+	}
+
+	// strongbox 7 settings, or strongbox 8 settings from before they were kept beneath a 'strongbox8' key.
 	err = json.Unmarshal(data, &settings)
 	if err != nil {
 		return empty_result, err
@@ -227,7 +266,8 @@ func read_settings_file(path PathToFile) (Settings, error) {
 	return settings, nil
 }
 
-// configures/parses/validates settings data
+// configures/parses/validates settings data.
+// settings are migrated to the current spec version, invalid entries are reported and dropped and then missing values are defaulted.
 func configure_settings(settings Settings) Settings {
 	default_settings := NewSettings()
 
@@ -236,49 +276,40 @@ func configure_settings(settings Settings) Settings {
 	// 'handle install dir'
 	// - going to remove this in 8.0, it doesn't fit neatly anymore
 
-	// 'remove invalid catalogue location entries'
 	// 'handle column preferences'
 
-	// - gui theme can only be certain values otherwise data fails to load
+	settings = migrate_settings(settings)
+	settings = without_v7_settings(settings)
 
-	// new in 8.0
-	// selected addon dir, catalogue, gui-theme moved to preferences and removed from output settings
-
-	if settings.DeprecatedSelectedAddonDir != "" {
-		settings.Preferences.SelectedAddonsDir = settings.DeprecatedSelectedAddonDir
+	settings, issue_list := validate_settings(settings)
+	for _, issue := range issue_list {
+		slog.Warn("invalid settings", "issue", issue)
 	}
 
-	if settings.DeprecatedSelectedCatalog != "" {
-		// very old setting is in use and the new location is empty.
-		if settings.Preferences.SelectedCatalogue == "" {
-			settings.Preferences.SelectedCatalogue = settings.DeprecatedSelectedCatalog
-		} else {
-			slog.Warn("'settings.selected-catalog' is set and will be ignored")
-		}
-	}
+	// --- set defaults if empty
 
-	if settings.DeprecatedSelectedCatalogue != "" {
-		settings.Preferences.SelectedCatalogue = settings.DeprecatedSelectedCatalogue
+	if len(settings.CatalogueLocationList) == 0 {
+		settings.CatalogueLocationList = DEFAULT_CATALOGUE_LOC_LIST
 	}
 
-	if settings.DeprecatedGUITheme != "" {
-		settings.Preferences.SelectedGUITheme = settings.DeprecatedGUITheme
+	if settings.Preferences.CheckForUpdate == nil {
+		settings.Preferences.CheckForUpdate = default_settings.Preferences.CheckForUpdate
 	}
 
-	// empty whatever values we have stored here to prevent them being propagated forwards.
-	settings.DeprecatedSelectedCatalog = ""
-	settings.DeprecatedSelectedCatalogue = ""
-	settings.DeprecatedSelectedAddonDir = ""
-	settings.DeprecatedGUITheme = ""
+	if settings.Preferences.WTFBackupsToKeep == nil {
+		settings.Preferences.WTFBackupsToKeep = default_settings.Preferences.WTFBackupsToKeep
+	}
 
-	// --- set defaults if empty
+	if settings.Preferences.BackupWTFBeforeUpdate == nil {
+		settings.Preferences.BackupWTFBeforeUpdate = default_settings.Preferences.BackupWTFBeforeUpdate
+	}
 
-	if len(settings.CatalogueLocationList) == 0 {
-		settings.CatalogueLocationList = DEFAULT_CATALOGUE_LOC_LIST
+	if settings.Preferences.AddonsDirSnapshotsToKeep == nil {
+		settings.Preferences.AddonsDirSnapshotsToKeep = default_settings.Preferences.AddonsDirSnapshotsToKeep
 	}
 
-	if settings.Preferences.CheckForUpdate == nil {
-		settings.Preferences.CheckForUpdate = default_settings.Preferences.CheckForUpdate
+	if settings.Preferences.SnapshotBeforeBulkOperation == nil {
+		settings.Preferences.SnapshotBeforeBulkOperation = default_settings.Preferences.SnapshotBeforeBulkOperation
 	}
 
 	if settings.Preferences.KeepUserCatalogueUpdated == nil {
@@ -297,54 +328,6 @@ func configure_settings(settings Settings) Settings {
 		settings.Preferences.SelectedGUITheme = default_settings.Preferences.SelectedGUITheme
 	}
 
-	// --- fix up catalogues
-
-	new_cat_locs := []CatalogueLocation{}
-	has_github := false
-	for _, cl := range settings.CatalogueLocationList {
-		// 'remove curseforge catalogue'
-		if cl.Name == CAT_CURSEFORGE.Name {
-			continue
-		}
-
-		// 'remove tukui catalogue'
-		if cl.Name == CAT_TUKUI.Name {
-			continue
-		}
-
-		if cl.Name == CAT_GITHUB.Name {
-			has_github = true
-		}
-
-		new_cat_locs = append(new_cat_locs, cl)
-	}
-	// 'add github catalogue'
-	if !has_github {
-		new_cat_locs = append(new_cat_locs, CAT_GITHUB)
-	}
-	settings.CatalogueLocationList = new_cat_locs
-
-	// --- handle addon dirs
-
-	// remove any invalid addon dirs (DNE, ...)
-	new_addon_dirs := []AddonsDir{}
-	for _, ad := range settings.AddonsDirList {
-		// `/tmp` prefix check is for testing fixtures
-		if !core.DirExists(ad.Path) && !strings.HasPrefix(ad.Path, "/tmp/") {
-			continue
-		}
-
-		// handle 'strict?' potentially missing and defaulting to 'false'
-		if ad.StrictPtr == nil || *ad.StrictPtr {
-			ad.Strict = true
-		}
-
-		ad.StrictPtr = nil
-
-		new_addon_dirs = append(new_addon_dirs, ad)
-	}
-	settings.AddonsDirList = new_addon_dirs
-
 	// 'handle selected addon dir'
 	// - selected addon dir must exist in list of addon dirs and also exist on fs
 	present := false
@@ -355,13 +338,6 @@ func configure_settings(settings Settings) Settings {
 		settings.Preferences.SelectedAddonsDir = settings.AddonsDirList[0].Path
 	}
 
-	// --- handle compound game tracks
-	for i, ad := range settings.AddonsDirList {
-		if is_compound_game_track(ad.GameTrackID) {
-			settings.AddonsDirList[i] = convert_compound_game_track(ad)
-		}
-	}
-
 	return settings
 }
 
@@ -370,14 +346,40 @@ func configure_settings(settings Settings) Settings {
 // configures/parses/validates the unmarshaled data and then stores it in app state.
 func LoadSettings(app *core.App) {
 	slog.Info("loading settings")
-	settings, err := read_settings_file(app.State.GetKeyVal("strongbox.paths.cfg-file"))
+	cfg_file := app.State.GetKeyVal("strongbox.paths.cfg-file")
+	settings, err := read_settings_file(cfg_file)
 	if err != nil {
 		slog.Warn("failed to load settings, using default settings", "error", err)
 		settings = NewSettings()
 	}
+	spec_version := settings.SpecVersion
+
+	// game tracks are needed to validate addons dirs
+	err = set_game_track_registry(settings.GameTrackList)
+	if err != nil {
+		slog.Error("failed to load game tracks from settings, using default game tracks", "error", err)
+	}
 
 	settings = configure_settings(settings)
 
+	// settings were migrated, backup the original file before it's replaced.
+	if spec_version < settings.SpecVersion && core.FileExists(cfg_file) {
+		backup_path, err := backup_settings_file(cfg_file, spec_version)
+		if err != nil {
+			slog.Error("failed to backup settings before migrating", "error", err)
+		} else {
+			slog.Info("settings backed up before migrating", "backup", backup_path)
+			err = save_settings_file(settings, cfg_file)
+			if err != nil {
+				slog.Error("failed to write migrated settings", "cfg-file", cfg_file, "error", err)
+			}
+		}
+	}
+
+	settings.Preferences.NFOStore = configure_nfo_store(app, settings.Preferences.NFOStore)
+	configure_zip_store(app)
+	set_default_release_channel(settings.Preferences.ReleaseChannel)
+
 	result_list := []core.Result{}
 
 	result := core.MakeResult(NS_SETTINGS, settings, ID_SETTINGS)
@@ -429,11 +431,11 @@ func FindSettings(app *core.App) Settings {
 
 // ---
 
-// writes `settings` to `cfg_file` as prettified JSON
+// writes `settings` to `cfg_file` as prettified JSON that strongbox 7 can also read
 func save_settings_file(settings Settings, cfg_file PathToFile) error {
 	prefix := ""
 	indent := "    "
-	b, err := json.MarshalIndent(settings, prefix, indent)
+	b, err := json.MarshalIndent(v7_settings(settings), prefix, indent)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,225 @@
+package strongbox
+
+import (
+	"bw/core"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// settings_migration.go upgrades settings files written by older versions of strongbox.
+// each migration upgrades settings from the previous spec version to it's own and runs at most once per settings file.
+// migrations are never removed or reordered, new migrations are appended.
+
+type settings_migration struct {
+	version     int    // spec version of the settings after this migration
+	description string // what changed
+	fn          func(Settings) Settings
+}
+
+var settings_migration_list = []settings_migration{
+	{1, "selected addons dir, catalogue and gui theme moved into preferences", migrate_settings__1},
+	{2, "dead catalogues removed and the github catalogue added", migrate_settings__2},
+	{3, "addons dirs missing 'strict?' are strict", migrate_settings__3},
+	{4, "compound game tracks replaced", migrate_settings__4},
+}
+
+// the spec version of settings written by this version of strongbox.
+var SETTINGS_SPEC_VERSION = settings_migration_list[len(settings_migration_list)-1].version
+
+// new in 8.0.
+// selected addon dir, catalogue and gui theme moved to preferences and removed from output settings.
+func migrate_settings__1(settings Settings) Settings {
+	if settings.DeprecatedSelectedAddonDir != "" {
+		settings.Preferences.SelectedAddonsDir = settings.DeprecatedSelectedAddonDir
+	}
+
+	if settings.DeprecatedSelectedCatalog != "" {
+		// very old setting is in use and the new location is empty.
+		if settings.Preferences.SelectedCatalogue == "" {
+			settings.Preferences.SelectedCatalogue = settings.DeprecatedSelectedCatalog
+		} else {
+			slog.Warn("'settings.selected-catalog' is set and will be ignored")
+		}
+	}
+
+	if settings.DeprecatedSelectedCatalogue != "" {
+		settings.Preferences.SelectedCatalogue = settings.DeprecatedSelectedCatalogue
+	}
+
+	if settings.DeprecatedGUITheme != "" {
+		settings.Preferences.SelectedGUITheme = settings.DeprecatedGUITheme
+	}
+
+	// empty whatever values we have stored here to prevent them being propagated forwards.
+	settings.DeprecatedSelectedCatalog = ""
+	settings.DeprecatedSelectedCatalogue = ""
+	settings.DeprecatedSelectedAddonDir = ""
+	settings.DeprecatedGUITheme = ""
+
+	return settings
+}
+
+// 'remove curseforge catalogue', 'remove tukui catalogue', 'add github catalogue'.
+// an empty catalogue list is left empty, the default catalogues are used instead.
+func migrate_settings__2(settings Settings) Settings {
+	if len(settings.CatalogueLocationList) == 0 {
+		return settings
+	}
+
+	new_cat_locs := []CatalogueLocation{}
+	has_github := false
+	for _, cl := range settings.CatalogueLocationList {
+		if cl.Name == CAT_CURSEFORGE.Name || cl.Name == CAT_TUKUI.Name {
+			continue
+		}
+		if cl.Name == CAT_GITHUB.Name {
+			has_github = true
+		}
+		new_cat_locs = append(new_cat_locs, cl)
+	}
+	if !has_github {
+		new_cat_locs = append(new_cat_locs, CAT_GITHUB)
+	}
+	settings.CatalogueLocationList = new_cat_locs
+	return settings
+}
+
+// handle 'strict?' potentially missing and defaulting to 'false'.
+// 'strict?' was renamed 'strict' in 8.0.
+func migrate_settings__3(settings Settings) Settings {
+	new_addons_dir_list := []AddonsDir{}
+	for _, ad := range settings.AddonsDirList {
+		if ad.StrictPtr == nil || *ad.StrictPtr {
+			ad.Strict = true
+		}
+		ad.StrictPtr = nil
+		new_addons_dir_list = append(new_addons_dir_list, ad)
+	}
+	settings.AddonsDirList = new_addons_dir_list
+	return settings
+}
+
+// 'compound' game tracks like 'retail-classic' were removed in 8.0.
+func migrate_settings__4(settings Settings) Settings {
+	new_addons_dir_list := []AddonsDir{}
+	for _, ad := range settings.AddonsDirList {
+		if is_compound_game_track(ad.GameTrackID) {
+			ad = convert_compound_game_track(ad)
+		}
+		new_addons_dir_list = append(new_addons_dir_list, ad)
+	}
+	settings.AddonsDirList = new_addons_dir_list
+	return settings
+}
+
+// ---
+
+// applies each migration newer than the spec version of `settings`, in order.
+// settings from a newer version of strongbox are left as they are.
+func migrate_settings(settings Settings) Settings {
+	if settings.SpecVersion > SETTINGS_SPEC_VERSION {
+		slog.Warn("settings are from a newer version of strongbox, not migrating", "spec-version", settings.SpecVersion, "supported-spec-version", SETTINGS_SPEC_VERSION)
+		return settings
+	}
+	for _, migration := range settings_migration_list {
+		if settings.SpecVersion >= migration.version {
+			continue
+		}
+		slog.Info("migrating settings", "spec-version", migration.version, "description", migration.description)
+		settings = migration.fn(settings)
+		settings.SpecVersion = migration.version
+	}
+	return settings
+}
+
+// validates each catalogue location, addons dir and preference in `settings`.
+// invalid catalogue locations and addons dirs are dropped, invalid preferences are emptied and later defaulted.
+// returns the valid settings and a description of each problem found.
+func validate_settings(settings Settings) (Settings, []string) {
+	issue_list := []string{}
+
+	// 'remove invalid catalogue location entries'
+	new_cat_locs := []CatalogueLocation{}
+	seen_names := []string{}
+	for _, cl := range settings.CatalogueLocationList {
+		issues := cl.Valid()
+		if issues != nil {
+			issue_list = append(issue_list, fmt.Sprintf("dropping catalogue location '%s': %s", cl.Name, format_spec_err(issues)))
+			continue
+		}
+		if slices.Contains(seen_names, cl.Name) {
+			issue_list = append(issue_list, fmt.Sprintf("dropping catalogue location '%s': duplicate name", cl.Name))
+			continue
+		}
+		seen_names = append(seen_names, cl.Name)
+		new_cat_locs = append(new_cat_locs, cl)
+	}
+	settings.CatalogueLocationList = new_cat_locs
+
+	// remove any invalid addon dirs (DNE, ...)
+	new_addons_dir_list := []AddonsDir{}
+	for _, ad := range settings.AddonsDirList {
+		issues := ad.Valid()
+		if issues != nil {
+			issue_list = append(issue_list, fmt.Sprintf("dropping addons directory '%s': %s", ad.Path, format_spec_err(issues)))
+			continue
+		}
+
+		// `/tmp` prefix check is for testing fixtures
+		if !core.DirExists(ad.Path) && !strings.HasPrefix(ad.Path, "/tmp/") {
+			issue_list = append(issue_list, fmt.Sprintf("dropping addons directory '%s': directory not found", ad.Path))
+			continue
+		}
+
+		issues = ad.ReleaseChannel.Valid()
+		if issues != nil {
+			issue_list = append(issue_list, fmt.Sprintf("ignoring release channel of addons directory '%s': %s", ad.Path, format_spec_err(issues)))
+			ad.ReleaseChannel = ReleaseChannel{}
+		}
+
+		new_addons_dir_list = append(new_addons_dir_list, ad)
+	}
+	settings.AddonsDirList = new_addons_dir_list
+
+	issues := settings.Preferences.Valid()
+	if issues != nil {
+		issue_list = append(issue_list, fmt.Sprintf("ignoring preferences: %s", format_spec_err(issues)))
+		prefs := reflect.ValueOf(&settings.Preferences).Elem()
+		for field := range issues {
+			val := prefs.FieldByName(field)
+			if val.IsValid() {
+				val.SetZero()
+			}
+		}
+	}
+
+	issues = settings.Preferences.ReleaseChannel.Valid()
+	if issues != nil {
+		issue_list = append(issue_list, fmt.Sprintf("ignoring release channel preference: %s", format_spec_err(issues)))
+		settings.Preferences.ReleaseChannel = ReleaseChannel{}
+	}
+
+	return settings, issue_list
+}
+
+// copies the settings file at `path` to a file named after it's spec version, unless that file already exists.
+// "/path/to/config.json" => "/path/to/config.json.spec-0.bak"
+func backup_settings_file(path PathToFile, spec_version int) (PathToFile, error) {
+	backup_path := fmt.Sprintf("%s.spec-%d.bak", path, spec_version)
+	if core.FileExists(backup_path) {
+		return backup_path, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to backup settings file: %w", err)
+	}
+	err = os.WriteFile(backup_path, data, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to backup settings file: %w", err)
+	}
+	return backup_path, nil
+}
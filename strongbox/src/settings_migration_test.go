@@ -0,0 +1,182 @@
+package strongbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// migrations are numbered one after the other and the latest is the current spec version
+func Test_settings_migration_list(t *testing.T) {
+	for i, migration := range settings_migration_list {
+		assert.Equal(t, i+1, migration.version)
+		assert.NotEmpty(t, migration.description)
+	}
+	assert.Equal(t, len(settings_migration_list), SETTINGS_SPEC_VERSION)
+	assert.Equal(t, SETTINGS_SPEC_VERSION, NewSettings().SpecVersion)
+}
+
+// deprecated top-level values are moved into preferences and emptied
+func Test_migrate_settings__1(t *testing.T) {
+	given := Settings{
+		DeprecatedSelectedAddonDir:  "/tmp/.strongbox-foo",
+		DeprecatedSelectedCatalog:   "full",
+		DeprecatedSelectedCatalogue: "",
+		DeprecatedGUITheme:          GUI_THEME_DARK,
+	}
+	expected := Settings{
+		Preferences: Preferences{
+			SelectedAddonsDir: "/tmp/.strongbox-foo",
+			SelectedCatalogue: "full",
+			SelectedGUITheme:  GUI_THEME_DARK,
+		},
+	}
+	assert.Equal(t, expected, migrate_settings__1(given))
+
+	// 'selected-catalogue' wins over the older 'selected-catalog'
+	given = Settings{
+		DeprecatedSelectedCatalog:   "full",
+		DeprecatedSelectedCatalogue: "github",
+	}
+	assert.Equal(t, "github", migrate_settings__1(given).Preferences.SelectedCatalogue)
+}
+
+// dead catalogues are removed and the github catalogue is added
+func Test_migrate_settings__2(t *testing.T) {
+	given := Settings{CatalogueLocationList: []CatalogueLocation{CAT_SHORT, CAT_CURSEFORGE, CAT_TUKUI, CAT_FULL}}
+	expected := []CatalogueLocation{CAT_SHORT, CAT_FULL, CAT_GITHUB}
+	assert.Equal(t, expected, migrate_settings__2(given).CatalogueLocationList)
+
+	// github isn't added twice
+	given = Settings{CatalogueLocationList: []CatalogueLocation{CAT_GITHUB, CAT_SHORT}}
+	expected = []CatalogueLocation{CAT_GITHUB, CAT_SHORT}
+	assert.Equal(t, expected, migrate_settings__2(given).CatalogueLocationList)
+
+	// an empty list is left for the defaults
+	assert.Empty(t, migrate_settings__2(Settings{}).CatalogueLocationList)
+}
+
+// a missing 'strict?' is strict, an explicit 'strict?' is preserved
+func Test_migrate_settings__3(t *testing.T) {
+	given := Settings{AddonsDirList: []AddonsDir{
+		{Path: "/tmp/.strongbox-foo"},
+		{Path: "/tmp/.strongbox-bar", StrictPtr: new(true)},
+		{Path: "/tmp/.strongbox-baz", StrictPtr: new(false)},
+	}}
+	expected := []AddonsDir{
+		{Path: "/tmp/.strongbox-foo", Strict: true},
+		{Path: "/tmp/.strongbox-bar", Strict: true},
+		{Path: "/tmp/.strongbox-baz", Strict: false},
+	}
+	assert.Equal(t, expected, migrate_settings__3(given).AddonsDirList)
+}
+
+// compound game tracks become their first game track, non-strict
+func Test_migrate_settings__4(t *testing.T) {
+	given := Settings{AddonsDirList: []AddonsDir{
+		{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_RETAIL_CLASSIC, Strict: true},
+		{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_CLASSIC, Strict: true},
+	}}
+	expected := []AddonsDir{
+		{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_RETAIL, Strict: false},
+		{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_CLASSIC, Strict: true},
+	}
+	assert.Equal(t, expected, migrate_settings__4(given).AddonsDirList)
+}
+
+// only migrations newer than the settings spec version are applied
+func Test_migrate_settings(t *testing.T) {
+	given := Settings{
+		AddonsDirList: []AddonsDir{{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_CLASSIC, Strict: false}},
+	}
+	actual := migrate_settings(given)
+	assert.Equal(t, SETTINGS_SPEC_VERSION, actual.SpecVersion)
+	assert.True(t, actual.AddonsDirList[0].Strict) // spec version 0 files had 'strict?'
+
+	// already migrated, a non-strict addons dir stays non-strict
+	given.SpecVersion = 3
+	actual = migrate_settings(given)
+	assert.Equal(t, SETTINGS_SPEC_VERSION, actual.SpecVersion)
+	assert.False(t, actual.AddonsDirList[0].Strict)
+
+	// settings from the future are left alone
+	given.SpecVersion = SETTINGS_SPEC_VERSION + 1
+	given.DeprecatedGUITheme = GUI_THEME_DARK
+	assert.Equal(t, given, migrate_settings(given))
+}
+
+// invalid entries are reported and dropped rather than failing
+func Test_validate_settings(t *testing.T) {
+	given := Settings{
+		CatalogueLocationList: []CatalogueLocation{
+			CAT_SHORT,
+			{Name: "nosource"},
+			{Name: "badsource", Source: "not a url"},
+			CAT_SHORT,
+		},
+		AddonsDirList: []AddonsDir{
+			{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_RETAIL},
+			{Path: "/tmp/.strongbox-bar", GameTrackID: "foo"},
+			{Path: "", GameTrackID: GAMETRACK_RETAIL},
+			{Path: "/path/to/missing/addons", GameTrackID: GAMETRACK_RETAIL},
+			{Path: "/tmp/.strongbox-baz", GameTrackID: GAMETRACK_CLASSIC, ReleaseChannel: ReleaseChannel{Stability: "experimental"}},
+		},
+		Preferences: Preferences{
+			SelectedGUITheme:  "pink",
+			NFOStore:          "cloud",
+			SelectedCatalogue: "short",
+			ReleaseChannel:    ReleaseChannel{Stability: RELEASE_STABILITY_BETA, Type: "some"},
+		},
+	}
+	actual, issue_list := validate_settings(given)
+
+	assert.Equal(t, []CatalogueLocation{CAT_SHORT}, actual.CatalogueLocationList)
+	expected_addons_dir_list := []AddonsDir{
+		{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_RETAIL},
+		{Path: "/tmp/.strongbox-baz", GameTrackID: GAMETRACK_CLASSIC},
+	}
+	assert.Equal(t, expected_addons_dir_list, actual.AddonsDirList)
+	expected_prefs := Preferences{SelectedCatalogue: "short"}
+	assert.Equal(t, expected_prefs, actual.Preferences)
+	assert.Equal(t, 9, len(issue_list))
+
+	// valid settings have no issues
+	_, issue_list = validate_settings(NewSettings())
+	assert.Empty(t, issue_list)
+}
+
+// invalid settings are replaced with defaults during configuration
+func Test_configure_settings__invalid(t *testing.T) {
+	given := Settings{
+		SpecVersion:           SETTINGS_SPEC_VERSION,
+		CatalogueLocationList: []CatalogueLocation{{Name: "nosource"}},
+		Preferences:           Preferences{SelectedGUITheme: "pink"},
+	}
+	actual := configure_settings(given)
+	assert.Equal(t, DEFAULT_CATALOGUE_LOC_LIST, actual.CatalogueLocationList)
+	assert.Equal(t, GUI_THEME_LIGHT, actual.Preferences.SelectedGUITheme)
+}
+
+// the original settings file is copied once per spec version
+func Test_backup_settings_file(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"original": true}`), 0644))
+
+	backup_path, err := backup_settings_file(path, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, path+".spec-0.bak", backup_path)
+
+	// a second backup of the same spec version doesn't replace the first
+	assert.Nil(t, os.WriteFile(path, []byte(`{"original": false}`), 0644))
+	_, err = backup_settings_file(path, 0)
+	assert.Nil(t, err)
+
+	data, err := os.ReadFile(backup_path)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"original": true}`, string(data))
+
+	_, err = backup_settings_file(filepath.Join(t.TempDir(), "missing.json"), 0)
+	assert.NotNil(t, err)
+}
@@ -41,6 +41,8 @@ func Test_read_settings_file__bad_path(t *testing.T) {
 func Test_configure_settings_file__0_9(t *testing.T) {
 	fixture := test_fixture_user_config_0_9_0
 	expected := Settings{
+		SpecVersion: SETTINGS_SPEC_VERSION,
+
 		AddonsDirList: []AddonsDir{
 			{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_RETAIL, Strict: true},
 			{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_CLASSIC, Strict: true},
@@ -50,10 +52,15 @@ func Test_configure_settings_file__0_9(t *testing.T) {
 			AddonZipsToKeep:          nil,
 			CheckForUpdate:           new(true),
 			KeepUserCatalogueUpdated: new(false),
+			WTFBackupsToKeep:         new(WTF_BACKUPS_TO_KEEP_DEFAULT),
+			BackupWTFBeforeUpdate:    new(false),
 			SelectedAddonsDir:        "/tmp/.strongbox-bar",
 			SelectedCatalogue:        CAT_SHORT.Name,
 			SelectedColumns:          COL_LIST_DEFAULT,
 			SelectedGUITheme:         GUI_THEME_LIGHT,
+
+			AddonsDirSnapshotsToKeep:    new(ADDONS_DIR_SNAPSHOTS_TO_KEEP_DEFAULT),
+			SnapshotBeforeBulkOperation: new(true),
 		},
 
 		// deprecated
@@ -71,6 +78,8 @@ func Test_configure_settings_file__0_9(t *testing.T) {
 func Test_configure_settings_file__0_10(t *testing.T) {
 	fixture := test_fixture_user_config_0_10_0
 	expected := Settings{
+		SpecVersion: SETTINGS_SPEC_VERSION,
+
 		AddonsDirList: []AddonsDir{
 			{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_RETAIL, Strict: true},
 			{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_CLASSIC, Strict: true},
@@ -80,10 +89,15 @@ func Test_configure_settings_file__0_10(t *testing.T) {
 			AddonZipsToKeep:          nil,
 			CheckForUpdate:           new(true),
 			KeepUserCatalogueUpdated: new(false),
+			WTFBackupsToKeep:         new(WTF_BACKUPS_TO_KEEP_DEFAULT),
+			BackupWTFBeforeUpdate:    new(false),
 			SelectedAddonsDir:        "/tmp/.strongbox-bar",
 			SelectedCatalogue:        CAT_FULL.Name,
 			SelectedColumns:          COL_LIST_DEFAULT,
 			SelectedGUITheme:         GUI_THEME_LIGHT,
+
+			AddonsDirSnapshotsToKeep:    new(ADDONS_DIR_SNAPSHOTS_TO_KEEP_DEFAULT),
+			SnapshotBeforeBulkOperation: new(true),
 		},
 
 		// deprecated
@@ -102,6 +116,8 @@ func Test_configure_settings_file__0_10(t *testing.T) {
 func Test_configure_settings_file__0_11(t *testing.T) {
 	fixture := test_fixture_user_config_0_11_0
 	expected := Settings{
+		SpecVersion: SETTINGS_SPEC_VERSION,
+
 		AddonsDirList: []AddonsDir{
 			{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_RETAIL, Strict: true},
 			{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_CLASSIC, Strict: true},
@@ -111,10 +127,15 @@ func Test_configure_settings_file__0_11(t *testing.T) {
 			AddonZipsToKeep:          nil,
 			CheckForUpdate:           new(true),
 			KeepUserCatalogueUpdated: new(false),
+			WTFBackupsToKeep:         new(WTF_BACKUPS_TO_KEEP_DEFAULT),
+			BackupWTFBeforeUpdate:    new(false),
 			SelectedAddonsDir:        "/tmp/.strongbox-bar",
 			SelectedCatalogue:        CAT_FULL.Name,
 			SelectedColumns:          COL_LIST_DEFAULT,
 			SelectedGUITheme:         GUI_THEME_DARK,
+
+			AddonsDirSnapshotsToKeep:    new(ADDONS_DIR_SNAPSHOTS_TO_KEEP_DEFAULT),
+			SnapshotBeforeBulkOperation: new(true),
 		},
 
 		// deprecated. to be removed in 10.0
@@ -132,6 +153,8 @@ func Test_configure_settings_file__0_11(t *testing.T) {
 func Test_configure_settings_file__0_12(t *testing.T) {
 	fixture := test_fixture_user_config_0_12_0
 	expected := Settings{
+		SpecVersion: SETTINGS_SPEC_VERSION,
+
 		AddonsDirList: []AddonsDir{
 			{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_RETAIL, Strict: true},
 			{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_CLASSIC, Strict: true},
@@ -141,10 +164,15 @@ func Test_configure_settings_file__0_12(t *testing.T) {
 			AddonZipsToKeep:          nil,
 			CheckForUpdate:           new(true),
 			KeepUserCatalogueUpdated: new(false),
+			WTFBackupsToKeep:         new(WTF_BACKUPS_TO_KEEP_DEFAULT),
+			BackupWTFBeforeUpdate:    new(false),
 			SelectedAddonsDir:        "/tmp/.strongbox-bar",
 			SelectedCatalogue:        CAT_FULL.Name,
 			SelectedColumns:          COL_LIST_DEFAULT,
 			SelectedGUITheme:         GUI_THEME_DARK,
+
+			AddonsDirSnapshotsToKeep:    new(ADDONS_DIR_SNAPSHOTS_TO_KEEP_DEFAULT),
+			SnapshotBeforeBulkOperation: new(true),
 		},
 
 		// deprecated. to be removed in 10.0
@@ -162,6 +190,8 @@ func Test_configure_settings_file__0_12(t *testing.T) {
 func Test_configure_settings_file__1_0(t *testing.T) {
 	fixture := test_fixture_user_config_1_0_0
 	expected := Settings{
+		SpecVersion: SETTINGS_SPEC_VERSION,
+
 		AddonsDirList: []AddonsDir{
 			{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_RETAIL, Strict: true},
 			{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_CLASSIC, Strict: true},
@@ -171,10 +201,15 @@ func Test_configure_settings_file__1_0(t *testing.T) {
 			AddonZipsToKeep:          nil,
 			CheckForUpdate:           new(true),
 			KeepUserCatalogueUpdated: new(false),
+			WTFBackupsToKeep:         new(WTF_BACKUPS_TO_KEEP_DEFAULT),
+			BackupWTFBeforeUpdate:    new(false),
 			SelectedAddonsDir:        "/tmp/.strongbox-foo",
 			SelectedCatalogue:        CAT_FULL.Name,
 			SelectedColumns:          COL_LIST_DEFAULT,
 			SelectedGUITheme:         GUI_THEME_DARK,
+
+			AddonsDirSnapshotsToKeep:    new(ADDONS_DIR_SNAPSHOTS_TO_KEEP_DEFAULT),
+			SnapshotBeforeBulkOperation: new(true),
 		},
 
 		// deprecated. to be removed in 10.0
@@ -193,6 +228,8 @@ func Test_configure_settings_file__1_0(t *testing.T) {
 func Test_configure_settings_file__3_1(t *testing.T) {
 	fixture := test_fixture_user_config_3_1_0
 	expected := Settings{
+		SpecVersion: SETTINGS_SPEC_VERSION,
+
 		AddonsDirList: []AddonsDir{
 			{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_RETAIL, Strict: false}, // compound game track
 			{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_CLASSIC, Strict: true},
@@ -202,10 +239,15 @@ func Test_configure_settings_file__3_1(t *testing.T) {
 			AddonZipsToKeep:          new(uint8(3)),
 			CheckForUpdate:           new(true),
 			KeepUserCatalogueUpdated: new(false),
+			WTFBackupsToKeep:         new(WTF_BACKUPS_TO_KEEP_DEFAULT),
+			BackupWTFBeforeUpdate:    new(false),
 			SelectedAddonsDir:        "/tmp/.strongbox-foo",
 			SelectedCatalogue:        CAT_FULL.Name,
 			SelectedColumns:          COL_LIST_DEFAULT,
 			SelectedGUITheme:         GUI_THEME_DARK,
+
+			AddonsDirSnapshotsToKeep:    new(ADDONS_DIR_SNAPSHOTS_TO_KEEP_DEFAULT),
+			SnapshotBeforeBulkOperation: new(true),
 		},
 
 		// deprecated. to be removed in 10.0
@@ -224,6 +266,8 @@ func Test_configure_settings_file__3_1(t *testing.T) {
 func Test_configure_settings_file__3_2(t *testing.T) {
 	fixture := test_fixture_user_config_3_2_0
 	expected := Settings{
+		SpecVersion: SETTINGS_SPEC_VERSION,
+
 		AddonsDirList: []AddonsDir{
 			{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_RETAIL, Strict: false}, // compound game track
 			{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_CLASSIC, Strict: true},
@@ -233,10 +277,15 @@ func Test_configure_settings_file__3_2(t *testing.T) {
 			AddonZipsToKeep:          new(uint8(3)),
 			CheckForUpdate:           new(true),
 			KeepUserCatalogueUpdated: new(false),
+			WTFBackupsToKeep:         new(WTF_BACKUPS_TO_KEEP_DEFAULT),
+			BackupWTFBeforeUpdate:    new(false),
 			SelectedAddonsDir:        "/tmp/.strongbox-foo",
 			SelectedCatalogue:        CAT_FULL.Name,
 			SelectedColumns:          COL_LIST_DEFAULT,
 			SelectedGUITheme:         GUI_THEME_DARK_GREEN,
+
+			AddonsDirSnapshotsToKeep:    new(ADDONS_DIR_SNAPSHOTS_TO_KEEP_DEFAULT),
+			SnapshotBeforeBulkOperation: new(true),
 		},
 
 		// deprecated. to be removed in 10.0
@@ -255,6 +304,8 @@ func Test_configure_settings_file__3_2(t *testing.T) {
 func Test_configure_settings_file__4_1(t *testing.T) {
 	fixture := test_fixture_user_config_4_1_0
 	expected := Settings{
+		SpecVersion: SETTINGS_SPEC_VERSION,
+
 		AddonsDirList: []AddonsDir{
 			{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_CLASSIC_TBC, Strict: true},
 			{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_RETAIL, Strict: false},
@@ -264,10 +315,15 @@ func Test_configure_settings_file__4_1(t *testing.T) {
 			AddonZipsToKeep:          new(uint8(3)),
 			CheckForUpdate:           new(true),
 			KeepUserCatalogueUpdated: new(false),
+			WTFBackupsToKeep:         new(WTF_BACKUPS_TO_KEEP_DEFAULT),
+			BackupWTFBeforeUpdate:    new(false),
 			SelectedAddonsDir:        "/tmp/.strongbox-foo",
 			SelectedCatalogue:        CAT_FULL.Name,
 			SelectedColumns:          COL_LIST_DEFAULT,
 			SelectedGUITheme:         GUI_THEME_DARK_GREEN,
+
+			AddonsDirSnapshotsToKeep:    new(ADDONS_DIR_SNAPSHOTS_TO_KEEP_DEFAULT),
+			SnapshotBeforeBulkOperation: new(true),
 		},
 
 		// deprecated. to be removed in 10.0
@@ -286,6 +342,8 @@ func Test_configure_settings_file__4_1(t *testing.T) {
 func Test_configure_settings_file__4_7(t *testing.T) {
 	fixture := test_fixture_user_config_4_7_0
 	expected := Settings{
+		SpecVersion: SETTINGS_SPEC_VERSION,
+
 		AddonsDirList: []AddonsDir{
 			{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_CLASSIC_TBC, Strict: true},
 			{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_RETAIL, Strict: false},
@@ -295,6 +353,8 @@ func Test_configure_settings_file__4_7(t *testing.T) {
 			AddonZipsToKeep:          new(uint8(3)),
 			CheckForUpdate:           new(true),
 			KeepUserCatalogueUpdated: new(false),
+			WTFBackupsToKeep:         new(WTF_BACKUPS_TO_KEEP_DEFAULT),
+			BackupWTFBeforeUpdate:    new(false),
 			SelectedAddonsDir:        "/tmp/.strongbox-foo",
 			SelectedCatalogue:        CAT_FULL.Name,
 			SelectedColumns: []string{
@@ -305,6 +365,9 @@ func Test_configure_settings_file__4_7(t *testing.T) {
 				"uber-button",
 			},
 			SelectedGUITheme: GUI_THEME_DARK_GREEN,
+
+			AddonsDirSnapshotsToKeep:    new(ADDONS_DIR_SNAPSHOTS_TO_KEEP_DEFAULT),
+			SnapshotBeforeBulkOperation: new(true),
 		},
 
 		// deprecated. to be removed in 10.0
@@ -323,6 +386,8 @@ func Test_configure_settings_file__4_7(t *testing.T) {
 func Test_configure_settings_file__4_9(t *testing.T) {
 	fixture := test_fixture_user_config_4_9_0
 	expected := Settings{
+		SpecVersion: SETTINGS_SPEC_VERSION,
+
 		AddonsDirList: []AddonsDir{
 			{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_CLASSIC_TBC, Strict: true},
 			{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_RETAIL, Strict: false},
@@ -332,6 +397,8 @@ func Test_configure_settings_file__4_9(t *testing.T) {
 			AddonZipsToKeep:          new(uint8(3)),
 			CheckForUpdate:           new(true),
 			KeepUserCatalogueUpdated: new(false),
+			WTFBackupsToKeep:         new(WTF_BACKUPS_TO_KEEP_DEFAULT),
+			BackupWTFBeforeUpdate:    new(false),
 			SelectedAddonsDir:        "/tmp/.strongbox-foo",
 			SelectedCatalogue:        CAT_FULL.Name,
 			SelectedColumns: []string{
@@ -342,6 +409,9 @@ func Test_configure_settings_file__4_9(t *testing.T) {
 				"uber-button",
 			},
 			SelectedGUITheme: GUI_THEME_DARK_GREEN,
+
+			AddonsDirSnapshotsToKeep:    new(ADDONS_DIR_SNAPSHOTS_TO_KEEP_DEFAULT),
+			SnapshotBeforeBulkOperation: new(true),
 		},
 
 		// deprecated. to be removed in 10.0
@@ -360,6 +430,8 @@ func Test_configure_settings_file__4_9(t *testing.T) {
 func Test_configure_settings_file__5_0(t *testing.T) {
 	fixture := test_fixture_user_config_5_0_0
 	expected := Settings{
+		SpecVersion: SETTINGS_SPEC_VERSION,
+
 		AddonsDirList: []AddonsDir{
 			{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_CLASSIC_TBC, Strict: true},
 			{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_RETAIL, Strict: false},
@@ -369,6 +441,8 @@ func Test_configure_settings_file__5_0(t *testing.T) {
 			AddonZipsToKeep:          new(uint8(3)),
 			CheckForUpdate:           new(true),
 			KeepUserCatalogueUpdated: new(false),
+			WTFBackupsToKeep:         new(WTF_BACKUPS_TO_KEEP_DEFAULT),
+			BackupWTFBeforeUpdate:    new(false),
 			SelectedAddonsDir:        "/tmp/.strongbox-foo",
 			SelectedCatalogue:        CAT_FULL.Name,
 			SelectedColumns: []string{
@@ -381,6 +455,9 @@ func Test_configure_settings_file__5_0(t *testing.T) {
 				"uber-button",
 			},
 			SelectedGUITheme: GUI_THEME_DARK_GREEN,
+
+			AddonsDirSnapshotsToKeep:    new(ADDONS_DIR_SNAPSHOTS_TO_KEEP_DEFAULT),
+			SnapshotBeforeBulkOperation: new(true),
 		},
 
 		// deprecated. to be removed in 10.0
@@ -399,6 +476,8 @@ func Test_configure_settings_file__5_0(t *testing.T) {
 func Test_configure_settings_file__6_0(t *testing.T) {
 	fixture := test_fixture_user_config_6_0_0
 	expected := Settings{
+		SpecVersion: SETTINGS_SPEC_VERSION,
+
 		AddonsDirList: []AddonsDir{
 			{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_CLASSIC_TBC, Strict: true},
 			{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_RETAIL, Strict: false},
@@ -408,6 +487,8 @@ func Test_configure_settings_file__6_0(t *testing.T) {
 			AddonZipsToKeep:          new(uint8(3)),
 			CheckForUpdate:           new(true),
 			KeepUserCatalogueUpdated: new(true),
+			WTFBackupsToKeep:         new(WTF_BACKUPS_TO_KEEP_DEFAULT),
+			BackupWTFBeforeUpdate:    new(false),
 			SelectedAddonsDir:        "/tmp/.strongbox-foo",
 			SelectedCatalogue:        CAT_FULL.Name,
 			SelectedColumns: []string{
@@ -420,6 +501,9 @@ func Test_configure_settings_file__6_0(t *testing.T) {
 				"uber-button",
 			},
 			SelectedGUITheme: GUI_THEME_DARK_GREEN,
+
+			AddonsDirSnapshotsToKeep:    new(ADDONS_DIR_SNAPSHOTS_TO_KEEP_DEFAULT),
+			SnapshotBeforeBulkOperation: new(true),
 		},
 
 		// deprecated. to be removed in 10.0
@@ -438,6 +522,8 @@ func Test_configure_settings_file__6_0(t *testing.T) {
 func Test_configure_settings_file__7_0(t *testing.T) {
 	fixture := test_fixture_user_config_7_0_0
 	expected := Settings{
+		SpecVersion: SETTINGS_SPEC_VERSION,
+
 		AddonsDirList: []AddonsDir{
 			{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_CLASSIC_TBC, Strict: true},
 			{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_RETAIL, Strict: false},
@@ -447,6 +533,8 @@ func Test_configure_settings_file__7_0(t *testing.T) {
 			AddonZipsToKeep:          new(uint8(3)),
 			CheckForUpdate:           new(false),
 			KeepUserCatalogueUpdated: new(true),
+			WTFBackupsToKeep:         new(WTF_BACKUPS_TO_KEEP_DEFAULT),
+			BackupWTFBeforeUpdate:    new(false),
 			SelectedAddonsDir:        "/tmp/.strongbox-foo",
 			SelectedCatalogue:        CAT_FULL.Name,
 			SelectedColumns: []string{
@@ -459,6 +547,9 @@ func Test_configure_settings_file__7_0(t *testing.T) {
 				"uber-button",
 			},
 			SelectedGUITheme: GUI_THEME_DARK_GREEN,
+
+			AddonsDirSnapshotsToKeep:    new(ADDONS_DIR_SNAPSHOTS_TO_KEEP_DEFAULT),
+			SnapshotBeforeBulkOperation: new(true),
 		},
 
 		// deprecated. to be removed in 10.0
@@ -477,6 +568,8 @@ func Test_configure_settings_file__7_0(t *testing.T) {
 func Test_configure_settings_file__8_0(t *testing.T) {
 	fixture := test_fixture_user_config_8_0_0
 	expected := Settings{
+		SpecVersion: SETTINGS_SPEC_VERSION,
+
 		AddonsDirList: []AddonsDir{
 			{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_RETAIL, Strict: true},
 			{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_CLASSIC_TBC, Strict: true},
@@ -486,6 +579,8 @@ func Test_configure_settings_file__8_0(t *testing.T) {
 			AddonZipsToKeep:          new(uint8(3)),
 			CheckForUpdate:           new(false),
 			KeepUserCatalogueUpdated: new(true),
+			WTFBackupsToKeep:         new(WTF_BACKUPS_TO_KEEP_DEFAULT),
+			BackupWTFBeforeUpdate:    new(false),
 			SelectedAddonsDir:        "/tmp/.strongbox-bar",
 			SelectedCatalogue:        CAT_FULL.Name,
 			SelectedColumns: []string{
@@ -499,6 +594,9 @@ func Test_configure_settings_file__8_0(t *testing.T) {
 				"uber-button",
 			},
 			SelectedGUITheme: GUI_THEME_DARK_ORANGE,
+
+			AddonsDirSnapshotsToKeep:    new(ADDONS_DIR_SNAPSHOTS_TO_KEEP_DEFAULT),
+			SnapshotBeforeBulkOperation: new(true),
 		},
 
 		// deprecated
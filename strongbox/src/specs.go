@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log/slog"
 	"reflect"
+	"slices"
+	"strings"
 
 	z "github.com/Oudwins/zog"
 )
@@ -23,6 +25,26 @@ func PrintSpecErr(err z.ZogIssueMap, data any) {
 	//panic("nfo has issues")
 }
 
+// returns the issues in `err` as a single line, ordered by field.
+// "Name: is required; Source: must be one of ..."
+func format_spec_err(err z.ZogIssueMap) string {
+	field_list := []string{}
+	for field := range err {
+		if field != "$first" {
+			field_list = append(field_list, field)
+		}
+	}
+	slices.Sort(field_list)
+
+	rv := []string{}
+	for _, field := range field_list {
+		for _, issue := range err[field] {
+			rv = append(rv, fmt.Sprintf("%v: %v", field, issue.Message))
+		}
+	}
+	return strings.Join(rv, "; ")
+}
+
 // ---
 
 func FlexStringSchema() *z.StringSchema[FlexString] {
@@ -35,6 +57,13 @@ var source_map_schema = z.Struct(z.Shape{
 	"SourceID": FlexStringSchema().Required(),
 })
 
+// a game track known to the game track registry
+func game_track_schema() *z.StringSchema[string] {
+	return z.String().TestFunc(func(val *string, _ z.Ctx) bool {
+		return game_tracks().Supported(*val)
+	}, z.Message("unsupported game track"))
+}
+
 // --- NFO
 
 // specs/:addon/-nfo
@@ -45,7 +74,7 @@ var _nfo_schema = z.Struct(z.Shape{
 	"GroupID":              z.String().Required(),
 	"Primary":              z.Bool(),
 	"Source":               z.String().Required().OneOf(SUPPORTED_HOSTS_LIST),
-	"InstalledGameTrackID": z.String().Required().OneOf(SUPPORTED_GAME_TRACKS_LIST),
+	"InstalledGameTrackID": game_track_schema().Required(),
 	"SourceID":             FlexStringSchema().Required(),
 	"SourceMapList":        z.Slice(source_map_schema),
 	"Ignored":              z.Ptr(z.Bool().Optional()),
@@ -73,12 +102,50 @@ func (nfo *NFO) Valid() z.ZogIssueMap {
 	if err1 != nil {
 		err2 := _nfo_just_grouped_schema.Validate(nfo)
 		if err2 != nil {
-			slog.Info("nfo not valid under full nor partial schema")
-			PrintSpecErr(err1, nfo)
-			slog.Error("nfo not valid under partial schema")
-			PrintSpecErr(err2, nfo)
+			slog.Debug("nfo not valid under full nor partial schema", "group-id", nfo.GroupID, "full-issues", format_spec_err(err1), "partial-issues", format_spec_err(err2))
 			return err2
 		}
 	}
 	return nil
 }
+
+// --- settings
+
+var _catalogue_location_schema = z.Struct(z.Shape{
+	"Name":   z.String().Required(),
+	"Label":  z.String().Optional(),
+	"Source": z.String().Required().URL(),
+})
+
+func (cl *CatalogueLocation) Valid() z.ZogIssueMap {
+	return _catalogue_location_schema.Validate(cl)
+}
+
+var _addons_dir_schema = z.Struct(z.Shape{
+	"Path":        z.String().Required(),
+	"GameTrackID": game_track_schema().Required(),
+})
+
+func (ad *AddonsDir) Valid() z.ZogIssueMap {
+	return _addons_dir_schema.Validate(ad)
+}
+
+// empty values defer to the next preference, see `resolve_release_channel`.
+var _release_channel_schema = z.Struct(z.Shape{
+	"Stability": z.String().OneOf(RELEASE_STABILITY_LIST),
+	"Type":      z.String().OneOf([]ReleaseType{RELEASE_TYPE_LIB, RELEASE_TYPE_NOLIB}),
+})
+
+func (rc *ReleaseChannel) Valid() z.ZogIssueMap {
+	return _release_channel_schema.Validate(rc)
+}
+
+// empty values are replaced with defaults, see `configure_settings`.
+var _preferences_schema = z.Struct(z.Shape{
+	"SelectedGUITheme": (&z.StringSchema[GUITheme]{}).OneOf(GUI_THEME_LIST),
+	"NFOStore":         z.String().OneOf([]string{NFO_STORE_FILE, NFO_STORE_DATABASE}),
+})
+
+func (p *Preferences) Valid() z.ZogIssueMap {
+	return _preferences_schema.Validate(p)
+}
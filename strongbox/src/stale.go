@@ -0,0 +1,264 @@
+package strongbox
+
+import (
+	"bw/core"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+// stale.go finds installed addons that look abandoned and suggests maintained replacements from the catalogue.
+
+// an addon without a release in this many months is considered stale.
+const STALE_MONTHS_DEFAULT = 12
+
+// the maximum number of replacements suggested per stale addon.
+const STALE_REPLACEMENT_LIMIT = 5
+
+const (
+	STALE_REASON_NO_RECENT_RELEASE    = "no-recent-release"    // nothing released in N months
+	STALE_REASON_NO_CURRENT_INTERFACE = "no-current-interface" // nothing released for the current interface version
+)
+
+// an installed addon that looks abandoned, why, and what could replace it.
+type StaleAddon struct {
+	Addon           Addon
+	ReasonList      []string
+	LastReleaseDate time.Time        // zero if not known
+	ReplacementList []CatalogueAddon // similar, maintained addons from the catalogue
+}
+
+var _ core.ItemInfo = (*StaleAddon)(nil)
+
+func (sa StaleAddon) ItemKeys() []string {
+	return []string{
+		"source",
+		core.ITEM_FIELD_NAME,
+		core.ITEM_FIELD_DESC,
+		core.ITEM_FIELD_DATE_UPDATED,
+		"installed-version",
+		"game-version",
+	}
+}
+
+func (sa StaleAddon) ItemMap() map[string]string {
+	updated_str := ""
+	if !sa.LastReleaseDate.IsZero() {
+		updated, err := core.FormatTimeHumanOffset(sa.LastReleaseDate)
+		if err == nil {
+			updated_str = updated
+		}
+	}
+	return map[string]string{
+		"source":                     sa.Addon.Source,
+		core.ITEM_FIELD_NAME:         sa.Addon.Label,
+		core.ITEM_FIELD_DESC:         strings.Join(sa.ReasonList, ", "),
+		core.ITEM_FIELD_DATE_UPDATED: updated_str,
+		"installed-version":          sa.Addon.InstalledVersion,
+		"game-version":               sa.Addon.GameVersion,
+	}
+}
+
+func (sa StaleAddon) ItemHasChildren() core.ITEM_CHILDREN_LOAD {
+	if len(sa.ReplacementList) > 0 {
+		return core.ITEM_CHILDREN_LOAD_TRUE
+	}
+	return core.ITEM_CHILDREN_LOAD_FALSE
+}
+
+// suggested replacements are catalogue addons and can be installed like any other.
+func (sa StaleAddon) ItemChildren(_ *core.App) []core.Result {
+	children := []core.Result{}
+	for _, ca := range sa.ReplacementList {
+		children = append(children, core.MakeResult(NS_CATALOGUE_ADDON, ca, core.UniqueID()))
+	}
+	return children
+}
+
+// returns the date of the most recent release of the addon,
+// from the updates fetched from it's source and the catalogue.
+// returns a zero time if neither are available.
+func last_release_date(a Addon) time.Time {
+	var latest time.Time
+	for _, su := range a.SourceUpdateList {
+		if su.PublishedDate.After(latest) {
+			latest = su.PublishedDate
+		}
+	}
+	if a.CatalogueAddon != nil && a.CatalogueAddon.UpdatedDate.After(latest) {
+		latest = a.CatalogueAddon.UpdatedDate
+	}
+	return latest
+}
+
+// returns the highest interface version for `game_track_id` across all of the addon's releases and `true`,
+// or `false` if the source doesn't say which interface versions it's releases support.
+func upstream_interface_version(a Addon, game_track_id GameTrackID) (int, bool) {
+	latest := 0
+	for _, su := range a.SourceUpdateList {
+		if su.InterfaceVersionSet == nil {
+			continue
+		}
+		for _, iv := range su.InterfaceVersionSet.ToSlice() {
+			gt, err := InterfaceVersionToGameTrack(iv)
+			if err == nil && gt == game_track_id {
+				latest = max(latest, iv)
+			}
+		}
+	}
+	return latest, latest > 0
+}
+
+// returns the reasons the given addon looks abandoned, if any.
+// an addon is stale when it has had no release since `since`,
+// or none of it's releases support the current interface version.
+func stale_reasons(a Addon, since time.Time) []string {
+	reason_list := []string{}
+	if a.IsIgnored {
+		return reason_list
+	}
+
+	last_release := last_release_date(a)
+	if !last_release.IsZero() && last_release.Before(since) {
+		reason_list = append(reason_list, STALE_REASON_NO_RECENT_RELEASE)
+	}
+
+	if a.AddonsDir != nil && a.CurrentInterfaceVersion > 0 {
+		upstream, known := upstream_interface_version(a, a.AddonsDir.GameTrackID)
+		if known && upstream < a.CurrentInterfaceVersion {
+			reason_list = append(reason_list, STALE_REASON_NO_CURRENT_INTERFACE)
+		} else if !known && a.IsPossiblyAbandoned {
+			// the source doesn't say, but the installed version is outdated and there is nothing newer
+			reason_list = append(reason_list, STALE_REASON_NO_CURRENT_INTERFACE)
+		}
+	}
+
+	return reason_list
+}
+
+// "adi-bags-classic" => {"adi", "bags", "classic"}
+func name_token_set(name string) mapset.Set[string] {
+	set := mapset.NewSet[string]()
+	for _, token := range strings.Split(slugify(name), "-") {
+		if token != "" {
+			set.Add(token)
+		}
+	}
+	return set
+}
+
+// returns the size of the intersection of `a` and `b` over the size of their union, 0.0 to 1.0
+func jaccard[T comparable](a, b mapset.Set[T]) float64 {
+	union := a.Union(b).Cardinality()
+	if union == 0 {
+		return 0
+	}
+	return float64(a.Intersect(b).Cardinality()) / float64(union)
+}
+
+// returns catalogue addons similar to the given addon that have been updated since `since`, most similar first.
+// similar addons share tags and have similar names.
+// an addon without tags (not matched against the catalogue) is compared on name alone.
+func similar_addons(a Addon, catalogue_addon_list []CatalogueAddon, since time.Time, limit int) []CatalogueAddon {
+	tag_set := mapset.NewSet(a.Tags...)
+	name_set := name_token_set(a.Name)
+	if a.Label != "" {
+		name_set = name_set.Union(name_token_set(a.Label))
+	}
+
+	var game_track_id GameTrackID
+	if a.AddonsDir != nil {
+		game_track_id = a.AddonsDir.GameTrackID
+	}
+
+	type candidate struct {
+		ca    CatalogueAddon
+		score float64
+	}
+	candidate_list := []candidate{}
+	for _, ca := range catalogue_addon_list {
+		if ca.Source == a.Source && string(ca.SourceID) == a.SourceID {
+			continue // itself
+		}
+		if ca.Name == a.Name {
+			continue // itself, from another source
+		}
+		if ca.UpdatedDate.Before(since) {
+			continue // also stale
+		}
+		if game_track_id != "" && len(ca.GameTrackIDList) > 0 && !slices.Contains(ca.GameTrackIDList, game_track_id) {
+			continue
+		}
+
+		tag_score := jaccard(tag_set, mapset.NewSet(ca.TagList...))
+		name_score := jaccard(name_set, name_token_set(ca.Name).Union(name_token_set(ca.Label)))
+		if name_score == 0 {
+			continue
+		}
+		if !tag_set.IsEmpty() && tag_score == 0 {
+			continue
+		}
+		candidate_list = append(candidate_list, candidate{ca, tag_score + name_score})
+	}
+
+	// most similar, then most popular
+	slices.SortStableFunc(candidate_list, func(x, y candidate) int {
+		if x.score != y.score {
+			if x.score > y.score {
+				return -1
+			}
+			return 1
+		}
+		return y.ca.DownloadCount - x.ca.DownloadCount
+	})
+
+	rv := []CatalogueAddon{}
+	for _, c := range candidate_list {
+		if len(rv) == limit {
+			break
+		}
+		rv = append(rv, c.ca)
+	}
+	return rv
+}
+
+// returns the addons in `addon_list` that haven't had a release in `months` months before `now`,
+// or have no release for the current interface version, with suggested replacements from the catalogue.
+func StaleAddonReport(addon_list []Addon, catalogue_addon_list []CatalogueAddon, now time.Time, months int) []StaleAddon {
+	since := now.AddDate(0, -months, 0)
+	rv := []StaleAddon{}
+	for _, a := range addon_list {
+		reason_list := stale_reasons(a, since)
+		if len(reason_list) == 0 {
+			continue
+		}
+		rv = append(rv, StaleAddon{
+			Addon:           a,
+			ReasonList:      reason_list,
+			LastReleaseDate: last_release_date(a),
+			ReplacementList: similar_addons(a, catalogue_addon_list, since, STALE_REPLACEMENT_LIMIT),
+		})
+	}
+	slices.SortStableFunc(rv, func(x, y StaleAddon) int {
+		return strings.Compare(x.Addon.Label, y.Addon.Label)
+	})
+	return rv
+}
+
+// returns the report of stale addons in the given `addons_dir`, using the addons and catalogue(s) in application state.
+func FindStaleAddons(app *core.App, addons_dir AddonsDir, months int) ([]StaleAddon, error) {
+	empty_response := []StaleAddon{}
+	if months < 1 {
+		return empty_response, fmt.Errorf("number of months must be greater than zero: %d", months)
+	}
+
+	addon_list := []Addon{}
+	for _, r := range installed_addons(app, addons_dir) {
+		addon_list = append(addon_list, r.Item.(Addon))
+	}
+
+	return StaleAddonReport(addon_list, db_catalogue_addons(app), time.Now(), months), nil
+}
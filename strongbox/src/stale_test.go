@@ -0,0 +1,151 @@
+package strongbox
+
+import (
+	"bw/core"
+	"testing"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+var stale_test_now = time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// returns an installed retail addon with the given name, tags and releases.
+func stale_test_addon(name string, tag_list []string, sul []SourceUpdate) Addon {
+	addons_dir := AddonsDir{GameTrackID: GAMETRACK_RETAIL, Strict: true}
+
+	toc := NewTOC()
+	toc.Name = name
+	toc.GameTrackIDSet = mapset.NewSet(GAMETRACK_RETAIL)
+	toc.InterfaceVersionSet = mapset.NewSet(110200)
+
+	ia := NewInstalledAddon()
+	ia.Name = name
+	ia.TOCMap = map[PathToFile]TOC{name + ".toc": toc}
+	ia.GametrackIDSet = mapset.NewSet(GAMETRACK_RETAIL)
+	nfo := NFO{Name: name, InstalledVersion: "1.0.0", InstalledGameTrackID: GAMETRACK_RETAIL, Source: SOURCE_WOWI, SourceID: FlexString(name)}
+	ia.NFOList = []NFO{nfo}
+
+	a := MakeAddon(addons_dir, []InstalledAddon{ia}, ia, &nfo, nil, sul)
+	a.Tags = tag_list
+	return a
+}
+
+// returns a retail release of the given version, published on the given date, supporting the given interface versions.
+func stale_test_release(version string, published time.Time, interface_version_list ...int) SourceUpdate {
+	su := NewSourceUpdate()
+	su.Version = version
+	su.PublishedDate = published
+	su.GameTrackIDSet = mapset.NewSet(GAMETRACK_RETAIL)
+	if len(interface_version_list) > 0 {
+		su.InterfaceVersionSet = mapset.NewSet(interface_version_list...)
+	}
+	return su
+}
+
+func Test_stale_reasons(t *testing.T) {
+	since := stale_test_now.AddDate(0, -12, 0)
+	recent := stale_test_now.AddDate(0, -1, 0)
+	old := stale_test_now.AddDate(-2, 0, 0)
+
+	var cases = []struct {
+		sul      []SourceUpdate
+		expected []string
+	}{
+		{[]SourceUpdate{stale_test_release("1.0.0", recent)}, []string{}},
+		{[]SourceUpdate{stale_test_release("1.0.0", old)}, []string{STALE_REASON_NO_RECENT_RELEASE}},
+		{[]SourceUpdate{stale_test_release("1.0.0", recent, 110200)}, []string{}},
+		{[]SourceUpdate{stale_test_release("1.0.0", recent, 110100, 11507)}, []string{STALE_REASON_NO_CURRENT_INTERFACE}},
+		{[]SourceUpdate{stale_test_release("1.0.0", old, 110000)}, []string{STALE_REASON_NO_RECENT_RELEASE, STALE_REASON_NO_CURRENT_INTERFACE}},
+		{nil, []string{}}, // never checked
+	}
+	for i, c := range cases {
+		a := stale_test_addon("everyaddon", nil, c.sul)
+		assert.Equal(t, c.expected, stale_reasons(a, since), i)
+	}
+
+	// ignored addons are never stale
+	a := stale_test_addon("everyaddon", nil, []SourceUpdate{stale_test_release("1.0.0", old)})
+	a.IsIgnored = true
+	assert.Equal(t, []string{}, stale_reasons(a, since))
+}
+
+// an outdated addon whose source doesn't list interface versions and has nothing newer is stale.
+func Test_stale_reasons__possibly_abandoned(t *testing.T) {
+	since := stale_test_now.AddDate(0, -12, 0)
+	a := stale_test_addon("everyaddon", nil, []SourceUpdate{stale_test_release("1.0.0", stale_test_now)})
+	a.CurrentInterfaceVersion = 120000
+	a.IsOutdated = true
+	a.IsPossiblyAbandoned = true
+	assert.Equal(t, []string{STALE_REASON_NO_CURRENT_INTERFACE}, stale_reasons(a, since))
+}
+
+func Test_similar_addons(t *testing.T) {
+	since := stale_test_now.AddDate(0, -12, 0)
+	recent := stale_test_now.AddDate(0, -1, 0)
+	old := stale_test_now.AddDate(-2, 0, 0)
+
+	a := stale_test_addon("every-bag-addon", []string{"bags", "inventory"}, nil)
+
+	catalogue := []CatalogueAddon{
+		{Name: "every-bag-addon", Label: "Every Bag Addon", TagList: []string{"bags"}, UpdatedDate: recent, Source: SOURCE_GITHUB, SourceID: "foo/every-bag-addon"}, // itself, elsewhere
+		{Name: "better-bag-addon", Label: "Better Bag Addon", TagList: []string{"bags", "inventory"}, UpdatedDate: recent, DownloadCount: 10},
+		{Name: "bag-thing", Label: "Bag Thing", TagList: []string{"bags"}, UpdatedDate: recent, DownloadCount: 100},
+		{Name: "old-bag-addon", Label: "Old Bag Addon", TagList: []string{"bags"}, UpdatedDate: old},                                                       // also stale
+		{Name: "bag-addon-classic", Label: "Bag Addon Classic", TagList: []string{"bags"}, UpdatedDate: recent, GameTrackIDList: []GameTrackID{"classic"}}, // wrong game track
+		{Name: "every-quest-addon", Label: "Every Quest Addon", TagList: []string{"quests"}, UpdatedDate: recent},                                          // similar name, different tags
+		{Name: "inventory-manager", Label: "Inventory Manager", TagList: []string{"bags", "inventory"}, UpdatedDate: recent},                               // same tags, different name
+	}
+
+	actual := similar_addons(a, catalogue, since, 5)
+	expected := []string{"better-bag-addon", "bag-thing"}
+	actual_names := []string{}
+	for _, ca := range actual {
+		actual_names = append(actual_names, ca.Name)
+	}
+	assert.Equal(t, expected, actual_names)
+
+	assert.Equal(t, 1, len(similar_addons(a, catalogue, since, 1)))
+}
+
+// similar addons are found from a result ID, a bad selection is an error and not silently ignored
+func Test_FindSimilarAddonsService(t *testing.T) {
+	app, stopfn := DummyApp2(t.TempDir())
+	defer stopfn()
+	a := stale_test_addon("every-bag-addon", []string{"bags"}, nil)
+	app.AddReplaceResults(core.MakeResult(NS_ADDON, a, "every-bag-addon")).Wait()
+
+	res := FindSimilarAddonsService(app, core.MakeServiceFnArgs("selected", "every-bag-addon"))
+	assert.Nil(t, res.Err)
+
+	res = FindSimilarAddonsService(app, core.MakeServiceFnArgs("selected", "foo"))
+	assert.NotNil(t, res.Err)
+}
+
+func Test_StaleAddonReport(t *testing.T) {
+	recent := stale_test_now.AddDate(0, -1, 0)
+	old := stale_test_now.AddDate(-2, 0, 0)
+
+	addon_list := []Addon{
+		stale_test_addon("zzz-bag-addon", []string{"bags"}, []SourceUpdate{stale_test_release("1.0.0", old)}),
+		stale_test_addon("fresh-addon", nil, []SourceUpdate{stale_test_release("1.0.0", recent)}),
+		stale_test_addon("aaa-bag-addon", []string{"bags"}, []SourceUpdate{stale_test_release("1.0.0", old)}),
+	}
+	catalogue := []CatalogueAddon{
+		{Name: "new-bag-addon", Label: "New Bag Addon", TagList: []string{"bags"}, UpdatedDate: recent},
+	}
+
+	report := StaleAddonReport(addon_list, catalogue, stale_test_now, 12)
+	assert.Equal(t, 2, len(report))
+	assert.Equal(t, "aaa-bag-addon", report[0].Addon.Name)
+	assert.Equal(t, "zzz-bag-addon", report[1].Addon.Name)
+	assert.Equal(t, old, report[0].LastReleaseDate)
+	assert.Equal(t, []string{STALE_REASON_NO_RECENT_RELEASE}, report[0].ReasonList)
+	assert.Equal(t, 1, len(report[0].ReplacementList))
+	assert.Equal(t, 1, len(report[0].ItemChildren(nil)))
+
+	// a longer window
+	report = StaleAddonReport(addon_list, catalogue, stale_test_now, 36)
+	assert.Equal(t, 0, len(report))
+}
@@ -222,7 +222,12 @@ func coerce_toc_data(kvs map[string]string, file_path PathToFile) TOC {
 	game_track_id := ""
 	matches := game_track_regex.FindStringSubmatch(file_name)
 	if len(matches) >= 2 && matches[2] != "" {
-		game_track_id = GuessGameTrack(strings.ToLower(matches[2]))
+		gt, present := game_tracks().FindTOCSuffix(matches[2])
+		if present {
+			game_track_id = gt
+		} else {
+			game_track_id = GuessGameTrack(strings.ToLower(matches[2]))
+		}
 	}
 
 	toc.URL = "file://" + file_path         // "file:///path/to/addon/dir/AdiBags/AdiBags_TBC.toc"
@@ -0,0 +1,314 @@
+package strongbox
+
+import (
+	"bw/core"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// toc_lint.go checks the .toc files of installed addons for problems.
+// most problems don't stop an addon from loading but may confuse strongbox (or the game) about what the addon supports.
+
+type LintSeverity string
+
+const (
+	LINT_ERROR   LintSeverity = "error"   // the addon is broken or won't be handled correctly
+	LINT_WARNING LintSeverity = "warning" // the addon is probably fine but something is off
+	LINT_INFO    LintSeverity = "info"    // nothing is wrong, just noteworthy
+)
+
+// lower is more severe, used for sorting
+var lint_severity_rank = map[LintSeverity]int{
+	LINT_ERROR:   0,
+	LINT_WARNING: 1,
+	LINT_INFO:    2,
+}
+
+const (
+	LINT_CHECK_INTERFACE          = "interface"
+	LINT_CHECK_GAME_TRACK_SUFFIX  = "game-track-suffix"
+	LINT_CHECK_SOURCE_ID          = "source-id"
+	LINT_CHECK_MISSING_FILE       = "missing-file"
+	LINT_CHECK_TITLE_ESCAPE_CODES = "title-escape-codes"
+	LINT_CHECK_DUPLICATE_TOC      = "duplicate-toc"
+)
+
+// a single problem found with a .toc file.
+type TOCFinding struct {
+	Severity LintSeverity
+	Check    string // "missing-file"
+	DirName  string // "EveryAddon"
+	FileName string // "EveryAddon.toc"
+	Message  string
+}
+
+var _ core.ItemInfo = (*TOCFinding)(nil)
+
+func (f TOCFinding) ItemKeys() []string {
+	return []string{
+		core.ITEM_FIELD_NAME,
+		core.ITEM_FIELD_DESC,
+		"severity",
+		"check",
+		"dir-name",
+	}
+}
+
+func (f TOCFinding) ItemMap() map[string]string {
+	return map[string]string{
+		core.ITEM_FIELD_NAME: f.FileName,
+		core.ITEM_FIELD_DESC: f.Message,
+		"severity":           string(f.Severity),
+		"check":              f.Check,
+		"dir-name":           f.DirName,
+	}
+}
+
+func (f TOCFinding) ItemHasChildren() core.ITEM_CHILDREN_LOAD {
+	return core.ITEM_CHILDREN_LOAD_FALSE
+}
+
+func (f TOCFinding) ItemChildren(_ *core.App) []core.Result {
+	return nil
+}
+
+// the x-* keys strongbox knows how to use as a source.
+// "x-website" is also used but it's not an ID.
+var known_toc_source_keys = map[string]bool{
+	"x-wowi-id": true,
+	"x-github":  true,
+}
+
+// "x-curse-project-id", "x-wago-id", "x-tukui-projectid", etc
+var toc_source_key_regex = regexp.MustCompile(`^x-.+-(project)?id$`)
+
+// returns the list of files a .toc file asks the game to load, in order.
+// paths use forward slashes and are relative to the addon directory.
+// lines with variables like "[Family]" or "[Game]" are skipped, they can't be resolved outside of the game.
+func toc_file_list(toc_contents string) []string {
+	rv := []string{}
+	for _, row := range strings.Split(strings.ReplaceAll(toc_contents, "\r\n", "\n"), "\n") {
+		row = strings.TrimSpace(row)
+		if row == "" || strings.HasPrefix(row, "#") || strings.Contains(row, "[") {
+			continue
+		}
+		rv = append(rv, strings.ReplaceAll(row, `\`, "/")) // "Libs\LibStub\LibStub.lua" => "Libs/LibStub/LibStub.lua"
+	}
+	return rv
+}
+
+// returns `true` if `path` exists beneath `dir` ignoring case, as it would on Windows and macOS.
+func file_exists_ignoring_case(dir PathToDir, path string) bool {
+	for _, bit := range strings.Split(path, "/") {
+		entry_list, err := os.ReadDir(dir)
+		if err != nil {
+			return false
+		}
+		found := false
+		for _, entry := range entry_list {
+			if strings.EqualFold(entry.Name(), bit) {
+				dir = filepath.Join(dir, entry.Name())
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// checks a single .toc file, returning a list of findings.
+// `toc_contents` is the raw contents of the .toc file at `toc_path`.
+func lint_toc_file(toc_path PathToFile, toc_contents string) []TOCFinding {
+	kvs := parse_toc_file(toc_contents)
+	toc := coerce_toc_data(kvs, toc_path)
+	addon_dir := filepath.Dir(toc_path)
+
+	finding_list := []TOCFinding{}
+	add := func(severity LintSeverity, check string, msg string, args ...any) {
+		finding_list = append(finding_list, TOCFinding{
+			Severity: severity,
+			Check:    check,
+			DirName:  toc.DirName,
+			FileName: toc.FileName,
+			Message:  fmt.Sprintf(msg, args...),
+		})
+	}
+
+	// interface
+
+	interface_version, has_interface_version := kvs["interface"]
+	if !has_interface_version {
+		add(LINT_ERROR, LINT_CHECK_INTERFACE, "missing '## Interface'")
+	} else {
+		for bit := range strings.SplitSeq(interface_version, ",") {
+			iv, err := core.StringToInt(strings.TrimSpace(bit))
+			if err != nil {
+				add(LINT_ERROR, LINT_CHECK_INTERFACE, "malformed interface version: '%s'", strings.TrimSpace(bit))
+				continue
+			}
+			_, err = InterfaceVersionToGameTrack(iv)
+			if err != nil {
+				add(LINT_WARNING, LINT_CHECK_INTERFACE, "interface version doesn't match any known game track: %d", iv)
+			}
+		}
+	}
+
+	// interface versions vs filename suffix
+
+	if toc.FileNameGameTrackID != "" && !toc.InterfaceVersionGameTrackIDSet.IsEmpty() && !toc.InterfaceVersionGameTrackIDSet.Contains(toc.FileNameGameTrackID) {
+		iv_gt_list := toc.InterfaceVersionGameTrackIDSet.ToSlice()
+		slices.Sort(iv_gt_list)
+		add(LINT_WARNING, LINT_CHECK_GAME_TRACK_SUFFIX, "file name suggests '%s' but interface version(s) suggest: %v", toc.FileNameGameTrackID, iv_gt_list)
+	}
+
+	// x-* source ids
+
+	key_list := []string{}
+	for key := range kvs {
+		key_list = append(key_list, key)
+	}
+	slices.Sort(key_list)
+	for _, key := range key_list {
+		val := kvs[key]
+		switch {
+		case key == "x-wowi-id":
+			_, err := core.StringToInt(val)
+			if err != nil {
+				add(LINT_WARNING, LINT_CHECK_SOURCE_ID, "'%s' should be a number: '%s'", key, val)
+			}
+		case key == "x-github":
+			bits := strings.Split(strings.Trim(val, "/"), "/") // "ogri-la/strongbox" => ["ogri-la", "strongbox"]
+			if len(bits) != 2 || bits[0] == "" || bits[1] == "" {
+				add(LINT_WARNING, LINT_CHECK_SOURCE_ID, "'%s' should look like 'owner/repository': '%s'", key, val)
+			}
+		case !known_toc_source_keys[key] && toc_source_key_regex.MatchString(key):
+			add(LINT_INFO, LINT_CHECK_SOURCE_ID, "unsupported source '%s': '%s'", key, val)
+		}
+	}
+
+	// listed files
+
+	for _, path := range toc_file_list(toc_contents) {
+		if core.FileExists(filepath.Join(addon_dir, filepath.FromSlash(path))) {
+			continue
+		}
+		if file_exists_ignoring_case(addon_dir, path) {
+			add(LINT_WARNING, LINT_CHECK_MISSING_FILE, "listed file differs in case and won't be found on case-sensitive filesystems: %s", path)
+			continue
+		}
+		add(LINT_ERROR, LINT_CHECK_MISSING_FILE, "listed file not found: %s", path)
+	}
+
+	// title
+
+	if toc.Title != RemoveEscapeSequences(toc.Title) {
+		add(LINT_INFO, LINT_CHECK_TITLE_ESCAPE_CODES, "title contains colour escape codes: %s", toc.Title)
+	}
+
+	return finding_list
+}
+
+// returns findings for .toc files in the same addon directory that claim the same game track with the same priority.
+// a .toc file with a game track suffix beats one without, so they don't conflict,
+// but two .toc files with the same suffix, or two without, leave strongbox guessing.
+func lint_duplicate_tocs(toc_list []TOC) []TOCFinding {
+	type claim struct {
+		game_track_id GameTrackID
+		suffixed      bool
+	}
+	idx := map[claim][]string{}
+	dir_name := ""
+	for _, toc := range toc_list {
+		dir_name = toc.DirName
+		for _, gt := range toc.GameTrackIDSet.ToSlice() {
+			c := claim{gt, toc.FileNameGameTrackID == gt}
+			idx[c] = append(idx[c], toc.FileName)
+		}
+	}
+
+	finding_list := []TOCFinding{}
+	for _, gt := range game_tracks().IDList() {
+		for _, suffixed := range []bool{true, false} {
+			file_name_list := idx[claim{gt, suffixed}]
+			if len(file_name_list) < 2 {
+				continue
+			}
+			slices.Sort(file_name_list)
+			finding_list = append(finding_list, TOCFinding{
+				Severity: LINT_WARNING,
+				Check:    LINT_CHECK_DUPLICATE_TOC,
+				DirName:  dir_name,
+				FileName: file_name_list[0],
+				Message:  fmt.Sprintf("many .toc files target '%s', only one will be used: %s", gt, strings.Join(file_name_list, ", ")),
+			})
+		}
+	}
+	return finding_list
+}
+
+// checks every .toc file in the given `addon_dir`.
+func lint_addon_dir(addon_dir PathToAddon) ([]TOCFinding, error) {
+	empty_response := []TOCFinding{}
+
+	toc_path_list, err := find_toc_files(addon_dir)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to lint addon: %w", err)
+	}
+
+	finding_list := []TOCFinding{}
+	toc_list := []TOC{}
+	for _, toc_path := range toc_path_list {
+		toc_data, err := core.SlurpBytesUTF8(toc_path)
+		if err != nil {
+			slog.Warn("failed to read .toc file, skipping", "path", toc_path, "error", err)
+			continue
+		}
+		finding_list = append(finding_list, lint_toc_file(toc_path, string(toc_data))...)
+		toc_list = append(toc_list, coerce_toc_data(parse_toc_file(string(toc_data)), toc_path))
+	}
+
+	return append(finding_list, lint_duplicate_tocs(toc_list)...), nil
+}
+
+// checks the .toc files of every addon in the given `addons_dir`.
+// findings are ordered by severity, then directory, then file name.
+func LintAddonsDir(addons_dir AddonsDir) ([]TOCFinding, error) {
+	empty_response := []TOCFinding{}
+	dir_list, err := core.DirList(addons_dir.Path)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to lint addons dir: %w", err)
+	}
+
+	finding_list := []TOCFinding{}
+	for _, full_path := range dir_list {
+		if BlizzardAddon(full_path) {
+			continue
+		}
+		addon_finding_list, err := lint_addon_dir(full_path)
+		if err != nil {
+			slog.Warn("failed to lint addon, skipping", "error", err)
+			continue
+		}
+		finding_list = append(finding_list, addon_finding_list...)
+	}
+
+	slices.SortStableFunc(finding_list, func(a, b TOCFinding) int {
+		if a.Severity != b.Severity {
+			return lint_severity_rank[a.Severity] - lint_severity_rank[b.Severity]
+		}
+		if a.DirName != b.DirName {
+			return strings.Compare(a.DirName, b.DirName)
+		}
+		return strings.Compare(a.FileName, b.FileName)
+	})
+
+	return finding_list, nil
+}
@@ -0,0 +1,104 @@
+package strongbox
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_toc_file_list(t *testing.T) {
+	given := "## Title: EveryAddon\r\n## Interface: 110000\r\n\r\n# a comment\r\nLibs\\LibStub\\LibStub.lua\r\n  EveryAddon.lua  \r\nLocales\\[TextLocale].lua\r\nEveryAddon.xml\r\n"
+	expected := []string{"Libs/LibStub/LibStub.lua", "EveryAddon.lua", "EveryAddon.xml"}
+	assert.Equal(t, expected, toc_file_list(given))
+}
+
+// returns the checks found in the given list of findings for the given file, with their severity.
+func finding_checks(finding_list []TOCFinding, file_name string) map[string]LintSeverity {
+	rv := map[string]LintSeverity{}
+	for _, f := range finding_list {
+		if f.FileName == file_name {
+			rv[f.Check] = f.Severity
+		}
+	}
+	return rv
+}
+
+func Test_lint_toc_file(t *testing.T) {
+	root := t.TempDir()
+	make_file_tree(t, root, map[string]string{
+		"EveryAddon/EveryAddon.lua": "",
+		"EveryAddon/libs/foo.lua":   "",
+	})
+
+	var cases = []struct {
+		file_name string
+		given     string
+		expected  map[string]LintSeverity
+	}{
+		{"EveryAddon.toc", "## Title: EveryAddon\n## Interface: 110000\nEveryAddon.lua\n", map[string]LintSeverity{}},
+		{"EveryAddon.toc", "## Title: EveryAddon\n", map[string]LintSeverity{LINT_CHECK_INTERFACE: LINT_ERROR}},
+		{"EveryAddon.toc", "## Title: EveryAddon\n## Interface: 110000, eleven\n", map[string]LintSeverity{LINT_CHECK_INTERFACE: LINT_ERROR}},
+		{"EveryAddon.toc", "## Title: EveryAddon\n## Interface: 123\n", map[string]LintSeverity{LINT_CHECK_INTERFACE: LINT_WARNING}},
+		{"EveryAddon_Vanilla.toc", "## Title: EveryAddon\n## Interface: 110000\n", map[string]LintSeverity{LINT_CHECK_GAME_TRACK_SUFFIX: LINT_WARNING}},
+		{"EveryAddon_Vanilla.toc", "## Title: EveryAddon\n## Interface: 11502\n", map[string]LintSeverity{}},
+		{"EveryAddon.toc", "## Title: EveryAddon\n## Interface: 110000\n## X-Wowi-ID: abc\n", map[string]LintSeverity{LINT_CHECK_SOURCE_ID: LINT_WARNING}},
+		{"EveryAddon.toc", "## Title: EveryAddon\n## Interface: 110000\n## X-Github: ogri-la\n", map[string]LintSeverity{LINT_CHECK_SOURCE_ID: LINT_WARNING}},
+		{"EveryAddon.toc", "## Title: EveryAddon\n## Interface: 110000\n## X-Curse-Project-ID: 1234\n", map[string]LintSeverity{LINT_CHECK_SOURCE_ID: LINT_INFO}},
+		{"EveryAddon.toc", "## Title: EveryAddon\n## Interface: 110000\n## X-Wowi-ID: 1234\n## X-Github: ogri-la/everyaddon\n## X-Website: https://example.org\n", map[string]LintSeverity{}},
+		{"EveryAddon.toc", "## Title: EveryAddon\n## Interface: 110000\nMissing.lua\n", map[string]LintSeverity{LINT_CHECK_MISSING_FILE: LINT_ERROR}},
+		{"EveryAddon.toc", "## Title: EveryAddon\n## Interface: 110000\nLibs\\Foo.lua\n", map[string]LintSeverity{LINT_CHECK_MISSING_FILE: LINT_WARNING}},
+		{"EveryAddon.toc", "## Title: |cff1784d1Every|rAddon\n## Interface: 110000\n", map[string]LintSeverity{LINT_CHECK_TITLE_ESCAPE_CODES: LINT_INFO}},
+	}
+	for i, c := range cases {
+		toc_path := filepath.Join(root, "EveryAddon", c.file_name)
+		actual := finding_checks(lint_toc_file(toc_path, c.given), c.file_name)
+		assert.Equal(t, c.expected, actual, i)
+	}
+}
+
+// .toc files claiming the same game track with the same priority are reported, suffixed .toc files beat unsuffixed ones.
+func Test_lint_duplicate_tocs(t *testing.T) {
+	toc := func(file_name string, interface_version string) TOC {
+		kvs := map[string]string{"title": "EveryAddon", "interface": interface_version}
+		return coerce_toc_data(kvs, filepath.Join("/", "EveryAddon", file_name))
+	}
+
+	// EveryAddon.toc is retail, EveryAddon_Vanilla.toc is classic
+	finding_list := lint_duplicate_tocs([]TOC{toc("EveryAddon.toc", "110000"), toc("EveryAddon_Vanilla.toc", "11502")})
+	assert.Equal(t, 0, len(finding_list))
+
+	// EveryAddon_Vanilla.toc beats EveryAddon.toc for classic
+	finding_list = lint_duplicate_tocs([]TOC{toc("EveryAddon.toc", "110000, 11502"), toc("EveryAddon_Vanilla.toc", "11502")})
+	assert.Equal(t, 0, len(finding_list))
+
+	// both are classic
+	finding_list = lint_duplicate_tocs([]TOC{toc("EveryAddon_Vanilla.toc", "11502"), toc("EveryAddon-Classic.toc", "11502")})
+	assert.Equal(t, 1, len(finding_list))
+	assert.Equal(t, LINT_CHECK_DUPLICATE_TOC, finding_list[0].Check)
+	assert.Equal(t, LINT_WARNING, finding_list[0].Severity)
+	assert.Equal(t, "EveryAddon-Classic.toc", finding_list[0].FileName)
+}
+
+func Test_LintAddonsDir(t *testing.T) {
+	root := t.TempDir()
+	make_file_tree(t, root, map[string]string{
+		"EveryAddon/EveryAddon.toc":           "## Title: EveryAddon\n## Interface: 110000\nEveryAddon.lua\n",
+		"EveryAddon/EveryAddon.lua":           "",
+		"EveryOtherAddon/EveryOtherAddon.toc": "## Title: |cffffffffEveryOtherAddon|r\nMissing.lua\n",
+		"Blizzard_Foo/Blizzard_Foo.toc":       "## Title: Foo\n",
+	})
+
+	finding_list, err := LintAddonsDir(AddonsDir{Path: root})
+	assert.Nil(t, err)
+
+	// ordered by severity
+	expected := []string{LINT_CHECK_INTERFACE, LINT_CHECK_MISSING_FILE, LINT_CHECK_TITLE_ESCAPE_CODES}
+	actual := []string{}
+	for _, f := range finding_list {
+		assert.Equal(t, "EveryOtherAddon", f.DirName)
+		actual = append(actual, f.Check)
+	}
+	assert.ElementsMatch(t, expected, actual)
+	assert.Equal(t, LINT_INFO, finding_list[len(finding_list)-1].Severity)
+}
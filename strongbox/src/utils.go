@@ -2,7 +2,11 @@ package strongbox
 
 import (
 	"bw/core"
+	"crypto/md5"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -20,76 +24,75 @@ func BlizzardAddon(path string) bool {
 }
 
 // returns the first game track it finds in the given string,
-// preferring `:classic-wotlk`, then `:classic-tbc`, then `:classic`, then `:retail` (most to least specific).
+// preferring exact matches to known aliases and then fuzzier matches against each game track's guess-regex,
+// from most to least specific (`:classic-wotlk`, then `:classic-tbc`, then `:classic`, then `:retail`).
 // returns an empty string if a game track couldn't be guessed.
 func GuessGameTrack(val string) GameTrackID {
+	reg := game_tracks()
 
 	// short-circuit for exact matches to known aliases, including release.json flavors
-	gametrack_from_common_cases, present := GAMETRACK_ALIAS_MAP[val]
+	gametrack_from_common_cases, present := reg.FindAlias(val)
 	if present {
 		return gametrack_from_common_cases
 	}
 
 	// fuzzier matching
-
-	// matches 'classic-wotlk', 'classic_wotlk', 'classic-wrath', 'classic_wrath', 'wotlk', 'wrath'
-	classic_wotlk_regex := regexp.MustCompile(`(?i)(classic[\W_])?(wrath|wotlk){1}\W?`)
-
-	// matches 'classic-tbc', 'classic-bc', 'classic-bcc', 'classic_tbc', 'classic_bc', 'classic_bcc', 'tbc', 'tbcc', 'bc', 'bcc'
-	// but not 'classictbc' or 'classicbc' or 'classicbcc'
-	// see tests.
-	classic_tbc_regex := regexp.MustCompile(`(?i)classic[\W_]t?bcc?|[\W_]t?bcc?\W?|t?bcc?$`)
-	classic_regex := regexp.MustCompile(`(?i)classic|vanilla`)
-	retail_regex := regexp.MustCompile(`(?i)retail|mainline`)
-
-	if classic_wotlk_regex.MatchString(val) {
-		return GAMETRACK_CLASSIC_WOTLK
-	}
-	if classic_tbc_regex.MatchString(val) {
-		return GAMETRACK_CLASSIC_TBC
-	}
-	if classic_regex.MatchString(val) {
-		return GAMETRACK_CLASSIC
-	}
-	if retail_regex.MatchString(val) {
-		return GAMETRACK_RETAIL
-	}
-
-	return ""
+	return reg.Guess(val)
 }
 
-var InterfaceVersionToGameVersion_regex = regexp.MustCompile(`(?P<major>\d0|\d{1})\d(?P<minor>\d{1})\d(?P<patch>\d{1}\w?)`)
-
-// 100105 => 10.1.5, 30402 => 3.4.2, 11402 => 1.4.2
+// 100105 => 10.1.5, 30402 => 3.4.2, 11502 => 1.15.2
 // see: https://wow.gamepedia.com/Patches
 func InterfaceVersionToGameVersion(interface_version_int int) (string, error) {
-	matches := InterfaceVersionToGameVersion_regex.FindStringSubmatch(core.IntToString(interface_version_int))
-	if len(matches) != 4 {
+	if interface_version_int < 10000 {
 		return "", fmt.Errorf("could not parse interface game track from interface version: %d", interface_version_int)
 	}
-	return fmt.Sprintf("%s.%s.%s", matches[1], matches[2], matches[3]), nil
+	major := interface_version_int / 10000
+	minor := (interface_version_int / 100) % 100
+	patch := interface_version_int % 100
+	return fmt.Sprintf("%d.%d.%d", major, minor, patch), nil
+}
+
+// 10.1.0 => 100100, 1.14.3 => 11403
+func GameVersionToInterfaceVersion(game_version string) (int, error) {
+	bits := strings.Split(game_version, ".")
+	if len(bits) < 2 || len(bits) > 3 {
+		return 0, fmt.Errorf("could not parse game version: %s", game_version)
+	}
+	interface_version := 0
+	for i, multiplier := range []int{10000, 100, 1} {
+		if i >= len(bits) {
+			break
+		}
+		n, err := core.StringToInt(bits[i])
+		if err != nil || n < 0 || (i > 0 && n > 99) {
+			return 0, fmt.Errorf("could not parse game version: %s", game_version)
+		}
+		interface_version += n * multiplier
+	}
+	return interface_version, nil
 }
 
 // 10.1.0 => retail, 1.14.3 => classic, etc
+// unparseable game versions are assumed to be retail.
 func GameVersionToGameTrack(game_version string) GameTrackID {
-	entry, present := map[string]string{
-		"1.": GAMETRACK_CLASSIC,
-		"2.": GAMETRACK_CLASSIC_TBC,
-		"3.": GAMETRACK_CLASSIC_WOTLK,
-	}[game_version[:2]] // "1.14.3" => "1."
-	if !present {
+	interface_version, err := GameVersionToInterfaceVersion(game_version)
+	if err != nil {
+		return GAMETRACK_RETAIL
+	}
+	game_track_id, err := InterfaceVersionToGameTrack(interface_version)
+	if err != nil {
 		return GAMETRACK_RETAIL
 	}
-	return entry
+	return game_track_id
 }
 
 // 100105 => retail, 30402 => classic-wotlk, 11402 => classic, etc
 func InterfaceVersionToGameTrack(interface_version int) (GameTrackID, error) {
-	game_version, err := InterfaceVersionToGameVersion(interface_version)
-	if err != nil {
-		return "", err
+	game_track_id, present := game_tracks().FindInterfaceVersion(interface_version)
+	if !present {
+		return "", fmt.Errorf("no game track found for interface version: %d", interface_version)
 	}
-	return GameVersionToGameTrack(game_version), nil
+	return game_track_id, nil
 }
 
 /* this path leads to madness.
@@ -98,7 +101,7 @@ func InterfaceVersionToGameTrack(interface_version int) (GameTrackID, error) {
 // bit of a hack for when accuracy is less important.
 func InstalledAddonToAddon(installed_addon InstalledAddon, parent *Addon) Addon {
 	var toc_to_use TOC
-	for _, gt := range GT_PREF_MAP[GAMETRACK_RETAIL] {
+	for _, gt := range game_tracks().FallbackList(GAMETRACK_RETAIL) {
 		toc, present := installed_addon.TOCMap[gt]
 		if present {
 			toc_to_use = toc
@@ -152,3 +155,24 @@ func convert_compound_game_track(ad AddonsDir) AddonsDir {
 	}
 	return ad
 }
+
+// returns an error if the MD5 checksum of the file at `path` doesn't match the `expected` hex-encoded checksum.
+func verify_md5(path PathToFile, expected string) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file for verification: %w", err)
+	}
+	defer fh.Close()
+
+	hash := md5.New()
+	_, err = io.Copy(hash, fh)
+	if err != nil {
+		return fmt.Errorf("failed to read file for verification: %w", err)
+	}
+
+	actual := hex.EncodeToString(hash.Sum(nil))
+	if !strings.EqualFold(actual, strings.TrimSpace(expected)) {
+		return fmt.Errorf("checksum mismatch, expected '%s' got '%s'", expected, actual)
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package strongbox
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -50,3 +52,102 @@ func TestRemoveEscapeSequences(t *testing.T) {
 		assert.Equal(t, c.expected, RemoveEscapeSequences(c.given), i)
 	}
 }
+
+func TestGuessGameTrack(t *testing.T) {
+	var cases = []struct {
+		given    string
+		expected GameTrackID
+	}{
+		{"", ""},
+		{"foo", ""},
+		// aliases
+		{"retail", GAMETRACK_RETAIL},
+		{"mainline", GAMETRACK_RETAIL},
+		{"vanilla", GAMETRACK_CLASSIC},
+		{"bcc", GAMETRACK_CLASSIC_TBC},
+		{"wrath", GAMETRACK_CLASSIC_WOTLK},
+		{"cata", GAMETRACK_CLASSIC_CATA},
+		{"classic-cata", GAMETRACK_CLASSIC_CATA},
+		// fuzzy
+		{"Addon-classic-wotlk-v1.2.3.zip", GAMETRACK_CLASSIC_WOTLK},
+		{"Addon-classic_tbc-v1.2.3.zip", GAMETRACK_CLASSIC_TBC},
+		{"Addon-bcc", GAMETRACK_CLASSIC_TBC},
+		{"Addon-Classic-v1.2.3.zip", GAMETRACK_CLASSIC},
+		{"Addon-Retail-v1.2.3.zip", GAMETRACK_RETAIL},
+	}
+	for i, c := range cases {
+		assert.Equal(t, c.expected, GuessGameTrack(c.given), i)
+	}
+}
+
+func TestInterfaceVersionToGameVersion(t *testing.T) {
+	var cases = []struct {
+		given    int
+		expected string
+	}{
+		{100105, "10.1.5"},
+		{110002, "11.0.2"},
+		{30402, "3.4.2"},
+		{11502, "1.15.2"},
+		{40400, "4.4.0"},
+	}
+	for i, c := range cases {
+		actual, err := InterfaceVersionToGameVersion(c.given)
+		assert.Nil(t, err, i)
+		assert.Equal(t, c.expected, actual, i)
+	}
+
+	_, err := InterfaceVersionToGameVersion(999)
+	assert.NotNil(t, err)
+}
+
+func TestGameVersionToGameTrack(t *testing.T) {
+	var cases = []struct {
+		given    string
+		expected GameTrackID
+	}{
+		{"10.1.0", GAMETRACK_RETAIL},
+		{"1.14.3", GAMETRACK_CLASSIC},
+		{"2.5.4", GAMETRACK_CLASSIC_TBC},
+		{"3.4.3", GAMETRACK_CLASSIC_WOTLK},
+		{"4.4.0", GAMETRACK_CLASSIC_CATA},
+		{"7.0", GAMETRACK_RETAIL},
+		{"foo", GAMETRACK_RETAIL},
+	}
+	for i, c := range cases {
+		assert.Equal(t, c.expected, GameVersionToGameTrack(c.given), i)
+	}
+}
+
+func TestInterfaceVersionToGameTrack(t *testing.T) {
+	var cases = []struct {
+		given    int
+		expected GameTrackID
+	}{
+		{100105, GAMETRACK_RETAIL},
+		{70000, GAMETRACK_RETAIL},
+		{11503, GAMETRACK_CLASSIC},
+		{20504, GAMETRACK_CLASSIC_TBC},
+		{30403, GAMETRACK_CLASSIC_WOTLK},
+		{40400, GAMETRACK_CLASSIC_CATA},
+	}
+	for i, c := range cases {
+		actual, err := InterfaceVersionToGameTrack(c.given)
+		assert.Nil(t, err, i)
+		assert.Equal(t, c.expected, actual, i)
+	}
+
+	_, err := InterfaceVersionToGameTrack(123)
+	assert.NotNil(t, err)
+}
+
+func Test_verify_md5(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foo.zip")
+	err := os.WriteFile(path, []byte("foo"), 0644)
+	assert.Nil(t, err)
+
+	assert.Nil(t, verify_md5(path, "acbd18db4cc2f85cedef654fccc4a4d8"))
+	assert.Nil(t, verify_md5(path, "ACBD18DB4CC2F85CEDEF654FCCC4A4D8"))
+	assert.NotNil(t, verify_md5(path, "d41d8cd98f00b204e9800998ecf8427e"))
+	assert.NotNil(t, verify_md5(path+".dne", "acbd18db4cc2f85cedef654fccc4a4d8"))
+}
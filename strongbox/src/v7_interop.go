@@ -0,0 +1,224 @@
+package strongbox
+
+import (
+	"bw/core"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+// v7_interop.go is about living alongside strongbox 7.
+// both versions read the .strongbox.json nfo files in addons directories and, when XDG_CONFIG_HOME is set, the same settings file.
+// strongbox 7 validates what it reads and deletes nfo files it considers invalid,
+// so anything written to a shared file is restricted to what strongbox 7 tolerates.
+
+// sources strongbox 7 knows about. nfo data for other sources is hidden from it.
+var V7_SOURCE_SET = mapset.NewSet(
+	SOURCE_CURSEFORGE,
+	SOURCE_WOWI,
+	SOURCE_GITHUB,
+	SOURCE_GITLAB,
+	SOURCE_TUKUI,
+	SOURCE_TUKUI_CLASSIC,
+	SOURCE_TUKUI_CLASSIC_TBC,
+	SOURCE_TUKUI_CLASSIC_WOTLK,
+)
+
+// sources strongbox 7 wrote integer source IDs for.
+var V7_INT_SOURCE_ID_SET = mapset.NewSet(
+	SOURCE_CURSEFORGE,
+	SOURCE_WOWI,
+	SOURCE_TUKUI,
+	SOURCE_TUKUI_CLASSIC,
+	SOURCE_TUKUI_CLASSIC_TBC,
+	SOURCE_TUKUI_CLASSIC_WOTLK,
+)
+
+// game tracks strongbox 7 knows about. game tracks added to the registry since are hidden from it.
+var V7_GAME_TRACK_SET = mapset.NewSet(
+	GAMETRACK_RETAIL,
+	GAMETRACK_CLASSIC,
+	GAMETRACK_CLASSIC_TBC,
+	GAMETRACK_CLASSIC_WOTLK,
+	GAMETRACK_CLASSIC_CATA,
+	GAMETRACK_RETAIL_CLASSIC,
+	GAMETRACK_CLASSIC_RETAIL,
+)
+
+// files in the strongbox 7 config directory that are imported.
+var V7_CONFIG_FILE_LIST = []FileName{
+	"config.json",
+	"user-catalogue.json",
+}
+
+// returns the source ID the way strongbox 7 wrote it, an int for some sources and a string for others.
+// returns nil for an empty source ID.
+func v7_source_id(source Source, source_id FlexString) any {
+	if source_id == "" {
+		return nil
+	}
+	if V7_INT_SOURCE_ID_SET.Contains(source) {
+		i, err := strconv.Atoi(string(source_id))
+		if err == nil {
+			return i
+		}
+	}
+	return string(source_id)
+}
+
+// the settings strongbox 7 reads.
+// strongbox 7 validates it's settings, so values it doesn't know, like game tracks added since, are left out.
+
+type v7_addons_dir struct {
+	Path        string      `json:"addon-dir"`
+	GameTrackID GameTrackID `json:"game-track"`
+	Strict      bool        `json:"strict?"`
+}
+
+type v7_preferences struct {
+	AddonZipsToKeep          *uint8   `json:"addon-zips-to-keep,omitempty"`
+	CheckForUpdate           *bool    `json:"check-for-update,omitempty"`
+	KeepUserCatalogueUpdated *bool    `json:"keep-user-catalogue-updated,omitempty"`
+	SelectedColumns          []string `json:"ui-selected-columns,omitempty"`
+}
+
+type v7_settings_file struct {
+	AddonsDirList         []v7_addons_dir     `json:"addon-dir-list"`
+	CatalogueLocationList []CatalogueLocation `json:"catalogue-location-list"`
+	Preferences           v7_preferences      `json:"preferences"`
+	GUITheme              GUITheme            `json:"gui-theme,omitempty"`
+	SelectedCatalogue     string              `json:"selected-catalogue,omitempty"`
+	SelectedAddonDir      string              `json:"selected-addon-dir,omitempty"`
+
+	// the complete strongbox 8 settings, ignored by strongbox 7.
+	// strongbox 7 doesn't write this key back, so settings saved by strongbox 7 are migrated again from the values above.
+	Strongbox8 *Settings `json:"strongbox8,omitempty"`
+}
+
+// returns `settings` as strongbox 7 reads them, with the complete strongbox 8 settings beneath a 'strongbox8' key.
+// addons dirs with game tracks strongbox 7 doesn't know are hidden from it.
+func v7_settings(settings Settings) v7_settings_file {
+	settings = without_v7_settings(settings)
+
+	v7 := v7_settings_file{
+		AddonsDirList:         []v7_addons_dir{},
+		CatalogueLocationList: settings.CatalogueLocationList,
+		Preferences: v7_preferences{
+			AddonZipsToKeep:          settings.Preferences.AddonZipsToKeep,
+			CheckForUpdate:           settings.Preferences.CheckForUpdate,
+			KeepUserCatalogueUpdated: settings.Preferences.KeepUserCatalogueUpdated,
+			SelectedColumns:          settings.Preferences.SelectedColumns,
+		},
+		GUITheme:          settings.Preferences.SelectedGUITheme,
+		SelectedCatalogue: settings.Preferences.SelectedCatalogue,
+		Strongbox8:        &settings,
+	}
+
+	for _, ad := range settings.AddonsDirList {
+		if !V7_GAME_TRACK_SET.Contains(ad.GameTrackID) {
+			continue
+		}
+		v7.AddonsDirList = append(v7.AddonsDirList, v7_addons_dir{Path: ad.Path, GameTrackID: ad.GameTrackID, Strict: ad.Strict})
+		if ad.Path == settings.Preferences.SelectedAddonsDir {
+			v7.SelectedAddonDir = ad.Path
+		}
+	}
+
+	return v7
+}
+
+// returns the strongbox 8 settings in settings file `data` and `true`,
+// or `false` if the settings were written by strongbox 7 or an older strongbox 8.
+func v8_settings(data []byte) (Settings, bool) {
+	v7 := v7_settings_file{}
+	err := json.Unmarshal(data, &v7)
+	if err != nil || v7.Strongbox8 == nil {
+		return Settings{}, false
+	}
+	return *v7.Strongbox8, true
+}
+
+// returns `settings` without the values strongbox 7 wrote, once they've been migrated.
+func without_v7_settings(settings Settings) Settings {
+	settings.DeprecatedSelectedCatalog = ""
+	settings.DeprecatedSelectedAddonDir = ""
+	settings.DeprecatedSelectedCatalogue = ""
+	settings.DeprecatedGUITheme = ""
+
+	new_addons_dir_list := []AddonsDir{}
+	for _, ad := range settings.AddonsDirList {
+		ad.StrictPtr = nil
+		new_addons_dir_list = append(new_addons_dir_list, ad)
+	}
+	settings.AddonsDirList = new_addons_dir_list
+
+	return settings
+}
+
+// the strongbox 7 config directory, "~/.config/strongbox".
+// strongbox 7 respects XDG_CONFIG_HOME the same way strongbox 8 does, in which case both versions share a config directory.
+func v7_config_dir() PathToDir {
+	config_dir, _ := xdg_path("XDG_CONFIG_HOME")
+	if config_dir != "" {
+		return config_dir
+	}
+	return core.HomePath("/.config/strongbox")
+}
+
+// copies the strongbox 7 settings and user catalogue in `v7_dir` to `config_dir`,
+// but only when `config_dir` has no settings of it's own. strongbox 7 files are never modified.
+// imported settings are migrated like any other old settings file when they're loaded.
+// returns the paths of the files that were imported.
+func import_v7_config(v7_dir PathToDir, config_dir PathToDir) ([]PathToFile, error) {
+	empty_response := []PathToFile{}
+
+	if filepath.Clean(v7_dir) == filepath.Clean(config_dir) {
+		// shared config dir, nothing to import.
+		return empty_response, nil
+	}
+
+	if core.FileExists(filepath.Join(config_dir, "config.json")) || !core.FileExists(filepath.Join(v7_dir, "config.json")) {
+		return empty_response, nil
+	}
+
+	imported := []PathToFile{}
+	for _, fname := range V7_CONFIG_FILE_LIST {
+		src := filepath.Join(v7_dir, fname)
+		dest := filepath.Join(config_dir, fname)
+		if !core.FileExists(src) || core.FileExists(dest) {
+			continue
+		}
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return empty_response, fmt.Errorf("failed to read strongbox 7 file: %w", err)
+		}
+		err = core.MakeParents(dest)
+		if err != nil {
+			return empty_response, fmt.Errorf("failed to import strongbox 7 file: %w", err)
+		}
+		err = core.Spit(dest, data)
+		if err != nil {
+			return empty_response, fmt.Errorf("failed to import strongbox 7 file: %w", err)
+		}
+		imported = append(imported, dest)
+	}
+	return imported, nil
+}
+
+// imports strongbox 7 settings into the strongbox config dir if strongbox hasn't been run before.
+func ImportV7Config(app *core.App) {
+	config_dir := app.State.GetKeyVal("app.config-dir")
+	imported, err := import_v7_config(v7_config_dir(), config_dir)
+	if err != nil {
+		slog.Error("failed to import strongbox 7 settings", "error", err)
+		return
+	}
+	for _, path := range imported {
+		slog.Info("imported strongbox 7 file", "path", path)
+	}
+}
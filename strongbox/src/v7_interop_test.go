@@ -0,0 +1,273 @@
+package strongbox
+
+import (
+	"bw/core"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// nfo files written by strongbox 7 are read and written back unchanged.
+// strongbox 7 wrote wowinterface source IDs as ints, so the string in the mixed nfo file is written back as an int.
+func Test_v7_nfo__round_trip(t *testing.T) {
+	fs := NFOFileStore{}
+	cases := []struct {
+		given    []byte
+		expected string
+	}{
+		{test_fixture_nfo_single_ints_json, string(test_fixture_nfo_single_ints_json)},
+		{test_fixture_nfo_multi_mixed_json, strings.ReplaceAll(string(test_fixture_nfo_multi_mixed_json), `"321"`, `321`)},
+	}
+	for _, c := range cases {
+		addon_dir := t.TempDir()
+		assert.Nil(t, core.Spit(nfo_path(addon_dir), c.given))
+
+		nfo_list, err := fs.Read(addon_dir)
+		assert.Nil(t, err)
+		assert.Nil(t, fs.Write(addon_dir, nfo_list))
+
+		actual, err := os.ReadFile(nfo_path(addon_dir))
+		assert.Nil(t, err)
+		assert.JSONEq(t, c.expected, string(actual))
+	}
+}
+
+// source IDs are read as strings and written back as ints for sources that strongbox 7 used ints for
+func Test_v7_nfo__source_id(t *testing.T) {
+	nfo_list := []NFO{}
+	assert.Nil(t, json.Unmarshal(test_fixture_nfo_multi_mixed_json, &nfo_list))
+	assert.Equal(t, FlexString("123"), nfo_list[0].SourceID)
+	assert.Equal(t, FlexString("321"), nfo_list[1].SourceID)
+
+	cases := []struct {
+		source   Source
+		given    FlexString
+		expected any
+	}{
+		{SOURCE_WOWI, "24726", 24726},
+		{SOURCE_CURSEFORGE, "12345", 12345},
+		{SOURCE_WOWI, "not-an-int", "not-an-int"},
+		{SOURCE_GITHUB, "12345", "12345"},
+		{SOURCE_GITHUB, "", nil},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, v7_source_id(c.source, c.given))
+	}
+}
+
+// sources and game tracks strongbox 7 doesn't know are hidden from it but still available to strongbox 8
+func Test_v7_nfo__unknown_to_v7(t *testing.T) {
+	given := NFO{
+		InstalledVersion:     "1.0.0",
+		Name:                 "everyaddon",
+		GroupID:              "file:///path/to/everyaddon",
+		Primary:              true,
+		Source:               SOURCE_LOCAL,
+		SourceID:             "/path/to/everyaddon",
+		InstalledGameTrackID: "classic-mop",
+		SourceMapList: []SourceMap{
+			{Source: SOURCE_GITHUB, SourceID: "ogri-la/everyaddon"},
+			{Source: SOURCE_LOCAL, SourceID: "/path/to/everyaddon"},
+		},
+	}
+	b, err := json.Marshal(given)
+	assert.Nil(t, err)
+
+	expected := `{
+		"installed-version": "1.0.0",
+		"name": "everyaddon",
+		"group-id": "file:///path/to/everyaddon",
+		"primary?": true,
+		"source-map-list": [{"source": "github", "source-id": "ogri-la/everyaddon"}],
+		"strongbox8": {
+			"source": "local",
+			"source-id": "/path/to/everyaddon",
+			"installed-game-track": "classic-mop",
+			"source-map-list": [
+				{"source": "github", "source-id": "ogri-la/everyaddon"},
+				{"source": "local", "source-id": "/path/to/everyaddon"}
+			]
+		}
+	}`
+	assert.JSONEq(t, expected, string(b))
+
+	actual := NFO{}
+	assert.Nil(t, json.Unmarshal(b, &actual))
+	assert.Equal(t, given, actual)
+}
+
+// user catalogues written by strongbox 7 are read and written back unchanged
+func Test_v7_user_catalogue__round_trip(t *testing.T) {
+	expected, err := os.ReadFile(test_fixture_catalogue_file)
+	assert.Nil(t, err)
+
+	cat, err := read_user_catalogue_file(test_fixture_catalogue_file)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(cat.AddonSummaryList))
+
+	output_path := filepath.Join(t.TempDir(), "user-catalogue.json")
+	assert.Nil(t, write_user_catalogue_file(cat, output_path))
+
+	actual, err := os.ReadFile(output_path)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(expected), string(actual))
+}
+
+// settings written by strongbox 8 have just the values strongbox 7 reads,
+// with the strongbox 8 settings beneath a 'strongbox8' key.
+func Test_v7_settings(t *testing.T) {
+	settings := configure_settings(NewSettings())
+	settings.AddonsDirList = []AddonsDir{
+		{
+			Path:                 "/tmp/.strongbox-foo",
+			GameTrackID:          GAMETRACK_RETAIL,
+			Strict:               false,
+			ReleaseChannel:       ReleaseChannel{Stability: RELEASE_STABILITY_BETA},
+			AddonsDirPreferences: AddonsDirPreferences{AddonZipsToKeep: new(uint8(1)), ExcludedAddonList: []string{"everyaddon"}},
+		},
+		{Path: "/tmp/.strongbox-bar", GameTrackID: "classic-mop", Strict: true},
+	}
+	settings.GameTrackList = []GameTrack{{ID: "classic-mop", Label: "Classic (MoP)", InterfaceVersionRangeList: []InterfaceVersionRange{{Min: 50000, Max: 59999}}}}
+	settings.Preferences.SelectedAddonsDir = "/tmp/.strongbox-bar"
+	settings.Preferences.SelectedGUITheme = GUI_THEME_DARK
+	settings.Preferences.GithubToken = "foo"
+	settings.Preferences.NFOStore = NFO_STORE_DATABASE
+	settings.Preferences.ReleaseChannel = ReleaseChannel{Stability: RELEASE_STABILITY_ALPHA}
+	settings.Preferences.BackupWTFBeforeUpdate = new(true)
+	settings.Preferences.WTFBackupsToKeep = new(uint8(5))
+	settings.Preferences.SnapshotBeforeBulkOperation = new(false)
+	settings.Preferences.AddonsDirSnapshotsToKeep = new(uint8(5))
+
+	output_path := filepath.Join(t.TempDir(), "config.json")
+	assert.Nil(t, save_settings_file(settings, output_path))
+
+	data, err := os.ReadFile(output_path)
+	assert.Nil(t, err)
+	raw := map[string]any{}
+	assert.Nil(t, json.Unmarshal(data, &raw))
+
+	expected_keys := []string{"addon-dir-list", "catalogue-location-list", "preferences", "gui-theme", "selected-catalogue", "strongbox8"}
+	assert.ElementsMatch(t, expected_keys, slices.Collect(maps.Keys(raw)))
+	assert.Equal(t, CAT_SHORT.Name, raw["selected-catalogue"])
+	assert.Equal(t, "dark", raw["gui-theme"])
+
+	// the selected addons dir has a game track strongbox 7 doesn't know
+	assert.NotContains(t, raw, "selected-addon-dir")
+	expected_addons_dir_list := []any{
+		map[string]any{"addon-dir": "/tmp/.strongbox-foo", "game-track": "retail", "strict?": false},
+	}
+	assert.Equal(t, expected_addons_dir_list, raw["addon-dir-list"])
+
+	expected_pref_keys := []string{"check-for-update", "keep-user-catalogue-updated", "ui-selected-columns"}
+	assert.ElementsMatch(t, expected_pref_keys, slices.Collect(maps.Keys(raw["preferences"].(map[string]any))))
+
+	// the strongbox 8 settings are read back unchanged
+	actual, err := read_settings_file(output_path)
+	assert.Nil(t, err)
+	assert.Equal(t, settings, actual)
+}
+
+// settings saved by strongbox 7 after strongbox 8 are migrated again using the values strongbox 7 changed
+func Test_v7_settings__resaved_by_v7(t *testing.T) {
+	output_path := filepath.Join(t.TempDir(), "config.json")
+	settings := configure_settings(NewSettings())
+	settings.Preferences.NFOStore = NFO_STORE_DATABASE
+	assert.Nil(t, save_settings_file(settings, output_path))
+
+	// strongbox 7 writes it's own settings without the 'strongbox8' key
+	data, err := os.ReadFile(test_fixture_user_config_7_0_0)
+	assert.Nil(t, err)
+	assert.Nil(t, core.Spit(output_path, data))
+
+	settings, err = read_settings_file(output_path)
+	assert.Nil(t, err)
+	actual := configure_settings(settings)
+
+	expected_addons_dir_list := []AddonsDir{
+		{Path: "/tmp/.strongbox-bar", GameTrackID: GAMETRACK_CLASSIC_TBC, Strict: true},
+		{Path: "/tmp/.strongbox-foo", GameTrackID: GAMETRACK_RETAIL, Strict: false},
+	}
+	assert.Equal(t, expected_addons_dir_list, actual.AddonsDirList)
+	assert.Equal(t, "/tmp/.strongbox-foo", actual.Preferences.SelectedAddonsDir)
+	assert.Equal(t, CAT_FULL.Name, actual.Preferences.SelectedCatalogue)
+	assert.Equal(t, GUI_THEME_DARK_GREEN, actual.Preferences.SelectedGUITheme)
+	assert.Equal(t, new(uint8(3)), actual.Preferences.AddonZipsToKeep)
+	assert.Equal(t, "", actual.DeprecatedSelectedAddonDir)
+
+	// strongbox 8 only settings are lost
+	assert.Equal(t, "", actual.Preferences.NFOStore)
+}
+
+// an nfo database isn't abandoned when strongbox 7 drops the nfo store preference
+func Test_v7_settings__resaved_by_v7__nfo_database(t *testing.T) {
+	app, stopfn := DummyApp2(t.TempDir())
+	defer stopfn()
+	defer set_nfo_store(NFOFileStore{})
+
+	cfg_file := app.State.GetKeyVal("strongbox.paths.cfg-file")
+	data, err := os.ReadFile(test_fixture_user_config_7_0_0)
+	assert.Nil(t, err)
+	assert.Nil(t, core.Spit(cfg_file, data))
+
+	// no nfo data in the database, nfo files are used
+	LoadSettings(app)
+	assert.Equal(t, "", FindSettings(app).Preferences.NFOStore)
+	assert.IsType(t, NFOFileStore{}, nfo_store())
+
+	db, err := OpenNFODatabase(get_paths(app)["strongbox.paths.nfo-db-file"])
+	assert.Nil(t, err)
+	assert.Nil(t, db.Write(filepath.Join(t.TempDir(), "EveryAddon"), []NFO{test_fixture_nfo_single}))
+
+	assert.Nil(t, core.Spit(cfg_file, data))
+	LoadSettings(app)
+	assert.Equal(t, NFO_STORE_DATABASE, FindSettings(app).Preferences.NFOStore)
+	assert.IsType(t, &NFODatabase{}, nfo_store())
+}
+
+// strongbox 7 settings and user catalogue are copied on first run only
+func Test_import_v7_config(t *testing.T) {
+	v7_dir := t.TempDir()
+	config_dir := filepath.Join(t.TempDir(), "strongbox8")
+
+	// nothing to import
+	actual, err := import_v7_config(v7_dir, config_dir)
+	assert.Nil(t, err)
+	assert.Empty(t, actual)
+
+	slurp := func(path PathToFile) []byte {
+		data, err := os.ReadFile(path)
+		assert.Nil(t, err)
+		return data
+	}
+
+	v7_config := slurp(test_fixture_user_config_7_0_0)
+	v7_user_catalogue := slurp(test_fixture_catalogue_file)
+	assert.Nil(t, core.Spit(filepath.Join(v7_dir, "config.json"), v7_config))
+	assert.Nil(t, core.Spit(filepath.Join(v7_dir, "user-catalogue.json"), v7_user_catalogue))
+
+	actual, err = import_v7_config(v7_dir, config_dir)
+	assert.Nil(t, err)
+	expected := []PathToFile{
+		filepath.Join(config_dir, "config.json"),
+		filepath.Join(config_dir, "user-catalogue.json"),
+	}
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, v7_config, slurp(expected[0]))
+	assert.Equal(t, v7_user_catalogue, slurp(expected[1]))
+
+	// strongbox 8 has settings of it's own now
+	actual, err = import_v7_config(v7_dir, config_dir)
+	assert.Nil(t, err)
+	assert.Empty(t, actual)
+
+	// shared config dir
+	actual, err = import_v7_config(v7_dir, v7_dir)
+	assert.Nil(t, err)
+	assert.Empty(t, actual)
+}
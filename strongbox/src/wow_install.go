@@ -0,0 +1,327 @@
+package strongbox
+
+import (
+	"bw/core"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// wow_install.go finds World of Warcraft installations on the filesystem and proposes `AddonsDir`s for them.
+
+// a WoW installation has a directory per 'product' (retail, classic, ptr, etc) and each product has its own addons dir:
+//   /path/to/World of Warcraft/_retail_/Interface/AddOns
+//   /path/to/World of Warcraft/_classic_era_/Interface/AddOns
+
+// how deep to look beneath a given root directory for WoW product directories.
+const WOW_INSTALL_SCAN_DEPTH = 5
+
+// the path to the addons dir within a WoW product directory.
+func wow_product_addons_dir(product_dir PathToDir) PathToDir {
+	return filepath.Join(product_dir, "Interface", "AddOns")
+}
+
+// returns the `GameTrackID` for the given WoW product directory and `true` if it's a known product directory.
+// the game track a product directory is running comes from the game track registry,
+// `_classic_` is progression classic and moves forward with each expansion.
+func wow_product_game_track(product_dir PathToDir) (GameTrackID, bool) {
+	return game_tracks().FindProductDir(filepath.Base(product_dir))
+}
+
+// returns a list of directories beneath the user's `home` dir where WoW is commonly installed.
+// this includes Wine prefixes, Lutris, Bottles and Steam Proton prefixes.
+// globs are expanded and only directories that exist are returned.
+func wow_install_candidate_list(home PathToDir) []PathToDir {
+	if home == "" {
+		return []PathToDir{}
+	}
+
+	// the 'drive_c' of a wine prefix
+	prefix_list := []string{
+		".wine/drive_c",
+		"Games/*/drive_c",
+		".local/share/lutris/prefixes/*/drive_c",
+		".local/share/bottles/bottles/*/drive_c",
+		".var/app/com.usebottles.bottles/data/bottles/bottles/*/drive_c",
+		".steam/steam/steamapps/compatdata/*/pfx/drive_c",
+		".local/share/Steam/steamapps/compatdata/*/pfx/drive_c",
+	}
+
+	// places within a 'drive_c' (or home) where WoW is typically found
+	install_list := []string{
+		"Program Files (x86)/World of Warcraft",
+		"Program Files/World of Warcraft",
+		"World of Warcraft",
+	}
+
+	pattern_list := []string{
+		filepath.Join(home, "World of Warcraft"),
+		filepath.Join(home, "Games", "World of Warcraft"),
+		filepath.Join(home, "Applications", "World of Warcraft"),
+	}
+	for _, prefix := range prefix_list {
+		for _, install := range install_list {
+			pattern_list = append(pattern_list, filepath.Join(home, prefix, install))
+		}
+	}
+
+	candidate_list := []PathToDir{}
+	for _, pattern := range pattern_list {
+		match_list, err := filepath.Glob(pattern)
+		if err != nil {
+			slog.Warn("bad wow install pattern", "pattern", pattern, "error", err)
+			continue
+		}
+		for _, match := range match_list {
+			if core.DirExists(match) {
+				candidate_list = append(candidate_list, match)
+			}
+		}
+	}
+	return candidate_list
+}
+
+// walks `root` looking for WoW product directories containing an addons dir, at most `depth` directories deep.
+// returns a list of `AddonsDir` with their game track set.
+func find_addons_dirs(root PathToDir, depth int) []AddonsDir {
+	addons_dir_list := []AddonsDir{}
+	if !core.DirExists(root) {
+		return addons_dir_list
+	}
+
+	root = filepath.Clean(root)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// permission denied, broken symlinks, etc. not fatal, keep looking.
+			slog.Debug("skipping path while looking for addons dirs", "path", path, "error", err)
+			if d != nil && d.IsDir() && path != root {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		// the root itself may be a product dir
+		game_track_id, is_product_dir := wow_product_game_track(path)
+		if is_product_dir {
+			addons_dir_path := wow_product_addons_dir(path)
+			if core.DirExists(addons_dir_path) {
+				ad := MakeAddonsDir(addons_dir_path)
+				ad.GameTrackID = game_track_id
+				addons_dir_list = append(addons_dir_list, ad)
+			}
+			return fs.SkipDir
+		}
+
+		if path != root {
+			if VCS_DIR_SET.Contains(d.Name()) {
+				return fs.SkipDir
+			}
+			rel, _ := filepath.Rel(root, path)
+			if strings.Count(rel, string(filepath.Separator))+1 >= depth {
+				return fs.SkipDir
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		slog.Warn("failed to scan directory for addons dirs", "root", root, "error", err)
+	}
+
+	return addons_dir_list
+}
+
+// looks for WoW installations beneath `root` and in common locations beneath `home`.
+// either may be empty.
+// returns a de-duplicated list of proposed `AddonsDir`s, ordered by path.
+func DetectAddonsDirs(root PathToDir, home PathToDir) []AddonsDir {
+	root_list := []PathToDir{}
+	if root != "" {
+		root_list = append(root_list, root)
+	}
+	root_list = append(root_list, wow_install_candidate_list(home)...)
+
+	seen := map[PathToDir]bool{}
+	addons_dir_list := []AddonsDir{}
+	for _, r := range root_list {
+		for _, ad := range find_addons_dirs(r, WOW_INSTALL_SCAN_DEPTH) {
+			if seen[ad.Path] {
+				continue
+			}
+			seen[ad.Path] = true
+			addons_dir_list = append(addons_dir_list, ad)
+		}
+	}
+
+	slices.SortFunc(addons_dir_list, func(a, b AddonsDir) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return addons_dir_list
+}
+
+// returns the addons dirs in `addons_dir_list` that are not already known to `settings`.
+func new_addons_dirs(settings Settings, addons_dir_list []AddonsDir) []AddonsDir {
+	known := map[PathToDir]bool{}
+	for _, ad := range settings.AddonsDirList {
+		known[filepath.Clean(ad.Path)] = true
+	}
+	rv := []AddonsDir{}
+	for _, ad := range addons_dir_list {
+		if !known[filepath.Clean(ad.Path)] {
+			rv = append(rv, ad)
+		}
+	}
+	return rv
+}
+
+// --- .build.info
+
+// the root of a WoW installation has a `.build.info` file describing each installed product:
+//   Branch!STRING:0|Active!DEC:1|...|Version!STRING:0|...|Product!STRING:0
+//   us|1|...|11.2.0.62213|...|wow
+// product codes, "wow", and their product directories, "_retail_", are part of the game track data, see `game_tracks.json`.
+
+// returns the path to the `.build.info` file of the WoW installation the `addons_dir` belongs to.
+// returns an empty string if `addons_dir` isn't within a WoW product directory.
+func wow_build_info_file(addons_dir PathToDir) PathToFile {
+	product_dir := filepath.Dir(filepath.Dir(filepath.Clean(addons_dir))) // "/path/to/World of Warcraft/_retail_/Interface/AddOns" => "/path/to/World of Warcraft/_retail_"
+	_, is_product_dir := wow_product_game_track(product_dir)
+	if !is_product_dir {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(product_dir), ".build.info")
+}
+
+// parses the contents of a `.build.info` file into a map of product directory names to game versions.
+// {"_retail_": "11.2.0.62213", "_classic_era_": "1.15.7.61582", ...}
+func parse_build_info(contents string) map[string]string {
+	rv := map[string]string{}
+	row_list := strings.Split(strings.ReplaceAll(contents, "\r\n", "\n"), "\n")
+	if len(row_list) < 2 {
+		return rv
+	}
+
+	// "Version!STRING:0" => "version"
+	version_idx, product_idx := -1, -1
+	for i, header := range strings.Split(row_list[0], "|") {
+		name, _, _ := strings.Cut(header, "!")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "version":
+			version_idx = i
+		case "product":
+			product_idx = i
+		}
+	}
+	if version_idx == -1 || product_idx == -1 {
+		return rv
+	}
+
+	for _, row := range row_list[1:] {
+		bits := strings.Split(row, "|")
+		if len(bits) <= version_idx || len(bits) <= product_idx {
+			continue
+		}
+		_, product_dir, present := game_tracks().FindProductCode(strings.TrimSpace(bits[product_idx]))
+		if !present {
+			continue
+		}
+		rv[product_dir] = strings.TrimSpace(bits[version_idx])
+	}
+	return rv
+}
+
+// "11.2.0.62213" => 110200
+func build_version_to_interface_version(build_version string) (int, error) {
+	bits := strings.Split(build_version, ".")
+	if len(bits) > 3 {
+		bits = bits[:3] // drop the build number
+	}
+	return GameVersionToInterfaceVersion(strings.Join(bits, "."))
+}
+
+type build_info_cache_entry struct {
+	mtime time.Time
+	idx   map[string]string
+}
+
+// `.build.info` files are read once and then again only when they change.
+var build_info_cache = map[PathToFile]build_info_cache_entry{}
+var build_info_cache_lock sync.Mutex
+
+// returns the parsed contents of the `.build.info` file at `path`, reading it only if it has changed.
+func read_build_info(path PathToFile) (map[string]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	build_info_cache_lock.Lock()
+	defer build_info_cache_lock.Unlock()
+
+	entry, present := build_info_cache[path]
+	if present && entry.mtime.Equal(info.ModTime()) {
+		return entry.idx, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .build.info file: %w", err)
+	}
+	idx := parse_build_info(string(b))
+	build_info_cache[path] = build_info_cache_entry{mtime: info.ModTime(), idx: idx}
+	return idx, nil
+}
+
+// returns the interface version of the WoW client installed alongside the given `addons_dir` and `true` if found.
+func installed_interface_version(addons_dir PathToDir) (int, bool) {
+	build_info_file := wow_build_info_file(addons_dir)
+	if build_info_file == "" || !core.FileExists(build_info_file) {
+		return 0, false
+	}
+
+	idx, err := read_build_info(build_info_file)
+	if err != nil {
+		slog.Debug("failed to read .build.info file", "path", build_info_file, "error", err)
+		return 0, false
+	}
+
+	product_dir := filepath.Base(filepath.Dir(filepath.Dir(filepath.Clean(addons_dir)))) // "_retail_"
+	build_version, present := idx[product_dir]
+	if !present {
+		return 0, false
+	}
+
+	interface_version, err := build_version_to_interface_version(build_version)
+	if err != nil {
+		slog.Debug("failed to parse version in .build.info file", "path", build_info_file, "version", build_version, "error", err)
+		return 0, false
+	}
+	return interface_version, true
+}
+
+// returns the interface version of the game the given `addons_dir` is for, or zero if not known.
+// the installed WoW client is preferred, then the game track data.
+func CurrentInterfaceVersion(addons_dir AddonsDir) int {
+	interface_version, present := installed_interface_version(addons_dir.Path)
+	if present {
+		// a client for a different game track than the addons dir, an anniversary realm for example.
+		game_track_id, err := InterfaceVersionToGameTrack(interface_version)
+		if err == nil && game_track_id == addons_dir.GameTrackID {
+			return interface_version
+		}
+	}
+	interface_version, _ = game_tracks().CurrentInterfaceVersion(addons_dir.GameTrackID)
+	return interface_version
+}
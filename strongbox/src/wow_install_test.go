@@ -0,0 +1,206 @@
+package strongbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// creates each of the given `path_list` as directories beneath `root`.
+func make_dir_tree(t *testing.T, root string, path_list ...string) {
+	for _, path := range path_list {
+		err := os.MkdirAll(filepath.Join(root, path), 0755)
+		assert.Nil(t, err)
+	}
+}
+
+func Test_wow_product_game_track(t *testing.T) {
+	var cases = []struct {
+		given          string
+		expected       GameTrackID
+		expected_found bool
+	}{
+		{"/foo/_retail_", GAMETRACK_RETAIL, true},
+		{"/foo/_RETAIL_", GAMETRACK_RETAIL, true},
+		{"/foo/_classic_", GAMETRACK_CLASSIC_MOP, true},
+		{"/foo/_classic_era_", GAMETRACK_CLASSIC, true},
+		{"/foo/_ptr_", GAMETRACK_RETAIL, true},
+		{"/foo/retail", "", false},
+		{"", "", false},
+	}
+	for i, c := range cases {
+		actual, found := wow_product_game_track(c.given)
+		assert.Equal(t, c.expected, actual, i)
+		assert.Equal(t, c.expected_found, found, i)
+	}
+}
+
+func Test_find_addons_dirs(t *testing.T) {
+	root := t.TempDir()
+	make_dir_tree(t, root,
+		"World of Warcraft/_retail_/Interface/AddOns",
+		"World of Warcraft/_classic_era_/Interface/AddOns",
+		"World of Warcraft/_classic_/Interface",        // no AddOns dir
+		"World of Warcraft/_unknown_/Interface/AddOns", // unknown product
+		"World of Warcraft/Data",
+	)
+
+	expected := []AddonsDir{
+		{Path: filepath.Join(root, "World of Warcraft/_retail_/Interface/AddOns"), GameTrackID: GAMETRACK_RETAIL, Strict: true},
+		{Path: filepath.Join(root, "World of Warcraft/_classic_era_/Interface/AddOns"), GameTrackID: GAMETRACK_CLASSIC, Strict: true},
+	}
+	assert.ElementsMatch(t, expected, find_addons_dirs(root, WOW_INSTALL_SCAN_DEPTH))
+}
+
+// product dirs found beneath the maximum depth are ignored.
+func Test_find_addons_dirs__depth(t *testing.T) {
+	root := t.TempDir()
+	make_dir_tree(t, root, "a/b/c/World of Warcraft/_retail_/Interface/AddOns")
+
+	assert.Equal(t, []AddonsDir{}, find_addons_dirs(root, 4))
+	assert.Equal(t, 1, len(find_addons_dirs(root, 5)))
+}
+
+// a product dir can be given as the root.
+func Test_find_addons_dirs__root_is_product_dir(t *testing.T) {
+	root := t.TempDir()
+	make_dir_tree(t, root, "_retail_/Interface/AddOns")
+
+	expected := []AddonsDir{
+		{Path: filepath.Join(root, "_retail_/Interface/AddOns"), GameTrackID: GAMETRACK_RETAIL, Strict: true},
+	}
+	assert.Equal(t, expected, find_addons_dirs(filepath.Join(root, "_retail_"), WOW_INSTALL_SCAN_DEPTH))
+}
+
+func Test_find_addons_dirs__missing_root(t *testing.T) {
+	assert.Equal(t, []AddonsDir{}, find_addons_dirs(filepath.Join(t.TempDir(), "foo"), WOW_INSTALL_SCAN_DEPTH))
+}
+
+// wine, proton and plain installations beneath the user's home dir are found.
+func Test_DetectAddonsDirs(t *testing.T) {
+	home := t.TempDir()
+	root := t.TempDir()
+	make_dir_tree(t, home,
+		".wine/drive_c/Program Files (x86)/World of Warcraft/_retail_/Interface/AddOns",
+		".steam/steam/steamapps/compatdata/12345/pfx/drive_c/Program Files (x86)/World of Warcraft/_classic_era_/Interface/AddOns",
+		"Games/world-of-warcraft/drive_c/Program Files (x86)/World of Warcraft/_classic_/Interface/AddOns",
+		"Documents/_retail_/Interface/AddOns", // not a common location
+	)
+	make_dir_tree(t, root, "World of Warcraft/_anniversary_/Interface/AddOns")
+
+	expected := []AddonsDir{
+		{Path: filepath.Join(home, ".steam/steam/steamapps/compatdata/12345/pfx/drive_c/Program Files (x86)/World of Warcraft/_classic_era_/Interface/AddOns"), GameTrackID: GAMETRACK_CLASSIC, Strict: true},
+		{Path: filepath.Join(home, ".wine/drive_c/Program Files (x86)/World of Warcraft/_retail_/Interface/AddOns"), GameTrackID: GAMETRACK_RETAIL, Strict: true},
+		{Path: filepath.Join(home, "Games/world-of-warcraft/drive_c/Program Files (x86)/World of Warcraft/_classic_/Interface/AddOns"), GameTrackID: GAMETRACK_CLASSIC_MOP, Strict: true},
+		{Path: filepath.Join(root, "World of Warcraft/_anniversary_/Interface/AddOns"), GameTrackID: GAMETRACK_CLASSIC, Strict: true},
+	}
+	actual := DetectAddonsDirs(root, home)
+	assert.ElementsMatch(t, expected, actual)
+}
+
+// the same installation found twice is only proposed once.
+func Test_DetectAddonsDirs__deduplicated(t *testing.T) {
+	home := t.TempDir()
+	make_dir_tree(t, home, "World of Warcraft/_retail_/Interface/AddOns")
+
+	actual := DetectAddonsDirs(home, home)
+	assert.Equal(t, 1, len(actual))
+}
+
+// addons dirs already present in settings are not proposed again.
+func Test_new_addons_dirs(t *testing.T) {
+	settings := NewSettings()
+	settings.AddonsDirList = []AddonsDir{MakeAddonsDir("/foo/_retail_/Interface/AddOns")}
+
+	given := []AddonsDir{
+		MakeAddonsDir("/foo/_retail_/Interface/AddOns/"),
+		MakeAddonsDir("/foo/_classic_/Interface/AddOns"),
+	}
+	expected := []AddonsDir{
+		MakeAddonsDir("/foo/_classic_/Interface/AddOns"),
+	}
+	assert.Equal(t, expected, new_addons_dirs(settings, given))
+}
+
+const test_build_info = "Branch!STRING:0|Active!DEC:1|Build Key!HEX:16|Version!STRING:0|Product!STRING:0\r\n" +
+	"us|1|abc|11.2.5.63704|wow\r\n" +
+	"us|1|def|1.15.8.64272|wow_classic_era\r\n" +
+	"us|1|ghi|2.5.5.64000|wow_anniversary\r\n" +
+	"us|1|jkl|1.2.3.4|unknown_product\r\n"
+
+// a `_classic_` (MoP Classic) installation's .build.info, with every column the Battle.net launcher writes.
+const test_build_info__classic = "Branch!STRING:0|Active!DEC:1|Build Key!HEX:16|CDN Key!HEX:16|Install Key!HEX:16|IM Size!DEC:4|CDN Path!STRING:0|CDN Hosts!STRING:0|CDN Servers!STRING:0|Tags!STRING:0|Armadillo!STRING:0|Last Activated!STRING:0|Version!STRING:0|KeyRing!HEX:16|Product!STRING:0\r\n" +
+	"eu|1|4d0c2b6a1a3f5e7d9c8b7a6f5e4d3c2b|8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d|||tpr/wow|level3.blizzard.com eu.cdn.blizzard.com|http://eu.cdn.blizzard.com/?maxhosts=4 https://level3.ssl.blizzard.com/?fallback=1|Windows x86_64 EU? acct-GBR? geoip-GB? enUS speech?:Windows x86_64 EU? acct-GBR? geoip-GB? enUS text?||2025-08-14T17:52:03Z|5.5.1.63311|3ca57fe7319a297346440e4d2a03a0cd|wow_classic\r\n"
+
+func Test_parse_build_info(t *testing.T) {
+	expected := map[string]string{
+		"_retail_":      "11.2.5.63704",
+		"_classic_era_": "1.15.8.64272",
+		"_anniversary_": "2.5.5.64000",
+	}
+	assert.Equal(t, expected, parse_build_info(test_build_info))
+	assert.Equal(t, map[string]string{"_classic_": "5.5.1.63311"}, parse_build_info(test_build_info__classic))
+	assert.Equal(t, map[string]string{}, parse_build_info(""))
+	assert.Equal(t, map[string]string{}, parse_build_info("Foo!STRING:0\nbar"))
+}
+
+func Test_build_version_to_interface_version(t *testing.T) {
+	var cases = []struct {
+		given    string
+		expected int
+	}{
+		{"11.2.5.63704", 110205},
+		{"1.15.8.64272", 11508},
+		{"11.2.5", 110205},
+	}
+	for i, c := range cases {
+		actual, err := build_version_to_interface_version(c.given)
+		assert.Nil(t, err, i)
+		assert.Equal(t, c.expected, actual, i)
+	}
+	_, err := build_version_to_interface_version("foo")
+	assert.NotNil(t, err)
+}
+
+// the installed client's interface version is preferred over the game track data.
+func Test_CurrentInterfaceVersion(t *testing.T) {
+	root := t.TempDir()
+	install := filepath.Join(root, "World of Warcraft")
+	make_dir_tree(t, install,
+		"_retail_/Interface/AddOns",
+		"_classic_era_/Interface/AddOns",
+		"_anniversary_/Interface/AddOns",
+		"_classic_/Interface/AddOns")
+
+	retail := AddonsDir{Path: filepath.Join(install, "_retail_", "Interface", "AddOns"), GameTrackID: GAMETRACK_RETAIL}
+	classic := AddonsDir{Path: filepath.Join(install, "_classic_era_", "Interface", "AddOns"), GameTrackID: GAMETRACK_CLASSIC}
+	anniversary := AddonsDir{Path: filepath.Join(install, "_anniversary_", "Interface", "AddOns"), GameTrackID: GAMETRACK_CLASSIC}
+	cata := AddonsDir{Path: filepath.Join(install, "_classic_", "Interface", "AddOns"), GameTrackID: GAMETRACK_CLASSIC_CATA}
+	elsewhere := AddonsDir{Path: root, GameTrackID: GAMETRACK_RETAIL}
+
+	// no .build.info, game track data is used
+	assert.Equal(t, 110200, CurrentInterfaceVersion(retail))
+
+	err := os.WriteFile(filepath.Join(install, ".build.info"), []byte(test_build_info), 0644)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 110205, CurrentInterfaceVersion(retail))
+	assert.Equal(t, 11508, CurrentInterfaceVersion(classic))
+	assert.Equal(t, 11507, CurrentInterfaceVersion(anniversary)) // installed client is for a different game track
+	assert.Equal(t, 40402, CurrentInterfaceVersion(cata))        // product not in .build.info
+	assert.Equal(t, 110200, CurrentInterfaceVersion(elsewhere))  // not a WoW installation
+	assert.Equal(t, 0, CurrentInterfaceVersion(AddonsDir{Path: root, GameTrackID: "foo"}))
+}
+
+// the MoP Classic client is used for `_classic_` addons dirs.
+func Test_CurrentInterfaceVersion__classic(t *testing.T) {
+	install := filepath.Join(t.TempDir(), "World of Warcraft")
+	make_dir_tree(t, install, "_classic_/Interface/AddOns")
+	err := os.WriteFile(filepath.Join(install, ".build.info"), []byte(test_build_info__classic), 0644)
+	assert.Nil(t, err)
+
+	mop := AddonsDir{Path: filepath.Join(install, "_classic_", "Interface", "AddOns"), GameTrackID: GAMETRACK_CLASSIC_MOP}
+	assert.Equal(t, 50501, CurrentInterfaceVersion(mop))
+}
@@ -4,12 +4,16 @@ import (
 	"bw/core"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
 )
 
-type WowinterfaceAPI struct{}
+type WowinterfaceAPI struct {
+	GameTrackIDList []GameTrackID // optional, the game tracks the catalogue says this addon supports
+}
 
 var _ AddonSource = (*WowinterfaceAPI)(nil)
 
@@ -19,6 +23,12 @@ func wowinterface_release_url(source_id string) string {
 	return fmt.Sprintf("%s/filedetails/%s.json", wowinterface_api_v3, source_id)
 }
 
+// a game version a file was marked as compatible with by it's author.
+type WowinterfaceCompatibility struct {
+	Version string `json:"version"` // "10.2.0"
+	Name    string `json:"name"`    // "Guardians of the Dream"
+}
+
 type WowinterfaceFileDetailsV3 struct {
 	ID              string `json:"UID"`
 	CatID           string `json:"UICATID"`
@@ -35,6 +45,63 @@ type WowinterfaceFileDetailsV3 struct {
 	HitCount        string `json:"UIHitCount"`
 	HitCountMonthly string `json:"UIHitCountMonthly"`
 	FavoriteTotal   string `json:"UIFavoriteTotal"`
+
+	CompatibilityList []WowinterfaceCompatibility `json:"UICompatibility"`
+}
+
+// returns `true` if the file is pending approval and shouldn't be offered yet.
+func (fd WowinterfaceFileDetailsV3) pending() bool {
+	pending := strings.TrimSpace(fd.Pending)
+	return pending != "" && pending != "0"
+}
+
+// returns the set of game tracks the file supports.
+// in order of preference: the game versions the author marked the file as compatible with,
+// the game tracks from the catalogue, a guess based on the file name and finally retail.
+func wowinterface_game_track_set(fd WowinterfaceFileDetailsV3, catalogue_game_track_list []GameTrackID) mapset.Set[GameTrackID] {
+	game_track_set := mapset.NewSet[GameTrackID]()
+	for _, compat := range fd.CompatibilityList {
+		interface_version, err := GameVersionToInterfaceVersion(compat.Version)
+		if err != nil {
+			slog.Debug("failed to parse wowinterface compatibility version", "version", compat.Version, "error", err)
+			continue
+		}
+		game_track_id, err := InterfaceVersionToGameTrack(interface_version)
+		if err == nil {
+			game_track_set.Add(game_track_id)
+		}
+	}
+	if !game_track_set.IsEmpty() {
+		return game_track_set
+	}
+
+	for _, game_track_id := range catalogue_game_track_list {
+		if game_tracks().Supported(game_track_id) {
+			game_track_set.Add(game_track_id)
+		}
+	}
+	if !game_track_set.IsEmpty() {
+		return game_track_set
+	}
+
+	game_track_id := GuessGameTrack(fd.FileName)
+	if game_track_id == "" {
+		game_track_id = GAMETRACK_RETAIL
+	}
+	game_track_set.Add(game_track_id)
+	return game_track_set
+}
+
+// returns the set of interface versions the author marked the file as compatible with.
+func wowinterface_interface_version_set(fd WowinterfaceFileDetailsV3) mapset.Set[int] {
+	interface_version_set := mapset.NewSet[int]()
+	for _, compat := range fd.CompatibilityList {
+		interface_version, err := GameVersionToInterfaceVersion(compat.Version)
+		if err == nil {
+			interface_version_set.Add(interface_version)
+		}
+	}
+	return interface_version_set
 }
 
 // ExpandSummary implements AddonSource.
@@ -60,11 +127,20 @@ func (w *WowinterfaceAPI) ExpandSummary(app *core.App, source_id string) ([]Sour
 
 	source_updates := []SourceUpdate{}
 	for _, update := range dest {
+		if update.pending() {
+			slog.Info("skipping wowinterface file pending approval", "source-id", source_id, "version", update.Version)
+			continue
+		}
 		su := NewSourceUpdate()
 		su.Version = update.Version
 		su.DownloadURL = update.Download
-		su.GameTrackIDSet = mapset.NewSet(GAMETRACK_RETAIL)
+		su.AssetName = update.FileName
+		su.GameTrackIDSet = wowinterface_game_track_set(update, w.GameTrackIDList)
+		su.InterfaceVersionSet = wowinterface_interface_version_set(update)
 		su.PublishedDate = time.UnixMilli(update.Date)
+		su.MD5 = strings.ToLower(strings.TrimSpace(update.MD5))
+		su.Author = update.AuthorName
+		su.ReleaseNotes = update.ChangeLog
 
 		source_updates = append(source_updates, su)
 	}
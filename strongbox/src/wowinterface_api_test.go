@@ -0,0 +1,80 @@
+package strongbox
+
+import (
+	"bw/core"
+	"bw/http_utils"
+	"testing"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_wowinterface_game_track_set(t *testing.T) {
+	var cases = []struct {
+		given_compat    []WowinterfaceCompatibility
+		given_catalogue []GameTrackID
+		given_filename  string
+		expected        mapset.Set[GameTrackID]
+	}{
+		// nothing to go on, retail
+		{nil, nil, "EveryAddon-1.2.3.zip", mapset.NewSet(GAMETRACK_RETAIL)},
+		// guessed from the file name
+		{nil, nil, "EveryAddon-1.2.3-classic.zip", mapset.NewSet(GAMETRACK_CLASSIC)},
+		// catalogue game tracks are preferred over a guess
+		{nil, []GameTrackID{GAMETRACK_CLASSIC_TBC, "foo"}, "EveryAddon-1.2.3-classic.zip", mapset.NewSet(GAMETRACK_CLASSIC_TBC)},
+		// compatibility is preferred over catalogue game tracks
+		{
+			[]WowinterfaceCompatibility{{Version: "1.15.2", Name: "Season of Discovery"}, {Version: "10.2.7", Name: "Dark Heart"}, {Version: "foo"}},
+			[]GameTrackID{GAMETRACK_CLASSIC_TBC},
+			"EveryAddon-1.2.3.zip",
+			mapset.NewSet(GAMETRACK_CLASSIC, GAMETRACK_RETAIL),
+		},
+	}
+	for i, c := range cases {
+		fd := WowinterfaceFileDetailsV3{FileName: c.given_filename, CompatibilityList: c.given_compat}
+		assert.Equal(t, c.expected, wowinterface_game_track_set(fd, c.given_catalogue), i)
+	}
+}
+
+func Test_WowinterfaceFileDetailsV3__pending(t *testing.T) {
+	assert.False(t, WowinterfaceFileDetailsV3{}.pending())
+	assert.False(t, WowinterfaceFileDetailsV3{Pending: "0"}.pending())
+	assert.True(t, WowinterfaceFileDetailsV3{Pending: "1"}.pending())
+}
+
+// file details are converted to source updates, pending files are skipped.
+func Test_WowinterfaceAPI__ExpandSummary(t *testing.T) {
+	resp := `[{
+		"UID": "1234",
+		"UIVersion": "1.2.3",
+		"UIDate": 1700000000000,
+		"UIMD5": "D41D8CD98F00B204E9800998ECF8427E",
+		"UIFileName": "EveryAddon-1.2.3.zip",
+		"UIDownload": "https://cdn.wowinterface.com/downloads/file1234/EveryAddon-1.2.3.zip",
+		"UIPending": "0",
+		"UIAuthorName": "Torkus",
+		"UIChangeLog": "fixed things",
+		"UICompatibility": [{"version": "1.15.2", "name": "Season of Discovery"}]
+	}, {
+		"UID": "1234",
+		"UIVersion": "1.2.4",
+		"UIPending": "1"
+	}]`
+
+	app := DummyApp()
+	app.Downloader = core.MakeDummyDownloader(&http_utils.ResponseWrapper{Bytes: []byte(resp)})
+
+	api := WowinterfaceAPI{}
+	sul, err := api.ExpandSummary(app, "1234")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(sul))
+
+	su := sul[0]
+	assert.Equal(t, "1.2.3", su.Version)
+	assert.Equal(t, "EveryAddon-1.2.3.zip", su.AssetName)
+	assert.Equal(t, "d41d8cd98f00b204e9800998ecf8427e", su.MD5)
+	assert.Equal(t, "Torkus", su.Author)
+	assert.Equal(t, "fixed things", su.ReleaseNotes)
+	assert.Equal(t, mapset.NewSet(GAMETRACK_CLASSIC), su.GameTrackIDSet)
+	assert.Equal(t, mapset.NewSet(11502), su.InterfaceVersionSet)
+}
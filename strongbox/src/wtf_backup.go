@@ -0,0 +1,428 @@
+package strongbox
+
+import (
+	"archive/zip"
+	"bw/core"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// wtf_backup.go snapshots the addon settings (SavedVariables) in the 'WTF' directory next to an addons directory.
+// snapshots are plain .zip files in the strongbox data directory, one directory of snapshots per addons directory.
+
+// the number of WTF backups to keep per addons directory when not otherwise set.
+const WTF_BACKUPS_TO_KEEP_DEFAULT uint8 = 10
+
+// "wtf--20260101T120000Z.zip"
+const WTF_BACKUP_PREFIX = "wtf--"
+
+// saved variables for the account and for each character, relative to the WTF directory.
+var wtf_saved_variables_glob_list = []string{
+	"Account/*/SavedVariables/*.lua",     // "Account/FOO/SavedVariables/EveryAddon.lua"
+	"Account/*/*/*/SavedVariables/*.lua", // "Account/FOO/Realm/Character/SavedVariables/EveryAddon.lua"
+}
+
+// a snapshot of the SavedVariables of an addons directory.
+type WTFBackup struct {
+	AddonsDir PathToDir  // the addons directory the backup was taken from
+	Path      PathToFile // the .zip file
+	Created   time.Time
+	NumFiles  int
+	SizeBytes int64
+}
+
+var _ core.ItemInfo = (*WTFBackup)(nil)
+
+func (b WTFBackup) ItemKeys() []string {
+	return []string{
+		core.ITEM_FIELD_NAME,
+		core.ITEM_FIELD_DATE_CREATED,
+		"num-files",
+		"size",
+	}
+}
+
+func (b WTFBackup) ItemMap() map[string]string {
+	created, err := core.FormatTimeHumanOffset(b.Created)
+	if err != nil {
+		created = b.Created.Format(time.RFC3339)
+	}
+	return map[string]string{
+		core.ITEM_FIELD_NAME:         filepath.Base(b.Path),
+		core.ITEM_FIELD_DATE_CREATED: created,
+		"num-files":                  core.IntToString(b.NumFiles),
+		"size":                       core.IntToString(int(b.SizeBytes)),
+	}
+}
+
+func (b WTFBackup) ItemHasChildren() core.ITEM_CHILDREN_LOAD {
+	return core.ITEM_CHILDREN_LOAD_FALSE
+}
+
+func (b WTFBackup) ItemChildren(_ *core.App) []core.Result {
+	return nil
+}
+
+// ---
+
+const (
+	WTF_DIFF_ADDED   = "added"   // exists now but not in the backup
+	WTF_DIFF_REMOVED = "removed" // exists in the backup but not now
+	WTF_DIFF_CHANGED = "changed" // exists in both but is different
+)
+
+// a single difference between a backup and the current WTF directory.
+type WTFDiff struct {
+	Path   string // "Account/FOO/SavedVariables/EveryAddon.lua"
+	Status string
+}
+
+var _ core.ItemInfo = (*WTFDiff)(nil)
+
+func (d WTFDiff) ItemKeys() []string {
+	return []string{
+		core.ITEM_FIELD_NAME,
+		"status",
+	}
+}
+
+func (d WTFDiff) ItemMap() map[string]string {
+	return map[string]string{
+		core.ITEM_FIELD_NAME: d.Path,
+		"status":             d.Status,
+	}
+}
+
+func (d WTFDiff) ItemHasChildren() core.ITEM_CHILDREN_LOAD {
+	return core.ITEM_CHILDREN_LOAD_FALSE
+}
+
+func (d WTFDiff) ItemChildren(_ *core.App) []core.Result {
+	return nil
+}
+
+// ---
+
+// returns the 'WTF' directory for the given addons directory.
+// "/path/to/_retail_/Interface/AddOns" => "/path/to/_retail_/WTF"
+func wtf_dir(addons_dir PathToDir) PathToDir {
+	return filepath.Join(filepath.Dir(filepath.Dir(addons_dir)), "WTF")
+}
+
+// returns the directory backups of the given addons directory are kept in, beneath `backup_root`.
+// "/path/to/_retail_/Interface/AddOns" => "/path/to/backup-root/path-to-retail-interface-addons"
+func wtf_addons_dir_backup_dir(backup_root PathToDir, addons_dir PathToDir) PathToDir {
+	return filepath.Join(backup_root, slugify(addons_dir))
+}
+
+// returns the root directory of all WTF backups.
+func wtf_backup_root(app *core.App) PathToDir {
+	return app.State.GetKeyVal("strongbox.paths.wtf-backup-dir")
+}
+
+// returns the list of SavedVariables files in `wtf`, relative to `wtf` and using forward slashes.
+func wtf_saved_variables_file_list(wtf PathToDir) ([]string, error) {
+	empty_response := []string{}
+	rv := []string{}
+	for _, glob := range wtf_saved_variables_glob_list {
+		match_list, err := filepath.Glob(filepath.Join(wtf, filepath.FromSlash(glob)))
+		if err != nil {
+			return empty_response, fmt.Errorf("failed to find SavedVariables: %w", err)
+		}
+		for _, path := range match_list {
+			if !core.FileExists(path) {
+				continue
+			}
+			rel, err := filepath.Rel(wtf, path)
+			if err != nil {
+				return empty_response, fmt.Errorf("failed to find SavedVariables: %w", err)
+			}
+			rv = append(rv, filepath.ToSlash(rel))
+		}
+	}
+	slices.Sort(rv)
+	return rv, nil
+}
+
+// writes the files in `file_list` beneath `wtf` to `zw`.
+func write_wtf_zipfile(zw *zip.Writer, wtf PathToDir, file_list []string) error {
+	for _, rel := range file_list {
+		err := zip_add_file(zw, filepath.Join(wtf, filepath.FromSlash(rel)), rel)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reads the backup at `path`, returning an error if it isn't a WTF backup.
+func read_wtf_backup(addons_dir PathToDir, path PathToFile) (WTFBackup, error) {
+	empty_response := WTFBackup{}
+
+	tz, err := parse_timestamped_zipfile(path, WTF_BACKUP_PREFIX)
+	if err != nil {
+		return empty_response, fmt.Errorf("not a WTF backup: %w", err)
+	}
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read WTF backup: %w", err)
+	}
+	defer r.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read WTF backup: %w", err)
+	}
+
+	return WTFBackup{
+		AddonsDir: addons_dir,
+		Path:      path,
+		Created:   tz.Created,
+		NumFiles:  len(r.File),
+		SizeBytes: info.Size(),
+	}, nil
+}
+
+// returns the backups of the given `addons_dir` beneath `backup_root`, newest first.
+func ListWTFBackups(backup_root PathToDir, addons_dir PathToDir) ([]WTFBackup, error) {
+	empty_response := []WTFBackup{}
+
+	zipfile_list, err := list_timestamped_zipfiles(wtf_addons_dir_backup_dir(backup_root, addons_dir), WTF_BACKUP_PREFIX)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to list WTF backups: %w", err)
+	}
+
+	rv := []WTFBackup{}
+	for _, tz := range zipfile_list {
+		backup, err := read_wtf_backup(addons_dir, tz.Path)
+		if err != nil {
+			slog.Warn("skipping file in WTF backup directory", "path", tz.Path, "error", err)
+			continue
+		}
+		rv = append(rv, backup)
+	}
+	return rv, nil
+}
+
+// deletes all but the newest `num_to_keep` backups of `addons_dir`.
+// a nil `num_to_keep` keeps all backups.
+func prune_wtf_backups(backup_root PathToDir, addons_dir PathToDir, num_to_keep *uint8) error {
+	err := prune_timestamped_zipfiles(wtf_addons_dir_backup_dir(backup_root, addons_dir), WTF_BACKUP_PREFIX, num_to_keep)
+	if err != nil {
+		return fmt.Errorf("failed to prune WTF backups: %w", err)
+	}
+	return nil
+}
+
+// snapshots the SavedVariables in the WTF directory of `addons_dir` to a timestamped .zip file beneath `backup_root`,
+// then removes all but the newest `num_to_keep` backups.
+func BackupWTF(backup_root PathToDir, addons_dir PathToDir, now time.Time, num_to_keep *uint8) (WTFBackup, error) {
+	empty_response := WTFBackup{}
+
+	wtf := wtf_dir(addons_dir)
+	if !core.DirExists(wtf) {
+		return empty_response, fmt.Errorf("WTF directory not found: %s", wtf)
+	}
+
+	file_list, err := wtf_saved_variables_file_list(wtf)
+	if err != nil {
+		return empty_response, err
+	}
+
+	created := now.UTC().Truncate(time.Second)
+	dir := wtf_addons_dir_backup_dir(backup_root, addons_dir)
+	zipfile, size, err := create_timestamped_zipfile(dir, WTF_BACKUP_PREFIX, created, func(zw *zip.Writer) error {
+		return write_wtf_zipfile(zw, wtf, file_list)
+	})
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to backup WTF: %w", err)
+	}
+
+	slog.Info("backed up WTF", "addons-dir", addons_dir, "path", zipfile, "num-files", len(file_list))
+
+	err = prune_wtf_backups(backup_root, addons_dir, num_to_keep)
+	if err != nil {
+		slog.Warn("failed to prune WTF backups", "error", err)
+	}
+
+	return WTFBackup{
+		AddonsDir: addons_dir,
+		Path:      zipfile,
+		Created:   created,
+		NumFiles:  len(file_list),
+		SizeBytes: size,
+	}, nil
+}
+
+// returns the crc32 checksum of the file at `path`, as stored in .zip files.
+func file_crc32(path PathToFile) (uint32, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer fh.Close()
+	h := crc32.NewIEEE()
+	_, err = io.Copy(h, fh)
+	if err != nil {
+		return 0, err
+	}
+	return h.Sum32(), nil
+}
+
+// compares the SavedVariables in `backup` with those currently in the WTF directory.
+// differences are ordered by path.
+func DiffWTFBackup(backup WTFBackup) ([]WTFDiff, error) {
+	empty_response := []WTFDiff{}
+
+	r, err := zip.OpenReader(backup.Path)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read WTF backup: %w", err)
+	}
+	defer r.Close()
+
+	wtf := wtf_dir(backup.AddonsDir)
+	current_file_list := []string{}
+	if core.DirExists(wtf) {
+		current_file_list, err = wtf_saved_variables_file_list(wtf)
+		if err != nil {
+			return empty_response, err
+		}
+	}
+
+	backup_idx := map[string]*zip.File{}
+	for _, f := range r.File {
+		backup_idx[f.Name] = f
+	}
+
+	rv := []WTFDiff{}
+	for _, rel := range current_file_list {
+		f, present := backup_idx[rel]
+		if !present {
+			rv = append(rv, WTFDiff{Path: rel, Status: WTF_DIFF_ADDED})
+			continue
+		}
+		delete(backup_idx, rel)
+
+		path := filepath.Join(wtf, filepath.FromSlash(rel))
+		info, err := os.Stat(path)
+		if err != nil {
+			return empty_response, fmt.Errorf("failed to compare WTF backup: %w", err)
+		}
+		if uint64(info.Size()) != f.UncompressedSize64 {
+			rv = append(rv, WTFDiff{Path: rel, Status: WTF_DIFF_CHANGED})
+			continue
+		}
+		checksum, err := file_crc32(path)
+		if err != nil {
+			return empty_response, fmt.Errorf("failed to compare WTF backup: %w", err)
+		}
+		if checksum != f.CRC32 {
+			rv = append(rv, WTFDiff{Path: rel, Status: WTF_DIFF_CHANGED})
+		}
+	}
+
+	for rel := range backup_idx {
+		rv = append(rv, WTFDiff{Path: rel, Status: WTF_DIFF_REMOVED})
+	}
+
+	slices.SortStableFunc(rv, func(a, b WTFDiff) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return rv, nil
+}
+
+// writes a single file from a backup into the `wtf` directory.
+func restore_wtf_file(wtf PathToDir, f *zip.File) error {
+	path := filepath.Join(wtf, filepath.FromSlash(f.Name))
+	if !strings.HasPrefix(path, filepath.Clean(wtf)+string(os.PathSeparator)) {
+		return fmt.Errorf("%s: illegal file path", path)
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, rc)
+	return err
+}
+
+// restores the SavedVariables in `backup` to the WTF directory it was taken from.
+// the current SavedVariables are backed up first and that backup is returned.
+// SavedVariables that didn't exist when `backup` was taken are left alone.
+// WoW overwrites SavedVariables on exit so it should not be running.
+func RestoreWTFBackup(backup_root PathToDir, backup WTFBackup, now time.Time, num_to_keep *uint8) (WTFBackup, error) {
+	empty_response := WTFBackup{}
+
+	r, err := zip.OpenReader(backup.Path)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to read WTF backup: %w", err)
+	}
+	defer r.Close()
+
+	// don't prune yet, the backup being restored may be the oldest
+	safety_backup, err := BackupWTF(backup_root, backup.AddonsDir, now, nil)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to backup WTF before restoring, nothing restored: %w", err)
+	}
+
+	wtf := wtf_dir(backup.AddonsDir)
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		err = restore_wtf_file(wtf, f)
+		if err != nil {
+			return safety_backup, fmt.Errorf("failed to restore WTF backup, restore the backup taken beforehand: %s: %w", safety_backup.Path, err)
+		}
+	}
+
+	slog.Info("restored WTF backup", "addons-dir", backup.AddonsDir, "path", backup.Path, "num-files", len(r.File))
+
+	err = prune_wtf_backups(backup_root, backup.AddonsDir, num_to_keep)
+	if err != nil {
+		slog.Warn("failed to prune WTF backups", "error", err)
+	}
+
+	return safety_backup, nil
+}
+
+// backs up the WTF directory of `addons_dir`, keeping as many backups as the user's preferences allow.
+func backup_wtf(app *core.App, addons_dir AddonsDir) (WTFBackup, error) {
+	prefs := FindSettings(app).Preferences
+	return BackupWTF(wtf_backup_root(app), addons_dir.Path, time.Now(), prefs.WTFBackupsToKeep)
+}
+
+// backs up the WTF directory of `addons_dir` if the user has asked for backups before updating.
+// the update should not go ahead if the backup fails.
+func backup_wtf_before_update(app *core.App, addons_dir AddonsDir) error {
+	if !addons_dir_preferences(app, addons_dir).BackupWTFBeforeUpdate {
+		return nil
+	}
+	_, err := backup_wtf(app, addons_dir)
+	if err != nil {
+		slog.Error("failed to backup WTF before updating addons", "addons-dir", addons_dir.Path, "error", err)
+		return fmt.Errorf("failed to backup WTF before updating addons: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package strongbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// creates a wow installation with addon settings, returning the path to the addons dir.
+func wtf_test_install(t *testing.T) PathToDir {
+	root := filepath.Join(t.TempDir(), "_retail_")
+	make_file_tree(t, root, map[string]string{
+		"Interface/AddOns/EveryAddon/EveryAddon.toc": local_test_toc,
+		"WTF/Config.wtf": "SET foo \"bar\"\n",
+		"WTF/Account/FOO/SavedVariables/EveryAddon.lua":                      "EveryAddonDB = {}\n",
+		"WTF/Account/FOO/SavedVariables/EveryAddon.lua.bak":                  "EveryAddonDB = {}\n",
+		"WTF/Account/FOO/Realm/Character/SavedVariables/EveryAddon.lua":      "EveryAddonCharDB = {}\n",
+		"WTF/Account/FOO/Realm/Character/SavedVariables/EveryOtherAddon.lua": "EveryOtherAddonCharDB = {}\n",
+		"WTF/Account/FOO/Realm/Character/layout-local.txt":                   "",
+		"WTF/Account/SavedVariables/not-an-account.lua":                      "",
+	})
+	return filepath.Join(root, "Interface", "AddOns")
+}
+
+func Test_wtf_dir(t *testing.T) {
+	assert.Equal(t, filepath.FromSlash("/wow/_retail_/WTF"), wtf_dir(filepath.FromSlash("/wow/_retail_/Interface/AddOns")))
+}
+
+// only account and character SavedVariables are backed up
+func Test_wtf_saved_variables_file_list(t *testing.T) {
+	addons_dir := wtf_test_install(t)
+	expected := []string{
+		"Account/FOO/Realm/Character/SavedVariables/EveryAddon.lua",
+		"Account/FOO/Realm/Character/SavedVariables/EveryOtherAddon.lua",
+		"Account/FOO/SavedVariables/EveryAddon.lua",
+	}
+	actual, err := wtf_saved_variables_file_list(wtf_dir(addons_dir))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func Test_BackupWTF(t *testing.T) {
+	addons_dir := wtf_test_install(t)
+	backup_root := t.TempDir()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	backup, err := BackupWTF(backup_root, addons_dir, now, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, backup.NumFiles)
+	assert.Equal(t, now, backup.Created)
+	assert.Equal(t, "wtf--20260102T030405Z.zip", filepath.Base(backup.Path))
+	assert.FileExists(t, backup.Path)
+
+	backup_list, err := ListWTFBackups(backup_root, addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []WTFBackup{backup}, backup_list)
+}
+
+// an addons dir without a WTF directory can't be backed up
+func Test_BackupWTF__no_wtf_dir(t *testing.T) {
+	addons_dir := filepath.Join(t.TempDir(), "_retail_", "Interface", "AddOns")
+	_, err := BackupWTF(t.TempDir(), addons_dir, time.Now(), nil)
+	assert.NotNil(t, err)
+}
+
+// old backups are removed, newest are kept
+func Test_BackupWTF__retention(t *testing.T) {
+	addons_dir := wtf_test_install(t)
+	backup_root := t.TempDir()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for i := range 5 {
+		_, err := BackupWTF(backup_root, addons_dir, now.Add(time.Duration(i)*time.Hour), new(uint8(2)))
+		assert.Nil(t, err)
+	}
+
+	backup_list, err := ListWTFBackups(backup_root, addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(backup_list))
+	assert.Equal(t, now.Add(4*time.Hour), backup_list[0].Created)
+	assert.Equal(t, now.Add(3*time.Hour), backup_list[1].Created)
+}
+
+// backups of different addons dirs are kept separately
+func Test_ListWTFBackups__many_addons_dirs(t *testing.T) {
+	addons_dir_1 := wtf_test_install(t)
+	addons_dir_2 := wtf_test_install(t)
+	backup_root := t.TempDir()
+
+	_, err := BackupWTF(backup_root, addons_dir_1, time.Now(), nil)
+	assert.Nil(t, err)
+
+	backup_list, err := ListWTFBackups(backup_root, addons_dir_2)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(backup_list))
+}
+
+func Test_DiffWTFBackup(t *testing.T) {
+	addons_dir := wtf_test_install(t)
+	wtf := wtf_dir(addons_dir)
+
+	backup, err := BackupWTF(t.TempDir(), addons_dir, time.Now(), nil)
+	assert.Nil(t, err)
+
+	diff_list, err := DiffWTFBackup(backup)
+	assert.Nil(t, err)
+	assert.Equal(t, []WTFDiff{}, diff_list)
+
+	make_file_tree(t, wtf, map[string]string{
+		"Account/FOO/SavedVariables/EveryAddon.lua":    "EveryAddonDB = {foo = true}\n",
+		"Account/FOO/SavedVariables/EveryNewAddon.lua": "EveryNewAddonDB = {}\n",
+	})
+	err = os.Remove(filepath.Join(wtf, "Account/FOO/Realm/Character/SavedVariables/EveryOtherAddon.lua"))
+	assert.Nil(t, err)
+
+	expected := []WTFDiff{
+		{Path: "Account/FOO/Realm/Character/SavedVariables/EveryOtherAddon.lua", Status: WTF_DIFF_REMOVED},
+		{Path: "Account/FOO/SavedVariables/EveryAddon.lua", Status: WTF_DIFF_CHANGED},
+		{Path: "Account/FOO/SavedVariables/EveryNewAddon.lua", Status: WTF_DIFF_ADDED},
+	}
+	diff_list, err = DiffWTFBackup(backup)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, diff_list)
+}
+
+// restoring a backup takes a backup of the current settings first
+func Test_RestoreWTFBackup(t *testing.T) {
+	addons_dir := wtf_test_install(t)
+	wtf := wtf_dir(addons_dir)
+	backup_root := t.TempDir()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	backup, err := BackupWTF(backup_root, addons_dir, now, nil)
+	assert.Nil(t, err)
+
+	sv := filepath.Join(wtf, "Account/FOO/SavedVariables/EveryAddon.lua")
+	err = os.WriteFile(sv, []byte("EveryAddonDB = nil\n"), 0644)
+	assert.Nil(t, err)
+	err = os.Remove(filepath.Join(wtf, "Account/FOO/Realm/Character/SavedVariables/EveryOtherAddon.lua"))
+	assert.Nil(t, err)
+
+	safety_backup, err := RestoreWTFBackup(backup_root, backup, now.Add(time.Hour), new(uint8(1)))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, safety_backup.NumFiles)
+
+	data, err := os.ReadFile(sv)
+	assert.Nil(t, err)
+	assert.Equal(t, "EveryAddonDB = {}\n", string(data))
+	assert.FileExists(t, filepath.Join(wtf, "Account/FOO/Realm/Character/SavedVariables/EveryOtherAddon.lua"))
+
+	// the backup taken before restoring is kept, the restored backup was pruned
+	backup_list, err := ListWTFBackups(backup_root, addons_dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []WTFBackup{safety_backup}, backup_list)
+}
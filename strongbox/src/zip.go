@@ -3,11 +3,18 @@ package strongbox
 import (
 	"archive/zip"
 	"bw/core"
+	"cmp"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
 )
@@ -150,3 +157,211 @@ func unzip_file(source, destination string) ([]string, error) {
 
 	return extractedFiles, nil
 }
+
+// --- timestamped .zip files
+// WTF backups and addons directory snapshots are .zip files named after the time they were created,
+// kept in a directory per addons directory.
+
+// the time a backup was taken, in UTC. "20260101T120000Z"
+const BACKUP_TIME_FORMAT = "20060102T150405Z"
+
+// the most .zip files that can be created with the same prefix in the same second.
+const TIMESTAMPED_ZIPFILE_MAX_SEQ = 100
+
+// a .zip file named after the time it was created, "prefix--20260101T120000Z.zip".
+// .zip files created in the same second are numbered from 2, "prefix--20260101T120000Z-2.zip".
+type timestamped_zipfile struct {
+	Path    PathToFile
+	Created time.Time
+	Seq     int // 1 for the first .zip file created in a second
+}
+
+// returns the path to the `seq`th .zip file in `dir` for the given `prefix` and `created` time.
+func timestamped_zipfile_path(dir PathToDir, prefix string, created time.Time, seq int) PathToFile {
+	suffix := ""
+	if seq > 1 {
+		suffix = "-" + strconv.Itoa(seq)
+	}
+	return filepath.Join(dir, prefix+created.UTC().Format(BACKUP_TIME_FORMAT)+suffix+".zip")
+}
+
+// parses the time the .zip file at `path` was created from it's name.
+func parse_timestamped_zipfile(path PathToFile, prefix string) (timestamped_zipfile, error) {
+	empty_response := timestamped_zipfile{}
+	name := filepath.Base(path)
+	if !strings.HasPrefix(name, prefix) || filepath.Ext(name) != ".zip" {
+		return empty_response, fmt.Errorf("unexpected file name: %s", name)
+	}
+	stem := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".zip")
+	seq := 1
+	if i := strings.LastIndex(stem, "-"); i != -1 {
+		n, err := strconv.Atoi(stem[i+1:])
+		if err != nil || n < 2 {
+			return empty_response, fmt.Errorf("unexpected file name: %s", name)
+		}
+		stem, seq = stem[:i], n
+	}
+	created, err := time.Parse(BACKUP_TIME_FORMAT, stem)
+	if err != nil {
+		return empty_response, fmt.Errorf("unexpected file name: %s", name)
+	}
+	return timestamped_zipfile{Path: path, Created: created, Seq: seq}, nil
+}
+
+// returns the .zip files in `dir` starting with `prefix`, newest first.
+// other files are logged and skipped.
+func list_timestamped_zipfiles(dir PathToDir, prefix string) ([]timestamped_zipfile, error) {
+	empty_response := []timestamped_zipfile{}
+	if !core.DirExists(dir) {
+		return empty_response, nil
+	}
+
+	entry_list, err := os.ReadDir(dir)
+	if err != nil {
+		return empty_response, err
+	}
+
+	rv := []timestamped_zipfile{}
+	for _, entry := range entry_list {
+		if entry.IsDir() {
+			continue
+		}
+		tz, err := parse_timestamped_zipfile(filepath.Join(dir, entry.Name()), prefix)
+		if err != nil {
+			slog.Warn("skipping file", "dir", dir, "path", entry.Name(), "error", err)
+			continue
+		}
+		rv = append(rv, tz)
+	}
+
+	slices.SortStableFunc(rv, func(a, b timestamped_zipfile) int {
+		return cmp.Or(b.Created.Compare(a.Created), cmp.Compare(b.Seq, a.Seq))
+	})
+	return rv, nil
+}
+
+// deletes all but the newest `num_to_keep` .zip files in `dir` starting with `prefix`.
+// a nil `num_to_keep` keeps everything.
+func prune_timestamped_zipfiles(dir PathToDir, prefix string, num_to_keep *uint8) error {
+	if num_to_keep == nil {
+		return nil
+	}
+
+	zipfile_list, err := list_timestamped_zipfiles(dir, prefix)
+	if err != nil {
+		return err
+	}
+
+	keep := int(*num_to_keep)
+	if len(zipfile_list) <= keep {
+		return nil
+	}
+
+	for _, tz := range zipfile_list[keep:] {
+		slog.Info("removing old .zip file", "path", tz.Path)
+		err = os.Remove(tz.Path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// creates a new .zip file in `dir` named after the `created` time, with contents written by `write_fn`.
+// existing .zip files are never replaced, a .zip file created in the same second as another is numbered instead.
+// the .zip file is removed again if it can't be written.
+// returns the path to the .zip file and it's size in bytes.
+func create_timestamped_zipfile(dir PathToDir, prefix string, created time.Time, write_fn func(*zip.Writer) error) (PathToFile, int64, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	var fh *os.File
+	zipfile := ""
+	for seq := 1; seq <= TIMESTAMPED_ZIPFILE_MAX_SEQ; seq++ {
+		zipfile = timestamped_zipfile_path(dir, prefix, created, seq)
+		fh, err = os.OpenFile(zipfile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if !errors.Is(err, fs.ErrExist) {
+			break
+		}
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create .zip file: %w", err)
+	}
+
+	size, err := func() (int64, error) {
+		defer fh.Close()
+
+		zw := zip.NewWriter(fh)
+		err = write_fn(zw)
+		if err != nil {
+			zw.Close()
+			return 0, fmt.Errorf("failed to write .zip file: %w", err)
+		}
+		err = zw.Close()
+		if err != nil {
+			return 0, fmt.Errorf("failed to write .zip file: %w", err)
+		}
+		info, err := fh.Stat()
+		if err != nil {
+			return 0, fmt.Errorf("failed to write .zip file: %w", err)
+		}
+		return info.Size(), nil
+	}()
+	if err != nil {
+		os.Remove(zipfile)
+		return "", 0, err
+	}
+	return zipfile, size, nil
+}
+
+// adds the file at `path` to `zw` as `name`, following symlinks.
+func zip_add_file(zw *zip.Writer, path string, name string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	_, err = io.Copy(w, in)
+	return err
+}
+
+// adds the symlink at `path` to `zw` as `name`, without following it.
+// the link's target is the content of the file, the way `zip --symlinks` stores them.
+func zip_add_symlink(zw *zip.Writer, path string, name string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	target, err := os.Readlink(path)
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, target)
+	return err
+}
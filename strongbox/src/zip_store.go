@@ -0,0 +1,512 @@
+package strongbox
+
+import (
+	"bw/core"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// zip_store.go is a single store of downloaded addon .zip files shared by every addons directory.
+// zip files are kept by the checksum of their contents and looked up by the URL and version they were downloaded as,
+// so the same release is downloaded once no matter how many addons directories install it.
+// an index records which addons directories have each zip file installed so unused zip files can be pruned.
+
+var ErrZipStoreEntryDNE = errors.New("zip file not found in store")
+var ErrZipStoreUnavailable = errors.New("zip store is not available")
+
+// a zip file in the store.
+type ZipStoreEntry struct {
+	SHA256        string      `json:"sha256"`
+	URLList       []URL       `json:"url-list"`        // every URL the zip file was downloaded from
+	Name          string      `json:"name"`            // normalised addon name, "everyaddon"
+	Version       string      `json:"version"`         // "1.2.3"
+	SizeBytes     int64       `json:"size-bytes"`      //
+	Created       time.Time   `json:"created"`         // when the zip file was added to the store
+	AddonsDirList []PathToDir `json:"addons-dir-list"` // addons directories with this zip file installed
+}
+
+var _ core.ItemInfo = (*ZipStoreEntry)(nil)
+
+func (e ZipStoreEntry) ItemKeys() []string {
+	return []string{
+		core.ITEM_FIELD_NAME,
+		"version",
+		core.ITEM_FIELD_DATE_CREATED,
+		"size",
+		"num-installed",
+	}
+}
+
+func (e ZipStoreEntry) ItemMap() map[string]string {
+	created, err := core.FormatTimeHumanOffset(e.Created)
+	if err != nil {
+		created = e.Created.Format(time.RFC3339)
+	}
+	return map[string]string{
+		core.ITEM_FIELD_NAME:         e.Name,
+		"version":                    e.Version,
+		core.ITEM_FIELD_DATE_CREATED: created,
+		"size":                       format_size_bytes(e.SizeBytes),
+		"num-installed":              core.IntToString(len(e.AddonsDirList)),
+	}
+}
+
+func (e ZipStoreEntry) ItemHasChildren() core.ITEM_CHILDREN_LOAD {
+	return core.ITEM_CHILDREN_LOAD_FALSE
+}
+
+func (e ZipStoreEntry) ItemChildren(_ *core.App) []core.Result {
+	return nil
+}
+
+// ---
+
+type zip_store_index struct {
+	EntryList []ZipStoreEntry `json:"entry-list"`
+}
+
+type ZipStore struct {
+	dir   PathToDir
+	lock  sync.Mutex
+	index zip_store_index
+}
+
+// opens the zip store in `dir`, creating it if it doesn't exist.
+func OpenZipStore(dir PathToDir) (*ZipStore, error) {
+	store := &ZipStore{dir: dir, index: zip_store_index{EntryList: []ZipStoreEntry{}}}
+	err := os.MkdirAll(store.blob_dir(), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zip store: %w", err)
+	}
+	if !core.FileExists(store.index_path()) {
+		return store, nil
+	}
+	bytes, err := os.ReadFile(store.index_path())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read zip store index: %w", err)
+	}
+	err = json.Unmarshal(bytes, &store.index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read zip store index: %w", err)
+	}
+	return store, nil
+}
+
+func (s *ZipStore) index_path() PathToFile {
+	return filepath.Join(s.dir, "index.json")
+}
+
+func (s *ZipStore) blob_dir() PathToDir {
+	return filepath.Join(s.dir, "zips")
+}
+
+// returns the path to the zip file of the given entry.
+func (s *ZipStore) Path(e ZipStoreEntry) PathToFile {
+	return filepath.Join(s.blob_dir(), e.SHA256+".zip")
+}
+
+// writes the index to disk. expects the lock to be held.
+func (s *ZipStore) save() error {
+	bytes, err := json.Marshal(s.index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal zip store index: %w", err)
+	}
+	tmp := s.index_path() + ".tmp"
+	err = os.WriteFile(tmp, bytes, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write zip store index: %w", err)
+	}
+	return os.Rename(tmp, s.index_path())
+}
+
+// returns the index of the entry matching `pred`, or -1. expects the lock to be held.
+func (s *ZipStore) find(pred func(ZipStoreEntry) bool) int {
+	return slices.IndexFunc(s.index.EntryList, pred)
+}
+
+// returns the entry for `version` previously downloaded from `url`.
+// some sources serve every release from the same URL, so the URL alone isn't enough.
+func (s *ZipStore) Lookup(url URL, version string) (ZipStoreEntry, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	i := s.find(func(e ZipStoreEntry) bool {
+		return e.Version == version && slices.Contains(e.URLList, url)
+	})
+	if i == -1 {
+		return ZipStoreEntry{}, ErrZipStoreEntryDNE
+	}
+	return s.index.EntryList[i], nil
+}
+
+// returns the entry for the zip file at `path` within the store.
+func (s *ZipStore) LookupPath(path PathToFile) (ZipStoreEntry, error) {
+	if filepath.Dir(path) != s.blob_dir() {
+		return ZipStoreEntry{}, ErrZipStoreEntryDNE
+	}
+	sha256 := strings.TrimSuffix(filepath.Base(path), ".zip")
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	i := s.find(func(e ZipStoreEntry) bool {
+		return e.SHA256 == sha256
+	})
+	if i == -1 {
+		return ZipStoreEntry{}, ErrZipStoreEntryDNE
+	}
+	return s.index.EntryList[i], nil
+}
+
+// returns the entries for the given addon, newest first.
+func (s *ZipStore) EntryList(name string) []ZipStoreEntry {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	rv := []ZipStoreEntry{}
+	for _, e := range s.index.EntryList {
+		if e.Name == name {
+			rv = append(rv, e)
+		}
+	}
+	slices.SortStableFunc(rv, func(a, b ZipStoreEntry) int {
+		return b.Created.Compare(a.Created)
+	})
+	return rv
+}
+
+// moves the zip file at `path` into the store.
+// a zip file with the same contents as one already in the store is removed and the existing entry is returned.
+func (s *ZipStore) Add(path PathToFile, url URL, name string, version string, now time.Time) (ZipStoreEntry, error) {
+	empty_response := ZipStoreEntry{}
+	checksum, err := file_sha256(path)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to add zip file to store: %w", err)
+	}
+	finfo, err := os.Stat(path)
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to add zip file to store: %w", err)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entry := ZipStoreEntry{SHA256: checksum, URLList: []URL{}, Name: name, Version: version, SizeBytes: finfo.Size(), Created: now.UTC(), AddonsDirList: []PathToDir{}}
+	i := s.find(func(e ZipStoreEntry) bool {
+		return e.SHA256 == checksum
+	})
+	if i == -1 {
+		s.index.EntryList = append(s.index.EntryList, entry)
+		i = len(s.index.EntryList) - 1
+	}
+	if !slices.Contains(s.index.EntryList[i].URLList, url) {
+		s.index.EntryList[i].URLList = append(s.index.EntryList[i].URLList, url)
+	}
+	entry = s.index.EntryList[i]
+
+	blob := s.Path(entry)
+	if core.FileExists(blob) {
+		err = os.Remove(path)
+	} else {
+		err = move_file(path, blob)
+	}
+	if err != nil {
+		return empty_response, fmt.Errorf("failed to add zip file to store: %w", err)
+	}
+
+	return entry, s.save()
+}
+
+// records that the zip file at `path` is installed in `addons_dir`.
+// any other zip file of the same addon is no longer installed there.
+func (s *ZipStore) Ref(path PathToFile, addons_dir PathToDir) error {
+	entry, err := s.LookupPath(path)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i, e := range s.index.EntryList {
+		if e.Name != entry.Name {
+			continue
+		}
+		addons_dir_list := slices.DeleteFunc(slices.Clone(e.AddonsDirList), func(ad PathToDir) bool {
+			return ad == addons_dir
+		})
+		if e.SHA256 == entry.SHA256 {
+			addons_dir_list = append(addons_dir_list, addons_dir)
+		}
+		s.index.EntryList[i].AddonsDirList = addons_dir_list
+	}
+	return s.save()
+}
+
+// records that the given addon is no longer installed in `addons_dir`.
+func (s *ZipStore) Unref(name string, addons_dir PathToDir) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i, e := range s.index.EntryList {
+		if e.Name == name {
+			s.index.EntryList[i].AddonsDirList = slices.DeleteFunc(slices.Clone(e.AddonsDirList), func(ad PathToDir) bool {
+				return ad == addons_dir
+			})
+		}
+	}
+	return s.save()
+}
+
+// removes the zip file at `path` from the store.
+func (s *ZipStore) Remove(path PathToFile) error {
+	entry, err := s.LookupPath(path)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.index.EntryList = slices.DeleteFunc(s.index.EntryList, func(e ZipStoreEntry) bool {
+		return e.SHA256 == entry.SHA256
+	})
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return s.save()
+}
+
+// removes all but the newest `num_to_keep` zip files of the given addon that aren't installed anywhere.
+// installed zip files are always kept. a nil `num_to_keep` keeps all zip files.
+func (s *ZipStore) Prune(name string, num_to_keep *uint8) error {
+	if num_to_keep == nil {
+		return nil
+	}
+	unused := []ZipStoreEntry{}
+	for _, e := range s.EntryList(name) {
+		if len(e.AddonsDirList) == 0 {
+			unused = append(unused, e)
+		}
+	}
+	if len(unused) <= int(*num_to_keep) {
+		return nil
+	}
+	for _, e := range unused[*num_to_keep:] {
+		slog.Info("removing unused zip file from store", "name", e.Name, "version", e.Version)
+		err := s.Remove(s.Path(e))
+		if err != nil {
+			return fmt.Errorf("failed to prune zip store: %w", err)
+		}
+	}
+	return nil
+}
+
+// returns the path to the zip file downloaded from `url`, downloading it into the store if it isn't already there.
+// a stored zip file that doesn't match the optional `md5` checksum is removed and downloaded again.
+func (s *ZipStore) Download(app *core.App, url URL, name string, version string, md5 string) (PathToFile, error) {
+	entry, err := s.Lookup(url, version)
+	if err == nil && core.FileExists(s.Path(entry)) {
+		if md5 != "" {
+			err = verify_md5(s.Path(entry), md5)
+		}
+		if err == nil {
+			slog.Info("using zip file from store", "url", url, "name", name, "version", version)
+			return s.Path(entry), nil
+		}
+		slog.Warn("zip file in store failed verification, downloading again", "url", url, "name", name, "version", version, "error", err)
+		err = s.Remove(s.Path(entry))
+		if err != nil {
+			return "", fmt.Errorf("failed to download zip file: %w", err)
+		}
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "download-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("failed to download zip file: %w", err)
+	}
+	tmp.Close()
+
+	err = app.DownloadFile(url, tmp.Name())
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	entry, err = s.Add(tmp.Name(), url, name, version, time.Now())
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return s.Path(entry), nil
+}
+
+// renames `src` to `dst`, copying it when they're on different filesystems.
+func move_file(src PathToFile, dst PathToFile) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	err = out.Close()
+	if err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// ---
+
+var zip_store_in_use atomic.Pointer[ZipStore]
+
+// returns the zip store in use, or nil if zip files are downloaded into each addons directory.
+func zip_store() *ZipStore {
+	return zip_store_in_use.Load()
+}
+
+func set_zip_store(store *ZipStore) {
+	zip_store_in_use.Store(store)
+}
+
+// opens the shared zip store in the data directory.
+// if it can't be opened zip files are downloaded into each addons directory instead.
+func configure_zip_store(app *core.App) {
+	store, err := OpenZipStore(get_paths(app)["strongbox.paths.zip-store-dir"])
+	if err != nil {
+		slog.Error("failed to open zip store, downloading zip files into addons directories", "error", err)
+		store = nil
+	}
+	set_zip_store(store)
+}
+
+// returns the most zip files of an addon any addons directory wants kept, nil if any addons directory keeps them all.
+// zip files in the store are shared by every addons directory, so one addons directory keeping fewer
+// zip files must not remove those another addons directory wants kept.
+func shared_zips_to_keep(settings Settings) *uint8 {
+	rv := settings.Preferences.AddonZipsToKeep
+	for _, ad := range settings.AddonsDirList {
+		num_to_keep := resolve_addons_dir_preferences(settings.Preferences, ad).AddonZipsToKeep
+		if num_to_keep == nil {
+			return nil
+		}
+		if rv != nil && *num_to_keep > *rv {
+			rv = num_to_keep
+		}
+	}
+	return rv
+}
+
+// returns the number of zip files of an addon to keep after installing it into `addons_dir`.
+// when the zip store is in use this is the most any addons directory wants kept, see `shared_zips_to_keep`.
+func zips_to_keep(app *core.App, addons_dir AddonsDir) *uint8 {
+	if zip_store() == nil {
+		return addons_dir_preferences(app, addons_dir).AddonZipsToKeep
+	}
+	settings, err := find_settings(app.State)
+	if err != nil {
+		settings = NewSettings()
+	}
+	return shared_zips_to_keep(settings)
+}
+
+// records the zip file as installed in `addons_dir` if it's in the zip store.
+func ref_zip_file(addons_dir AddonsDir, zipfile PathToFile) {
+	store := zip_store()
+	if store == nil {
+		return
+	}
+	err := store.Ref(zipfile, addons_dir.Path)
+	if err != nil && !errors.Is(err, ErrZipStoreEntryDNE) {
+		slog.Warn("failed to record zip file as installed", "zipfile", zipfile, "error", err)
+	}
+}
+
+// records the addon as no longer installed in `addons_dir`.
+func unref_zip_files(addons_dir AddonsDir, name string) {
+	store := zip_store()
+	if store == nil {
+		return
+	}
+	err := store.Unref(name, addons_dir.Path)
+	if err != nil {
+		slog.Warn("failed to record zip files as uninstalled", "name", name, "error", err)
+	}
+}
+
+// removes a downloaded zip file that shouldn't be installed, from the store or the addons directory.
+func discard_zip_file(zipfile PathToFile) error {
+	store := zip_store()
+	if store != nil {
+		err := store.Remove(zipfile)
+		if !errors.Is(err, ErrZipStoreEntryDNE) {
+			return err
+		}
+	}
+	return os.Remove(zipfile)
+}
+
+// reinstalls the addon from a zip file in the store without downloading anything.
+// an empty `version` reinstalls the installed version.
+func ReinstallAddonFromStore(app *core.App, a Addon, version string) error {
+	store := zip_store()
+	if store == nil {
+		return ErrZipStoreUnavailable
+	}
+	if a.AddonsDir == nil {
+		slog.Error("addon is missing it's addons directory")
+		panic("programming error")
+	}
+	if version == "" {
+		version = a.InstalledVersion
+	}
+
+	idx := slices.IndexFunc(store.EntryList(a.Name), func(e ZipStoreEntry) bool {
+		return e.Version == version
+	})
+	if idx == -1 {
+		return fmt.Errorf("%w: %s %s", ErrZipStoreEntryDNE, a.Name, version)
+	}
+	entry := store.EntryList(a.Name)[idx]
+
+	// the nfo data written during installation comes from the addon's chosen update.
+	a.SourceUpdate = &SourceUpdate{Version: entry.Version, DownloadURL: entry.URLList[0]}
+	return install_addon_guard(app, *a.AddonsDir, a, store.Path(entry), InstallOpts{})
+}
+
+// reinstalls the version of the addon added to the zip store before the installed version, without downloading anything.
+func RollbackAddonFromStore(app *core.App, a Addon) error {
+	store := zip_store()
+	if store == nil {
+		return ErrZipStoreUnavailable
+	}
+
+	entry_list := store.EntryList(a.Name) // newest first
+	idx := slices.IndexFunc(entry_list, func(e ZipStoreEntry) bool {
+		return e.Version == a.InstalledVersion
+	})
+	if idx == -1 {
+		return fmt.Errorf("%w: %s %s", ErrZipStoreEntryDNE, a.Name, a.InstalledVersion)
+	}
+	for _, e := range entry_list[idx+1:] {
+		if e.Version != a.InstalledVersion {
+			return ReinstallAddonFromStore(app, a, e.Version)
+		}
+	}
+	return fmt.Errorf("%w: nothing older than %s %s", ErrZipStoreEntryDNE, a.Name, a.InstalledVersion)
+}
@@ -0,0 +1,310 @@
+package strongbox
+
+import (
+	"bw/core"
+	"crypto/md5"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// copies a fixture zip file somewhere it can be moved into the store from
+func copy_zip_fixture(t *testing.T, fixture PathToFile) PathToFile {
+	bytes, err := os.ReadFile(fixture)
+	assert.Nil(t, err)
+	path := filepath.Join(t.TempDir(), filepath.Base(fixture))
+	assert.Nil(t, os.WriteFile(path, bytes, 0644))
+	return path
+}
+
+// uses the given zip store for the duration of the test
+func use_zip_store(t *testing.T, store *ZipStore) {
+	prev := zip_store()
+	set_zip_store(store)
+	t.Cleanup(func() {
+		set_zip_store(prev)
+	})
+}
+
+// zip files are moved into the store and identical zip files are stored once
+func Test_ZipStore_Add(t *testing.T) {
+	store, err := OpenZipStore(t.TempDir())
+	assert.Nil(t, err)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	path := copy_zip_fixture(t, test_fixture_everyaddon_minimal_zip)
+	entry, err := store.Add(path, "https://example.org/everyaddon-1.2.3.zip", "everyaddon", "1.2.3", now)
+	assert.Nil(t, err)
+	assert.False(t, core.FileExists(path))
+	assert.True(t, core.FileExists(store.Path(entry)))
+
+	// same contents, different url
+	path = copy_zip_fixture(t, test_fixture_everyaddon_minimal_zip)
+	entry2, err := store.Add(path, "https://mirror.example.org/everyaddon-1.2.3.zip", "everyaddon", "1.2.3", now.Add(time.Hour))
+	assert.Nil(t, err)
+	assert.False(t, core.FileExists(path))
+	assert.Equal(t, entry.SHA256, entry2.SHA256)
+	assert.Equal(t, []URL{"https://example.org/everyaddon-1.2.3.zip", "https://mirror.example.org/everyaddon-1.2.3.zip"}, entry2.URLList)
+	assert.Equal(t, now, entry2.Created)
+	assert.Equal(t, 1, len(store.EntryList("everyaddon")))
+
+	actual, err := store.Lookup("https://mirror.example.org/everyaddon-1.2.3.zip", "1.2.3")
+	assert.Nil(t, err)
+	assert.Equal(t, entry2, actual)
+
+	_, err = store.Lookup("https://example.org/unknown.zip", "1.2.3")
+	assert.ErrorIs(t, err, ErrZipStoreEntryDNE)
+
+	// a known url, but a different version
+	_, err = store.Lookup("https://example.org/everyaddon-1.2.3.zip", "1.2.4")
+	assert.ErrorIs(t, err, ErrZipStoreEntryDNE)
+
+	// the index survives reopening the store
+	store2, err := OpenZipStore(store.dir)
+	assert.Nil(t, err)
+	assert.Equal(t, store.EntryList("everyaddon"), store2.EntryList("everyaddon"))
+}
+
+// a url already in the store isn't downloaded again
+func Test_ZipStore_Download(t *testing.T) {
+	app := DummyApp()
+	store, err := OpenZipStore(t.TempDir())
+	assert.Nil(t, err)
+
+	url := "https://example.org/everyaddon-1.2.3.zip"
+	path, err := store.Download(app, url, "everyaddon", "1.2.3", "")
+	assert.Nil(t, err)
+	assert.True(t, core.FileExists(path))
+
+	path2, err := store.Download(app, url, "everyaddon", "1.2.3", "")
+	assert.Nil(t, err)
+	assert.Equal(t, path, path2)
+	assert.Equal(t, 1, len(store.EntryList("everyaddon")))
+}
+
+// a url serving every release is downloaded again for a new version,
+// a stored zip file that fails it's checksum is downloaded again.
+func Test_ZipStore_Download__version_md5(t *testing.T) {
+	app := DummyApp()
+	store, err := OpenZipStore(t.TempDir())
+	assert.Nil(t, err)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	url := "https://example.org/everyaddon/latest.zip"
+	entry, err := store.Add(copy_zip_fixture(t, test_fixture_everyaddon_minimal_zip), url, "everyaddon", "1.2.3", now)
+	assert.Nil(t, err)
+
+	data, err := os.ReadFile(test_fixture_everyaddon_minimal_zip)
+	assert.Nil(t, err)
+	checksum := fmt.Sprintf("%x", md5.Sum(data))
+
+	path, err := store.Download(app, url, "everyaddon", "1.2.3", checksum)
+	assert.Nil(t, err)
+	assert.Equal(t, store.Path(entry), path)
+
+	// the dummy downloader downloads empty files
+	path, err = store.Download(app, url, "everyaddon", "1.2.4", "")
+	assert.Nil(t, err)
+	assert.NotEqual(t, store.Path(entry), path)
+	assert.Equal(t, 2, len(store.EntryList("everyaddon")))
+
+	path, err = store.Download(app, url, "everyaddon", "1.2.3", "00000000000000000000000000000000")
+	assert.Nil(t, err)
+	assert.NotEqual(t, store.Path(entry), path)
+	assert.False(t, core.FileExists(store.Path(entry)))
+}
+
+// zip files installed in an addons dir are never pruned, unused zip files are pruned oldest first
+func Test_ZipStore_Prune(t *testing.T) {
+	store, err := OpenZipStore(t.TempDir())
+	assert.Nil(t, err)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	fixture_list := []PathToFile{
+		test_fixture_everyaddon_minimal_zip,
+		test_fixture_everyaddon_minimal_update_zip,
+		test_fixture_everyaddon_maximal_zip,
+	}
+	entry_list := []ZipStoreEntry{}
+	for i, fixture := range fixture_list {
+		path := copy_zip_fixture(t, fixture)
+		entry, err := store.Add(path, "https://example.org/"+filepath.Base(fixture), "everyaddon", filepath.Base(fixture), now.Add(time.Duration(i)*time.Hour))
+		assert.Nil(t, err)
+		entry_list = append(entry_list, entry)
+	}
+
+	retail := "/path/to/retail/Interface/AddOns"
+	classic := "/path/to/classic/Interface/AddOns"
+
+	// the oldest zip file is installed in both addons dirs
+	assert.Nil(t, store.Ref(store.Path(entry_list[0]), retail))
+	assert.Nil(t, store.Ref(store.Path(entry_list[0]), classic))
+
+	// retail is updated, the oldest zip file is still installed in classic
+	assert.Nil(t, store.Ref(store.Path(entry_list[1]), retail))
+
+	assert.Nil(t, store.Prune("everyaddon", new(uint8(0))))
+	actual := store.EntryList("everyaddon")
+	assert.Equal(t, 2, len(actual))
+	assert.Equal(t, []PathToDir{retail}, actual[0].AddonsDirList)
+	assert.Equal(t, []PathToDir{classic}, actual[1].AddonsDirList)
+	assert.False(t, core.FileExists(store.Path(entry_list[2])))
+
+	// the addon is removed from classic, leaving the oldest zip file unused
+	assert.Nil(t, store.Unref("everyaddon", classic))
+	assert.Nil(t, store.Prune("everyaddon", new(uint8(1))))
+	assert.Equal(t, 2, len(store.EntryList("everyaddon")))
+
+	assert.Nil(t, store.Prune("everyaddon", new(uint8(0))))
+	assert.Equal(t, 1, len(store.EntryList("everyaddon")))
+	assert.False(t, core.FileExists(store.Path(entry_list[0])))
+
+	// nil keeps everything
+	assert.Nil(t, store.Unref("everyaddon", retail))
+	assert.Nil(t, store.Prune("everyaddon", nil))
+	assert.Equal(t, 1, len(store.EntryList("everyaddon")))
+}
+
+// without a zip store, older zip files in the addons dir are removed
+func Test_remove_zip_files(t *testing.T) {
+	use_zip_store(t, nil)
+	ad := MakeAddonsDir(t.TempDir())
+	now := time.Now()
+	for i, fname := range []string{"everyaddon--1-2-3.zip", "everyaddon--1-2-4.zip", "everyaddon--1-2-5.zip", "everyotheraddon--1-0-0.zip"} {
+		path := filepath.Join(ad.Path, fname)
+		assert.Nil(t, os.WriteFile(path, []byte{}, 0644))
+		modtime := now.Add(time.Duration(i) * time.Minute)
+		assert.Nil(t, os.Chtimes(path, modtime, modtime))
+	}
+
+	assert.Nil(t, remove_zip_files(ad, "everyaddon", new(uint8(2))))
+
+	actual, err := filepath.Glob(filepath.Join(ad.Path, "*.zip"))
+	assert.Nil(t, err)
+	expected := []string{
+		filepath.Join(ad.Path, "everyaddon--1-2-4.zip"),
+		filepath.Join(ad.Path, "everyaddon--1-2-5.zip"),
+		filepath.Join(ad.Path, "everyotheraddon--1-0-0.zip"),
+	}
+	assert.Equal(t, expected, actual)
+}
+
+// an addon can be rolled back to a version in the zip store without downloading anything
+func Test_ReinstallAddonFromStore(t *testing.T) {
+	app := DummyApp()
+	ad := MakeAddonsDir(t.TempDir())
+	store, err := OpenZipStore(t.TempDir())
+	assert.Nil(t, err)
+	use_zip_store(t, store)
+
+	ca := test_fixture_catalogue.AddonSummaryList[0]
+	a := MakeAddonFromCatalogueAddon(ad, ca, []SourceUpdate{})
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	old_entry, err := store.Add(copy_zip_fixture(t, test_fixture_everyaddon_minimal_zip), "https://example.org/everyaddon-1.2.3.zip", a.Name, "1.2.3", now)
+	assert.Nil(t, err)
+	new_entry, err := store.Add(copy_zip_fixture(t, test_fixture_everyaddon_minimal_update_zip), "https://example.org/everyaddon-1.2.4.zip", a.Name, "1.2.4", now.Add(time.Hour))
+	assert.Nil(t, err)
+
+	a.SourceUpdate = &SourceUpdate{Version: "1.2.4", DownloadURL: "https://example.org/everyaddon-1.2.4.zip"}
+	assert.Nil(t, install_addon_guard(app, ad, a, store.Path(new_entry), InstallOpts{}))
+
+	addon_list, err := LoadAllInstalledAddons(ad)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(addon_list))
+
+	err = ReinstallAddonFromStore(app, addon_list[0], "1.2.3")
+	assert.Nil(t, err)
+
+	addon_list, err = LoadAllInstalledAddons(ad)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(addon_list))
+	assert.Equal(t, "1.2.3", addon_list[0].InstalledVersion)
+
+	// the installed zip file moved with the rollback
+	old_entry, err = store.LookupPath(store.Path(old_entry))
+	assert.Nil(t, err)
+	assert.Equal(t, []PathToDir{ad.Path}, old_entry.AddonsDirList)
+	new_entry, err = store.LookupPath(store.Path(new_entry))
+	assert.Nil(t, err)
+	assert.Equal(t, []PathToDir{}, new_entry.AddonsDirList)
+
+	err = ReinstallAddonFromStore(app, addon_list[0], "9.9.9")
+	assert.ErrorIs(t, err, ErrZipStoreEntryDNE)
+
+	// nothing older than 1.2.3 to roll back to
+	err = RollbackAddonFromStore(app, addon_list[0])
+	assert.ErrorIs(t, err, ErrZipStoreEntryDNE)
+}
+
+// an addon is rolled back to the version stored before the installed version
+func Test_RollbackAddonFromStore(t *testing.T) {
+	app := DummyApp()
+	ad := MakeAddonsDir(t.TempDir())
+	store, err := OpenZipStore(t.TempDir())
+	assert.Nil(t, err)
+	use_zip_store(t, store)
+
+	ca := test_fixture_catalogue.AddonSummaryList[0]
+	a := MakeAddonFromCatalogueAddon(ad, ca, []SourceUpdate{})
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	_, err = store.Add(copy_zip_fixture(t, test_fixture_everyaddon_minimal_zip), "https://example.org/everyaddon-1.2.3.zip", a.Name, "1.2.3", now)
+	assert.Nil(t, err)
+	new_entry, err := store.Add(copy_zip_fixture(t, test_fixture_everyaddon_minimal_update_zip), "https://example.org/everyaddon-1.2.4.zip", a.Name, "1.2.4", now.Add(time.Hour))
+	assert.Nil(t, err)
+
+	a.SourceUpdate = &SourceUpdate{Version: "1.2.4", DownloadURL: "https://example.org/everyaddon-1.2.4.zip"}
+	assert.Nil(t, install_addon_guard(app, ad, a, store.Path(new_entry), InstallOpts{}))
+
+	addon_list, err := LoadAllInstalledAddons(ad)
+	assert.Nil(t, err)
+	assert.Nil(t, RollbackAddonFromStore(app, addon_list[0]))
+
+	addon_list, err = LoadAllInstalledAddons(ad)
+	assert.Nil(t, err)
+	assert.Equal(t, "1.2.3", addon_list[0].InstalledVersion)
+}
+
+// the stored zip files of an addon are listed from a result ID, a bad selection is an error and not silently ignored
+func Test_ListStoredAddonZipsService(t *testing.T) {
+	app, stopfn := DummyApp2(t.TempDir())
+	defer stopfn()
+	store, err := OpenZipStore(t.TempDir())
+	assert.Nil(t, err)
+	use_zip_store(t, store)
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	_, err = store.Add(copy_zip_fixture(t, test_fixture_everyaddon_minimal_zip), "https://example.org/everyaddon-1.2.3.zip", "everyaddon", "1.2.3", now)
+	assert.Nil(t, err)
+	app.AddReplaceResults(core.MakeResult(NS_ADDON, Addon{Name: "everyaddon"}, "everyaddon")).Wait()
+
+	res := ListStoredAddonZipsService(app, core.MakeServiceFnArgs("selected", "everyaddon"))
+	assert.Nil(t, res.Err)
+	assert.Equal(t, 1, len(res.Result))
+
+	res = ListStoredAddonZipsService(app, core.MakeServiceFnArgs("selected", "foo"))
+	assert.NotNil(t, res.Err)
+}
+
+// the zip store keeps as many zip files as the most generous addons directory
+func Test_shared_zips_to_keep(t *testing.T) {
+	settings := NewSettings()
+	settings.Preferences.AddonZipsToKeep = new(uint8(1))
+	assert.Equal(t, new(uint8(1)), shared_zips_to_keep(settings))
+
+	settings.AddonsDirList = []AddonsDir{
+		{Path: "/tmp/.strongbox-foo"},
+		{Path: "/tmp/.strongbox-bar", AddonsDirPreferences: AddonsDirPreferences{AddonZipsToKeep: new(uint8(3))}},
+		{Path: "/tmp/.strongbox-baz", AddonsDirPreferences: AddonsDirPreferences{AddonZipsToKeep: new(uint8(0))}},
+	}
+	assert.Equal(t, new(uint8(3)), shared_zips_to_keep(settings))
+
+	// keeping everything wins
+	settings.Preferences.AddonZipsToKeep = nil
+	assert.Nil(t, shared_zips_to_keep(settings))
+}
@@ -1,8 +1,12 @@
 package strongbox
 
 import (
+	"archive/zip"
+	"bw/core"
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/stretchr/testify/assert"
@@ -43,3 +47,63 @@ func Test_unzip_file(t *testing.T) {
 	assert.FileExists(t, filepath.Join(tmp, "EveryAddon/EveryAddon.lua"))
 	assert.FileExists(t, filepath.Join(tmp, "EveryAddon/EveryAddon.toc"))
 }
+
+// .zip files named after the time they were created are listed newest first and pruned oldest first
+func Test_timestamped_zipfiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "backups")
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	expected_name_list := []string{"foo--20260102T030405Z.zip", "foo--20260102T040405Z.zip", "foo--20260102T050405Z.zip"}
+	for i, expected_name := range expected_name_list {
+		path, size, err := create_timestamped_zipfile(dir, "foo--", now.Add(time.Duration(i)*time.Hour), func(zw *zip.Writer) error {
+			_, err := zw.Create("empty.txt")
+			return err
+		})
+		assert.Nil(t, err)
+		assert.True(t, size > 0)
+		assert.Equal(t, filepath.Join(dir, expected_name), path)
+	}
+
+	// other files are skipped
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "bar--20260102T030405Z.zip"), nil, 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "foo--yesterday.zip"), nil, 0644))
+
+	actual, err := list_timestamped_zipfiles(dir, "foo--")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(actual))
+	assert.Equal(t, now.Add(2*time.Hour), actual[0].Created)
+	assert.Equal(t, now, actual[2].Created)
+
+	// a .zip file isn't replaced, .zip files created in the same second are numbered
+	for _, expected_name := range []string{"foo--20260102T050405Z-2.zip", "foo--20260102T050405Z-3.zip"} {
+		path, _, err := create_timestamped_zipfile(dir, "foo--", now.Add(2*time.Hour), func(zw *zip.Writer) error { return nil })
+		assert.Nil(t, err)
+		assert.Equal(t, filepath.Join(dir, expected_name), path)
+	}
+	actual, err = list_timestamped_zipfiles(dir, "foo--")
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(actual))
+	assert.Equal(t, filepath.Join(dir, "foo--20260102T050405Z-3.zip"), actual[0].Path)
+	assert.Equal(t, filepath.Join(dir, "foo--20260102T050405Z-2.zip"), actual[1].Path)
+	assert.Equal(t, filepath.Join(dir, "foo--20260102T050405Z.zip"), actual[2].Path)
+
+	// badly numbered .zip files are skipped
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "foo--20260102T030405Z-1.zip"), nil, 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "foo--20260102T030405Z-bar.zip"), nil, 0644))
+	actual, err = list_timestamped_zipfiles(dir, "foo--")
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(actual))
+
+	// a .zip file that can't be written is removed
+	_, _, err = create_timestamped_zipfile(dir, "foo--", now.Add(-time.Hour), func(zw *zip.Writer) error { return os.ErrInvalid })
+	assert.NotNil(t, err)
+	assert.False(t, core.FileExists(timestamped_zipfile_path(dir, "foo--", now.Add(-time.Hour), 1)))
+
+	num_to_keep := uint8(1)
+	assert.Nil(t, prune_timestamped_zipfiles(dir, "foo--", &num_to_keep))
+	actual, err = list_timestamped_zipfiles(dir, "foo--")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(actual))
+	assert.Equal(t, filepath.Join(dir, "foo--20260102T050405Z-3.zip"), actual[0].Path)
+	assert.True(t, core.FileExists(filepath.Join(dir, "bar--20260102T030405Z.zip")))
+}